@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/export"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring/alerts"
+	"github.com/sirupsen/logrus"
+)
+
+// newAlertManager creates the alert manager for the [alerts] config section,
+// logging through the application logger when it is backed by logrus
+func newAlertManager(cfg *config.Config, log logger.Logger) *alerts.AlertManager {
+	logrusLogger := logrus.New()
+	if standard, ok := log.(*logger.StandardLogger); ok {
+		logrusLogger = standard.Logger
+	}
+	return alerts.NewAlertManager(logrusLogger, cfg.Alerts)
+}
+
+// notifyRun starts timing an export run. The returned function sends its
+// outcome through the [alerts] channels once it ended: a failure when err
+// is set, a success with the number of items exported otherwise.
+func notifyRun(cfg *config.Config, log logger.Logger, exporter *export.LetterboxdExporter, exportType, exportMode string) func(skipped []string, err error) {
+	a := cfg.Alerts
+	if len(a.Channels) == 0 && a.WebhookURL == "" && !a.EmailEnabled && !a.SlackEnabled {
+		return func([]string, error) {}
+	}
+
+	am := newAlertManager(cfg, log)
+	start := time.Now()
+	return func(skipped []string, err error) {
+		// The export context may be cancelled already
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		summary := alerts.ExportSummary{
+			ExportType: exportType,
+			Mode:       exportMode,
+			Duration:   time.Since(start),
+			Items:      exporter.ExportedItems(),
+			Skipped:    skipped,
+			Err:        err,
+		}
+		if err := am.SendExportSummary(ctx, summary); err != nil {
+			log.Warn("alerts.send_failed", map[string]interface{}{"error": err.Error()})
+		}
+	}
+}
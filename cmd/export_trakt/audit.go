@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/audit"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/encryption"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/keyring"
+)
+
+// auditCheckpointKeyName is the keyring entry holding the key that signs
+// the checkpoints of the audit log
+const auditCheckpointKeyName = "audit_checkpoint_key"
+
+// auditCheckpointKey returns the audit checkpoint key, generating and
+// storing a new one when none exists and create is set
+func auditCheckpointKey(keyringMgr *keyring.Manager, create bool) ([]byte, error) {
+	encoded, err := keyringMgr.Retrieve(auditCheckpointKeyName)
+	if err == nil {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid audit checkpoint key: %w", err)
+		}
+		return key, nil
+	}
+	if err != keyring.ErrCredentialNotFound || !create {
+		return nil, err
+	}
+
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := keyringMgr.Store(auditCheckpointKeyName, base64.StdEncoding.EncodeToString(key)); err != nil {
+		return nil, fmt.Errorf("failed to store audit checkpoint key: %w", err)
+	}
+	return key, nil
+}
+
+// newSecurityManager creates the security manager, signing the checkpoints
+// of the audit log with the key kept in the keyring. Every process writing
+// the audit log must use it: an entry written without the key leaves an
+// unsigned head file, which a later verification reports as tampering.
+func newSecurityManager(cfg *config.Config, log logger.Logger, keyringMgr *keyring.Manager) (*security.Manager, error) {
+	var opts []security.ManagerOption
+	if cfg.Security.AuditLogging {
+		if key, err := auditCheckpointKey(keyringMgr, true); err != nil {
+			log.Warn("audit.checkpoint_key_unavailable", map[string]interface{}{"error": err.Error()})
+		} else {
+			opts = append(opts, security.WithAuditCheckpointKey(key))
+		}
+	}
+	return security.NewManager(cfg.Security, opts...)
+}
+
+// runAuditCommand handles the 'audit' command and its subcommands
+func runAuditCommand(cfg *config.Config, log logger.Logger, keyringMgr *keyring.Manager, args []string) error {
+	if len(args) == 0 {
+		fmt.Println("Usage: audit verify [--file logs/audit.log] | audit search [--type t1,t2] [--severity high,critical] [--since 24h] [--until time] [--remote addr] [--result r] [--page n] [--limit n] [--format table|csv|json] [--output file]")
+		return fmt.Errorf("missing audit subcommand")
+	}
+
+	switch args[0] {
+	case "verify":
+		return verifyAuditLog(cfg, log, keyringMgr, args[1:])
+	case "search":
+		return searchAuditLog(cfg, log, args[1:])
+	default:
+		return fmt.Errorf("unknown audit subcommand: %s", args[0])
+	}
+}
+
+// verifyAuditLog checks the hash chain and signatures of the audit log
+func verifyAuditLog(cfg *config.Config, log logger.Logger, keyringMgr *keyring.Manager, args []string) error {
+	fs := flag.NewFlagSet("audit verify", flag.ContinueOnError)
+	file := fs.String("file", filepath.FromSlash(security.AuditLogFile), "Audit log to verify, with its rotated files")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if cfg.Security.Audit.OutputFormat == "text" {
+		return fmt.Errorf("only JSON audit logs can be verified, set output_format = \"json\" in [security.audit]")
+	}
+
+	fmt.Println("🔗 Audit Log Verification")
+	fmt.Println("=========================")
+
+	key, err := auditCheckpointKey(keyringMgr, false)
+	if err != nil {
+		fmt.Printf("⚠️  Checkpoint key unavailable (%s), signatures are not checked\n", err)
+		key = nil
+	}
+
+	report, err := audit.Verify(*file, key)
+	if err != nil {
+		return fmt.Errorf("failed to verify audit log: %w", err)
+	}
+	if len(report.Files) == 0 {
+		return fmt.Errorf("no audit log found at %s", *file)
+	}
+
+	for _, f := range report.Files {
+		fmt.Printf("📄 %s\n", f)
+	}
+	if report.Entries > 0 {
+		fmt.Printf("Entries: %d (seq %d to %d)\n", report.Entries, report.FirstSeq, report.LastSeq)
+	}
+	fmt.Printf("Checkpoints: %d\n", report.Checkpoints)
+	if report.Unchained > 0 {
+		fmt.Printf("Entries written before hash chaining: %d\n", report.Unchained)
+	}
+	if report.FirstSeq > 1 {
+		fmt.Printf("ℹ️  Entries before seq %d were rotated away\n", report.FirstSeq)
+	}
+	if report.UnsignedEntries > 0 {
+		fmt.Printf("⚠️  The last %d entries were written without the checkpoint key, only the hash chain covers them\n", report.UnsignedEntries)
+	}
+
+	log.Info("audit.verified", map[string]interface{}{
+		"entries":  report.Entries,
+		"problems": len(report.Problems),
+		"signed":   report.Signed,
+	})
+
+	if !report.OK() {
+		fmt.Println()
+		for _, problem := range report.Problems {
+			fmt.Printf("❌ %s\n", problem)
+		}
+		return fmt.Errorf("audit log integrity problems found: %d", len(report.Problems))
+	}
+
+	fmt.Println("✅ Audit log chain is intact")
+	return nil
+}
+
+// defaultAuditPageSize is the number of events shown per page by audit search
+const defaultAuditPageSize = 50
+
+// searchAuditLog prints or exports the audit events matching the filters,
+// newest first, across the rotated files of the log
+func searchAuditLog(cfg *config.Config, log logger.Logger, args []string) error {
+	fs := flag.NewFlagSet("audit search", flag.ContinueOnError)
+	file := fs.String("file", filepath.FromSlash(security.AuditLogFile), "Audit log to search, with its rotated files")
+	types := fs.String("type", "", "Comma separated event types, such as auth_failure,rate_limit_hit")
+	severities := fs.String("severity", "", "Comma separated severities: low, medium, high, critical")
+	since := fs.String("since", "", "Only events at or after this time, or this long ago such as 24h")
+	until := fs.String("until", "", "Only events before this time, or this long ago")
+	remote := fs.String("remote", "", "Only events from remote addresses starting with this value")
+	result := fs.String("result", "", "Only events with this result, such as failed")
+	checkpoints := fs.Bool("checkpoints", false, "Include the checkpoint entries of the hash chain")
+	page := fs.Int("page", 1, "Page to show, newest events first")
+	limit := fs.Int("limit", 0, "Events per page, 50 for the table and every event for csv and json")
+	format := fs.String("format", "table", "Output format: table, csv or json")
+	output := fs.String("output", "", "Write the result to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if cfg.Security.Audit.OutputFormat == "text" {
+		return fmt.Errorf("only JSON audit logs can be searched, set output_format = \"json\" in [security.audit]")
+	}
+
+	query := audit.Query{
+		EventTypes:         audit.ParseEventTypes(*types),
+		RemoteAddr:         *remote,
+		Result:             *result,
+		IncludeCheckpoints: *checkpoints,
+		Limit:              *limit,
+	}
+	var err error
+	if query.Severities, err = audit.ParseSeverities(*severities); err != nil {
+		return err
+	}
+	now := time.Now()
+	if *since != "" {
+		if query.Since, err = audit.ParseTime(*since, now); err != nil {
+			return err
+		}
+	}
+	if *until != "" {
+		if query.Until, err = audit.ParseTime(*until, now); err != nil {
+			return err
+		}
+	}
+	if *page < 1 || *limit < 0 {
+		return fmt.Errorf("page must be at least 1 and limit cannot be negative")
+	}
+	switch *format {
+	case "table":
+		if query.Limit == 0 {
+			query.Limit = defaultAuditPageSize
+		}
+	case "csv", "json":
+	default:
+		return fmt.Errorf("unknown format %q, expected table, csv or json", *format)
+	}
+	query.Offset = (*page - 1) * query.Limit
+
+	res, err := audit.Search(*file, query)
+	if err != nil {
+		return fmt.Errorf("failed to search audit log: %w", err)
+	}
+	if len(res.Files) == 0 {
+		return fmt.Errorf("no audit log found at %s", *file)
+	}
+
+	log.Info("audit.searched", map[string]interface{}{
+		"matches": res.Total,
+		"format":  *format,
+	})
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.OpenFile(*output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", *output, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch *format {
+	case "csv":
+		err = audit.WriteCSV(w, res.Events)
+	case "json":
+		err = audit.WriteJSON(w, res.Events)
+	default:
+		printAuditEvents(w, res, *page, query.Limit)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write audit events: %w", err)
+	}
+	if *output != "" {
+		fmt.Printf("✅ %d audit events written to %s\n", len(res.Events), *output)
+	}
+	return nil
+}
+
+// printAuditEvents prints a page of search results as a table followed by
+// the counters of every matching event
+func printAuditEvents(w io.Writer, res *audit.SearchResult, page, limit int) {
+	fmt.Fprintln(w, "🔎 Audit Log Search")
+	fmt.Fprintln(w, "===================")
+
+	if res.Total == 0 {
+		fmt.Fprintln(w, "No matching audit events")
+		return
+	}
+
+	for _, event := range res.Events {
+		line := fmt.Sprintf("%s  %-8s  %-20s  %-8s  %s", event.Timestamp.Local().Format("2006-01-02 15:04:05"),
+			event.Severity, event.EventType, event.Result, event.Message)
+		if event.RemoteAddr != "" {
+			line += "  [" + event.RemoteAddr + "]"
+		}
+		fmt.Fprintln(w, line)
+	}
+
+	pages := (res.Total + limit - 1) / limit
+	fmt.Fprintf(w, "\nPage %d of %d, %d matching events\n", page, pages, res.Total)
+	fmt.Fprintf(w, "By severity: %s\n", formatCounts(res.Stats.BySeverity))
+	fmt.Fprintf(w, "By type: %s\n", formatCounts(res.Stats.ByType))
+	if res.Skipped > 0 {
+		fmt.Fprintf(w, "⚠️  %d lines are not JSON audit entries and were skipped\n", res.Skipped)
+	}
+}
+
+// formatCounts formats counters as "key=count" pairs, most frequent first
+func formatCounts[K ~string](counts map[K]int) string {
+	parts := make([]string, 0, len(counts))
+	for _, key := range audit.SortedKeys(counts) {
+		parts = append(parts, fmt.Sprintf("%s=%d", key, counts[key]))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/audit"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/keyring"
+)
+
+// TestSecurityManagersShareCheckpointKey writes the audit log from the
+// main process, then from a one-shot run and a validation, as separate
+// processes would, and verifies it with the key
+func TestSecurityManagersShareCheckpointKey(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	cfg := &config.Config{Security: security.DefaultSecurityConfig()}
+	cfg.Security.KeyringBackend = "env"
+	cfg.Security.AuditLogging = true
+	cfg.Security.Audit.OutputFormat = "json"
+	cfg.Security.Audit.LogLevel = "info"
+
+	keyringMgr, err := keyring.NewManager(keyring.MemoryBackend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logger.NewLogger()
+
+	for _, writer := range []string{"main", "run_once", "validate"} {
+		manager, err := newSecurityManager(cfg, log, keyringMgr)
+		if err != nil {
+			t.Fatalf("%s: failed to create security manager: %v", writer, err)
+		}
+		manager.AuditLogger().LogSystemEvent(audit.SystemStart, writer, "run", "success")
+		if err := manager.Close(); err != nil {
+			t.Fatalf("%s: failed to close security manager: %v", writer, err)
+		}
+	}
+
+	key, err := auditCheckpointKey(keyringMgr, false)
+	if err != nil {
+		t.Fatalf("Expected the checkpoint key in the keyring: %v", err)
+	}
+	report, err := audit.Verify(filepath.FromSlash(security.AuditLogFile), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || report.UnsignedEntries != 0 {
+		t.Errorf("Expected a signed intact log, got %+v", report)
+	}
+}
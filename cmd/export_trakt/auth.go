@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/auth"
@@ -82,6 +86,60 @@ func runInteractiveAuth(cfg *config.Config, log logger.Logger, tokenManager *aut
 	}
 }
 
+// runDeviceAuth performs OAuth authentication with the device code flow,
+// which needs no callback server and suits headless machines
+func runDeviceAuth(cfg *config.Config, log logger.Logger, tokenManager *auth.TokenManager) error {
+	oauthMgr := auth.NewOAuthManager(cfg, log)
+
+	fmt.Println("🔑 Starting Device OAuth Authentication")
+	fmt.Println("=====================================")
+
+	// Check if credentials are configured
+	if cfg.Trakt.ClientID == "" || cfg.Trakt.ClientSecret == "" {
+		fmt.Println("❌ Missing Trakt.tv API credentials")
+		fmt.Println("\nPlease configure your Trakt.tv API credentials in config.toml")
+		return fmt.Errorf("missing API credentials")
+	}
+
+	code, err := oauthMgr.RequestDeviceCode()
+	if err != nil {
+		return fmt.Errorf("failed to request device code: %w", err)
+	}
+
+	fmt.Printf("📱 Client ID: %s\n", cfg.Trakt.ClientID)
+	fmt.Println("\n📋 NEXT STEPS:")
+	fmt.Println("1. Open the following URL on any device:")
+	fmt.Printf("   %s\n\n", code.VerificationURL)
+	fmt.Println("2. Enter this code:")
+	fmt.Printf("   %s\n\n", code.UserCode)
+	fmt.Printf("⏳ The code expires at %s\n", code.ExpiresAt(time.Now()).Format("15:04:05"))
+	fmt.Println("\nWaiting for authorization...")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	token, err := oauthMgr.PollDeviceToken(ctx, code)
+	switch {
+	case errors.Is(err, auth.ErrDeviceCodeExpired):
+		return fmt.Errorf("the code expired before it was entered, run 'auth --device' again")
+	case errors.Is(err, auth.ErrDeviceCodeDenied):
+		return fmt.Errorf("authorization was denied on Trakt.tv")
+	case err != nil:
+		return fmt.Errorf("device authentication failed: %w", err)
+	}
+
+	// Store token
+	if err := tokenManager.StoreToken(token); err != nil {
+		return fmt.Errorf("failed to store token: %w", err)
+	}
+
+	fmt.Println("🎉 Authentication successful!")
+	fmt.Printf("📅 Token expires: %s\n", oauthMgr.GetTokenExpiryTime(token).Format("2006-01-02 15:04:05"))
+	fmt.Println("🔄 Automatic refresh is enabled")
+
+	return nil
+}
+
 // showTokenStatus displays the current token status
 func showTokenStatus(tokenManager *auth.TokenManager) error {
 	fmt.Println("🔍 Token Status Check")
@@ -258,7 +316,7 @@ func authenticateWithCode(cfg *config.Config, log logger.Logger, tokenManager *a
 
 // fixCredentialsPermissions fixes file permissions for credentials storage
 func fixCredentialsPermissions(cfg *config.Config, log logger.Logger) error {
-	credentialsPath := "./config/credentials.enc"
+	credentialsPath := fileKeyOptions(cfg).CredentialsPath
 
 	fmt.Printf("🔧 Fixing credentials file permissions...\n\n")
 
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/api"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/auth"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/performance/cache"
+)
+
+// runCacheCommand handles the 'cache' command and its subcommands
+func runCacheCommand(cfg *config.Config, log logger.Logger, tokenManager *auth.TokenManager, args []string) error {
+	if len(args) == 0 {
+		fmt.Println("Usage: cache stats | cache clear | cache warm")
+		return fmt.Errorf("missing cache subcommand")
+	}
+
+	switch args[0] {
+	case "stats":
+		diskCache, err := api.OpenDiskCache(cfg)
+		if err != nil {
+			return err
+		}
+		printCacheStats(cfg, diskCache.Stats())
+		return nil
+	case "clear":
+		diskCache, err := api.OpenDiskCache(cfg)
+		if err != nil {
+			return err
+		}
+		removed, err := diskCache.Clear()
+		if err != nil {
+			return err
+		}
+		log.Info("cache.cleared", map[string]interface{}{"entries": removed, "dir": diskCache.Dir()})
+		fmt.Printf("🧹 Removed %d cached responses from %s\n", removed, diskCache.Dir())
+		return nil
+	case "warm":
+		return warmCache(cfg, log, tokenManager)
+	default:
+		return fmt.Errorf("unknown cache subcommand: %s", args[0])
+	}
+}
+
+// warmCache fetches every dataset once so the next export only revalidates
+func warmCache(cfg *config.Config, log logger.Logger, tokenManager *auth.TokenManager) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Warming always goes through the persistent cache, even when exports don't use it
+	warmCfg := *cfg
+	warmCfg.Client.PersistentCache = true
+
+	client, err := newTraktClient(&warmCfg, log, tokenManager)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	fmt.Println("🔥 Warming the response cache")
+	fmt.Println("=============================")
+
+	datasets := []struct {
+		name  string
+		fetch func(ctx context.Context) (int, error)
+	}{
+		{"watched movies", countOf(client.GetWatchedMoviesContext)},
+		{"movie history", countOf(client.GetMovieHistoryContext)},
+		{"collection", countOf(client.GetCollectionMoviesContext)},
+		{"watched shows", countOf(client.GetWatchedShowsContext)},
+		{"movie ratings", countOf(client.GetRatingsContext)},
+		{"show ratings", countOf(client.GetShowRatingsContext)},
+		{"episode ratings", countOf(client.GetEpisodeRatingsContext)},
+		{"watchlist", countOf(client.GetWatchlistContext)},
+	}
+
+	for _, dataset := range datasets {
+		start := time.Now()
+		count, err := dataset.fetch(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s: %w", dataset.name, err)
+		}
+		fmt.Printf("  ✅ %-16s %6d items (%s)\n", dataset.name, count, time.Since(start).Round(time.Millisecond))
+	}
+
+	diskCache, err := api.OpenDiskCache(&warmCfg)
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+	printCacheStats(&warmCfg, diskCache.Stats())
+	return nil
+}
+
+// countOf adapts a dataset getter to return the number of items
+func countOf[T any](get func(ctx context.Context) ([]T, error)) func(ctx context.Context) (int, error) {
+	return func(ctx context.Context) (int, error) {
+		items, err := get(ctx)
+		return len(items), err
+	}
+}
+
+func printCacheStats(cfg *config.Config, stats cache.DiskCacheStats) {
+	fmt.Println("📦 Response Cache")
+	fmt.Println("=================")
+	fmt.Printf("Directory:  %s\n", stats.Dir)
+	if cfg.Client.PersistentCache {
+		fmt.Println("Status:     enabled")
+	} else {
+		fmt.Println("Status:     disabled (set client.persistent_cache = true to use it for exports)")
+	}
+	fmt.Printf("Entries:    %d\n", stats.Entries)
+	fmt.Printf("Size:       %.1f KB\n", float64(stats.SizeBytes)/1024)
+	fmt.Printf("Requests:   %d revalidated, %d downloaded (hit ratio %.0f%%)\n", stats.Hits, stats.Misses, stats.HitRatio*100)
+	if !stats.Oldest.IsZero() {
+		fmt.Printf("Oldest:     %s\n", stats.Oldest.Format(time.RFC3339))
+		fmt.Printf("Newest:     %s\n", stats.Newest.Format(time.RFC3339))
+	}
+}
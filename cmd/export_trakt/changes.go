@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/api"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/export"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+)
+
+// allDatasets lists the datasets of the 'all' export type in export order
+var allDatasets = []string{
+	api.DatasetWatched,
+	api.DatasetCollection,
+	api.DatasetShows,
+	api.DatasetRatings,
+	api.DatasetWatchlist,
+}
+
+// changeDetector compares the Trakt last activities with the state of the
+// previous exports to find the datasets that need exporting
+type changeDetector struct {
+	state      *export.ActivityState
+	activities *api.LastActivities
+	settings   string
+	log        logger.Logger
+}
+
+// newChangeDetector returns nil when unchanged datasets are not skipped:
+// when export.skip_unchanged is off, for initial exports, which always
+// export everything, or when the last activities can't be fetched. The
+// complete mode, used by scheduled runs, still skips unchanged datasets.
+func newChangeDetector(ctx context.Context, cfg *config.Config, client api.TraktAPIClient, log logger.Logger, exportMode, historyMode string) *changeDetector {
+	if !cfg.Export.SkipUnchanged || exportMode == "initial" {
+		return nil
+	}
+
+	state, err := export.LoadActivityState(filepath.Join(cfg.Letterboxd.ExportDir, export.ActivityStateFile))
+	if err != nil {
+		log.Warn("export.state_unavailable", map[string]interface{}{"error": err.Error()})
+		return nil
+	}
+
+	activities, err := client.GetLastActivitiesContext(ctx)
+	if err != nil {
+		log.Warn("export.last_activities_unavailable", map[string]interface{}{"error": err.Error()})
+		return nil
+	}
+
+	return &changeDetector{
+		state:      state,
+		activities: activities,
+		settings:   exportSettings(cfg, historyMode),
+		log:        log,
+	}
+}
+
+// exportSettings fingerprints the configuration that shapes the exported
+// files, so changing it exports the datasets again
+func exportSettings(cfg *config.Config, historyMode string) string {
+	if historyMode == "" {
+		historyMode = cfg.Export.HistoryMode
+	}
+	return strings.Join([]string{
+		cfg.Export.Format,
+		cfg.Export.DateFormat,
+		cfg.Export.Timezone,
+		historyMode,
+		cfg.Trakt.ExtendedInfo,
+	}, "|")
+}
+
+// unchanged reports whether the dataset had no activity since its last export
+func (d *changeDetector) unchanged(dataset string) bool {
+	if d == nil {
+		return false
+	}
+	activityAt, ok := d.activities.DatasetActivity(dataset)
+	return ok && d.state.Unchanged(dataset, activityAt, d.settings)
+}
+
+// record stores the successful export of a dataset
+func (d *changeDetector) record(dataset string) {
+	if d == nil {
+		return
+	}
+	activityAt, _ := d.activities.DatasetActivity(dataset)
+	if err := d.state.Record(dataset, activityAt, d.settings); err != nil {
+		d.log.Warn("export.state_save_failed", map[string]interface{}{
+			"dataset": dataset,
+			"error":   err.Error(),
+		})
+	}
+}
+
+// runExports exports the datasets of exportType, skipping the ones that are
+// unchanged on Trakt since their last export. It returns the skipped datasets
+// and the error of a failed export, already logged.
+func runExports(ctx context.Context, cfg *config.Config, client api.TraktAPIClient, exporter *export.LetterboxdExporter, log logger.Logger, exportType, exportMode, historyMode string) (skipped []string, err error) {
+	datasets := []string{exportType}
+	if exportType == "all" {
+		log.Info("export.executing_all_types", nil)
+		datasets = allDatasets
+	}
+	for _, dataset := range datasets {
+		if !isDataset(dataset) {
+			log.Error("errors.invalid_export_type", map[string]interface{}{"type": exportType})
+			fmt.Printf("Invalid export type: %s. Valid types are 'watched', 'collection', 'shows', 'ratings', 'watchlist', or 'all'\n", exportType)
+			return nil, fmt.Errorf("invalid export type: %s", exportType)
+		}
+	}
+
+	ctx, endJob := startJobSpan(ctx, log, exportType, exportMode)
+	defer func() { endJob(err) }()
+	notify := notifyRun(cfg, log, exporter, exportType, exportMode)
+	defer func() { notify(skipped, err) }()
+
+	detector := newChangeDetector(ctx, cfg, client, log, exportMode, historyMode)
+
+	for _, dataset := range datasets {
+		if detector.unchanged(dataset) {
+			previous := detector.state.Datasets[dataset]
+			log.Info("export.dataset_unchanged", map[string]interface{}{
+				"dataset":     dataset,
+				"exported_at": previous.ExportedAt.Format(time.RFC3339),
+			})
+			skipped = append(skipped, dataset)
+			continue
+		}
+
+		switch dataset {
+		case api.DatasetWatched:
+			log.Info("export.executing_watched_movies", nil)
+			err = exportWatchedMovies(ctx, client, exporter, log, historyMode)
+		case api.DatasetCollection:
+			log.Info("export.executing_collection", nil)
+			err = exportCollection(ctx, client, exporter, log)
+		case api.DatasetShows:
+			log.Info("export.executing_shows", nil)
+			err = exportShows(ctx, client, exporter, log)
+		case api.DatasetRatings:
+			log.Info("export.executing_ratings", nil)
+			err = exportRatings(ctx, client, exporter, log)
+		case api.DatasetWatchlist:
+			log.Info("export.executing_watchlist", nil)
+			err = exportWatchlist(ctx, client, exporter, log)
+		}
+		if err != nil {
+			return skipped, err
+		}
+		detector.record(dataset)
+	}
+
+	// In manifest mode the files are signed together once they are all written
+	if err := exporter.SignManifests(); err != nil {
+		return skipped, exportError(log, "export.signing_failed", err)
+	}
+
+	if len(skipped) > 0 {
+		fmt.Printf("⏭️  Skipped unchanged since last export: %s\n", strings.Join(skipped, ", "))
+	}
+	return skipped, nil
+}
+
+func isDataset(name string) bool {
+	for _, dataset := range allDatasets {
+		if dataset == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/api"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/export"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+)
+
+// activitiesClient only answers the last activities request
+type activitiesClient struct {
+	api.TraktAPIClient
+	activities *api.LastActivities
+}
+
+func (c *activitiesClient) GetLastActivitiesContext(ctx context.Context) (*api.LastActivities, error) {
+	return c.activities, nil
+}
+
+// failingWatchlistClient fails the watchlist request
+type failingWatchlistClient struct {
+	api.TraktAPIClient
+	err error
+}
+
+func (c *failingWatchlistClient) GetWatchlistContext(ctx context.Context) ([]api.WatchlistMovie, error) {
+	return nil, c.err
+}
+
+func TestRunExportsReturnsFailure(t *testing.T) {
+	var mutex sync.Mutex
+	var alerts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		alerts = append(alerts, string(body))
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Letterboxd: config.LetterboxdConfig{ExportDir: t.TempDir()},
+		Export:     config.ExportConfig{Format: "csv"},
+	}
+	cfg.Alerts.WebhookURL = server.URL
+	log := logger.NewLogger()
+	exporter := export.NewLetterboxdExporter(cfg, log)
+	errUnavailable := errors.New("trakt unavailable")
+
+	_, err := runExports(context.Background(), cfg, &failingWatchlistClient{err: errUnavailable}, exporter, log, api.DatasetWatchlist, "normal", "")
+	if !errors.Is(err, errUnavailable) {
+		t.Fatalf("Expected the watchlist error, got %v", err)
+	}
+
+	// The failure is notified once the run returned
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(alerts) != 1 || !strings.Contains(alerts[0], "Export Failed") {
+		t.Errorf("Expected one failure alert, got %v", alerts)
+	}
+}
+
+func TestChangeDetectorExportModes(t *testing.T) {
+	cfg := &config.Config{
+		Letterboxd: config.LetterboxdConfig{ExportDir: t.TempDir()},
+		Export:     config.ExportConfig{SkipUnchanged: true, Format: "csv"},
+	}
+	activities := &api.LastActivities{}
+	activities.Movies.WatchlistedAt = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	client := &activitiesClient{activities: activities}
+	log := logger.NewLogger()
+
+	// A first export records the watchlist
+	newChangeDetector(context.Background(), cfg, client, log, "normal", "").record(api.DatasetWatchlist)
+
+	for _, tc := range []struct {
+		mode      string
+		unchanged bool
+	}{
+		{"", true},
+		{"normal", true},
+		{"complete", true},
+		{"initial", false},
+	} {
+		detector := newChangeDetector(context.Background(), cfg, client, log, tc.mode, "")
+		if got := detector.unchanged(api.DatasetWatchlist); got != tc.unchanged {
+			t.Errorf("Mode %q: expected unchanged=%v, got %v", tc.mode, tc.unchanged, got)
+		}
+	}
+
+	// Without skip_unchanged every mode exports everything
+	cfg.Export.SkipUnchanged = false
+	if newChangeDetector(context.Background(), cfg, client, log, "complete", "") != nil {
+		t.Error("Expected no change detector without skip_unchanged")
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/api"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/auth"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+)
+
+// newTraktClient builds the Trakt client selected by the [client] section of the configuration
+func newTraktClient(cfg *config.Config, log logger.Logger, tokenManager *auth.TokenManager) (api.TraktAPIClient, error) {
+	factoryConfig := api.ClientFactoryConfig{Logger: log}
+	// Only set the token manager when OAuth is used, a typed nil would hide the configured access token
+	if cfg.Auth.UseOAuth && tokenManager != nil {
+		factoryConfig.TokenManager = tokenManager
+	}
+
+	return api.NewClientFactory(factoryConfig).CreateClientWithCapabilities(api.CapabilitiesFromConfig(cfg))
+}
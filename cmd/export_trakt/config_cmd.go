@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+)
+
+// overrideFlags collects repeated --set key=value command line overrides
+type overrideFlags map[string]string
+
+func (o overrideFlags) String() string {
+	pairs := make([]string, 0, len(o))
+	for key, value := range o {
+		pairs = append(pairs, key+"="+value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (o overrideFlags) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	o[strings.TrimSpace(parts[0])] = parts[1]
+	return nil
+}
+
+// runConfigCommand handles the 'config' command and its subcommands
+func runConfigCommand(loader *config.Loader, args []string) error {
+	if len(args) == 0 {
+		fmt.Println("Usage: config show [--effective] | config migrate [--write]")
+		return fmt.Errorf("missing config subcommand")
+	}
+
+	switch args[0] {
+	case "show":
+		fs := flag.NewFlagSet("config show", flag.ContinueOnError)
+		effective := fs.Bool("effective", false, "Print the merged configuration with the origin of each value")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		return showConfig(loader, *effective)
+	case "migrate":
+		fs := flag.NewFlagSet("config migrate", flag.ContinueOnError)
+		write := fs.Bool("write", false, "Write the migrated file, keeping a backup of the original")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		return migrateConfig(loader.Path, *write)
+	default:
+		return fmt.Errorf("unknown config subcommand: %s", args[0])
+	}
+}
+
+// showConfig prints the configuration layers, or the merged result when effective is set
+func showConfig(loader *config.Loader, effective bool) error {
+	if !effective {
+		fmt.Println("📄 Configuration Sources")
+		fmt.Println("========================")
+		for i, file := range loader.Files() {
+			fmt.Printf("%d. %s\n", i+1, file)
+		}
+		fmt.Printf("\n🌍 Environment overrides use the %s prefix (e.g. %s)\n",
+			config.DefaultEnvPrefix, config.EnvName(config.DefaultEnvPrefix, "export.timezone"))
+		fmt.Println("💡 Run 'config show --effective' to see the merged values and their origins.")
+		return nil
+	}
+
+	fmt.Println("# Effective configuration (secrets redacted)")
+	return loader.WriteEffective(os.Stdout, true)
+}
+
+// migrateConfig shows, and optionally applies, the schema migrations for a config file
+func migrateConfig(path string, write bool) error {
+	fmt.Println("🔄 Configuration Migration")
+	fmt.Println("==========================")
+	fmt.Printf("📄 File: %s\n", path)
+
+	result, err := config.MigrateFile(path, write)
+	if err != nil {
+		return err
+	}
+
+	if !result.Changed() {
+		fmt.Printf("✅ Already at config version %d, nothing to do.\n", result.ToVersion)
+		return nil
+	}
+
+	fmt.Printf("📋 Version %d → %d\n\n", result.FromVersion, result.ToVersion)
+	for _, step := range result.Steps {
+		fmt.Printf("v%d → v%d: %s\n", step.From, step.To, step.Description)
+		if len(step.Changes) == 0 {
+			fmt.Println("   (no changes needed)")
+		}
+		for _, change := range step.Changes {
+			fmt.Printf("   • %s\n", change)
+		}
+	}
+	fmt.Println()
+
+	if !write {
+		fmt.Println("💡 Dry run. Run 'config migrate --write' to apply these changes.")
+		return nil
+	}
+
+	fmt.Printf("💾 Backup saved to: %s\n", result.BackupPath)
+	fmt.Println("✅ Configuration migrated successfully!")
+	fmt.Println("⚠️  Comments are not preserved in the migrated file; see the backup for the original.")
+	return nil
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/auth"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/audit"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/keyring"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/setup"
+	"golang.org/x/term"
+)
+
+// credentialsCommand holds what the 'credentials' subcommands work with
+type credentialsCommand struct {
+	cfg          *config.Config
+	log          logger.Logger
+	loader       *config.Loader
+	security     *security.Manager
+	keyringMgr   *keyring.Manager
+	tokenManager *auth.TokenManager
+}
+
+// runCredentialsCommand handles the 'credentials' command and its subcommands
+func runCredentialsCommand(c *credentialsCommand, args []string) error {
+	if len(args) == 0 {
+		fmt.Println("Usage: credentials status | credentials rotate [--device] [--keep-secret]")
+		return fmt.Errorf("missing credentials subcommand")
+	}
+
+	switch args[0] {
+	case "status":
+		return c.status()
+	case "rotate":
+		return c.rotate(args[1:])
+	default:
+		return fmt.Errorf("unknown credentials subcommand: %s", args[0])
+	}
+}
+
+// status prints the age of the credentials and whether a rotation is due
+func (c *credentialsCommand) status() error {
+	fmt.Println("🔏 Credentials Status")
+	fmt.Println("=====================")
+
+	rotatedAt, err := c.tokenManager.CredentialsRotatedAt()
+	if err != nil {
+		return err
+	}
+	age := time.Since(rotatedAt)
+	fmt.Printf("Last rotation: %s (%d days ago)\n", rotatedAt.Local().Format("2006-01-02 15:04"), int(age.Hours()/24))
+
+	if c.cfg.Auth.CredentialMaxAge <= 0 {
+		fmt.Println("Maximum age: none, rotation reminders are disabled")
+		return nil
+	}
+	fmt.Printf("Maximum age: %d days\n", int(c.cfg.Auth.CredentialMaxAge.Hours()/24))
+
+	if age >= c.cfg.Auth.CredentialMaxAge {
+		fmt.Println("⚠️  Rotation due: run 'credentials rotate'")
+	} else {
+		fmt.Printf("✅ Next rotation due: %s\n", rotatedAt.Add(c.cfg.Auth.CredentialMaxAge).Local().Format("2006-01-02"))
+	}
+	return nil
+}
+
+// rotate replaces the client secret, revokes the current token,
+// re-authenticates and verifies the new token. Each step is recorded as a
+// credential_rotation audit event.
+func (c *credentialsCommand) rotate(args []string) error {
+	fs := flag.NewFlagSet("credentials rotate", flag.ContinueOnError)
+	device := fs.Bool("device", false, "Re-authenticate with the device code flow")
+	keepSecret := fs.Bool("keep-secret", false, "Only rotate the token, keeping the current client secret")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	fmt.Println("🔄 Credentials Rotation")
+	fmt.Println("=======================")
+	if !c.cfg.Security.AuditLogging {
+		fmt.Println("⚠️  Audit logging is disabled, the rotation steps are not recorded")
+	}
+	c.record("start", nil, "Credentials rotation started")
+
+	// The new secret is stored before the token is touched, so an invalid
+	// secret leaves the current credentials working. Once regenerated on
+	// Trakt, only the new secret can revoke the token.
+	if *keepSecret {
+		fmt.Println("\n1️⃣  Keeping the current client secret")
+	} else {
+		fmt.Println("\n1️⃣  Replacing the client secret")
+		if err := c.replaceClientSecret(); err != nil {
+			c.record("replace_client_secret", err, "Failed to replace the client secret")
+			return err
+		}
+		c.record("replace_client_secret", nil, "Client secret replaced")
+	}
+
+	fmt.Println("\n2️⃣  Revoking the current token...")
+	if err := c.tokenManager.RevokeToken(); err != nil {
+		c.record("revoke_token", err, "Failed to revoke the current token")
+		fmt.Printf("⚠️  Could not revoke the current token: %s\n", err)
+		fmt.Println("   Revoke it from https://trakt.tv/oauth/authorized_applications if it may have leaked.")
+		if err := c.tokenManager.ClearToken(); err != nil {
+			return fmt.Errorf("failed to clear tokens: %w", err)
+		}
+	} else {
+		c.record("revoke_token", nil, "Current token revoked")
+		fmt.Println("✅ Token revoked")
+	}
+
+	fmt.Println("\n3️⃣  Re-authenticating...")
+	authenticate := runInteractiveAuth
+	if *device {
+		authenticate = runDeviceAuth
+	}
+	if err := authenticate(c.cfg, c.log, c.tokenManager); err != nil {
+		c.record("authenticate", err, "Re-authentication failed")
+		return fmt.Errorf("re-authentication failed: %w", err)
+	}
+	c.record("authenticate", nil, "Re-authenticated with Trakt")
+
+	fmt.Println("\n4️⃣  Verifying the new token...")
+	if err := c.tokenManager.ValidateStoredToken(); err != nil {
+		c.record("verify_token", err, "New token rejected by Trakt")
+		return fmt.Errorf("new token verification failed: %w", err)
+	}
+	c.record("verify_token", nil, "New token accepted by Trakt")
+	fmt.Println("✅ New token accepted by Trakt")
+
+	if err := c.tokenManager.MarkCredentialsRotated(time.Now()); err != nil {
+		fmt.Printf("⚠️  %s\n", err)
+	}
+	c.record("complete", nil, "Credentials rotation completed")
+
+	fmt.Println("\n🎉 Credentials rotated successfully!")
+	if c.cfg.Auth.CredentialMaxAge > 0 {
+		fmt.Printf("📅 Next rotation due: %s\n", time.Now().Add(c.cfg.Auth.CredentialMaxAge).Format("2006-01-02"))
+	}
+	return nil
+}
+
+// replaceClientSecret guides the user through regenerating the client
+// secret on Trakt and stores the new one in the keyring
+func (c *credentialsCommand) replaceClientSecret() error {
+	fmt.Println("   1. Open https://trakt.tv/oauth/applications and select your application")
+	fmt.Println("   2. Regenerate the client secret and copy it")
+
+	secret, err := readClientSecret()
+	if err != nil {
+		return err
+	}
+	if secret == "" {
+		return fmt.Errorf("the client secret must not be empty")
+	}
+	if secret == c.cfg.Trakt.ClientSecret {
+		return fmt.Errorf("the new client secret is the same as the current one")
+	}
+
+	origin := c.loader.Origin("trakt.client_secret")
+	switch {
+	case c.cfg.Security.KeyringBackend == string(keyring.EnvBackend):
+		fmt.Println("⚠️  The env backend cannot persist secrets. Set this variable in your environment:")
+		fmt.Println("   TRAKT_CLIENT_SECRET=<your new client secret>")
+	case origin.Layer == config.LayerEnv || origin.Layer == config.LayerFlag:
+		fmt.Printf("⚠️  The client secret is set by %s, update it there\n", origin.Source)
+	default:
+		if err := c.keyringMgr.Store(setup.ClientSecretKey, secret); err != nil {
+			return fmt.Errorf("failed to store client secret: %w", err)
+		}
+		fmt.Printf("🔐 Client secret stored in the %s keyring\n", c.cfg.Security.KeyringBackend)
+		if !origin.Secret && origin.Layer != config.LayerDefault {
+			fmt.Printf("⚠️  %s holds the client secret in clear, replace it with:\n", origin.Source)
+			fmt.Printf("   client_secret = \"%s%s\"\n", keyring.KeyringReferencePrefix, setup.ClientSecretKey)
+		}
+	}
+
+	c.cfg.Trakt.ClientSecret = secret
+	return nil
+}
+
+// readClientSecret reads the new client secret without echo, or as a line
+// from standard input when it is not a terminal
+func readClientSecret() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read client secret: %w", err)
+		}
+		return strings.TrimSpace(line), nil
+	}
+
+	fmt.Fprint(os.Stderr, "🔑 New client secret: ")
+	secret, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read client secret: %w", err)
+	}
+	return strings.TrimSpace(string(secret)), nil
+}
+
+// record logs a rotation step as a credential_rotation audit event
+func (c *credentialsCommand) record(step string, err error, message string) {
+	result := "success"
+	if err != nil {
+		result = "failed"
+		message += ": " + err.Error()
+	}
+	c.security.LogSecurityEvent(audit.CredentialRotation, step, result, message)
+	c.log.Info("credentials.rotation_step", map[string]interface{}{
+		"step":   step,
+		"result": result,
+	})
+}
+
+// remindCredentialRotation prints a reminder when the credentials are older
+// than the configured maximum age
+func remindCredentialRotation(cfg *config.Config, log logger.Logger, tokenManager *auth.TokenManager) {
+	if cfg.Auth.CredentialMaxAge <= 0 {
+		return
+	}
+	rotatedAt, err := tokenManager.CredentialsRotatedAt()
+	if err != nil {
+		log.Warn("credentials.rotation_check_failed", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	if age := time.Since(rotatedAt); age >= cfg.Auth.CredentialMaxAge {
+		log.Warn("credentials.rotation_due", map[string]interface{}{
+			"rotated_at": rotatedAt,
+			"age_days":   int(age.Hours() / 24),
+		})
+		fmt.Printf("⚠️  Trakt credentials were last rotated %d days ago, run 'credentials rotate'\n", int(age.Hours()/24))
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/auth"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/diagnostics"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring/health"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security"
+	"github.com/sirupsen/logrus"
+)
+
+// runDiagnosticsCommand writes a redacted support bundle with the versions,
+// the effective configuration, recent logs and health checks
+func runDiagnosticsCommand(cfg *config.Config, log logger.Logger, loader *config.Loader, tokenManager *auth.TokenManager, args []string) error {
+	fs := flag.NewFlagSet("diagnostics", flag.ContinueOnError)
+	output := fs.String("output", fmt.Sprintf("export-trakt-diagnostics-%s.zip", time.Now().Format("20060102-150405")), "Bundle to write")
+	logLines := fs.Int("log-lines", 1000, "Number of recent log lines to include")
+	offline := fs.Bool("offline", false, "Skip the Trakt API reachability check")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	fmt.Println("🩺 Diagnostics Bundle")
+	fmt.Println("====================")
+
+	file, err := os.OpenFile(*output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	defer file.Close()
+
+	bundle := diagnostics.NewBundle(file)
+
+	if err := bundle.AddText("versions.txt", versionsReport(cfg)); err != nil {
+		return err
+	}
+
+	var effective bytes.Buffer
+	if err := loader.WriteEffective(&effective, true); err != nil {
+		return fmt.Errorf("failed to render configuration: %w", err)
+	}
+	if err := bundle.AddText("config.toml", effective.String()); err != nil {
+		return err
+	}
+
+	if err := bundle.AddLogTail("logs/app.log", cfg.Logging.File, *logLines); err != nil {
+		return err
+	}
+	if err := bundle.AddLogTail("logs/audit.log", filepath.FromSlash(security.AuditLogFile), *logLines); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	report := diagnosticsHealth(cfg, tokenManager, *offline).Check(ctx)
+	if err := bundle.AddJSON("health.json", report); err != nil {
+		return err
+	}
+
+	if err := bundle.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	log.Info("diagnostics.bundle_created", map[string]interface{}{
+		"file":   *output,
+		"health": string(report.Status),
+	})
+
+	fmt.Printf("📦 Bundle: %s\n", *output)
+	for _, name := range bundle.Files() {
+		fmt.Printf("   • %s\n", name)
+	}
+	fmt.Printf("🩺 Health: %s\n", report.Status)
+	for name, component := range report.Components {
+		if component.Status != monitoring.HealthStatusHealthy {
+			fmt.Printf("   ⚠️  %s: %s\n", name, component.Message)
+		}
+	}
+	fmt.Println("🔒 Titles, user IDs, tokens and IP addresses were redacted, review the bundle before sharing it.")
+	return nil
+}
+
+// versionsReport describes the build, the platform and the dependencies
+func versionsReport(cfg *config.Config) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Export Trakt 4 Letterboxd %s\n", version)
+	fmt.Fprintf(&b, "Go: %s\n", runtime.Version())
+	fmt.Fprintf(&b, "Platform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "Config version: %d (supported %d)\n", cfg.Version, config.CurrentVersion)
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if strings.HasPrefix(setting.Key, "vcs.") {
+				fmt.Fprintf(&b, "%s: %s\n", setting.Key, setting.Value)
+			}
+		}
+		b.WriteString("\nDependencies:\n")
+		for _, dep := range info.Deps {
+			fmt.Fprintf(&b, "  %s %s\n", dep.Path, dep.Version)
+		}
+	}
+	return b.String()
+}
+
+// diagnosticsHealth registers the checks of the bundle
+func diagnosticsHealth(cfg *config.Config, tokenManager *auth.TokenManager, offline bool) *health.HealthChecker {
+	quiet := logrus.New()
+	quiet.SetOutput(io.Discard)
+	checker := health.NewHealthChecker(quiet, version)
+
+	checker.RegisterChecker(health.NewBasicHealthChecker("config", func(ctx context.Context) error {
+		return cfg.Validate()
+	}))
+
+	checker.RegisterChecker(health.NewBasicHealthChecker("token", func(ctx context.Context) error {
+		status, err := tokenManager.GetTokenStatus()
+		if err != nil {
+			return err
+		}
+		if status.Error != "" {
+			return fmt.Errorf("%s", status.Error)
+		}
+		if !status.HasToken {
+			return fmt.Errorf("no authentication token")
+		}
+		if !status.IsValid && !status.HasRefreshToken {
+			return fmt.Errorf("token expired")
+		}
+		return nil
+	}))
+
+	checker.RegisterChecker(health.NewBasicHealthChecker("export_dir", func(ctx context.Context) error {
+		probe, err := os.CreateTemp(cfg.Letterboxd.ExportDir, ".diagnostics-*")
+		if err != nil {
+			return fmt.Errorf("export directory is not writable: %w", err)
+		}
+		probe.Close()
+		return os.Remove(probe.Name())
+	}))
+
+	checker.RegisterChecker(health.NewBasicHealthChecker("log_file", func(ctx context.Context) error {
+		_, err := os.Stat(cfg.Logging.File)
+		return err
+	}))
+
+	if !offline {
+		checker.RegisterChecker(health.NewBasicHealthChecker("trakt_api", func(ctx context.Context) error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.Trakt.APIBaseURL, nil)
+			if err != nil {
+				return err
+			}
+			client := &http.Client{Timeout: 10 * time.Second}
+			resp, err := client.Do(req)
+			if err != nil {
+				return fmt.Errorf("Trakt API unreachable: %w", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode >= http.StatusInternalServerError {
+				return fmt.Errorf("Trakt API returned %s", resp.Status)
+			}
+			return nil
+		}))
+	}
+
+	return checker
+}
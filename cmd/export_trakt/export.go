@@ -1,8 +1,9 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/api"
@@ -10,11 +11,11 @@ import (
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/export"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
-	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/keyring"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/telemetry"
 )
 
-func exportWatchedMovies(client *api.Client, exporter *export.LetterboxdExporter, log logger.Logger, historyMode string) {
+func exportWatchedMovies(ctx context.Context, client api.TraktAPIClient, exporter *export.LetterboxdExporter, log logger.Logger, historyMode string) error {
 	// Determine which history mode to use
 	effectiveHistoryMode := historyMode
 	if effectiveHistoryMode == "" {
@@ -25,10 +26,9 @@ func exportWatchedMovies(client *api.Client, exporter *export.LetterboxdExporter
 	if effectiveHistoryMode == "individual" {
 		// Get complete movie history (individual watch events)
 		log.Info("export.retrieving_movie_history", nil)
-		history, err := client.GetMovieHistory()
+		history, err := client.GetMovieHistoryContext(ctx)
 		if err != nil {
-			log.Error("errors.api_request_failed", map[string]interface{}{"error": err.Error()})
-			os.Exit(1)
+			return exportError(log, "errors.api_request_failed", err)
 		}
 
 		log.Info("export.history_retrieved", map[string]interface{}{
@@ -38,21 +38,19 @@ func exportWatchedMovies(client *api.Client, exporter *export.LetterboxdExporter
 
 		// Export individual watch history
 		log.Info("export.exporting_movie_history", nil)
-		if err := exporter.ExportMovieHistory(history, client); err != nil {
-			log.Error("export.export_failed", map[string]interface{}{"error": err.Error()})
-			os.Exit(1)
+		if err := exporter.ExportMovieHistoryContext(ctx, history, client); err != nil {
+			return exportError(log, "export.export_failed", err)
 		}
-		return
+		return nil
 	}
 
 	// Default: aggregated mode (original behavior)
 	log.Info("export.retrieving_watched_movies", map[string]interface{}{
 		"mode": "aggregated",
 	})
-	movies, err := client.GetWatchedMovies()
+	movies, err := client.GetWatchedMoviesContext(ctx)
 	if err != nil {
-		log.Error("errors.api_request_failed", map[string]interface{}{"error": err.Error()})
-		os.Exit(1)
+		return exportError(log, "errors.api_request_failed", err)
 	}
 
 	log.Info("export.movies_retrieved", map[string]interface{}{
@@ -64,57 +62,53 @@ func exportWatchedMovies(client *api.Client, exporter *export.LetterboxdExporter
 	if client.GetConfig().Trakt.ExtendedInfo == "letterboxd" {
 		// Get ratings for Letterboxd format
 		log.Info("export.retrieving_ratings", nil)
-		ratings, err := client.GetRatings()
+		ratings, err := client.GetRatingsContext(ctx)
 		if err != nil {
-			log.Error("errors.api_request_failed", map[string]interface{}{"error": err.Error()})
-			os.Exit(1)
+			return exportError(log, "errors.api_request_failed", err)
 		}
 
 		log.Info("export.ratings_retrieved", map[string]interface{}{"count": len(ratings)})
 
 		// Export in Letterboxd format
 		log.Info("export.exporting_letterboxd_format", nil)
-		if err := exporter.ExportLetterboxdFormat(movies, ratings); err != nil {
-			log.Error("export.export_failed", map[string]interface{}{"error": err.Error()})
-			os.Exit(1)
+		if err := exporter.ExportLetterboxdFormatContext(ctx, movies, ratings); err != nil {
+			return exportError(log, "export.export_failed", err)
 		}
-		return
+		return nil
 	}
 
 	// Export movies in standard format
 	log.Info("export.exporting_watched_movies", nil)
-	if err := exporter.ExportMovies(movies, client); err != nil {
-		log.Error("export.export_failed", map[string]interface{}{"error": err.Error()})
-		os.Exit(1)
+	if err := exporter.ExportMoviesContext(ctx, movies, client); err != nil {
+		return exportError(log, "export.export_failed", err)
 	}
+	return nil
 }
 
-func exportCollection(client *api.Client, exporter *export.LetterboxdExporter, log logger.Logger) {
+func exportCollection(ctx context.Context, client api.TraktAPIClient, exporter *export.LetterboxdExporter, log logger.Logger) error {
 	// Get collection movies
 	log.Info("export.retrieving_collection", nil)
-	movies, err := client.GetCollectionMovies()
+	movies, err := client.GetCollectionMoviesContext(ctx)
 	if err != nil {
-		log.Error("errors.api_request_failed", map[string]interface{}{"error": err.Error()})
-		os.Exit(1)
+		return exportError(log, "errors.api_request_failed", err)
 	}
 
 	log.Info("export.collection_retrieved", map[string]interface{}{"count": len(movies)})
 
 	// Export collection
 	log.Info("export.exporting_collection", nil)
-	if err := exporter.ExportCollectionMovies(movies); err != nil {
-		log.Error("export.export_failed", map[string]interface{}{"error": err.Error()})
-		os.Exit(1)
+	if err := exporter.ExportCollectionMoviesContext(ctx, movies); err != nil {
+		return exportError(log, "export.export_failed", err)
 	}
+	return nil
 }
 
-func exportShows(client *api.Client, exporter *export.LetterboxdExporter, log logger.Logger) {
+func exportShows(ctx context.Context, client api.TraktAPIClient, exporter *export.LetterboxdExporter, log logger.Logger) error {
 	// Get watched shows
 	log.Info("export.retrieving_watched_shows", nil)
-	shows, err := client.GetWatchedShows()
+	shows, err := client.GetWatchedShowsContext(ctx)
 	if err != nil {
-		log.Error("errors.api_request_failed", map[string]interface{}{"error": err.Error()})
-		os.Exit(1)
+		return exportError(log, "errors.api_request_failed", err)
 	}
 
 	// Count total episodes
@@ -132,94 +126,80 @@ func exportShows(client *api.Client, exporter *export.LetterboxdExporter, log lo
 
 	// Export shows
 	log.Info("export.exporting_shows", nil)
-	if err := exporter.ExportShows(shows); err != nil {
-		log.Error("export.export_failed", map[string]interface{}{"error": err.Error()})
-		os.Exit(1)
+	if err := exporter.ExportShowsContext(ctx, shows); err != nil {
+		return exportError(log, "export.export_failed", err)
 	}
+	return nil
 }
 
-func exportRatings(client *api.Client, exporter *export.LetterboxdExporter, log logger.Logger) {
+func exportRatings(ctx context.Context, client api.TraktAPIClient, exporter *export.LetterboxdExporter, log logger.Logger) error {
 	// Get ratings
 	log.Info("export.retrieving_ratings", nil)
-	ratings, err := client.GetRatings()
+	ratings, err := client.GetRatingsContext(ctx)
 	if err != nil {
-		log.Error("errors.api_request_failed", map[string]interface{}{"error": err.Error()})
-		os.Exit(1)
+		return exportError(log, "errors.api_request_failed", err)
 	}
 
 	log.Info("export.ratings_retrieved", map[string]interface{}{"count": len(ratings)})
 
 	// Export ratings
 	log.Info("export.exporting_ratings", nil)
-	if err := exporter.ExportRatings(ratings); err != nil {
-		log.Error("export.export_failed", map[string]interface{}{"error": err.Error()})
-		os.Exit(1)
+	if err := exporter.ExportRatingsContext(ctx, ratings); err != nil {
+		return exportError(log, "export.export_failed", err)
 	}
+	return nil
 }
 
-func exportWatchlist(client *api.Client, exporter *export.LetterboxdExporter, log logger.Logger) {
+func exportWatchlist(ctx context.Context, client api.TraktAPIClient, exporter *export.LetterboxdExporter, log logger.Logger) error {
 	// Get watchlist
 	log.Info("export.retrieving_watchlist", nil)
-	watchlist, err := client.GetWatchlist()
+	watchlist, err := client.GetWatchlistContext(ctx)
 	if err != nil {
-		log.Error("errors.api_request_failed", map[string]interface{}{"error": err.Error()})
-		os.Exit(1)
+		return exportError(log, "errors.api_request_failed", err)
 	}
 
 	log.Info("export.watchlist_retrieved", map[string]interface{}{"count": len(watchlist)})
 
 	// Export watchlist
 	log.Info("export.exporting_watchlist", nil)
-	if err := exporter.ExportWatchlist(watchlist); err != nil {
-		log.Error("export.export_failed", map[string]interface{}{"error": err.Error()})
-		os.Exit(1)
+	if err := exporter.ExportWatchlistContext(ctx, watchlist); err != nil {
+		return exportError(log, "export.export_failed", err)
 	}
+	return nil
 }
 
-// runExportOnce executes the export once and then exits
-func runExportOnce(cfg *config.Config, log logger.Logger, exportType, exportMode, historyMode string) {
+// exportError logs err under messageID and returns it. An export cancelled
+// through its context is reported as such rather than as a failure.
+func exportError(log logger.Logger, messageID string, err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		log.Warn("export.cancelled", map[string]interface{}{"error": err.Error()})
+	} else {
+		log.Error(messageID, map[string]interface{}{"error": err.Error()})
+	}
+	return err
+}
+
+// runExportOnce executes the export once and returns its error, already
+// logged. Cancelling ctx, for example on SIGINT, stops the in-flight API
+// requests.
+func runExportOnce(ctx context.Context, cfg *config.Config, log logger.Logger, tm *telemetry.TelemetryManager, exportType, exportMode, historyMode string) error {
 	log.Info("export.starting_execution", map[string]interface{}{
 		"export_type": exportType,
 		"export_mode": exportMode,
 		"timestamp":   time.Now().Format(time.RFC3339),
 	})
 
-	// Initialize security manager and keyring
-	securityManager, err := security.NewManager(cfg.Security)
+	// Initialize keyring and security manager
+	keyringMgr, err := newKeyringManager(cfg)
 	if err != nil {
-		log.Error("errors.security_manager_failed", map[string]interface{}{"error": err.Error()})
-		os.Exit(1)
+		return exportError(log, "errors.keyring_manager_failed", err)
 	}
-	defer securityManager.Close()
 
-	var keyringMgr *keyring.Manager
-	switch cfg.Security.KeyringBackend {
-	case "system":
-		keyringMgr, err = keyring.NewManager(keyring.SystemBackend)
-	case "env":
-		keyringMgr, err = keyring.NewManager(keyring.EnvBackend)
-	case "file":
-		// For file backend, we need to provide options
-		var options []keyring.Option
-		if cfg.Security.EncryptionEnabled {
-			// Generate a simple encryption key for demo purposes
-			key := make([]byte, 32) // AES-256 key
-			for i := range key {
-				key[i] = byte(i % 256) // Simple pattern for demo
-			}
-			options = append(options, keyring.WithEncryptionKey(key))
-		}
-		options = append(options, keyring.WithFilePath("./config/credentials.enc"))
-		keyringMgr, err = keyring.NewManager(keyring.FileBackend, options...)
-	case "memory":
-		keyringMgr, err = keyring.NewManager(keyring.MemoryBackend)
-	default:
-		keyringMgr, err = keyring.NewManager(keyring.SystemBackend)
-	}
+	securityManager, err := newSecurityManager(cfg, log, keyringMgr)
 	if err != nil {
-		log.Error("errors.keyring_manager_failed", map[string]interface{}{"error": err.Error()})
-		os.Exit(1)
+		return exportError(log, "errors.security_manager_failed", err)
 	}
+	defer securityManager.Close()
 
 	// Initialize token manager
 	tokenManager := auth.NewTokenManager(cfg, log, keyringMgr)
@@ -229,8 +209,9 @@ func runExportOnce(cfg *config.Config, log logger.Logger, exportType, exportMode
 		log.Info("export.checking_authentication", nil)
 		status, err := tokenManager.GetTokenStatus()
 		if err != nil || !status.HasToken {
+			errTokenMissing := errors.New("No valid OAuth token found")
 			log.Error("auth.token_missing", map[string]interface{}{
-				"error": "No valid OAuth token found",
+				"error": errTokenMissing.Error(),
 			})
 
 			// Generate auth URL for display
@@ -260,7 +241,7 @@ func runExportOnce(cfg *config.Config, log logger.Logger, exportType, exportMode
 			fmt.Println("\n💡 Authentication only needs to be done once.")
 			fmt.Println("   Tokens will be automatically refreshed afterwards.")
 			fmt.Println("==========================================")
-			os.Exit(1)
+			return errTokenMissing
 		}
 
 		if !status.IsValid {
@@ -272,16 +253,19 @@ func runExportOnce(cfg *config.Config, log logger.Logger, exportType, exportMode
 
 	// Initialize Trakt client with token management
 	log.Info("export.initializing_trakt_client", nil)
-	var traktClient *api.Client
-	if cfg.Auth.UseOAuth {
-		traktClient = api.NewClientWithTokenManager(cfg, log, tokenManager)
-	} else {
-		traktClient = api.NewClient(cfg, log)
+	traktClient, err := newTraktClient(cfg, log, tokenManager)
+	if err != nil {
+		return exportError(log, "errors.api_client_failed", err)
 	}
+	defer traktClient.Close()
 
 	// Initialize Letterboxd exporter
 	log.Info("export.initializing_letterboxd_exporter", nil)
 	letterboxdExporter := export.NewLetterboxdExporter(cfg, log)
+	instrumentExporter(letterboxdExporter, tm)
+	if err := signExports(cfg, log, keyringMgr, letterboxdExporter); err != nil {
+		return exportError(log, "export.signing_failed", err)
+	}
 
 	// Log export mode
 	log.Info("export.mode", map[string]interface{}{
@@ -293,38 +277,62 @@ func runExportOnce(cfg *config.Config, log logger.Logger, exportType, exportMode
 		"export_type": exportType,
 	})
 
-	switch exportType {
-	case "watched":
-		log.Info("export.executing_watched_movies", nil)
-		exportWatchedMovies(traktClient, letterboxdExporter, log, historyMode)
-	case "collection":
-		log.Info("export.executing_collection", nil)
-		exportCollection(traktClient, letterboxdExporter, log)
-	case "shows":
-		log.Info("export.executing_shows", nil)
-		exportShows(traktClient, letterboxdExporter, log)
-	case "ratings":
-		log.Info("export.executing_ratings", nil)
-		exportRatings(traktClient, letterboxdExporter, log)
-	case "watchlist":
-		log.Info("export.executing_watchlist", nil)
-		exportWatchlist(traktClient, letterboxdExporter, log)
-	case "all":
-		log.Info("export.executing_all_types", nil)
-		exportWatchedMovies(traktClient, letterboxdExporter, log, historyMode)
-		exportCollection(traktClient, letterboxdExporter, log)
-		exportShows(traktClient, letterboxdExporter, log)
-		exportRatings(traktClient, letterboxdExporter, log)
-		exportWatchlist(traktClient, letterboxdExporter, log)
-	default:
-		log.Error("errors.invalid_export_type", map[string]interface{}{"type": exportType})
-		fmt.Printf("Invalid export type: %s. Valid types are 'watched', 'collection', 'shows', 'ratings', 'watchlist', or 'all'\n", exportType)
-		os.Exit(1)
+	skipped, err := runExports(ctx, cfg, traktClient, letterboxdExporter, log, exportType, exportMode, historyMode)
+	if err != nil {
+		return err
 	}
 
 	log.Info("export.completed_successfully", map[string]interface{}{
 		"export_type": exportType,
 		"export_mode": exportMode,
+		"skipped":     skipped,
 		"timestamp":   time.Now().Format(time.RFC3339),
 	})
+	return nil
+}
+
+// runExportCommand runs the export command with the keyring and token
+// manager of main and returns its error, already logged. Failures are
+// reported as failed runs.
+func runExportCommand(ctx context.Context, cfg *config.Config, log logger.Logger, keyringMgr *keyring.Manager, tokenManager *auth.TokenManager, exportType, exportMode, historyMode string) (err error) {
+	tm := startTelemetry(cfg, log)
+	defer stopTelemetry(tm, log)
+	publishRun := trackRun(cfg, tm, log)
+	defer func() { publishRun(err) }()
+
+	// Initialize Trakt client with token management
+	traktClient, err := newTraktClient(cfg, log, tokenManager)
+	if err != nil {
+		return exportError(log, "errors.api_client_failed", err)
+	}
+	defer traktClient.Close()
+	remindCredentialRotation(cfg, log, tokenManager)
+
+	// Initialize Letterboxd exporter
+	letterboxdExporter := export.NewLetterboxdExporter(cfg, log)
+	instrumentExporter(letterboxdExporter, tm)
+	if err := signExports(cfg, log, keyringMgr, letterboxdExporter); err != nil {
+		return exportError(log, "export.signing_failed", err)
+	}
+
+	// Log export mode
+	log.Info("export.mode", map[string]interface{}{
+		"mode": exportMode,
+	})
+
+	// Perform the export based on type
+	log.Info("export.starting_data_retrieval", map[string]interface{}{
+		"export_type": exportType,
+	})
+
+	skipped, err := runExports(ctx, cfg, traktClient, letterboxdExporter, log, exportType, exportMode, historyMode)
+	if err != nil {
+		return err
+	}
+	log.Info("export.completed_successfully", map[string]interface{}{
+		"export_type": exportType,
+		"export_mode": exportMode,
+		"skipped":     skipped,
+	})
+	return nil
 }
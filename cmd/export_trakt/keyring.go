@@ -0,0 +1,315 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/keyring"
+	"golang.org/x/term"
+)
+
+// fileKeys caches the file backend keys by credentials path, so a
+// passphrase is asked for once per run
+var fileKeys = make(map[string]*keyring.FileKey)
+
+// newKeyringManager creates the credential manager for the configured keyring backend
+func newKeyringManager(cfg *config.Config) (*keyring.Manager, error) {
+	name := keyring.BackendName(cfg.Security.KeyringBackend)
+	if name == "" {
+		name = keyring.SystemBackend
+	}
+
+	var chain []keyring.BackendName
+	for _, backend := range cfg.Security.ChainKeyring.Backends {
+		chain = append(chain, keyring.BackendName(backend))
+	}
+	options := []keyring.Option{
+		keyring.WithDir(dirKeyringPath(cfg)),
+		keyring.WithVault(vaultConfig(cfg)),
+		keyring.WithChain(chain...),
+	}
+
+	usesFile := name == keyring.FileBackend
+	if name == keyring.ChainBackend {
+		for _, backend := range chain {
+			usesFile = usesFile || backend == keyring.FileBackend
+		}
+	}
+
+	var fileKey *keyring.FileKey
+	opts := fileKeyOptions(cfg)
+	if usesFile {
+		fileKey = fileKeys[opts.CredentialsPath]
+		if fileKey == nil {
+			var err error
+			if fileKey, err = keyring.LoadFileKey(opts); err != nil {
+				return nil, err
+			}
+		}
+		options = append(options,
+			keyring.WithEncryptionKey(fileKey.Key),
+			keyring.WithFilePath(opts.CredentialsPath))
+	}
+
+	mgr, err := keyring.NewManager(name, options...)
+	if err != nil {
+		return nil, err
+	}
+	if err := mgr.Verify(); err != nil {
+		return nil, err
+	}
+	if fileKey != nil {
+		fileKeys[opts.CredentialsPath] = fileKey
+	}
+	return mgr, nil
+}
+
+// newSecretResolver resolves secret references in the configuration through the keyring
+func newSecretResolver(cfg *config.Config) (config.SecretResolver, error) {
+	return newKeyringManager(cfg)
+}
+
+// dirKeyringPath returns the secrets directory of the dir backend
+func dirKeyringPath(cfg *config.Config) string {
+	if cfg.Security.DirKeyring.Path != "" {
+		return cfg.Security.DirKeyring.Path
+	}
+	return security.DefaultDirKeyringConfig().Path
+}
+
+// vaultConfig returns the Vault settings of the vault backend, completed
+// with defaults for configurations built without them
+func vaultConfig(cfg *config.Config) keyring.VaultConfig {
+	vc := cfg.Security.VaultKeyring
+	defaults := security.DefaultVaultKeyringConfig()
+	if vc.TokenEnv == "" {
+		vc.TokenEnv = defaults.TokenEnv
+	}
+	if vc.Mount == "" {
+		vc.Mount = defaults.Mount
+	}
+	if vc.Path == "" {
+		vc.Path = defaults.Path
+	}
+
+	return keyring.VaultConfig{
+		Address:   vc.Address,
+		Token:     os.Getenv(vc.TokenEnv),
+		TokenFile: vc.TokenFile,
+		Namespace: vc.Namespace,
+		Mount:     vc.Mount,
+		Path:      vc.Path,
+		KVVersion: vc.KVVersion,
+		Timeout:   vc.Timeout,
+	}
+}
+
+// fileKeyOptions returns the key options of the file keyring configuration,
+// completed with defaults for configurations built without them
+func fileKeyOptions(cfg *config.Config) keyring.FileKeyOptions {
+	fc := cfg.Security.FileKeyring
+	defaults := security.DefaultFileKeyringConfig()
+	if fc.Path == "" {
+		fc.Path = defaults.Path
+	}
+	if fc.KeySource == "" {
+		fc.KeySource = defaults.KeySource
+	}
+	if fc.KeyFile == "" {
+		fc.KeyFile = defaults.KeyFile
+	}
+	if fc.PassphraseEnv == "" {
+		fc.PassphraseEnv = defaults.PassphraseEnv
+	}
+
+	opts := keyring.FileKeyOptions{
+		Source:          keyring.KeySource(fc.KeySource),
+		CredentialsPath: fc.Path,
+		KeyFile:         fc.KeyFile,
+		PassphraseEnv:   fc.PassphraseEnv,
+	}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		opts.Prompt = promptPassphrase
+	}
+	return opts
+}
+
+// promptPassphrase reads a passphrase from the terminal without echoing it
+func promptPassphrase(label string, confirm bool) (string, error) {
+	read := func(label string) (string, error) {
+		fmt.Fprintf(os.Stderr, "🔑 %s: ", label)
+		passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase: %w", err)
+		}
+		return string(passphrase), nil
+	}
+
+	passphrase, err := read(label)
+	if err != nil || !confirm {
+		return passphrase, err
+	}
+	again, err := read("Confirm " + strings.ToLower(label[:1]) + label[1:])
+	if err != nil {
+		return "", err
+	}
+	if again != passphrase {
+		return "", fmt.Errorf("the passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// runKeyringCommand handles the 'keyring' command and its subcommands
+func runKeyringCommand(cfg *config.Config, log logger.Logger, args []string) error {
+	if len(args) == 0 {
+		fmt.Println("Usage: keyring status | keyring rekey [--to passphrase|key_file|system] [--legacy]")
+		return fmt.Errorf("missing keyring subcommand")
+	}
+
+	switch args[0] {
+	case "status":
+		return showKeyringStatus(cfg)
+	case "rekey":
+		return rekeyCredentials(cfg, log, args[1:])
+	default:
+		return fmt.Errorf("unknown keyring subcommand: %s", args[0])
+	}
+}
+
+// showKeyringStatus prints the keyring backend, its key source and how many
+// credentials it holds
+func showKeyringStatus(cfg *config.Config) error {
+	fmt.Println("🔐 Keyring Status")
+	fmt.Println("=================")
+	fmt.Printf("Backend: %s\n", cfg.Security.KeyringBackend)
+	switch cfg.Security.KeyringBackend {
+	case "chain":
+		fmt.Printf("Chain: %s\n", strings.Join(cfg.Security.ChainKeyring.Backends, " → "))
+	case "dir":
+		fmt.Printf("Secrets directory: %s\n", dirKeyringPath(cfg))
+	case "vault":
+		vc := vaultConfig(cfg)
+		fmt.Printf("Vault: %s (%s/%s)\n", vc.Address, vc.Mount, vc.Path)
+	}
+
+	if cfg.Security.KeyringBackend == "file" {
+		opts := fileKeyOptions(cfg)
+		fmt.Printf("Credentials file: %s\n", opts.CredentialsPath)
+		key, err := keyring.LoadFileKey(opts)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Key source: %s\n", key.Source)
+		if key.Source == keyring.KeySourceKeyFile {
+			fmt.Printf("Key file: %s\n", opts.KeyFile)
+		}
+		fileKeys[opts.CredentialsPath] = key
+	}
+
+	mgr, err := newKeyringManager(cfg)
+	if err != nil {
+		return err
+	}
+	defer mgr.Destroy()
+
+	keys, err := mgr.List()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Credentials: %d\n", len(keys))
+	return nil
+}
+
+// rekeyCredentials re-encrypts the credentials file with a new key. The new
+// key material is saved before the credentials are rewritten, and the old
+// material restored when rewriting them fails.
+func rekeyCredentials(cfg *config.Config, log logger.Logger, args []string) error {
+	rekeyFlags := flag.NewFlagSet("keyring rekey", flag.ContinueOnError)
+	to := rekeyFlags.String("to", "", "Key source of the new key (passphrase, key_file, system), defaults to the configured one")
+	legacy := rekeyFlags.Bool("legacy", false, "Decrypt credentials written with the fixed key of earlier versions")
+	if err := rekeyFlags.Parse(args); err != nil {
+		return err
+	}
+
+	if cfg.Security.KeyringBackend != "file" {
+		return fmt.Errorf("rekey requires the file keyring backend, the configured backend is %s", cfg.Security.KeyringBackend)
+	}
+
+	opts := fileKeyOptions(cfg)
+	oldOpts := opts
+	if *legacy {
+		oldOpts.Source = keyring.KeySourceLegacy
+	}
+	oldKey, err := keyring.LoadFileKey(oldOpts)
+	if err != nil {
+		return fmt.Errorf("failed to load the current key: %w", err)
+	}
+	defer oldKey.Destroy()
+
+	mgr, err := keyring.NewManager(keyring.FileBackend,
+		keyring.WithEncryptionKey(oldKey.Key),
+		keyring.WithFilePath(opts.CredentialsPath))
+	if err != nil {
+		return err
+	}
+	defer mgr.Destroy()
+	if err := mgr.Verify(); err != nil {
+		return err
+	}
+
+	source := keyring.KeySource(*to)
+	if source == "" {
+		source = opts.Source
+	}
+	if source == keyring.KeySourceAuto {
+		source = oldKey.Source
+	}
+	if source == keyring.KeySourceLegacy {
+		source = keyring.KeySourceKeyFile
+	}
+
+	passphrase := ""
+	if source == keyring.KeySourcePassphrase && opts.Prompt == nil {
+		passphrase = os.Getenv(opts.PassphraseEnv)
+	}
+	newKey, err := keyring.NewFileKey(opts, source, passphrase)
+	if err != nil {
+		return err
+	}
+	defer newKey.Destroy()
+
+	if err := newKey.Save(); err != nil {
+		return fmt.Errorf("failed to save the new key: %w", err)
+	}
+	if err := mgr.Rekey(newKey.Key); err != nil {
+		if restoreErr := oldKey.Save(); restoreErr != nil {
+			log.Error("keyring.rekey_restore_failed", map[string]interface{}{"error": restoreErr.Error()})
+		}
+		return fmt.Errorf("failed to re-encrypt credentials: %w", err)
+	}
+	if oldKey.Source != newKey.Source {
+		// Left behind, the old material would be found first by the auto source
+		if err := oldKey.Remove(); err != nil {
+			log.Warn("keyring.old_key_not_removed", map[string]interface{}{"error": err.Error()})
+		}
+	}
+
+	log.Info("keyring.rekeyed", map[string]interface{}{
+		"from": string(oldKey.Source),
+		"to":   string(newKey.Source),
+	})
+	fmt.Printf("✅ Credentials re-encrypted with a new %s key\n", newKey.Source)
+	if newKey.Source == keyring.KeySourceKeyFile {
+		fmt.Printf("🔑 Key saved to %s, back it up: the credentials cannot be read without it\n", opts.KeyFile)
+	}
+	if opts.Source != keyring.KeySourceAuto && opts.Source != newKey.Source {
+		fmt.Printf("💡 Set security.file_keyring.key_source = \"%s\" in your configuration\n", newKey.Source)
+	}
+	return nil
+}
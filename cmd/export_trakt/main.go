@@ -1,20 +1,20 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
-	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/api"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/auth"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
-	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/export"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/i18n"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/purge"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/scheduler"
-	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security"
-	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/keyring"
 )
 
 func main() {
@@ -34,6 +34,8 @@ func main() {
 	runOnce := flag.Bool("run", false, "Run the script immediately once then exit")
 	scheduleFlag := flag.String("schedule", "", "Run the script according to cron schedule format (e.g., '0 */6 * * *' for every 6 hours)")
 	validateSecurity := flag.Bool("validate-security", false, "Validate security configuration and exit")
+	overrides := make(overrideFlags)
+	flag.Var(overrides, "set", "Override a configuration value (e.g. --set export.timezone=UTC), can be repeated")
 	flag.Parse()
 
 	// Get command from args
@@ -45,16 +47,40 @@ func main() {
 	// Initialize logger
 	log := logger.NewLogger()
 
+	// The setup wizard creates the configuration, so it runs before loading it
+	if command == "setup" {
+		if err := runSetupCommand(*configPath, flag.Args()[1:], log); err != nil {
+			log.Error("setup.failed", map[string]interface{}{"error": err.Error()})
+			fmt.Printf("❌ Setup failed: %s\n", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Load configuration
 	log.Info("startup.loading_config", map[string]interface{}{"path": *configPath})
-	cfg, err := config.LoadConfig(*configPath)
+	loader := config.NewLoader(*configPath)
+	loader.Overrides = overrides
+	loader.NewResolver = newSecretResolver
+	cfg, err := loader.Load()
 	if err != nil {
 		log.Error("errors.config_load_failed", map[string]interface{}{"error": err.Error()})
 		os.Exit(1)
 	}
 
+	if migration := loader.Migration(); migration != nil && migration.Changed() {
+		log.Warn("config.migrated_in_memory", map[string]interface{}{
+			"from_version": migration.FromVersion,
+			"to_version":   migration.ToVersion,
+			"hint":         "run 'config migrate --write' to upgrade the file",
+		})
+	}
+
 	// Configure logger based on config
 	log.SetLogLevel(cfg.Logging.Level)
+	if standard, ok := log.(*logger.StandardLogger); ok {
+		standard.SetPrivacyMode(cfg.Logging.Privacy)
+	}
 	if cfg.Logging.File != "" && os.Getenv("DISABLE_LOG_FILE") == "" {
 		if err := log.SetLogFile(cfg.Logging.File); err != nil {
 			log.Error("errors.log_file_failed", map[string]interface{}{"error": err.Error()})
@@ -72,6 +98,16 @@ func main() {
 	// Update logger to use translator
 	log.SetTranslator(translator)
 
+	// The keyring command rekeys credentials the keyring manager cannot open yet
+	if command == "keyring" {
+		if err := runKeyringCommand(cfg, log, flag.Args()[1:]); err != nil {
+			log.Error("keyring.command_failed", map[string]interface{}{"error": err.Error()})
+			fmt.Printf("❌ Keyring command failed: %s\n", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Handle security validation flag
 	if *validateSecurity {
 		log.Info("security.validation_starting", nil)
@@ -85,7 +121,16 @@ func main() {
 			"export_type": *exportType,
 			"export_mode": *exportMode,
 		})
-		runExportOnce(cfg, log, *exportType, *exportMode, *historyMode)
+		tm := startTelemetry(cfg, log)
+		publishRun := trackRun(cfg, tm, log)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err := runExportOnce(ctx, cfg, log, tm, *exportType, *exportMode, *historyMode)
+		stop()
+		publishRun(err)
+		stopTelemetry(tm, log)
+		if err != nil {
+			os.Exit(1)
+		}
 		return
 	}
 
@@ -96,7 +141,7 @@ func main() {
 			"export_type": *exportType,
 			"export_mode": *exportMode,
 		})
-		runWithSchedule(cfg, log, *scheduleFlag, *exportType, *exportMode)
+		runWithSchedule(cfg, log, startTelemetry(cfg, log), *scheduleFlag, *exportType, *exportMode)
 		return
 	}
 
@@ -106,90 +151,35 @@ func main() {
 	})
 	log.Info("startup.config_loaded", nil)
 
-	// Initialize security manager and keyring
-	securityManager, err := security.NewManager(cfg.Security)
+	// Initialize keyring and security manager
+	keyringMgr, err := newKeyringManager(cfg)
 	if err != nil {
-		log.Error("errors.security_manager_failed", map[string]interface{}{"error": err.Error()})
+		log.Error("errors.keyring_manager_failed", map[string]interface{}{"error": err.Error()})
 		os.Exit(1)
 	}
-	defer securityManager.Close()
 
-	var keyringMgr *keyring.Manager
-	switch cfg.Security.KeyringBackend {
-	case "system":
-		keyringMgr, err = keyring.NewManager(keyring.SystemBackend)
-	case "env":
-		keyringMgr, err = keyring.NewManager(keyring.EnvBackend)
-	case "file":
-		// For file backend, we need to provide options
-		var options []keyring.Option
-		if cfg.Security.EncryptionEnabled {
-			// Generate a simple encryption key for demo purposes
-			key := make([]byte, 32) // AES-256 key
-			for i := range key {
-				key[i] = byte(i % 256) // Simple pattern for demo
-			}
-			options = append(options, keyring.WithEncryptionKey(key))
-		}
-		options = append(options, keyring.WithFilePath("./config/credentials.enc"))
-		keyringMgr, err = keyring.NewManager(keyring.FileBackend, options...)
-	case "memory":
-		keyringMgr, err = keyring.NewManager(keyring.MemoryBackend)
-	default:
-		keyringMgr, err = keyring.NewManager(keyring.SystemBackend)
-	}
+	// Sign the checkpoints of the audit log with a key kept in the keyring
+	securityManager, err := newSecurityManager(cfg, log, keyringMgr)
 	if err != nil {
-		log.Error("errors.keyring_manager_failed", map[string]interface{}{"error": err.Error()})
+		log.Error("errors.security_manager_failed", map[string]interface{}{"error": err.Error()})
 		os.Exit(1)
 	}
+	defer securityManager.Close()
 
 	// Initialize token manager
 	tokenManager := auth.NewTokenManager(cfg, log, keyringMgr)
 
-	// Initialize Trakt client with token management
-	var traktClient *api.Client
-	if cfg.Auth.UseOAuth {
-		traktClient = api.NewClientWithTokenManager(cfg, log, tokenManager)
-	} else {
-		traktClient = api.NewClient(cfg, log)
-	}
+	// Removes the local data of the account, keeping the audit log and export signing keys
+	purger := purge.NewPurger(cfg, log, securityManager, keyringMgr, tokenManager, auditCheckpointKeyName, cfg.Export.Signing.KeyName)
 
 	// Process command
 	switch strings.ToLower(command) {
 	case "export":
-		// Initialize Letterboxd exporter
-		letterboxdExporter := export.NewLetterboxdExporter(cfg, log)
-
-		// Log export mode
-		log.Info("export.mode", map[string]interface{}{
-			"mode": *exportMode,
-		})
-
-		// Perform the export based on type
-		log.Info("export.starting_data_retrieval", map[string]interface{}{
-			"export_type": *exportType,
-		})
+		// Interrupting the export stops its in-flight API requests
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
 
-		switch *exportType {
-		case "watched":
-			exportWatchedMovies(traktClient, letterboxdExporter, log, *historyMode)
-		case "collection":
-			exportCollection(traktClient, letterboxdExporter, log)
-		case "shows":
-			exportShows(traktClient, letterboxdExporter, log)
-		case "ratings":
-			exportRatings(traktClient, letterboxdExporter, log)
-		case "watchlist":
-			exportWatchlist(traktClient, letterboxdExporter, log)
-		case "all":
-			exportWatchedMovies(traktClient, letterboxdExporter, log, *historyMode)
-			exportCollection(traktClient, letterboxdExporter, log)
-			exportShows(traktClient, letterboxdExporter, log)
-			exportRatings(traktClient, letterboxdExporter, log)
-			exportWatchlist(traktClient, letterboxdExporter, log)
-		default:
-			log.Error("errors.invalid_export_type", map[string]interface{}{"type": *exportType})
-			fmt.Printf("Invalid export type: %s. Valid types are 'watched', 'collection', 'shows', 'ratings', 'watchlist', or 'all'\n", *exportType)
+		if err := runExportCommand(ctx, cfg, log, keyringMgr, tokenManager, *exportType, *exportMode, *historyMode); err != nil {
 			os.Exit(1)
 		}
 
@@ -212,17 +202,38 @@ func main() {
 		// Block forever (or until SIGINT/SIGTERM)
 		select {}
 
-	case "setup":
-		// Handle setup command - just inform for now
-		fmt.Println(translator.Translate("setup.instructions", nil))
+	case "config":
+		// Inspect the layered configuration
+		if err := runConfigCommand(loader, flag.Args()[1:]); err != nil {
+			log.Error("config.command_failed", map[string]interface{}{"error": err.Error()})
+			fmt.Printf("❌ Config command failed: %s\n", err.Error())
+			os.Exit(1)
+		}
+
+	case "cache":
+		// Inspect, clear or warm the persistent response cache
+		if err := runCacheCommand(cfg, log, tokenManager, flag.Args()[1:]); err != nil {
+			log.Error("cache.command_failed", map[string]interface{}{"error": err.Error()})
+			fmt.Printf("❌ Cache command failed: %s\n", err.Error())
+			os.Exit(1)
+		}
 
 	case "validate":
 		// Validate the configuration
 		fmt.Println(translator.Translate("validate.success", nil))
 
 	case "auth":
+		authFlags := flag.NewFlagSet("auth", flag.ExitOnError)
+		device := authFlags.Bool("device", false, "Use the device code flow (no callback server, for headless machines)")
+		authFlags.Parse(flag.Args()[1:])
+
+		authenticate := runInteractiveAuth
+		if *device {
+			authenticate = runDeviceAuth
+		}
+
 		// Interactive OAuth authentication
-		if err := runInteractiveAuth(cfg, log, tokenManager); err != nil {
+		if err := authenticate(cfg, log, tokenManager); err != nil {
 			log.Error("auth.interactive_failed", map[string]interface{}{"error": err.Error()})
 			fmt.Printf("❌ Authentication failed: %s\n", err.Error())
 			os.Exit(1)
@@ -277,12 +288,70 @@ func main() {
 
 	case "server":
 		// Start persistent server with callback and export endpoints
-		if err := startPersistentServer(cfg, log, tokenManager, *scheduleFlag, *exportType, *exportMode); err != nil {
+		tm := startTelemetry(cfg, log)
+		defer stopTelemetry(tm, log)
+		if err := startPersistentServer(cfg, log, tokenManager, securityManager, purger, tm, *scheduleFlag, *exportType, *exportMode); err != nil {
 			log.Error("server.start_failed", map[string]interface{}{"error": err.Error()})
 			fmt.Printf("❌ Failed to start server: %s\n", err.Error())
 			os.Exit(1)
 		}
 
+	case "credentials":
+		// Rotate the client secret and token, or show their age
+		c := &credentialsCommand{
+			cfg:          cfg,
+			log:          log,
+			loader:       loader,
+			security:     securityManager,
+			keyringMgr:   keyringMgr,
+			tokenManager: tokenManager,
+		}
+		if err := runCredentialsCommand(c, flag.Args()[1:]); err != nil {
+			log.Error("credentials.command_failed", map[string]interface{}{"error": err.Error()})
+			fmt.Printf("❌ Credentials command failed: %s\n", err.Error())
+			os.Exit(1)
+		}
+
+	case "audit":
+		// Verify the integrity of the audit log
+		if err := runAuditCommand(cfg, log, keyringMgr, flag.Args()[1:]); err != nil {
+			log.Error("audit.command_failed", map[string]interface{}{"error": err.Error()})
+			fmt.Printf("❌ Audit command failed: %s\n", err.Error())
+			os.Exit(1)
+		}
+
+	case "diagnostics":
+		// Write a redacted support bundle
+		if err := runDiagnosticsCommand(cfg, log, loader, tokenManager, flag.Args()[1:]); err != nil {
+			log.Error("diagnostics.command_failed", map[string]interface{}{"error": err.Error()})
+			fmt.Printf("❌ Diagnostics command failed: %s\n", err.Error())
+			os.Exit(1)
+		}
+
+	case "purge":
+		// Remove the local data of the account
+		if err := runPurgeCommand(purger, flag.Args()[1:]); err != nil {
+			log.Error("purge.command_failed", map[string]interface{}{"error": err.Error()})
+			fmt.Printf("❌ Purge command failed: %s\n", err.Error())
+			os.Exit(1)
+		}
+
+	case "signing-key":
+		// Print or write the public key verifying export signatures
+		if err := runSigningKeyCommand(cfg, keyringMgr, flag.Args()[1:]); err != nil {
+			log.Error("signing.command_failed", map[string]interface{}{"error": err.Error()})
+			fmt.Printf("❌ Signing key command failed: %s\n", err.Error())
+			os.Exit(1)
+		}
+
+	case "verify-signature":
+		// Check export files against their signatures
+		if err := runVerifySignatureCommand(cfg, keyringMgr, flag.Args()[1:]); err != nil {
+			log.Error("signing.verify_failed", map[string]interface{}{"error": err.Error()})
+			fmt.Printf("❌ Signature verification failed: %s\n", err.Error())
+			os.Exit(1)
+		}
+
 	case "fix-permissions":
 		// Fix file permissions for credentials storage
 		if err := fixCredentialsPermissions(cfg, log); err != nil {
@@ -293,7 +362,7 @@ func main() {
 
 	default:
 		log.Error("errors.invalid_command", map[string]interface{}{"command": command})
-		fmt.Printf("Invalid command: %s. Valid commands are 'export', 'schedule', 'setup', 'validate', 'auth', 'auth-url', 'auth-code', 'server', 'fix-permissions', 'token-status', 'token-refresh', 'token-clear'\n", command)
+		fmt.Printf("Invalid command: %s. Valid commands are 'export', 'schedule', 'config', 'cache', 'setup', 'validate', 'auth', 'auth-url', 'auth-code', 'server', 'fix-permissions', 'keyring', 'credentials', 'audit', 'diagnostics', 'purge', 'signing-key', 'verify-signature', 'token-status', 'token-refresh', 'token-clear'\n", command)
 		os.Exit(1)
 	}
 }
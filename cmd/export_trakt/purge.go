@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/purge"
+	"golang.org/x/term"
+)
+
+// purgeConfirmation must be typed to confirm an interactive purge
+const purgeConfirmation = "PURGE"
+
+// runPurgeCommand removes the exports, caches, checkpoints, logs, token and
+// keyring entries of the configured account
+func runPurgeCommand(purger *purge.Purger, args []string) error {
+	fs := flag.NewFlagSet("purge", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "List what would be removed without removing it")
+	yes := fs.Bool("yes", false, "Purge without asking for confirmation")
+	skipRevoke := fs.Bool("skip-revoke", false, "Clear the token without revoking it at Trakt")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	fmt.Println("🗑️  Local Data Purge")
+	fmt.Println("===================")
+
+	if !*dryRun && !*yes {
+		plan := purger.Purge(purge.Options{DryRun: true})
+		printPurgeReport(plan)
+		if !confirmPurge() {
+			return fmt.Errorf("purge not confirmed")
+		}
+	}
+
+	report := purger.Purge(purge.Options{DryRun: *dryRun, SkipRevoke: *skipRevoke, Origin: "cli"})
+	printPurgeReport(report)
+
+	if report.DryRun {
+		fmt.Println("ℹ️  Dry run: nothing was removed. Run 'purge --yes' to purge.")
+		return nil
+	}
+	if report.Failed() > 0 {
+		return fmt.Errorf("%d items could not be removed", report.Failed())
+	}
+	fmt.Println("✅ Local data purged, the audit log records the purge.")
+	return nil
+}
+
+// confirmPurge asks for the confirmation word on a terminal
+func confirmPurge() bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Println("❌ Not a terminal: run 'purge --yes' to purge without confirmation.")
+		return false
+	}
+
+	fmt.Printf("⚠️  This cannot be undone. Type %s to confirm: ", purgeConfirmation)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(line) == purgeConfirmation
+}
+
+// printPurgeReport prints what was, or would be, removed from each category
+func printPurgeReport(report *purge.Report) {
+	verb := "Removed"
+	if report.DryRun {
+		verb = "Would remove"
+	}
+
+	for _, category := range report.Categories {
+		fmt.Printf("%-12s %s %d\n", category.Name+":", verb, len(category.Removed))
+		if category.Name == purge.CategoryKeyring || category.Name == purge.CategoryToken {
+			for _, key := range category.Removed {
+				fmt.Printf("   • %s\n", key)
+			}
+		}
+		for _, message := range category.Errors {
+			fmt.Printf("   ❌ %s\n", message)
+		}
+	}
+	if report.Revoked {
+		fmt.Println("🔒 Token revoked at Trakt")
+	}
+}
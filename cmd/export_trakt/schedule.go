@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/telemetry"
 	"github.com/robfig/cron/v3"
 )
 
@@ -48,27 +50,13 @@ func getConfiguredTimezone(cfg *config.Config, log logger.Logger) *time.Location
 }
 
 // runWithSchedule sets up a cron scheduler and runs the export according to the schedule
-func runWithSchedule(cfg *config.Config, log logger.Logger, schedule, exportType, exportMode string) {
+func runWithSchedule(cfg *config.Config, log logger.Logger, tm *telemetry.TelemetryManager, schedule, exportType, exportMode string) {
 	log.Info("scheduler.initializing", map[string]interface{}{
 		"schedule":    schedule,
 		"export_type": exportType,
 		"export_mode": exportMode,
 	})
 
-	// Check for verbose logging environment variable
-	if os.Getenv("EXPORT_VERBOSE") == "true" {
-		log.SetLogLevel("debug")
-		log.Info("scheduler.verbose_mode_enabled", nil)
-	}
-
-	// Override log level if LOG_LEVEL environment variable is set
-	if logLevel := os.Getenv("LOG_LEVEL"); logLevel != "" {
-		log.SetLogLevel(logLevel)
-		log.Info("scheduler.log_level_set", map[string]interface{}{
-			"level": logLevel,
-		})
-	}
-
 	// Get configured timezone for display
 	configuredTZ := getConfiguredTimezone(cfg, log)
 
@@ -95,6 +83,9 @@ func runWithSchedule(cfg *config.Config, log logger.Logger, schedule, exportType
 	// Create a new cron scheduler
 	c := cron.New()
 
+	// Cancelled on shutdown to stop the in-flight API requests of a running export
+	ctx, cancel := context.WithCancel(context.Background())
+
 	// Add the export job to the scheduler
 	entryID, err := c.AddFunc(schedule, func() {
 		log.Info("scheduler.job_triggered", map[string]interface{}{
@@ -111,7 +102,7 @@ func runWithSchedule(cfg *config.Config, log logger.Logger, schedule, exportType
 		})
 
 		startTime := time.Now()
-		runExportOnce(cfg, log, exportType, exportMode, "")
+		exportErr := runExportOnce(ctx, cfg, log, tm, exportType, exportMode, "")
 		duration := time.Since(startTime)
 
 		// Get next run time for display
@@ -129,6 +120,15 @@ func runWithSchedule(cfg *config.Config, log logger.Logger, schedule, exportType
 			"next_run":    nextRunDisplay,
 		})
 
+		// A failed run is already logged; the scheduler keeps running
+		if exportErr != nil {
+			fmt.Printf("\n❌ === EXPORT FAILED ===\n")
+			fmt.Printf("⏱️  Duration: %s\n", duration.String())
+			fmt.Printf("▶️  Next run: %s\n", nextRunDisplay)
+			fmt.Printf("============================\n\n")
+			return
+		}
+
 		// Display visual completion message with next run
 		fmt.Printf("\n✅ === EXPORT COMPLETED ===\n")
 		fmt.Printf("⏱️  Duration: %s\n", duration.String())
@@ -220,7 +220,9 @@ func runWithSchedule(cfg *config.Config, log logger.Logger, schedule, exportType
 			"signal": sig.String(),
 		})
 		fmt.Printf("\nReceived signal %s, shutting down gracefully...\n", sig)
-		c.Stop()
+		cancel()
+		<-c.Stop().Done()
+		stopTelemetry(tm, log)
 		log.Info("scheduler.shutdown_complete", nil)
 		os.Exit(0)
 	}()
@@ -13,6 +13,9 @@ import (
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/auth"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/purge"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/telemetry"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/web"
 )
 
@@ -30,18 +33,30 @@ type ExportItem struct {
 }
 
 // startPersistentServer starts a persistent HTTP server that handles OAuth callbacks and export requests
-func startPersistentServer(cfg *config.Config, log logger.Logger, tokenManager *auth.TokenManager, scheduleFlag, exportType, exportMode string) error {
+func startPersistentServer(cfg *config.Config, log logger.Logger, tokenManager *auth.TokenManager, securityManager *security.Manager, purger *purge.Purger, tm *telemetry.TelemetryManager, scheduleFlag, exportType, exportMode string) error {
 	// Use the real web package with pagination support
-	webServer, err := web.NewServer(cfg, log, tokenManager)
+	webServer, err := web.NewServer(cfg, log, tokenManager, web.WithAuditLogger(securityManager.AuditLogger()), web.WithPurger(purger))
 	if err != nil {
 		return fmt.Errorf("failed to create web server: %w", err)
 	}
+	mountMetrics(webServer, cfg, tm)
 
 	port := cfg.Auth.CallbackPort
 	if port == 0 {
 		port = 8080
 	}
 
+	// Fall back to the schedule written by the setup wizard
+	if scheduleFlag == "" && cfg.Schedule.Cron != "" {
+		scheduleFlag = cfg.Schedule.Cron
+		if cfg.Schedule.ExportType != "" {
+			exportType = cfg.Schedule.ExportType
+		}
+		if cfg.Schedule.ExportMode != "" {
+			exportMode = cfg.Schedule.ExportMode
+		}
+	}
+
 	// Start scheduler if schedule flag is provided
 	if scheduleFlag != "" {
 		log.Info("server.starting_scheduler", map[string]interface{}{
@@ -51,7 +66,7 @@ func startPersistentServer(cfg *config.Config, log logger.Logger, tokenManager *
 		})
 
 		go func() {
-			runWithSchedule(cfg, log, scheduleFlag, exportType, exportMode)
+			runWithSchedule(cfg, log, tm, scheduleFlag, exportType, exportMode)
 		}()
 
 		fmt.Println("🕒 Automatic Export Scheduler Started")
@@ -61,14 +76,41 @@ func startPersistentServer(cfg *config.Config, log logger.Logger, tokenManager *
 		fmt.Println()
 	}
 
+	// Renew the token in the background so scheduled exports never find it expired
+	var refresher *auth.Refresher
+	if cfg.Auth.AutoRefresh {
+		refresher = auth.NewRefresher(tokenManager, cfg, log)
+		refresher.SetAlertManager(newAlertManager(cfg, log))
+		refresher.AddListener(webServer.GetStatusBroadcaster())
+		refresher.Start(context.Background())
+		fmt.Printf("🔄 Background token refresh: %s before expiry\n", cfg.Auth.RefreshBefore)
+	}
+
 	fmt.Println("🚀 Starting Enhanced Web Interface Server with Pagination")
 	fmt.Println("=========================================================")
 	fmt.Printf("📱 Client ID: %s\n", cfg.Trakt.ClientID)
 	fmt.Printf("🔗 Redirect URI: %s\n", cfg.Auth.RedirectURI)
-	fmt.Printf("🌐 Server running on: http://0.0.0.0:%d\n", port)
-	fmt.Printf("📊 Dashboard: http://0.0.0.0:%d/\n", port)
-	fmt.Printf("📁 Exports: http://0.0.0.0:%d/exports\n", port)
-	fmt.Printf("🔍 Status: http://0.0.0.0:%d/status\n", port)
+	scheme := cfg.Security.TLS.Scheme()
+	fmt.Printf("🌐 Server running on: %s://0.0.0.0:%d\n", scheme, port)
+	fmt.Printf("📊 Dashboard: %s://0.0.0.0:%d/\n", scheme, port)
+	fmt.Printf("📁 Exports: %s://0.0.0.0:%d/exports\n", scheme, port)
+	fmt.Printf("🔍 Status: %s://0.0.0.0:%d/status\n", scheme, port)
+	if cfg.Security.TLS.Enabled() {
+		fmt.Printf("🔒 TLS: %s\n", cfg.Security.TLS.Mode)
+		if fingerprint := webServer.TLSFingerprint(); fingerprint != "" {
+			fmt.Printf("🔑 Certificate SHA-256: %s\n", fingerprint)
+		}
+		if cfg.Security.TLS.HTTPRedirectPort != 0 {
+			fmt.Printf("↪️  HTTP redirect: http://0.0.0.0:%d\n", cfg.Security.TLS.HTTPRedirectPort)
+		}
+	}
+	if tm != nil {
+		if cfg.Monitoring.MetricsPort != 0 {
+			fmt.Printf("📈 Metrics: http://0.0.0.0:%d%s\n", cfg.Monitoring.MetricsPort, cfg.Monitoring.MetricsPath)
+		} else {
+			fmt.Printf("📈 Metrics: %s://0.0.0.0:%d%s\n", scheme, port, cfg.Monitoring.MetricsPath)
+		}
+	}
 	fmt.Println("📄 Features: Server-side pagination, lazy loading, configurable page sizes")
 	fmt.Println()
 
@@ -83,6 +125,10 @@ func startPersistentServer(cfg *config.Config, log logger.Logger, tokenManager *
 		})
 		fmt.Printf("\nReceived signal %s, shutting down server...\n", sig)
 
+		if refresher != nil {
+			refresher.Stop()
+		}
+
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
@@ -91,14 +137,15 @@ func startPersistentServer(cfg *config.Config, log logger.Logger, tokenManager *
 				"error": err.Error(),
 			})
 		}
+		stopTelemetry(tm, log)
 
 		log.Info("server.shutdown_complete", nil)
 		os.Exit(0)
 	}()
 
 	fmt.Printf("\n✅ Enhanced Web Interface with Pagination started! Press Ctrl+C to stop.\n")
-	fmt.Printf("🌐 Access your dashboard at: http://localhost:%d\n", port)
-	fmt.Printf("📁 Exports page: http://localhost:%d/exports\n", port)
+	fmt.Printf("🌐 Access your dashboard at: %s://localhost:%d\n", scheme, port)
+	fmt.Printf("📁 Exports page: %s://localhost:%d/exports\n", scheme, port)
 	fmt.Println()
 
 	// Start the web server with pagination support
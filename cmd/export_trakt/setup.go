@@ -0,0 +1,249 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/api"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/auth"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/validation"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/setup"
+)
+
+const traktAPIBaseURL = "https://api.trakt.tv"
+
+// runSetupCommand runs the setup wizard, interactively or from an answers file
+func runSetupCommand(configPath string, args []string, log logger.Logger) error {
+	fs := flag.NewFlagSet("setup", flag.ContinueOnError)
+	answersPath := fs.String("answers", "", "JSON file with the wizard answers (non-interactive mode)")
+	force := fs.Bool("force", false, "Overwrite an existing config file, keeping a backup")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	fmt.Println("🧙 Export Trakt 4 Letterboxd - Setup Wizard")
+	fmt.Println("===========================================")
+
+	var answers *setup.Answers
+	overwrite := *force
+	if *answersPath != "" {
+		loaded, err := setup.LoadAnswers(*answersPath)
+		if err != nil {
+			return err
+		}
+		if err := loaded.Validate(); err != nil {
+			return fmt.Errorf("invalid answers: %w", err)
+		}
+		if !loaded.SkipVerify {
+			fmt.Println("🔍 Verifying client ID with Trakt.tv...")
+			if err := api.VerifyClientID(nil, traktAPIBaseURL, loaded.ClientID); err != nil {
+				return fmt.Errorf("client ID verification failed: %w", err)
+			}
+			fmt.Println("✅ Client ID accepted by Trakt.tv")
+		}
+		answers = loaded
+	} else {
+		asked, confirmed, err := askSetupAnswers(configPath, *force)
+		if err != nil {
+			return err
+		}
+		answers = asked
+		overwrite = confirmed
+	}
+
+	// Store the client secret before the config referencing it is loaded
+	if err := storeClientSecret(answers); err != nil {
+		return err
+	}
+
+	backupPath, err := setup.WriteConfig(configPath, *answers, overwrite)
+	if err != nil {
+		return err
+	}
+	log.Info("setup.config_written", map[string]interface{}{"path": configPath})
+	fmt.Printf("\n💾 Configuration written to: %s\n", configPath)
+	if backupPath != "" {
+		fmt.Printf("📦 Previous configuration saved as: %s\n", backupPath)
+	}
+
+	return authenticateAfterSetup(configPath, answers, log, *answersPath == "")
+}
+
+// askSetupAnswers collects the answers interactively. It returns whether
+// an existing config file may be overwritten.
+func askSetupAnswers(configPath string, force bool) (*setup.Answers, bool, error) {
+	p := setup.NewPrompter(os.Stdin, os.Stdout)
+	answers := setup.DefaultAnswers()
+
+	overwrite := force
+	if _, err := os.Stat(configPath); err == nil && !force {
+		confirmed, err := p.Confirm(fmt.Sprintf("⚠️  %s already exists. Replace it (a backup is kept)?", configPath), false)
+		if err != nil {
+			return nil, false, err
+		}
+		if !confirmed {
+			return nil, false, errors.New("setup cancelled, existing configuration kept")
+		}
+		overwrite = true
+	}
+
+	fmt.Println("\n🔑 Step 1: Trakt.tv API credentials")
+	fmt.Println("   Create an application at https://trakt.tv/oauth/applications")
+	for {
+		var err error
+		if answers.ClientID, err = p.Ask("Client ID", "", nil); err != nil {
+			return nil, false, err
+		}
+		if answers.ClientSecret, err = p.AskSecret("Client Secret"); err != nil {
+			return nil, false, err
+		}
+		if err := validation.ValidateCredentials(answers.ClientID, answers.ClientSecret); err != nil {
+			fmt.Printf("   ❌ %s\n", err)
+			continue
+		}
+
+		fmt.Println("   🔍 Verifying client ID with Trakt.tv...")
+		if err := api.VerifyClientID(nil, traktAPIBaseURL, answers.ClientID); err != nil {
+			fmt.Printf("   ❌ %s\n", err)
+			keep, err := p.Confirm("   Keep these credentials anyway?", false)
+			if err != nil {
+				return nil, false, err
+			}
+			if !keep {
+				continue
+			}
+		} else {
+			fmt.Println("   ✅ Client ID accepted by Trakt.tv")
+		}
+		break
+	}
+
+	fmt.Println("\n🔒 Step 2: Credential storage")
+	fmt.Println("   system = OS keychain, file = encrypted file, env = environment variables (Docker)")
+	var err error
+	if answers.KeyringBackend, err = p.Choose("Keyring backend", []string{"system", "file", "env"}, answers.KeyringBackend); err != nil {
+		return nil, false, err
+	}
+
+	fmt.Println("\n🌐 Step 3: OAuth authentication")
+	fmt.Println("   The redirect URI must match your Trakt.tv application settings")
+	if answers.RedirectURI, err = p.Ask("Redirect URI", answers.RedirectURI, setup.ValidateRedirectURI); err != nil {
+		return nil, false, err
+	}
+	fmt.Println("   browser = local callback server, device = enter a code on any device (headless)")
+	fmt.Println("   code = paste the authorization code, skip = later")
+	if answers.AuthFlow, err = p.Choose("Authentication flow", []string{setup.AuthFlowBrowser, setup.AuthFlowDevice, setup.AuthFlowCode, setup.AuthFlowSkip}, answers.AuthFlow); err != nil {
+		return nil, false, err
+	}
+
+	fmt.Println("\n⚙️  Step 4: Export preferences")
+	if answers.Timezone, err = p.Ask("Timezone (e.g. Europe/Paris)", answers.Timezone, setup.ValidateTimezone); err != nil {
+		return nil, false, err
+	}
+	if answers.DateFormat, err = p.Ask("Date format (Go layout)", answers.DateFormat, setup.ValidateDateFormat); err != nil {
+		return nil, false, err
+	}
+	if answers.ExportDir, err = p.Ask("Export directory", answers.ExportDir, setup.ValidateExportDir); err != nil {
+		return nil, false, err
+	}
+	if answers.HistoryMode, err = p.Choose("Watch history mode", []string{"aggregated", "individual"}, answers.HistoryMode); err != nil {
+		return nil, false, err
+	}
+
+	fmt.Println("\n📅 Step 5: Scheduling (leave empty to disable)")
+	fmt.Println("   Examples: '0 */6 * * *' every 6 hours, '30 2 * * *' daily at 2:30 AM")
+	if answers.Schedule, err = p.Ask("Cron schedule", answers.Schedule, setup.ValidateSchedule); err != nil {
+		return nil, false, err
+	}
+	if answers.Schedule != "" {
+		if answers.ExportType, err = p.Choose("Scheduled export type", []string{"watched", "collection", "shows", "ratings", "watchlist", "all"}, answers.ExportType); err != nil {
+			return nil, false, err
+		}
+		if answers.ExportMode, err = p.Choose("Scheduled export mode", []string{"normal", "initial", "complete"}, answers.ExportMode); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return &answers, overwrite, nil
+}
+
+// storeClientSecret saves the client secret in the chosen keyring backend
+func storeClientSecret(answers *setup.Answers) error {
+	keyringCfg := &config.Config{Security: security.Config{
+		KeyringBackend:    answers.KeyringBackend,
+		EncryptionEnabled: true,
+	}}
+	keyringMgr, err := newKeyringManager(keyringCfg)
+	if err != nil {
+		return fmt.Errorf("failed to open keyring: %w", err)
+	}
+
+	if err := keyringMgr.Store(setup.ClientSecretKey, answers.ClientSecret); err != nil {
+		return fmt.Errorf("failed to store client secret: %w", err)
+	}
+
+	if answers.KeyringBackend == "env" {
+		fmt.Println("\n⚠️  The env backend cannot persist secrets. Set this variable in your environment:")
+		fmt.Println("   TRAKT_CLIENT_SECRET=<your client secret>")
+	} else {
+		fmt.Printf("\n🔐 Client secret stored in the %s keyring\n", answers.KeyringBackend)
+	}
+	return nil
+}
+
+// authenticateAfterSetup loads the new configuration and runs the chosen OAuth flow
+func authenticateAfterSetup(configPath string, answers *setup.Answers, log logger.Logger, interactive bool) error {
+	if answers.AuthFlow == setup.AuthFlowSkip {
+		fmt.Println("\n💡 Run the 'auth' command when you are ready to authenticate.")
+		return nil
+	}
+
+	loader := config.NewLoader(configPath)
+	loader.NewResolver = newSecretResolver
+	cfg, err := loader.Load()
+	if err != nil {
+		return fmt.Errorf("generated configuration is invalid: %w", err)
+	}
+
+	keyringMgr, err := newKeyringManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to open keyring: %w", err)
+	}
+	tokenManager := auth.NewTokenManager(cfg, log, keyringMgr)
+
+	fmt.Println()
+	switch answers.AuthFlow {
+	case setup.AuthFlowBrowser:
+		if err := runInteractiveAuth(cfg, log, tokenManager); err != nil {
+			return err
+		}
+	case setup.AuthFlowDevice:
+		if err := runDeviceAuth(cfg, log, tokenManager); err != nil {
+			return err
+		}
+	case setup.AuthFlowCode:
+		code := answers.AuthCode
+		if code == "" {
+			if !interactive {
+				return errors.New("auth_code is required for the code flow in non-interactive mode")
+			}
+			if err := showAuthURL(cfg, log); err != nil {
+				return err
+			}
+			if code, err = setup.NewPrompter(os.Stdin, os.Stdout).Ask("\nAuthorization code", "", nil); err != nil {
+				return err
+			}
+		}
+		if err := authenticateWithCode(cfg, log, tokenManager, code); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println("\n🎉 Setup complete! Run an export with: --run --export all")
+	return nil
+}
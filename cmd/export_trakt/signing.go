@@ -0,0 +1,171 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/export"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/keyring"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/signing"
+)
+
+// signExports makes the exporter sign its files when [export.signing] is
+// enabled, generating the signing key on first use
+func signExports(cfg *config.Config, log logger.Logger, keyringMgr *keyring.Manager, exporter *export.LetterboxdExporter) error {
+	if !cfg.Export.Signing.Enabled {
+		return nil
+	}
+
+	key, err := signing.LoadKey(keyringMgr, cfg.Export.Signing.KeyName, true)
+	if err != nil {
+		return fmt.Errorf("signing key unavailable: %w", err)
+	}
+	signer := signing.NewSigner(key)
+	exporter.SetSigner(signer)
+
+	log.Info("export.signing_enabled", map[string]interface{}{
+		"mode":        cfg.Export.Signing.Mode,
+		"fingerprint": signing.Fingerprint(signer.PublicKey()),
+	})
+	return nil
+}
+
+// runSigningKeyCommand prints or writes the public key verifying the
+// signatures of exports, generating the signing key when none exists
+func runSigningKeyCommand(cfg *config.Config, keyringMgr *keyring.Manager, args []string) error {
+	fs := flag.NewFlagSet("signing-key", flag.ContinueOnError)
+	output := fs.String("output", "", "Write the public key to this file instead of printing it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	key, err := signing.LoadKey(keyringMgr, cfg.Export.Signing.KeyName, true)
+	if err != nil {
+		return fmt.Errorf("signing key unavailable: %w", err)
+	}
+	pub := key.Public().(ed25519.PublicKey)
+	data, err := signing.MarshalPublicKey(pub)
+	if err != nil {
+		return err
+	}
+
+	// Printed alone so it can be piped to a file
+	if *output == "" {
+		fmt.Print(string(data))
+		return nil
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		return fmt.Errorf("failed to write public key: %w", err)
+	}
+	fmt.Printf("✅ Public key %s written to %s\n", signing.Fingerprint(pub), *output)
+	return nil
+}
+
+// runVerifySignatureCommand checks export files, or the export directories
+// given, against their signatures or signed manifest
+func runVerifySignatureCommand(cfg *config.Config, keyringMgr *keyring.Manager, args []string) error {
+	fs := flag.NewFlagSet("verify-signature", flag.ContinueOnError)
+	publicKey := fs.String("public-key", "", "Public key file written by 'signing-key', instead of the key in the keyring")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fmt.Println("Usage: verify-signature [--public-key key.pem] <file or export directory>...")
+		return fmt.Errorf("missing file or directory to verify")
+	}
+
+	pub, err := verificationKey(cfg, keyringMgr, *publicKey)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("🔏 Export Signature Verification")
+	fmt.Println("================================")
+	fmt.Printf("Public key: %s\n", signing.Fingerprint(pub))
+
+	failed := 0
+	for _, path := range fs.Args() {
+		results, err := verifyPath(pub, path)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", path, err)
+			failed++
+			continue
+		}
+		for _, result := range results {
+			switch {
+			case result.Err == nil:
+				fmt.Printf("✅ %s\n", result.Name)
+			case errors.Is(result.Err, signing.ErrUnsigned):
+				fmt.Printf("⚠️  %s: not signed\n", result.Name)
+				failed++
+			default:
+				fmt.Printf("❌ %s: %v\n", result.Name, result.Err)
+				failed++
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d files failed verification", failed)
+	}
+	fmt.Println("✅ All signatures are valid")
+	return nil
+}
+
+// verificationKey reads the public key from file, or derives it from the
+// signing key in the keyring
+func verificationKey(cfg *config.Config, keyringMgr *keyring.Manager, file string) (ed25519.PublicKey, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read public key: %w", err)
+		}
+		return signing.ParsePublicKey(data)
+	}
+
+	key, err := signing.LoadKey(keyringMgr, cfg.Export.Signing.KeyName, false)
+	if err == keyring.ErrCredentialNotFound {
+		return nil, fmt.Errorf("no signing key in the keyring, pass the public key with --public-key")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return key.Public().(ed25519.PublicKey), nil
+}
+
+// verifyPath verifies a file, or a directory through its manifest when it
+// has one and through the signatures of its files otherwise
+func verifyPath(pub ed25519.PublicKey, path string) ([]signing.FileResult, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return []signing.FileResult{{Name: path, Err: signing.VerifyFile(pub, path)}}, nil
+	}
+
+	if _, err := os.Stat(filepath.Join(path, signing.ManifestFile)); err == nil {
+		_, results, err := signing.VerifyManifest(pub, path)
+		return prefixResults(path, results), err
+	}
+	results, err := signing.VerifyDir(pub, path)
+	if err == nil && len(results) == 0 {
+		return nil, fmt.Errorf("no export files found")
+	}
+	return prefixResults(path, results), err
+}
+
+// prefixResults names the results of a directory by their path
+func prefixResults(dir string, results []signing.FileResult) []signing.FileResult {
+	for i := range results {
+		results[i].Name = filepath.Join(dir, results[i].Name)
+	}
+	return results
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/export"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/telemetry"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/web"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// version is reported by the telemetry, set at build time with
+// -ldflags "-X main.version=..."
+var version = "dev"
+
+// startTelemetry starts the telemetry manager of the [monitoring] section.
+// It returns nil when monitoring is disabled or cannot start, as exports
+// never depend on it.
+func startTelemetry(cfg *config.Config, log logger.Logger) *telemetry.TelemetryManager {
+	if !cfg.Monitoring.Enabled {
+		return nil
+	}
+
+	tm, err := telemetry.InitializeTelemetryFromConfig(cfg, version)
+	if err != nil {
+		log.Warn("telemetry.init_failed", map[string]interface{}{"error": err.Error()})
+		return nil
+	}
+	if err := tm.Start(context.Background()); err != nil {
+		log.Warn("telemetry.start_failed", map[string]interface{}{"error": err.Error()})
+		return nil
+	}
+
+	log.Info("telemetry.started", map[string]interface{}{
+		"metrics_path":  cfg.Monitoring.MetricsPath,
+		"metrics_port":  cfg.Monitoring.MetricsPort,
+		"health_checks": cfg.Monitoring.HealthChecksEnabled,
+		"tracing":       cfg.Monitoring.TracingEnabled,
+	})
+	return tm
+}
+
+// stopTelemetry stops the telemetry manager, flushing pending traces
+func stopTelemetry(tm *telemetry.TelemetryManager, log logger.Logger) {
+	if tm == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := tm.Stop(ctx); err != nil {
+		log.Warn("telemetry.stop_failed", map[string]interface{}{"error": err.Error()})
+	}
+}
+
+// instrumentExporter makes the exporter record its exports in the telemetry metrics
+func instrumentExporter(exporter *export.LetterboxdExporter, tm *telemetry.TelemetryManager) {
+	if tm == nil || tm.GetMetrics() == nil {
+		return
+	}
+	exporter.SetMetrics(tm.GetMetrics())
+}
+
+// mountMetrics exposes the metrics on the web server, unless metrics_port
+// gives them a listener of their own
+func mountMetrics(webServer *web.Server, cfg *config.Config, tm *telemetry.TelemetryManager) {
+	if tm == nil || tm.GetMetrics() == nil || cfg.Monitoring.MetricsPort != 0 {
+		return
+	}
+	webServer.HandleMetrics(cfg.Monitoring.MetricsPath, tm.GetMetrics().GetHTTPHandler())
+}
+
+// publishRunMetrics records the outcome of a one-shot run and publishes the
+// metrics to the Pushgateway and textfile of the [monitoring] section, as a
+// process that exits right away is never scraped
+func publishRunMetrics(cfg *config.Config, tm *telemetry.TelemetryManager, log logger.Logger, start time.Time, success bool) {
+	if tm == nil || tm.GetMetrics() == nil {
+		return
+	}
+	m := tm.GetMetrics()
+	m.RecordRun(success, time.Since(start), time.Now())
+
+	if cfg.Monitoring.PushgatewayURL != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := m.PushToGateway(ctx, cfg.Monitoring.PushgatewayURL, cfg.Monitoring.PushgatewayJob); err != nil {
+			log.Warn("telemetry.push_failed", map[string]interface{}{"error": err.Error()})
+		} else {
+			log.Info("telemetry.metrics_pushed", map[string]interface{}{
+				"url": cfg.Monitoring.PushgatewayURL,
+				"job": cfg.Monitoring.PushgatewayJob,
+			})
+		}
+	}
+
+	if cfg.Monitoring.TextfilePath != "" {
+		if err := m.WriteTextfile(cfg.Monitoring.TextfilePath); err != nil {
+			log.Warn("telemetry.textfile_failed", map[string]interface{}{"error": err.Error()})
+		} else {
+			log.Info("telemetry.textfile_written", map[string]interface{}{"path": cfg.Monitoring.TextfilePath})
+		}
+	}
+}
+
+// trackRun starts timing a one-shot run. The returned function publishes the
+// metrics of the run once it ended, as failed when err is set.
+func trackRun(cfg *config.Config, tm *telemetry.TelemetryManager, log logger.Logger) func(err error) {
+	start := time.Now()
+	return func(err error) {
+		publishRunMetrics(cfg, tm, log, start, err == nil)
+	}
+}
+
+// traceparentEnv carries the W3C trace context of the web request that
+// started an export to the export process
+const traceparentEnv = "TRACEPARENT"
+
+// startJobSpan starts the root span of an export job, the parent of the
+// Trakt API request and file write spans. When started by the web server,
+// the job joins the trace of the request found in TRACEPARENT. The returned
+// function ends the span, with an error status when err is set.
+func startJobSpan(ctx context.Context, log logger.Logger, exportType, exportMode string) (context.Context, func(err error)) {
+	if traceparent := os.Getenv(traceparentEnv); traceparent != "" {
+		ctx = propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{"traceparent": traceparent})
+	}
+
+	ctx, span := otel.Tracer("github.com/JohanDevl/Export_Trakt_4_Letterboxd/cmd/export_trakt").Start(ctx, "export.job",
+		trace.WithAttributes(
+			attribute.String("export.type", exportType),
+			attribute.String("export.mode", exportMode),
+		),
+	)
+	if span.SpanContext().IsValid() {
+		log.Info("export.trace_started", map[string]interface{}{
+			"trace_id": span.SpanContext().TraceID().String(),
+		})
+	}
+
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "export failed")
+		}
+		span.End()
+	}
+}
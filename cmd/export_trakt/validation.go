@@ -40,7 +40,11 @@ func validateSecurityConfiguration(cfg *config.Config, log logger.Logger) int {
 
 	// 3. Test security manager initialization
 	fmt.Println("\n🔧 Testing Security Manager...")
-	securityManager, err := security.NewManager(cfg.Security)
+	var securityManager *security.Manager
+	keyringMgr, err := newKeyringManager(cfg)
+	if err == nil {
+		securityManager, err = newSecurityManager(cfg, log, keyringMgr)
+	}
 	if err != nil {
 		errors = append(errors, fmt.Sprintf("Security manager initialization failed: %v", err))
 	} else {
@@ -117,6 +121,15 @@ func validateSecurityConfiguration(cfg *config.Config, log logger.Logger) int {
 	case "file":
 		fmt.Println("⚠️  Using encrypted file for credentials")
 		warnings = append(warnings, "File-based credential storage is less secure than system keyring")
+	case "dir":
+		fmt.Printf("✅ Using mounted secrets from %s\n", cfg.Security.DirKeyring.Path)
+	case "vault":
+		fmt.Println("✅ Using HashiCorp Vault for credentials")
+		if strings.HasPrefix(cfg.Security.VaultKeyring.Address, "http://") {
+			warnings = append(warnings, "Vault address does not use HTTPS")
+		}
+	case "chain":
+		fmt.Printf("✅ Reading credentials from %s, in that order\n", strings.Join(cfg.Security.ChainKeyring.Backends, ", "))
 	default:
 		errors = append(errors, fmt.Sprintf("Unknown keyring backend: %s", cfg.Security.KeyringBackend))
 	}
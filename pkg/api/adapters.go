@@ -58,15 +58,65 @@ func (ca *ClientAdapter) GetMovieHistory() ([]HistoryItem, error) {
 	return ca.client.GetMovieHistory()
 }
 
+// GetWatchedMoviesContext implements TraktAPIClient
+func (ca *ClientAdapter) GetWatchedMoviesContext(ctx context.Context) ([]Movie, error) {
+	return ca.client.GetWatchedMoviesContext(ctx)
+}
+
+// GetCollectionMoviesContext implements TraktAPIClient
+func (ca *ClientAdapter) GetCollectionMoviesContext(ctx context.Context) ([]CollectionMovie, error) {
+	return ca.client.GetCollectionMoviesContext(ctx)
+}
+
+// GetWatchedShowsContext implements TraktAPIClient
+func (ca *ClientAdapter) GetWatchedShowsContext(ctx context.Context) ([]WatchedShow, error) {
+	return ca.client.GetWatchedShowsContext(ctx)
+}
+
+// GetRatingsContext implements TraktAPIClient
+func (ca *ClientAdapter) GetRatingsContext(ctx context.Context) ([]Rating, error) {
+	return ca.client.GetRatingsContext(ctx)
+}
+
+// GetWatchlistContext implements TraktAPIClient
+func (ca *ClientAdapter) GetWatchlistContext(ctx context.Context) ([]WatchlistMovie, error) {
+	return ca.client.GetWatchlistContext(ctx)
+}
+
+// GetShowRatingsContext implements TraktAPIClient
+func (ca *ClientAdapter) GetShowRatingsContext(ctx context.Context) ([]ShowRating, error) {
+	return ca.client.GetShowRatingsContext(ctx)
+}
+
+// GetEpisodeRatingsContext implements TraktAPIClient
+func (ca *ClientAdapter) GetEpisodeRatingsContext(ctx context.Context) ([]EpisodeRating, error) {
+	return ca.client.GetEpisodeRatingsContext(ctx)
+}
+
+// GetMovieHistoryContext implements TraktAPIClient
+func (ca *ClientAdapter) GetMovieHistoryContext(ctx context.Context) ([]HistoryItem, error) {
+	return ca.client.GetMovieHistoryContext(ctx)
+}
+
+// GetLastActivities implements TraktAPIClient
+func (ca *ClientAdapter) GetLastActivities() (*LastActivities, error) {
+	return ca.client.GetLastActivities()
+}
+
+// GetLastActivitiesContext implements TraktAPIClient
+func (ca *ClientAdapter) GetLastActivitiesContext(ctx context.Context) (*LastActivities, error) {
+	return ca.client.GetLastActivitiesContext(ctx)
+}
+
 // GetConfig implements TraktAPIClient
 func (ca *ClientAdapter) GetConfig() *config.Config {
 	return ca.client.GetConfig()
 }
 
-// Close implements TraktAPIClient - basic client doesn't need explicit cleanup
+// Close implements TraktAPIClient, saving the pending statistics of the
+// persistent response cache
 func (ca *ClientAdapter) Close() error {
-	// Basic client doesn't require explicit cleanup
-	return nil
+	return FlushDiskCaches()
 }
 
 // OptimizedClientAdapter adapts the OptimizedClient to implement both TraktAPIClient and OptimizedTraktAPIClient
@@ -91,11 +141,10 @@ func (oca *OptimizedClientAdapter) GetCollectionMovies() ([]CollectionMovie, err
 	return oca.client.GetCollectionMoviesConcurrent(ctx)
 }
 
-// GetWatchedShows implements TraktAPIClient - fallback implementation
+// GetWatchedShows implements TraktAPIClient - uses concurrent version for better performance
 func (oca *OptimizedClientAdapter) GetWatchedShows() ([]WatchedShow, error) {
-	// OptimizedClient doesn't have GetWatchedShows, would need to be implemented
-	// For now, return empty slice - this should be implemented in the actual OptimizedClient
-	return []WatchedShow{}, nil
+	ctx := context.Background()
+	return oca.client.GetWatchedShowsConcurrent(ctx)
 }
 
 // GetRatings implements TraktAPIClient - uses concurrent version for better performance
@@ -110,25 +159,73 @@ func (oca *OptimizedClientAdapter) GetWatchlist() ([]WatchlistMovie, error) {
 	return oca.client.GetWatchlistConcurrent(ctx)
 }
 
-// GetShowRatings implements TraktAPIClient - fallback implementation
+// GetShowRatings implements TraktAPIClient - uses concurrent version for better performance
 func (oca *OptimizedClientAdapter) GetShowRatings() ([]ShowRating, error) {
-	// OptimizedClient doesn't have GetShowRatings, would need to be implemented
-	// For now, return empty slice - this should be implemented in the actual OptimizedClient
-	return []ShowRating{}, nil
+	ctx := context.Background()
+	return oca.client.GetShowRatingsConcurrent(ctx)
 }
 
-// GetEpisodeRatings implements TraktAPIClient - fallback implementation
+// GetEpisodeRatings implements TraktAPIClient - uses concurrent version for better performance
 func (oca *OptimizedClientAdapter) GetEpisodeRatings() ([]EpisodeRating, error) {
-	// OptimizedClient doesn't have GetEpisodeRatings, would need to be implemented
-	// For now, return empty slice - this should be implemented in the actual OptimizedClient
-	return []EpisodeRating{}, nil
+	ctx := context.Background()
+	return oca.client.GetEpisodeRatingsConcurrent(ctx)
 }
 
-// GetMovieHistory implements TraktAPIClient - fallback implementation
+// GetMovieHistory implements TraktAPIClient - uses concurrent version for better performance
 func (oca *OptimizedClientAdapter) GetMovieHistory() ([]HistoryItem, error) {
-	// OptimizedClient doesn't have GetMovieHistory, would need to be implemented
-	// For now, return empty slice - this should be implemented in the actual OptimizedClient
-	return []HistoryItem{}, nil
+	ctx := context.Background()
+	return oca.client.GetMovieHistoryConcurrent(ctx)
+}
+
+// GetWatchedMoviesContext implements TraktAPIClient - uses concurrent version for better performance
+func (oca *OptimizedClientAdapter) GetWatchedMoviesContext(ctx context.Context) ([]Movie, error) {
+	return oca.client.GetWatchedMoviesConcurrent(ctx)
+}
+
+// GetCollectionMoviesContext implements TraktAPIClient - uses concurrent version for better performance
+func (oca *OptimizedClientAdapter) GetCollectionMoviesContext(ctx context.Context) ([]CollectionMovie, error) {
+	return oca.client.GetCollectionMoviesConcurrent(ctx)
+}
+
+// GetWatchedShowsContext implements TraktAPIClient - uses concurrent version for better performance
+func (oca *OptimizedClientAdapter) GetWatchedShowsContext(ctx context.Context) ([]WatchedShow, error) {
+	return oca.client.GetWatchedShowsConcurrent(ctx)
+}
+
+// GetRatingsContext implements TraktAPIClient - uses concurrent version for better performance
+func (oca *OptimizedClientAdapter) GetRatingsContext(ctx context.Context) ([]Rating, error) {
+	return oca.client.GetRatingsConcurrent(ctx)
+}
+
+// GetWatchlistContext implements TraktAPIClient - uses concurrent version for better performance
+func (oca *OptimizedClientAdapter) GetWatchlistContext(ctx context.Context) ([]WatchlistMovie, error) {
+	return oca.client.GetWatchlistConcurrent(ctx)
+}
+
+// GetShowRatingsContext implements TraktAPIClient - uses concurrent version for better performance
+func (oca *OptimizedClientAdapter) GetShowRatingsContext(ctx context.Context) ([]ShowRating, error) {
+	return oca.client.GetShowRatingsConcurrent(ctx)
+}
+
+// GetEpisodeRatingsContext implements TraktAPIClient - uses concurrent version for better performance
+func (oca *OptimizedClientAdapter) GetEpisodeRatingsContext(ctx context.Context) ([]EpisodeRating, error) {
+	return oca.client.GetEpisodeRatingsConcurrent(ctx)
+}
+
+// GetMovieHistoryContext implements TraktAPIClient - uses concurrent version for better performance
+func (oca *OptimizedClientAdapter) GetMovieHistoryContext(ctx context.Context) ([]HistoryItem, error) {
+	return oca.client.GetMovieHistoryConcurrent(ctx)
+}
+
+// GetLastActivities implements TraktAPIClient
+func (oca *OptimizedClientAdapter) GetLastActivities() (*LastActivities, error) {
+	ctx := context.Background()
+	return oca.client.GetLastActivitiesConcurrent(ctx)
+}
+
+// GetLastActivitiesContext implements TraktAPIClient
+func (oca *OptimizedClientAdapter) GetLastActivitiesContext(ctx context.Context) (*LastActivities, error) {
+	return oca.client.GetLastActivitiesConcurrent(ctx)
 }
 
 // GetConfig implements TraktAPIClient
@@ -302,4 +302,116 @@ func TestOptimizedClient_BatchRequests(t *testing.T) {
 		assert.NotNil(t, result.Request)
 		assert.True(t, result.Index >= 0)
 	}
-}
\ No newline at end of file
+}
+
+func TestOptimizedClient_MovieHistoryConcurrentPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/sync/history/movies", r.URL.Path)
+		assert.Equal(t, "Bearer test_token", r.Header.Get("Authorization"))
+		assert.Equal(t, "test_client_id", r.Header.Get("trakt-api-key"))
+
+		page := r.URL.Query().Get("page")
+		action := "watch"
+		if page == "2" {
+			action = "checkin"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Pagination-Page-Count", "3")
+		w.Write([]byte(`[{"id":` + page + `,"action":"` + action + `","type":"movie","movie":{"title":"Page ` + page + `"}}]`))
+	}))
+	defer server.Close()
+
+	cfg := createTestConfig()
+	cfg.Trakt.APIBaseURL = server.URL
+	cfg.Trakt.AccessToken = "test_token"
+
+	client := NewOptimizedClient(OptimizedClientConfig{
+		Config:         cfg,
+		Logger:         &mockLogger{},
+		WorkerPoolSize: 2,
+		DisableCache:   true,
+	})
+	defer client.Close()
+
+	history, err := client.GetMovieHistoryConcurrent(context.Background())
+	require.NoError(t, err)
+
+	// Pages keep their order and non-watch actions are dropped
+	require.Len(t, history, 2)
+	assert.Equal(t, 1, history[0].ID)
+	assert.Equal(t, 3, history[1].ID)
+}
+
+func TestOptimizedClient_FailedPageCancelsOthers(t *testing.T) {
+	cancelled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Pagination-Page-Count", "3")
+		switch r.URL.Query().Get("page") {
+		case "1":
+			w.Write([]byte(`[]`))
+		case "2":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			select {
+			case <-r.Context().Done():
+				close(cancelled)
+			case <-time.After(10 * time.Second):
+			}
+		}
+	}))
+	defer server.Close()
+
+	cfg := createTestConfig()
+	cfg.Trakt.APIBaseURL = server.URL
+	cfg.Trakt.AccessToken = "test_token"
+
+	client := NewOptimizedClient(OptimizedClientConfig{
+		Config:         cfg,
+		Logger:         &mockLogger{},
+		WorkerPoolSize: 2,
+		DisableCache:   true,
+	})
+	defer client.Close()
+
+	_, err := client.GetMovieHistoryConcurrent(context.Background())
+	require.Error(t, err)
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the failed page to cancel the pending page request")
+	}
+}
+
+func TestCapabilitiesFromConfig(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.Client = config.ClientConfig{WorkerPoolSize: 1}
+
+	capabilities := CapabilitiesFromConfig(cfg)
+	assert.False(t, capabilities.EnableConcurrency)
+	assert.Zero(t, capabilities.WorkerPoolSize)
+
+	factory := NewClientFactory(ClientFactoryConfig{Logger: &mockLogger{}})
+	client, err := factory.CreateClientWithCapabilities(capabilities)
+	require.NoError(t, err)
+	assert.IsType(t, &ClientAdapter{}, client)
+
+	cfg.Client = config.ClientConfig{
+		WorkerPoolSize: 4,
+		EnableCaching:  true,
+		CacheCapacity:  10,
+		CacheTTL:       time.Minute,
+	}
+
+	capabilities = CapabilitiesFromConfig(cfg)
+	assert.True(t, capabilities.EnableConcurrency)
+	assert.Equal(t, 4, capabilities.WorkerPoolSize)
+	require.NotNil(t, capabilities.CacheConfig)
+	assert.Equal(t, 10, capabilities.CacheConfig.Capacity)
+
+	client, err = factory.CreateClientWithCapabilities(capabilities)
+	require.NoError(t, err)
+	defer client.Close()
+	assert.IsType(t, &OptimizedClientAdapter{}, client)
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metricsService is the service label of Trakt API call metrics
+const metricsService = "trakt"
+
+// CallRecorder receives the outcome of every request sent to the Trakt API.
+// The Prometheus metrics of the telemetry manager implement it.
+type CallRecorder interface {
+	RecordAPICall(service, endpoint, method, statusCode string, duration time.Duration)
+	RecordAPIError(service, endpoint, errorType string)
+}
+
+var (
+	callRecorderMu sync.RWMutex
+	callRecorder   CallRecorder
+)
+
+// SetCallRecorder makes every Trakt client of the process report its
+// requests to r. A nil r stops the reporting.
+func SetCallRecorder(r CallRecorder) {
+	callRecorderMu.Lock()
+	defer callRecorderMu.Unlock()
+	callRecorder = r
+}
+
+func currentCallRecorder() CallRecorder {
+	callRecorderMu.RLock()
+	defer callRecorderMu.RUnlock()
+	return callRecorder
+}
+
+// metricsTransport reports each request sent on the wire, so retries made
+// by the rate limit transport are counted individually
+type metricsTransport struct {
+	base http.RoundTripper
+}
+
+func newMetricsTransport(base http.RoundTripper) *metricsTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &metricsTransport{base: base}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorder := currentCallRecorder()
+	if recorder == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	endpoint := metricsEndpoint(req.URL.Path)
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	duration := time.Since(start)
+
+	if err != nil {
+		recorder.RecordAPICall(metricsService, endpoint, req.Method, "error", duration)
+		recorder.RecordAPIError(metricsService, endpoint, "network")
+		return nil, err
+	}
+
+	recorder.RecordAPICall(metricsService, endpoint, req.Method, strconv.Itoa(resp.StatusCode), duration)
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		recorder.RecordAPIError(metricsService, endpoint, "rate_limited")
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		recorder.RecordAPIError(metricsService, endpoint, "auth")
+	case resp.StatusCode >= 500:
+		recorder.RecordAPIError(metricsService, endpoint, "server_error")
+	case resp.StatusCode >= 400:
+		recorder.RecordAPIError(metricsService, endpoint, "client_error")
+	}
+	return resp, nil
+}
+
+// metricsEndpoint keeps the endpoint label bounded by replacing the user
+// slug of /users/{id}/... paths
+func metricsEndpoint(path string) string {
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 3)
+	if len(parts) == 3 && parts[0] == "users" {
+		return "/users/{id}/" + parts[2]
+	}
+	return path
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordedCall is one call seen by recordingCallRecorder
+type recordedCall struct {
+	endpoint   string
+	method     string
+	statusCode string
+}
+
+type recordingCallRecorder struct {
+	mu     sync.Mutex
+	calls  []recordedCall
+	errors []string
+}
+
+func (r *recordingCallRecorder) RecordAPICall(service, endpoint, method, statusCode string, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, recordedCall{endpoint: endpoint, method: method, statusCode: statusCode})
+}
+
+func (r *recordingCallRecorder) RecordAPIError(service, endpoint, errorType string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, errorType)
+}
+
+func TestClientRecordsAPICalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/sync/watchlist/movies" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"not_found"}`))
+	}))
+	defer server.Close()
+
+	recorder := &recordingCallRecorder{}
+	SetCallRecorder(recorder)
+	defer SetCallRecorder(nil)
+
+	cfg := &config.Config{
+		Trakt: config.TraktConfig{
+			ClientID:    "client",
+			AccessToken: "token",
+			APIBaseURL:  server.URL,
+		},
+	}
+	client := NewClient(cfg, &MockLogger{})
+
+	_, err := client.GetWatchlist()
+	assert.NoError(t, err)
+	_, err = client.GetRatings()
+	assert.Error(t, err)
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if assert.Len(t, recorder.calls, 2) {
+		assert.Equal(t, recordedCall{"/sync/watchlist/movies", "GET", "200"}, recorder.calls[0])
+		assert.Equal(t, recordedCall{"/sync/ratings/movies", "GET", "404"}, recorder.calls[1])
+	}
+	assert.Equal(t, []string{"client_error"}, recorder.errors)
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	assert.Equal(t, "/sync/history/movies", metricsEndpoint("/sync/history/movies"))
+	assert.Equal(t, "/users/settings", metricsEndpoint("/users/settings"))
+	assert.Equal(t, "/users/{id}/watched/movies", metricsEndpoint("/users/johndoe/watched/movies"))
+}
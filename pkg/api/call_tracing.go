@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the Trakt API spans
+const tracerName = "github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/api"
+
+// tracingTransport starts a span per Trakt API request, so each page of a
+// paginated dataset shows up as a child of the export that fetched it. The
+// spans use the global tracer provider and cost nothing while tracing is
+// disabled.
+type tracingTransport struct {
+	base http.RoundTripper
+}
+
+func newTracingTransport(base http.RoundTripper) *tracingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &tracingTransport{base: base}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := metricsEndpoint(req.URL.Path)
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", req.Method),
+		attribute.String("url.path", endpoint),
+	}
+	query := req.URL.Query()
+	if page := query.Get("page"); page != "" {
+		attrs = append(attrs, attribute.String("trakt.page", page))
+	}
+	if limit := query.Get("limit"); limit != "" {
+		attrs = append(attrs, attribute.String("trakt.limit", limit))
+	}
+
+	ctx, span := otel.Tracer(tracerName).Start(req.Context(), "trakt "+req.Method+" "+endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	defer span.End()
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if count := resp.Header.Get("X-Pagination-Page-Count"); count != "" {
+		span.SetAttributes(attribute.String("trakt.page_count", count))
+	}
+	if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestClientTracesAPIRequests(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Trakt: config.TraktConfig{
+			ClientID:    "client",
+			AccessToken: "token",
+			APIBaseURL:  server.URL,
+		},
+	}
+	client := NewClient(cfg, &MockLogger{})
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "export.job")
+	_, err := client.GetWatchlistContext(ctx)
+	parent.End()
+	assert.NoError(t, err)
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 2) {
+		request := spans[0]
+		assert.Equal(t, "trakt GET /sync/watchlist/movies", request.Name())
+		assert.Equal(t, parent.SpanContext().SpanID(), request.Parent().SpanID())
+		assert.Contains(t, request.Attributes(), attribute.Int("http.response.status_code", 200))
+	}
+}
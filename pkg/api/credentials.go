@@ -0,0 +1,41 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// VerifyClientID checks a Trakt client ID against the API with a public,
+// unauthenticated request. A nil httpClient uses a client with a 15 second timeout.
+func VerifyClientID(httpClient *http.Client, baseURL, clientID string) error {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 15 * time.Second}
+	}
+
+	req, err := http.NewRequest("GET", strings.TrimRight(baseURL, "/")+"/movies/trending?limit=1", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("trakt-api-version", APIVersion)
+	req.Header.Set("trakt-api-key", clientID)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return nil
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("client ID rejected by Trakt (status %d)", resp.StatusCode)
+	default:
+		return fmt.Errorf("unexpected response from Trakt: %d", resp.StatusCode)
+	}
+}
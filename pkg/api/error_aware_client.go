@@ -112,6 +112,107 @@ func (eac *ErrorAwareClient) GetMovieHistory() ([]HistoryItem, error) {
 	return history, nil
 }
 
+// GetWatchedMoviesContext implements TraktAPIClient with error handling
+func (eac *ErrorAwareClient) GetWatchedMoviesContext(ctx context.Context) ([]Movie, error) {
+	movies, err := eac.client.GetWatchedMoviesContext(ctx)
+	if err != nil {
+		appErr := eac.errorManager.HandleError(ctx, err)
+		return movies, appErr
+	}
+	return movies, nil
+}
+
+// GetCollectionMoviesContext implements TraktAPIClient with error handling
+func (eac *ErrorAwareClient) GetCollectionMoviesContext(ctx context.Context) ([]CollectionMovie, error) {
+	movies, err := eac.client.GetCollectionMoviesContext(ctx)
+	if err != nil {
+		appErr := eac.errorManager.HandleError(ctx, err)
+		return movies, appErr
+	}
+	return movies, nil
+}
+
+// GetWatchedShowsContext implements TraktAPIClient with error handling
+func (eac *ErrorAwareClient) GetWatchedShowsContext(ctx context.Context) ([]WatchedShow, error) {
+	shows, err := eac.client.GetWatchedShowsContext(ctx)
+	if err != nil {
+		appErr := eac.errorManager.HandleError(ctx, err)
+		return shows, appErr
+	}
+	return shows, nil
+}
+
+// GetRatingsContext implements TraktAPIClient with error handling
+func (eac *ErrorAwareClient) GetRatingsContext(ctx context.Context) ([]Rating, error) {
+	ratings, err := eac.client.GetRatingsContext(ctx)
+	if err != nil {
+		appErr := eac.errorManager.HandleError(ctx, err)
+		return ratings, appErr
+	}
+	return ratings, nil
+}
+
+// GetWatchlistContext implements TraktAPIClient with error handling
+func (eac *ErrorAwareClient) GetWatchlistContext(ctx context.Context) ([]WatchlistMovie, error) {
+	watchlist, err := eac.client.GetWatchlistContext(ctx)
+	if err != nil {
+		appErr := eac.errorManager.HandleError(ctx, err)
+		return watchlist, appErr
+	}
+	return watchlist, nil
+}
+
+// GetShowRatingsContext implements TraktAPIClient with error handling
+func (eac *ErrorAwareClient) GetShowRatingsContext(ctx context.Context) ([]ShowRating, error) {
+	ratings, err := eac.client.GetShowRatingsContext(ctx)
+	if err != nil {
+		appErr := eac.errorManager.HandleError(ctx, err)
+		return ratings, appErr
+	}
+	return ratings, nil
+}
+
+// GetEpisodeRatingsContext implements TraktAPIClient with error handling
+func (eac *ErrorAwareClient) GetEpisodeRatingsContext(ctx context.Context) ([]EpisodeRating, error) {
+	ratings, err := eac.client.GetEpisodeRatingsContext(ctx)
+	if err != nil {
+		appErr := eac.errorManager.HandleError(ctx, err)
+		return ratings, appErr
+	}
+	return ratings, nil
+}
+
+// GetMovieHistoryContext implements TraktAPIClient with error handling
+func (eac *ErrorAwareClient) GetMovieHistoryContext(ctx context.Context) ([]HistoryItem, error) {
+	history, err := eac.client.GetMovieHistoryContext(ctx)
+	if err != nil {
+		appErr := eac.errorManager.HandleError(ctx, err)
+		return history, appErr
+	}
+	return history, nil
+}
+
+// GetLastActivities implements TraktAPIClient with error handling
+func (eac *ErrorAwareClient) GetLastActivities() (*LastActivities, error) {
+	activities, err := eac.client.GetLastActivities()
+	if err != nil {
+		ctx := context.Background()
+		appErr := eac.errorManager.HandleError(ctx, err)
+		return activities, appErr
+	}
+	return activities, nil
+}
+
+// GetLastActivitiesContext implements TraktAPIClient with error handling
+func (eac *ErrorAwareClient) GetLastActivitiesContext(ctx context.Context) (*LastActivities, error) {
+	activities, err := eac.client.GetLastActivitiesContext(ctx)
+	if err != nil {
+		appErr := eac.errorManager.HandleError(ctx, err)
+		return activities, appErr
+	}
+	return activities, nil
+}
+
 // GetConfig implements TraktAPIClient
 func (eac *ErrorAwareClient) GetConfig() *config.Config {
 	return eac.client.GetConfig()
@@ -248,6 +349,107 @@ func (eaoc *ErrorAwareOptimizedClient) GetMovieHistory() ([]HistoryItem, error)
 	return history, nil
 }
 
+// GetWatchedMoviesContext implements TraktAPIClient with error handling
+func (eaoc *ErrorAwareOptimizedClient) GetWatchedMoviesContext(ctx context.Context) ([]Movie, error) {
+	movies, err := eaoc.client.GetWatchedMoviesContext(ctx)
+	if err != nil {
+		appErr := eaoc.errorManager.HandleError(ctx, err)
+		return movies, appErr
+	}
+	return movies, nil
+}
+
+// GetCollectionMoviesContext implements TraktAPIClient with error handling
+func (eaoc *ErrorAwareOptimizedClient) GetCollectionMoviesContext(ctx context.Context) ([]CollectionMovie, error) {
+	movies, err := eaoc.client.GetCollectionMoviesContext(ctx)
+	if err != nil {
+		appErr := eaoc.errorManager.HandleError(ctx, err)
+		return movies, appErr
+	}
+	return movies, nil
+}
+
+// GetWatchedShowsContext implements TraktAPIClient with error handling
+func (eaoc *ErrorAwareOptimizedClient) GetWatchedShowsContext(ctx context.Context) ([]WatchedShow, error) {
+	shows, err := eaoc.client.GetWatchedShowsContext(ctx)
+	if err != nil {
+		appErr := eaoc.errorManager.HandleError(ctx, err)
+		return shows, appErr
+	}
+	return shows, nil
+}
+
+// GetRatingsContext implements TraktAPIClient with error handling
+func (eaoc *ErrorAwareOptimizedClient) GetRatingsContext(ctx context.Context) ([]Rating, error) {
+	ratings, err := eaoc.client.GetRatingsContext(ctx)
+	if err != nil {
+		appErr := eaoc.errorManager.HandleError(ctx, err)
+		return ratings, appErr
+	}
+	return ratings, nil
+}
+
+// GetWatchlistContext implements TraktAPIClient with error handling
+func (eaoc *ErrorAwareOptimizedClient) GetWatchlistContext(ctx context.Context) ([]WatchlistMovie, error) {
+	watchlist, err := eaoc.client.GetWatchlistContext(ctx)
+	if err != nil {
+		appErr := eaoc.errorManager.HandleError(ctx, err)
+		return watchlist, appErr
+	}
+	return watchlist, nil
+}
+
+// GetShowRatingsContext implements TraktAPIClient with error handling
+func (eaoc *ErrorAwareOptimizedClient) GetShowRatingsContext(ctx context.Context) ([]ShowRating, error) {
+	ratings, err := eaoc.client.GetShowRatingsContext(ctx)
+	if err != nil {
+		appErr := eaoc.errorManager.HandleError(ctx, err)
+		return ratings, appErr
+	}
+	return ratings, nil
+}
+
+// GetEpisodeRatingsContext implements TraktAPIClient with error handling
+func (eaoc *ErrorAwareOptimizedClient) GetEpisodeRatingsContext(ctx context.Context) ([]EpisodeRating, error) {
+	ratings, err := eaoc.client.GetEpisodeRatingsContext(ctx)
+	if err != nil {
+		appErr := eaoc.errorManager.HandleError(ctx, err)
+		return ratings, appErr
+	}
+	return ratings, nil
+}
+
+// GetMovieHistoryContext implements TraktAPIClient with error handling
+func (eaoc *ErrorAwareOptimizedClient) GetMovieHistoryContext(ctx context.Context) ([]HistoryItem, error) {
+	history, err := eaoc.client.GetMovieHistoryContext(ctx)
+	if err != nil {
+		appErr := eaoc.errorManager.HandleError(ctx, err)
+		return history, appErr
+	}
+	return history, nil
+}
+
+// GetLastActivities implements TraktAPIClient with error handling
+func (eaoc *ErrorAwareOptimizedClient) GetLastActivities() (*LastActivities, error) {
+	activities, err := eaoc.client.GetLastActivities()
+	if err != nil {
+		ctx := context.Background()
+		appErr := eaoc.errorManager.HandleError(ctx, err)
+		return activities, appErr
+	}
+	return activities, nil
+}
+
+// GetLastActivitiesContext implements TraktAPIClient with error handling
+func (eaoc *ErrorAwareOptimizedClient) GetLastActivitiesContext(ctx context.Context) (*LastActivities, error) {
+	activities, err := eaoc.client.GetLastActivitiesContext(ctx)
+	if err != nil {
+		appErr := eaoc.errorManager.HandleError(ctx, err)
+		return activities, appErr
+	}
+	return activities, nil
+}
+
 // GetConfig implements TraktAPIClient
 func (eaoc *ErrorAwareOptimizedClient) GetConfig() *config.Config {
 	return eaoc.client.GetConfig()
@@ -13,12 +13,17 @@ import (
 // DefaultClientFactory implements the ClientFactory interface
 type DefaultClientFactory struct {
 	errorManager *errors.ErrorManager
+	logger       logger.Logger
+	tokenManager TokenManager
 }
 
 // ClientFactoryConfig configures the client factory
 type ClientFactoryConfig struct {
 	ErrorManager *errors.ErrorManager
 	Logger       logger.Logger
+	// TokenManager provides OAuth access tokens. When nil, clients use the
+	// access token of the configuration.
+	TokenManager TokenManager
 }
 
 // NewDefaultClientFactory creates a new default client factory
@@ -30,21 +35,52 @@ func NewDefaultClientFactory() ClientFactory {
 func NewClientFactory(config ClientFactoryConfig) ClientFactory {
 	return &DefaultClientFactory{
 		errorManager: config.ErrorManager,
+		logger:       config.Logger,
+		tokenManager: config.TokenManager,
 	}
 }
 
+// CapabilitiesFromConfig derives the client capabilities from the [client]
+// section of the configuration. A worker pool size of 1 without caching or
+// metrics selects the basic sequential client.
+func CapabilitiesFromConfig(cfg *config.Config) ClientCapabilitiesConfig {
+	capabilities := ClientCapabilitiesConfig{
+		BaseConfig:     cfg,
+		EnableCaching:  cfg.Client.EnableCaching,
+		EnableMetrics:  cfg.Client.EnableMetrics,
+		RequestTimeout: cfg.Client.RequestTimeout,
+	}
+
+	if cfg.Client.WorkerPoolSize > 1 {
+		capabilities.EnableConcurrency = true
+	}
+	if capabilities.EnableConcurrency || capabilities.EnableCaching || capabilities.EnableMetrics {
+		capabilities.WorkerPoolSize = cfg.Client.WorkerPoolSize
+	}
+
+	if cfg.Client.EnableCaching {
+		capabilities.CacheConfig = &cache.CacheConfig{
+			Capacity: cfg.Client.CacheCapacity,
+			TTL:      cfg.Client.CacheTTL,
+		}
+	}
+
+	return capabilities
+}
+
 // CreateBasicClient creates a basic Trakt API client
 func (f *DefaultClientFactory) CreateBasicClient(cfg *config.Config) (TraktAPIClient, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("configuration cannot be nil")
 	}
 
-	// Note: Logger needs to be provided externally in a real implementation
-	// For now, we'll assume a logger is available or create a no-op logger
-	// This would typically be injected via dependency injection
-	
 	// Create basic client
-	client := NewClient(cfg, nil) // Logger will need to be injected properly
+	var client *Client
+	if f.tokenManager != nil {
+		client = NewClientWithTokenManager(cfg, f.logger, f.tokenManager)
+	} else {
+		client = NewClient(cfg, f.logger)
+	}
 	
 	// Wrap in adapter
 	adaptedClient := NewClientAdapter(client)
@@ -64,8 +100,17 @@ func (f *DefaultClientFactory) CreateOptimizedClient(cfg OptimizedClientConfig)
 		return nil, fmt.Errorf("base configuration cannot be nil")
 	}
 
-	// Configuration is already complete - just create the client
+	// Fill in the dependencies held by the factory
 	optimizedConfig := cfg
+	if optimizedConfig.Logger == nil {
+		optimizedConfig.Logger = f.logger
+	}
+	if optimizedConfig.Logger == nil {
+		return nil, fmt.Errorf("logger cannot be nil")
+	}
+	if optimizedConfig.TokenManager == nil {
+		optimizedConfig.TokenManager = f.tokenManager
+	}
 
 	// Create optimized client
 	client := NewOptimizedClient(optimizedConfig)
@@ -101,9 +146,12 @@ func (f *DefaultClientFactory) CreateClientWithCapabilities(cfg ClientCapabiliti
 
 		optimizedConfig := OptimizedClientConfig{
 			Config:           cfg.BaseConfig,
-			Logger:           nil, // Would need to be provided
+			Logger:           f.logger,
+			TokenManager:     f.tokenManager,
 			WorkerPoolSize:   cfg.WorkerPoolSize,
 			CacheConfig:      cacheConfig,
+			DisableCache:     !cfg.EnableCaching,
+			RequestTimeout:   cfg.RequestTimeout,
 		}
 
 		if cfg.WorkerPoolSize <= 0 {
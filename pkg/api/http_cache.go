@@ -0,0 +1,274 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/performance/cache"
+)
+
+// maxCachedBodySize bounds the responses stored in the persistent cache
+const maxCachedBodySize = 50 * 1024 * 1024
+
+var (
+	diskCachesMu sync.Mutex
+	diskCaches   = make(map[string]*cache.DiskCache)
+)
+
+// OpenDiskCache returns the persistent response cache configured in the
+// [client] section. Clients of one process share the cache of a directory
+// so they don't overwrite each other's index.
+func OpenDiskCache(cfg *config.Config) (*cache.DiskCache, error) {
+	diskCachesMu.Lock()
+	defer diskCachesMu.Unlock()
+
+	if diskCache, ok := diskCaches[cfg.Client.CacheDir]; ok {
+		return diskCache, nil
+	}
+
+	diskCache, err := cache.NewDiskCache(cache.DiskCacheConfig{
+		Dir:        cfg.Client.CacheDir,
+		MaxEntries: cfg.Client.CacheCapacity,
+	})
+	if err != nil {
+		return nil, err
+	}
+	diskCaches[cfg.Client.CacheDir] = diskCache
+	return diskCache, nil
+}
+
+// FlushDiskCaches saves the pending statistics of the persistent caches
+// opened by this process
+func FlushDiskCaches() error {
+	diskCachesMu.Lock()
+	defer diskCachesMu.Unlock()
+
+	var errs []error
+	for _, diskCache := range diskCaches {
+		if err := diskCache.Flush(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// newHTTPTransport builds the transport chain of the Trakt clients: a span
+// per request, conditional requests against the persistent cache when
+// enabled, rate limiting with a timeout for each attempt and call metrics
+func newHTTPTransport(cfg *config.Config, base http.RoundTripper, log logger.Logger, timeout time.Duration) http.RoundTripper {
+	transport := NewRateLimitTransport(newMetricsTransport(base), log, timeout)
+	if cfg == nil || !cfg.Client.PersistentCache {
+		return newTracingTransport(transport)
+	}
+
+	diskCache, err := OpenDiskCache(cfg)
+	if err != nil {
+		if log != nil {
+			log.Warn("api.disk_cache_unavailable", map[string]interface{}{
+				"dir":   cfg.Client.CacheDir,
+				"error": err.Error(),
+			})
+		}
+		return newTracingTransport(transport)
+	}
+	return newTracingTransport(NewCachingTransport(transport, diskCache, log))
+}
+
+// CachingTransport stores GET responses carrying an ETag or Last-Modified
+// header on disk and revalidates them with If-None-Match and
+// If-Modified-Since. A 304 Not Modified is answered from the stored response.
+// Responses are kept per account, the client ID and the user slug of the
+// access token, so they are still revalidated after a token refresh.
+type CachingTransport struct {
+	base  http.RoundTripper
+	cache *cache.DiskCache
+	log   logger.Logger
+
+	accountsMu sync.Mutex
+	accounts   map[string]string // account of each access token, "" when unknown
+}
+
+// NewCachingTransport wraps base with the persistent response cache
+func NewCachingTransport(base http.RoundTripper, diskCache *cache.DiskCache, log logger.Logger) *CachingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &CachingTransport{base: base, cache: diskCache, log: log, accounts: make(map[string]string)}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *CachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+
+	account, ok := t.account(req)
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+	key := cache.DiskCacheKey(account, req.URL.String())
+	entry, cached := t.cache.Get(key)
+	if cached {
+		req = req.Clone(req.Context())
+		if etag := entry.ETag(); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := entry.LastModified(); lastModified != "" {
+			req.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached && resp.StatusCode == http.StatusNotModified {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		t.cache.RecordHit(key)
+		if t.log != nil {
+			t.log.Debug("api.disk_cache_revalidated", map[string]interface{}{
+				"url": req.URL.String(),
+			})
+		}
+		return cachedResponse(entry, req), nil
+	}
+
+	t.cache.RecordMiss()
+	if resp.StatusCode != http.StatusOK || (resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == "") {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCachedBodySize+1))
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if len(body) > maxCachedBodySize {
+		return resp, nil
+	}
+
+	header := resp.Header.Clone()
+	header.Del("Set-Cookie")
+	if err := t.cache.Set(key, &cache.DiskEntry{
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     header,
+		Body:       body,
+	}); err != nil && t.log != nil {
+		t.log.Warn("api.disk_cache_store_failed", map[string]interface{}{
+			"url":   req.URL.String(),
+			"error": err.Error(),
+		})
+	}
+
+	return resp, nil
+}
+
+// account identifies the account a request is made for. Public requests
+// share the account of the client ID. The user of an access token is looked
+// up once; requests of a token whose user is unknown are not cached.
+func (t *CachingTransport) account(req *http.Request) (string, bool) {
+	clientID := req.Header.Get("trakt-api-key")
+	authorization := req.Header.Get("Authorization")
+	if authorization == "" {
+		return clientID, true
+	}
+
+	// Lookups are serialized so concurrent page fetches make a single one
+	t.accountsMu.Lock()
+	defer t.accountsMu.Unlock()
+
+	if slug, ok := t.accounts[authorization]; ok {
+		return clientID + "/" + slug, slug != ""
+	}
+	slug, err := t.lookupUser(req)
+	if err != nil && t.log != nil {
+		t.log.Warn("api.disk_cache_account_unknown", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+	// Transient failures are retried with the next request
+	if err == nil || !errors.Is(err, errAccountUnavailable) {
+		t.accounts[authorization] = slug
+	}
+	return clientID + "/" + slug, slug != ""
+}
+
+// errAccountUnavailable marks lookups that may succeed on a later request
+var errAccountUnavailable = errors.New("account lookup unavailable")
+
+// lookupUser returns the slug of the user of the access token of req
+func (t *CachingTransport) lookupUser(req *http.Request) (string, error) {
+	settingsURL := *req.URL
+	settingsURL.Path = "/users/settings"
+	settingsURL.RawPath = ""
+	settingsURL.RawQuery = ""
+
+	lookup, err := http.NewRequestWithContext(req.Context(), http.MethodGet, settingsURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	for _, name := range []string{"Authorization", "trakt-api-key", "trakt-api-version", "Content-Type", "User-Agent"} {
+		if value := req.Header.Get(name); value != "" {
+			lookup.Header.Set(name, value)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(lookup)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errAccountUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return "", fmt.Errorf("%w: user settings status %d", errAccountUnavailable, resp.StatusCode)
+		}
+		return "", fmt.Errorf("user settings status %d", resp.StatusCode)
+	}
+
+	var settings struct {
+		User struct {
+			Username string `json:"username"`
+			IDs      struct {
+				Slug string `json:"slug"`
+			} `json:"ids"`
+		} `json:"user"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&settings); err != nil {
+		return "", fmt.Errorf("invalid user settings: %w", err)
+	}
+	if settings.User.IDs.Slug != "" {
+		return settings.User.IDs.Slug, nil
+	}
+	if settings.User.Username != "" {
+		return settings.User.Username, nil
+	}
+	return "", errors.New("user settings without user")
+}
+
+// cachedResponse rebuilds the stored response for a revalidated request
+func cachedResponse(entry *cache.DiskEntry, req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", entry.StatusCode, http.StatusText(entry.StatusCode)),
+		StatusCode:    entry.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        entry.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       req,
+	}
+}
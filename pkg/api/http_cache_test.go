@@ -0,0 +1,128 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/performance/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachingTransportRevalidatesWithETag(t *testing.T) {
+	var downloads, notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/users/settings" {
+			w.Write([]byte(`{"user":{"username":"Sean","ids":{"slug":"sean"}}}`))
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		atomic.AddInt32(&downloads, 1)
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("X-Pagination-Page-Count", "1")
+		w.Write([]byte(`[{"movie":{"title":"Cached"}}]`))
+	}))
+	defer server.Close()
+
+	diskCache, err := cache.NewDiskCache(cache.DiskCacheConfig{Dir: t.TempDir()})
+	require.NoError(t, err)
+	client := &http.Client{Transport: NewCachingTransport(http.DefaultTransport, diskCache, &MockLogger{})}
+
+	get := func() (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/sync/watchlist/movies", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer token")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(body)
+	}
+
+	resp, body := get()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "Cached")
+
+	// The second request is revalidated and answered from disk
+	resp, body = get()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "Cached")
+	assert.Equal(t, "1", resp.Header.Get("X-Pagination-Page-Count"))
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&downloads))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&notModified))
+
+	stats := diskCache.Stats()
+	assert.Equal(t, 1, stats.Entries)
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+}
+
+func TestCachingTransportKeysByAccount(t *testing.T) {
+	var lookups, downloads, notModified int32
+	users := map[string]string{
+		"Bearer first":   "sean",
+		"Bearer renewed": "sean",
+		"Bearer other":   "justin",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/users/settings" {
+			atomic.AddInt32(&lookups, 1)
+			slug, ok := users[r.Header.Get("Authorization")]
+			if !ok {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprintf(w, `{"user":{"username":"%s","ids":{"slug":"%s"}}}`, slug, slug)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		atomic.AddInt32(&downloads, 1)
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	diskCache, err := cache.NewDiskCache(cache.DiskCacheConfig{Dir: t.TempDir()})
+	require.NoError(t, err)
+	client := &http.Client{Transport: NewCachingTransport(http.DefaultTransport, diskCache, &MockLogger{})}
+
+	get := func(token string) {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/sync/watchlist/movies", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", token)
+		req.Header.Set("trakt-api-key", "client")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	get("Bearer first")
+	get("Bearer first")
+	// A refreshed token of the same account still revalidates
+	get("Bearer renewed")
+	// Another account downloads its own copy
+	get("Bearer other")
+	// A token whose user is unknown is not cached
+	get("Bearer revoked")
+	get("Bearer revoked")
+
+	assert.Equal(t, int32(4), atomic.LoadInt32(&lookups), "one lookup per token")
+	assert.Equal(t, int32(4), atomic.LoadInt32(&downloads))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&notModified))
+	assert.Equal(t, 2, diskCache.Stats().Entries)
+}
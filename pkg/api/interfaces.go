@@ -21,6 +21,20 @@ type TraktAPIClient interface {
 	GetEpisodeRatings() ([]EpisodeRating, error)
 	GetMovieHistory() ([]HistoryItem, error)
 	
+	// Cancellable data retrieval operations
+	GetWatchedMoviesContext(ctx context.Context) ([]Movie, error)
+	GetCollectionMoviesContext(ctx context.Context) ([]CollectionMovie, error)
+	GetWatchedShowsContext(ctx context.Context) ([]WatchedShow, error)
+	GetRatingsContext(ctx context.Context) ([]Rating, error)
+	GetWatchlistContext(ctx context.Context) ([]WatchlistMovie, error)
+	GetShowRatingsContext(ctx context.Context) ([]ShowRating, error)
+	GetEpisodeRatingsContext(ctx context.Context) ([]EpisodeRating, error)
+	GetMovieHistoryContext(ctx context.Context) ([]HistoryItem, error)
+	
+	// Change detection
+	GetLastActivities() (*LastActivities, error)
+	GetLastActivitiesContext(ctx context.Context) (*LastActivities, error)
+	
 	// Configuration and lifecycle
 	GetConfig() *config.Config
 	Close() error
@@ -67,6 +81,7 @@ type ClientCapabilitiesConfig struct {
 	EnableConcurrency bool
 	WorkerPoolSize    int
 	CacheConfig       *cache.CacheConfig
+	RequestTimeout    time.Duration
 }
 
 // APIOperation represents a generic API operation that can be executed
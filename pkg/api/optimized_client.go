@@ -3,10 +3,13 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,17 +20,28 @@ import (
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/performance/pool"
 )
 
+const (
+	// historyPageLimit is the page size used for paginated endpoints
+	historyPageLimit = 100
+	// maxPages stops pagination of runaway responses
+	maxPages = 1000
+	// pageJobTimeout bounds a single page fetch, including rate limit waits
+	pageJobTimeout = 5 * time.Minute
+)
+
 // OptimizedClient represents an optimized Trakt API client with caching and concurrency
 type OptimizedClient struct {
-	config     *config.Config
-	logger     logger.Logger
-	httpClient *http.Client
-	cache      *cache.APIResponseCache
-	metrics    *metrics.PerformanceMetrics
-	workerPool *pool.WorkerPool
-	rateLimiter chan struct{}
-	done       chan struct{}
-	closeOnce  sync.Once
+	config       *config.Config
+	logger       logger.Logger
+	httpClient   *http.Client
+	tokenManager TokenManager
+	cache        *cache.APIResponseCache
+	cacheEnabled bool
+	metrics      *metrics.PerformanceMetrics
+	workerPool   *pool.WorkerPool
+	rateLimiter  chan struct{}
+	done         chan struct{}
+	closeOnce    sync.Once
 
 	// Connection pooling
 	transport *http.Transport
@@ -37,7 +51,9 @@ type OptimizedClient struct {
 type OptimizedClientConfig struct {
 	Config           *config.Config
 	Logger           logger.Logger
+	TokenManager     TokenManager
 	CacheConfig      cache.CacheConfig
+	DisableCache     bool
 	WorkerPoolSize   int
 	RateLimitPerSec  int
 	ConnectionPool   int
@@ -76,10 +92,10 @@ func NewOptimizedClient(cfg OptimizedClientConfig) *OptimizedClient {
 		DisableCompression:  false,
 	}
 
-	// Create HTTP client
+	// Create HTTP client, timing out each attempt rather than the rate
+	// limit waits between them
 	httpClient := &http.Client{
-		Transport: transport,
-		Timeout:   cfg.RequestTimeout,
+		Transport: newHTTPTransport(cfg.Config, transport, cfg.Logger, cfg.RequestTimeout),
 	}
 
 	// Create cache
@@ -95,25 +111,33 @@ func NewOptimizedClient(cfg OptimizedClientConfig) *OptimizedClient {
 	workerPoolConfig := pool.WorkerPoolConfig{
 		Workers:    cfg.WorkerPoolSize,
 		BufferSize: cfg.WorkerPoolSize * 2,
+		JobTimeout: pageJobTimeout,
 		Logger:     cfg.Logger,
 		Metrics:    performanceMetrics,
 	}
 	workerPool := pool.NewWorkerPool(workerPoolConfig)
 
 	client := &OptimizedClient{
-		config:      cfg.Config,
-		logger:      cfg.Logger,
-		httpClient:  httpClient,
-		cache:       apiCache,
-		metrics:     performanceMetrics,
-		workerPool:  workerPool,
-		rateLimiter: rateLimiter,
-		done:        make(chan struct{}),
-		transport:   transport,
-	}
-
-	// Start worker pool
+		config:       cfg.Config,
+		logger:       cfg.Logger,
+		httpClient:   httpClient,
+		tokenManager: cfg.TokenManager,
+		cache:        apiCache,
+		cacheEnabled: !cfg.DisableCache,
+		metrics:      performanceMetrics,
+		workerPool:   workerPool,
+		rateLimiter:  rateLimiter,
+		done:         make(chan struct{}),
+		transport:    transport,
+	}
+
+	// Start worker pool. Page jobs report through their own channels, so
+	// the pool results are drained to keep the workers from blocking.
 	workerPool.Start()
+	go func() {
+		for range workerPool.Results() {
+		}
+	}()
 
 	// Start rate limiter refill goroutine
 	go client.rateLimiterRefill(cfg.RateLimitPerSec)
@@ -143,18 +167,12 @@ func (c *OptimizedClient) rateLimiterRefill(ratePerSec int) {
 
 // makeOptimizedRequest makes an HTTP request with caching, rate limiting, and metrics
 func (c *OptimizedClient) makeOptimizedRequest(ctx context.Context, endpoint string, result interface{}) error {
-	start := time.Now()
-	defer func() {
-		c.metrics.RecordAPIResponseTime(time.Since(start))
-	}()
-
-	c.metrics.IncrementAPICall()
-
 	// Add extended info if configured
-	fullEndpoint := c.addExtendedInfo(endpoint)
+	fullEndpoint := c.addExtendedInfo(c.resolveEndpoint(endpoint))
 
 	// Check cache first
-	if c.cache.GetJSON(fullEndpoint, result) {
+	if c.cacheEnabled && c.cache.GetJSON(fullEndpoint, result) {
+		c.metrics.IncrementAPICall()
 		c.metrics.IncrementCacheHit()
 		c.metrics.IncrementAPISuccess()
 		c.logger.Debug("api.cache_hit", map[string]interface{}{
@@ -163,59 +181,89 @@ func (c *OptimizedClient) makeOptimizedRequest(ctx context.Context, endpoint str
 		return nil
 	}
 
-	c.metrics.IncrementCacheMiss()
+	if c.cacheEnabled {
+		c.metrics.IncrementCacheMiss()
+	}
+
+	body, _, err := c.fetch(ctx, fullEndpoint)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	// Cache the result
+	if c.cacheEnabled {
+		if err := c.cache.SetJSON(fullEndpoint, result); err != nil {
+			c.logger.Warn("api.cache_set_failed", map[string]interface{}{
+				"endpoint": fullEndpoint,
+				"error":    err.Error(),
+			})
+		}
+	}
+
+	return nil
+}
+
+// fetch performs an uncached GET request and returns the response body and headers
+func (c *OptimizedClient) fetch(ctx context.Context, fullEndpoint string) ([]byte, http.Header, error) {
+	start := time.Now()
+	defer func() {
+		c.metrics.RecordAPIResponseTime(time.Since(start))
+	}()
+
+	c.metrics.IncrementAPICall()
 
 	// Wait for rate limiter
 	select {
 	case <-c.rateLimiter:
 	case <-ctx.Done():
-		return ctx.Err()
+		return nil, nil, ctx.Err()
 	}
 
 	// Create request
 	req, err := http.NewRequestWithContext(ctx, "GET", fullEndpoint, nil)
 	if err != nil {
 		c.metrics.IncrementAPIError()
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Add headers
 	req.Header.Set("User-Agent", "Export_Trakt_4_Letterboxd/1.0")
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("trakt-api-version", APIVersion)
 	
 	if c.config.Trakt.ClientID != "" {
 		req.Header.Set("trakt-api-key", c.config.Trakt.ClientID)
 	}
 
+	if err := c.setAuthHeader(ctx, req); err != nil {
+		c.metrics.IncrementAPIError()
+		return nil, nil, fmt.Errorf("failed to set auth header: %w", err)
+	}
+
 	// Make request with retries
 	resp, err := c.makeRequestWithRetries(ctx, req)
 	if err != nil {
 		c.metrics.IncrementAPIError()
-		return err
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
 		c.metrics.IncrementAPIError()
-		return fmt.Errorf("API request failed with status %d", resp.StatusCode)
+		return nil, nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
 	}
 
-	// Parse response with size limit
-	limitedBody := io.LimitReader(resp.Body, 50*1024*1024)
-	decoder := json.NewDecoder(limitedBody)
-	if err := decoder.Decode(result); err != nil {
+	// Read response with size limit
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 50*1024*1024))
+	if err != nil {
 		c.metrics.IncrementAPIError()
-		return fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	// Cache the result
-	if err := c.cache.SetJSON(fullEndpoint, result); err != nil {
-		c.logger.Warn("api.cache_set_failed", map[string]interface{}{
-			"endpoint": fullEndpoint,
-			"error":    err.Error(),
-		})
+		return nil, nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	c.metrics.IncrementAPISuccess()
@@ -224,6 +272,33 @@ func (c *OptimizedClient) makeOptimizedRequest(ctx context.Context, endpoint str
 		"duration": time.Since(start).String(),
 	})
 
+	return body, resp.Header, nil
+}
+
+// setAuthHeader sets the bearer token from the token manager, falling back
+// to the access token of the configuration
+func (c *OptimizedClient) setAuthHeader(ctx context.Context, req *http.Request) error {
+	var accessToken string
+	switch tm := c.tokenManager.(type) {
+	case nil:
+		accessToken = c.config.Trakt.AccessToken
+	case ContextTokenManager:
+		token, err := tm.GetValidAccessTokenContext(ctx)
+		if err != nil {
+			return err
+		}
+		accessToken = token
+	default:
+		token, err := tm.GetValidAccessToken()
+		if err != nil {
+			return err
+		}
+		accessToken = token
+	}
+
+	if accessToken != "" {
+		req.Header.Set("Authorization", AuthHeaderPrefix+accessToken)
+	}
 	return nil
 }
 
@@ -250,12 +325,26 @@ func (c *OptimizedClient) makeRequestWithRetries(ctx context.Context, req *http.
 
 		resp, err := c.httpClient.Do(req)
 		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
 			lastErr = fmt.Errorf("request failed: %w", err)
 			continue
 		}
 
-		// Only retry on server errors (5xx) or rate limiting (429)
-		if resp.StatusCode >= 500 || resp.StatusCode == 429 {
+		if resp.StatusCode == http.StatusUnauthorized {
+			resp.Body.Close()
+			return nil, fmt.Errorf("authentication failed: status %d", resp.StatusCode)
+		}
+
+		// The transport already waited and retried rate limited requests
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+			resp.Body.Close()
+			return nil, fmt.Errorf("rate limit exceeded: status %d", resp.StatusCode)
+		}
+
+		// Only retry on server errors (5xx)
+		if resp.StatusCode >= 500 {
 			resp.Body.Close()
 			lastErr = fmt.Errorf("server error: %d", resp.StatusCode)
 			continue
@@ -267,10 +356,185 @@ func (c *OptimizedClient) makeRequestWithRetries(ctx context.Context, req *http.
 	return nil, fmt.Errorf("max retries exceeded: %w", lastErr)
 }
 
+// pageJob fetches one page of a paginated endpoint on the worker pool
+type pageJob struct {
+	client   *OptimizedClient
+	ctx      context.Context
+	endpoint string
+	page     int
+	body     []byte
+	done     chan<- *pageJob
+	err      error
+}
+
+// ID implements pool.Job
+func (j *pageJob) ID() string {
+	return fmt.Sprintf("%s#%d", j.endpoint, j.page)
+}
+
+// Execute implements pool.Job. The fetch stops when either the caller's
+// context or the pool's job context is done.
+func (j *pageJob) Execute(poolCtx context.Context) error {
+	ctx, cancel := context.WithCancel(j.ctx)
+	defer cancel()
+	stop := context.AfterFunc(poolCtx, cancel)
+	defer stop()
+
+	j.body, _, j.err = j.client.fetch(ctx, j.client.pageURL(j.endpoint, j.page))
+	j.done <- j
+	return j.err
+}
+
+// fetchPages retrieves every page of a paginated endpoint. The first page
+// gives the page count from the X-Pagination-Page-Count header and the
+// remaining pages are fetched concurrently on the worker pool. The raw page
+// bodies are returned in page order.
+func (c *OptimizedClient) fetchPages(ctx context.Context, path string) ([][]byte, error) {
+	endpoint := c.resolveEndpoint(path)
+
+	first, header, err := c.fetch(ctx, c.pageURL(endpoint, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	pageCount, err := strconv.Atoi(header.Get("X-Pagination-Page-Count"))
+	if err != nil {
+		// Without pagination headers, pages are fetched until a short one
+		return c.fetchPagesSequentially(ctx, endpoint, first)
+	}
+	if pageCount > maxPages {
+		c.logger.Warn("api.pagination_limit_reached", map[string]interface{}{
+			"endpoint": endpoint,
+			"pages":    pageCount,
+		})
+		pageCount = maxPages
+	}
+
+	pages := make([][]byte, pageCount)
+	pages[0] = first
+	if pageCount <= 1 {
+		return pages[:1], nil
+	}
+
+	// The first failed page stops the fetches of the others
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan *pageJob, pageCount-1)
+	for page := 2; page <= pageCount; page++ {
+		job := &pageJob{client: c, ctx: ctx, endpoint: endpoint, page: page, done: done}
+		if err := c.submit(ctx, job); err != nil {
+			return nil, err
+		}
+	}
+
+	for remaining := pageCount - 1; remaining > 0; remaining-- {
+		select {
+		case job := <-done:
+			if job.err != nil {
+				return nil, fmt.Errorf("failed to fetch page %d: %w", job.page, job.err)
+			}
+			pages[job.page-1] = job.body
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	c.logger.Debug("api.pages_fetched", map[string]interface{}{
+		"endpoint": endpoint,
+		"pages":    pageCount,
+	})
+	return pages, nil
+}
+
+// fetchPagesSequentially continues a pagination without page count until a short page
+func (c *OptimizedClient) fetchPagesSequentially(ctx context.Context, endpoint string, first []byte) ([][]byte, error) {
+	pages := [][]byte{first}
+	body := first
+	for page := 2; page <= maxPages; page++ {
+		var items []json.RawMessage
+		if err := json.Unmarshal(body, &items); err != nil {
+			return nil, fmt.Errorf("failed to decode page %d: %w", page-1, err)
+		}
+		if len(items) < historyPageLimit {
+			break
+		}
+
+		var err error
+		if body, _, err = c.fetch(ctx, c.pageURL(endpoint, page)); err != nil {
+			return nil, fmt.Errorf("failed to fetch page %d: %w", page, err)
+		}
+		pages = append(pages, body)
+	}
+	return pages, nil
+}
+
+// submit queues a job on the worker pool, waiting while the queue is full
+func (c *OptimizedClient) submit(ctx context.Context, job pool.Job) error {
+	for {
+		err := c.workerPool.Submit(job)
+		if !errors.Is(err, pool.ErrPoolFull) {
+			return err
+		}
+		if !c.workerPool.Stats().IsRunning {
+			return fmt.Errorf("worker pool is stopped")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// resolveEndpoint prefixes API paths with the configured base URL
+func (c *OptimizedClient) resolveEndpoint(endpoint string) string {
+	if strings.HasPrefix(endpoint, "/") {
+		baseURL := c.config.Trakt.APIBaseURL
+		if baseURL == "" {
+			baseURL = "https://api.trakt.tv"
+		}
+		return strings.TrimRight(baseURL, "/") + endpoint
+	}
+	return endpoint
+}
+
+// pageURL returns the URL of one page of a paginated endpoint
+func (c *OptimizedClient) pageURL(endpoint string, page int) string {
+	u, err := url.Parse(c.addExtendedInfo(endpoint))
+	if err != nil {
+		return endpoint
+	}
+	q := u.Query()
+	q.Set("page", strconv.Itoa(page))
+	q.Set("limit", strconv.Itoa(historyPageLimit))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// decodePages decodes the JSON array of every page into one slice
+func decodePages(pages [][]byte, result interface{}) error {
+	var items []json.RawMessage
+	for i, page := range pages {
+		var pageItems []json.RawMessage
+		if err := json.Unmarshal(page, &pageItems); err != nil {
+			return fmt.Errorf("failed to decode page %d: %w", i+1, err)
+		}
+		items = append(items, pageItems...)
+	}
+
+	data, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, result)
+}
+
 // GetWatchedMoviesConcurrent retrieves watched movies using concurrent processing
 func (c *OptimizedClient) GetWatchedMoviesConcurrent(ctx context.Context) ([]Movie, error) {
 	var movies []Movie
-	err := c.makeOptimizedRequest(ctx, "https://api.trakt.tv/users/me/watched/movies", &movies)
+	err := c.makeOptimizedRequest(ctx, "/sync/watched/movies", &movies)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get watched movies: %w", err)
 	}
@@ -285,7 +549,7 @@ func (c *OptimizedClient) GetWatchedMoviesConcurrent(ctx context.Context) ([]Mov
 // GetCollectionMoviesConcurrent retrieves collection movies using concurrent processing
 func (c *OptimizedClient) GetCollectionMoviesConcurrent(ctx context.Context) ([]CollectionMovie, error) {
 	var movies []CollectionMovie
-	err := c.makeOptimizedRequest(ctx, "https://api.trakt.tv/users/me/collection/movies", &movies)
+	err := c.makeOptimizedRequest(ctx, "/sync/collection/movies", &movies)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get collection movies: %w", err)
 	}
@@ -297,11 +561,10 @@ func (c *OptimizedClient) GetCollectionMoviesConcurrent(ctx context.Context) ([]
 	return movies, nil
 }
 
-// GetRatingsConcurrent retrieves ratings using concurrent processing
+// GetRatingsConcurrent retrieves ratings, fetching the pages concurrently
 func (c *OptimizedClient) GetRatingsConcurrent(ctx context.Context) ([]Rating, error) {
 	var ratings []Rating
-	err := c.makeOptimizedRequest(ctx, "https://api.trakt.tv/users/me/ratings/movies", &ratings)
-	if err != nil {
+	if err := c.getPaged(ctx, "/sync/ratings/movies", &ratings); err != nil {
 		return nil, fmt.Errorf("failed to get ratings: %w", err)
 	}
 
@@ -315,7 +578,7 @@ func (c *OptimizedClient) GetRatingsConcurrent(ctx context.Context) ([]Rating, e
 // GetWatchlistConcurrent retrieves watchlist using concurrent processing
 func (c *OptimizedClient) GetWatchlistConcurrent(ctx context.Context) ([]WatchlistMovie, error) {
 	var watchlist []WatchlistMovie
-	err := c.makeOptimizedRequest(ctx, "https://api.trakt.tv/users/me/watchlist/movies", &watchlist)
+	err := c.makeOptimizedRequest(ctx, "/sync/watchlist/movies", &watchlist)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get watchlist: %w", err)
 	}
@@ -327,6 +590,99 @@ func (c *OptimizedClient) GetWatchlistConcurrent(ctx context.Context) ([]Watchli
 	return watchlist, nil
 }
 
+// GetWatchedShowsConcurrent retrieves watched shows
+func (c *OptimizedClient) GetWatchedShowsConcurrent(ctx context.Context) ([]WatchedShow, error) {
+	var shows []WatchedShow
+	if err := c.makeOptimizedRequest(ctx, "/sync/watched/shows", &shows); err != nil {
+		return nil, fmt.Errorf("failed to get watched shows: %w", err)
+	}
+
+	c.logger.Info("api.watched_shows_fetched", map[string]interface{}{
+		"count": len(shows),
+	})
+
+	return shows, nil
+}
+
+// GetShowRatingsConcurrent retrieves show ratings, fetching the pages concurrently
+func (c *OptimizedClient) GetShowRatingsConcurrent(ctx context.Context) ([]ShowRating, error) {
+	var ratings []ShowRating
+	if err := c.getPaged(ctx, "/sync/ratings/shows", &ratings); err != nil {
+		return nil, fmt.Errorf("failed to get show ratings: %w", err)
+	}
+
+	c.logger.Info("api.show_ratings_fetched", map[string]interface{}{
+		"count": len(ratings),
+	})
+
+	return ratings, nil
+}
+
+// GetEpisodeRatingsConcurrent retrieves episode ratings, fetching the pages concurrently
+func (c *OptimizedClient) GetEpisodeRatingsConcurrent(ctx context.Context) ([]EpisodeRating, error) {
+	var ratings []EpisodeRating
+	if err := c.getPaged(ctx, "/sync/ratings/episodes", &ratings); err != nil {
+		return nil, fmt.Errorf("failed to get episode ratings: %w", err)
+	}
+
+	c.logger.Info("api.episode_ratings_fetched", map[string]interface{}{
+		"count": len(ratings),
+	})
+
+	return ratings, nil
+}
+
+// GetMovieHistoryConcurrent retrieves the complete movie watch history,
+// fetching the pages concurrently. Like Client.GetMovieHistory only watch
+// and scrobble events are kept.
+func (c *OptimizedClient) GetMovieHistoryConcurrent(ctx context.Context) ([]HistoryItem, error) {
+	var history []HistoryItem
+	if err := c.getPaged(ctx, "/sync/history/movies", &history); err != nil {
+		return nil, fmt.Errorf("failed to get movie history: %w", err)
+	}
+
+	var watchHistory []HistoryItem
+	for _, item := range history {
+		if item.Action == "watch" || item.Action == "scrobble" {
+			watchHistory = append(watchHistory, item)
+		}
+	}
+
+	c.logger.Info("api.movie_history_fetched", map[string]interface{}{
+		"count": len(watchHistory),
+	})
+
+	return watchHistory, nil
+}
+
+// getPaged fetches every page of a paginated endpoint into result, using
+// the cache for the combined result when enabled
+func (c *OptimizedClient) getPaged(ctx context.Context, path string, result interface{}) error {
+	cacheKey := c.addExtendedInfo(c.resolveEndpoint(path)) + "#all-pages"
+	if c.cacheEnabled && c.cache.GetJSON(cacheKey, result) {
+		c.metrics.IncrementCacheHit()
+		return nil
+	}
+
+	pages, err := c.fetchPages(ctx, path)
+	if err != nil {
+		return err
+	}
+	if err := decodePages(pages, result); err != nil {
+		return err
+	}
+
+	if c.cacheEnabled {
+		if err := c.cache.SetJSON(cacheKey, result); err != nil {
+			c.logger.Warn("api.cache_set_failed", map[string]interface{}{
+				"endpoint": cacheKey,
+				"error":    err.Error(),
+			})
+		}
+	}
+	return nil
+}
+
 // ProcessBatchRequests processes multiple API requests concurrently
 func (c *OptimizedClient) ProcessBatchRequests(ctx context.Context, requests []BatchRequest) ([]BatchResult, error) {
 	if len(requests) == 0 {
@@ -413,7 +769,7 @@ func (c *OptimizedClient) Close() error {
 	c.metrics.LogStats()
 
 	c.logger.Info("api.client_closed", nil)
-	return nil
+	return FlushDiskCaches()
 }
 
 // addExtendedInfo adds the extended parameter to the URL if configured
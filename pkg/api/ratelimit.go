@@ -0,0 +1,413 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+)
+
+// Trakt allows 1000 authenticated GET calls per 5 minutes and one
+// POST, PUT or DELETE call per second
+const (
+	traktGetLimit       = 1000
+	traktGetPeriod      = 5 * time.Minute
+	traktWriteLimit     = 1
+	traktWritePeriod    = time.Second
+	rateLimitMaxRetries = 4
+	rateLimitBaseDelay  = time.Second
+	rateLimitMaxDelay   = time.Minute
+	lowBudgetThreshold  = 100
+)
+
+// RateLimitBudget is the rate limit state reported by Trakt in the
+// X-Ratelimit header
+type RateLimitBudget struct {
+	Name      string    `json:"name"`
+	Period    int       `json:"period"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Until     time.Time `json:"until"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Low reports whether less than a tenth of the budget remains
+func (b RateLimitBudget) Low() bool {
+	switch {
+	case b.Limit == 0:
+		return b.Remaining < lowBudgetThreshold
+	case b.Limit > 1:
+		return b.Remaining*10 < b.Limit
+	}
+	return false
+}
+
+// tokenBucket paces requests to a steady rate with a burst capacity
+type tokenBucket struct {
+	tokens     float64
+	capacity   float64
+	refillRate float64 // tokens per second
+	lastRefill time.Time
+	mu         sync.Mutex
+}
+
+func newTokenBucket(limit int, period time.Duration) *tokenBucket {
+	return &tokenBucket{
+		tokens:     float64(limit),
+		capacity:   float64(limit),
+		refillRate: float64(limit) / period.Seconds(),
+		lastRefill: time.Now(),
+	}
+}
+
+// reserve takes a token and returns how long the caller must wait for it
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.lastRefill).Seconds() * b.refillRate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.lastRefill = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.refillRate * float64(time.Second))
+}
+
+// drain empties the bucket until the given time, when Trakt reports the budget exhausted
+func (b *tokenBucket) drain(until time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wait := time.Until(until)
+	if wait <= 0 {
+		return
+	}
+	b.tokens = -wait.Seconds() * b.refillRate
+	b.lastRefill = time.Now()
+}
+
+// RateLimiter holds the request budget shared by every client talking to
+// Trakt with the same credentials
+type RateLimiter struct {
+	get       *tokenBucket
+	write     *tokenBucket
+	budgets   map[string]RateLimitBudget
+	observers []func(RateLimitBudget)
+	mu        sync.RWMutex
+}
+
+// NewRateLimiter creates a rate limiter tuned to Trakt's GET and POST limits
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		get:     newTokenBucket(traktGetLimit, traktGetPeriod),
+		write:   newTokenBucket(traktWriteLimit, traktWritePeriod),
+		budgets: make(map[string]RateLimitBudget),
+	}
+}
+
+var (
+	defaultRateLimiter     *RateLimiter
+	defaultRateLimiterOnce sync.Once
+)
+
+// DefaultRateLimiter returns the process-wide rate limiter used by NewClient
+// and the optimized client
+func DefaultRateLimiter() *RateLimiter {
+	defaultRateLimiterOnce.Do(func() {
+		defaultRateLimiter = NewRateLimiter()
+	})
+	return defaultRateLimiter
+}
+
+// Wait blocks until the request method fits in the budget or ctx is done
+func (l *RateLimiter) Wait(ctx context.Context, method string) error {
+	delay := l.bucket(method).reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// AddObserver registers a function called with every budget update
+func (l *RateLimiter) AddObserver(fn func(RateLimitBudget)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.observers = append(l.observers, fn)
+}
+
+// Budgets returns the last known budget of each Trakt limit, sorted by name
+func (l *RateLimiter) Budgets() []RateLimitBudget {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	budgets := make([]RateLimitBudget, 0, len(l.budgets))
+	for _, b := range l.budgets {
+		budgets = append(budgets, b)
+	}
+	sort.Slice(budgets, func(i, j int) bool { return budgets[i].Name < budgets[j].Name })
+	return budgets
+}
+
+// Budget returns the last known budget for the given request method
+func (l *RateLimiter) Budget(method string) (RateLimitBudget, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var found RateLimitBudget
+	var ok bool
+	for _, b := range l.budgets {
+		if isWriteMethod(method) == isWriteLimitName(b.Name) && b.UpdatedAt.After(found.UpdatedAt) {
+			found, ok = b, true
+		}
+	}
+	return found, ok
+}
+
+// update records the budget reported by a response
+func (l *RateLimiter) update(method string, budget RateLimitBudget) {
+	budget.UpdatedAt = time.Now()
+	if budget.Name == "" {
+		budget.Name = "API_GET_LIMIT"
+		if isWriteMethod(method) {
+			budget.Name = "API_POST_LIMIT"
+		}
+	}
+
+	if budget.Remaining <= 0 && budget.Until.After(time.Now()) {
+		l.bucket(method).drain(budget.Until)
+	}
+
+	l.mu.Lock()
+	l.budgets[budget.Name] = budget
+	observers := append([]func(RateLimitBudget){}, l.observers...)
+	l.mu.Unlock()
+
+	for _, fn := range observers {
+		fn(budget)
+	}
+}
+
+func (l *RateLimiter) bucket(method string) *tokenBucket {
+	if isWriteMethod(method) {
+		return l.write
+	}
+	return l.get
+}
+
+func isWriteMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch:
+		return true
+	}
+	return false
+}
+
+func isWriteLimitName(name string) bool {
+	return strings.HasSuffix(name, "POST_LIMIT")
+}
+
+// RateLimitTransport is an http.RoundTripper that paces requests with a
+// RateLimiter, records the X-Ratelimit budget of each response and retries
+// 429 and 503 responses after Retry-After or a jittered backoff. Timeout
+// bounds each attempt, up to the end of its response body, but not the
+// waits between attempts, which an http.Client timeout would include.
+type RateLimitTransport struct {
+	Base       http.RoundTripper
+	Limiter    *RateLimiter
+	Logger     logger.Logger
+	MaxRetries int
+	Timeout    time.Duration
+}
+
+// NewRateLimitTransport wraps base, which defaults to http.DefaultTransport,
+// with the process-wide rate limiter and a timeout for each attempt
+func NewRateLimitTransport(base http.RoundTripper, log logger.Logger, timeout time.Duration) *RateLimitTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &RateLimitTransport{
+		Base:       base,
+		Limiter:    DefaultRateLimiter(),
+		Logger:     log,
+		MaxRetries: rateLimitMaxRetries,
+		Timeout:    timeout,
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := t.Limiter.Wait(req.Context(), req.Method); err != nil {
+			return nil, err
+		}
+
+		attemptReq := req
+		if attempt > 0 {
+			var err error
+			if attemptReq, err = rewindRequest(req); err != nil {
+				return nil, err
+			}
+		}
+
+		// The deadline starts after the rate limiter and retry waits
+		cancel := context.CancelFunc(func() {})
+		if t.Timeout > 0 {
+			var ctx context.Context
+			ctx, cancel = context.WithTimeout(req.Context(), t.Timeout)
+			attemptReq = attemptReq.WithContext(ctx)
+		}
+
+		resp, err := t.Base.RoundTrip(attemptReq)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+
+		if budget, ok := parseRateLimitHeader(resp.Header); ok {
+			t.Limiter.update(req.Method, budget)
+			if budget.Low() && t.Logger != nil {
+				t.Logger.Warn("api.rate_limit_warning", map[string]interface{}{
+					"name":      budget.Name,
+					"remaining": budget.Remaining,
+					"limit":     budget.Limit,
+				})
+			}
+		}
+
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		}
+		if attempt >= t.MaxRetries || !canRewind(req) {
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		}
+
+		delay, fromHeader := retryAfter(resp.Header, time.Now())
+		if !fromHeader {
+			delay = backoffDelay(attempt)
+		}
+		resp.Body.Close()
+		cancel()
+
+		if t.Logger != nil {
+			t.Logger.Warn("api.rate_limited_retrying", map[string]interface{}{
+				"status":  resp.StatusCode,
+				"attempt": attempt + 1,
+				"delay":   delay.String(),
+			})
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// cancelOnClose releases the deadline of an attempt once its response body
+// is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// parseRateLimitHeader reads the X-Ratelimit JSON header, falling back to
+// the legacy X-Ratelimit-Remaining header
+func parseRateLimitHeader(h http.Header) (RateLimitBudget, bool) {
+	var budget RateLimitBudget
+	if raw := h.Get("X-Ratelimit"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &budget); err == nil {
+			return budget, true
+		}
+	}
+
+	if raw := h.Get("X-Ratelimit-Remaining"); raw != "" {
+		remaining, err := strconv.Atoi(raw)
+		if err != nil {
+			return budget, false
+		}
+		budget.Remaining = remaining
+		budget.Limit, _ = strconv.Atoi(h.Get("X-Ratelimit-Limit"))
+		return budget, true
+	}
+	return budget, false
+}
+
+// retryAfter parses the Retry-After header given in seconds or as an HTTP date
+func retryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	raw := h.Get("Retry-After")
+	if raw == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(raw); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(raw); err == nil {
+		if d := date.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// backoffDelay returns an exponential delay with equal jitter for the attempt:
+// half of the delay is fixed and the other half random
+func backoffDelay(attempt int) time.Duration {
+	delay := rateLimitBaseDelay << uint(attempt)
+	if delay <= 0 || delay > rateLimitMaxDelay {
+		delay = rateLimitMaxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func canRewind(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// rewindRequest clones req with a fresh body for a retry
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return clone, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("failed to rewind request body: %w", err)
+	}
+	clone.Body = body
+	return clone, nil
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRateLimitTransport() *RateLimitTransport {
+	return &RateLimitTransport{
+		Base:       http.DefaultTransport,
+		Limiter:    NewRateLimiter(),
+		Logger:     &MockLogger{},
+		MaxRetries: 2,
+	}
+}
+
+func TestParseRateLimitHeader(t *testing.T) {
+	h := http.Header{}
+	h.Set("X-Ratelimit", `{"name":"AUTHED_API_GET_LIMIT","period":300,"limit":1000,"remaining":42,"until":"2025-01-02T03:04:05Z"}`)
+
+	budget, ok := parseRateLimitHeader(h)
+	require.True(t, ok)
+	assert.Equal(t, "AUTHED_API_GET_LIMIT", budget.Name)
+	assert.Equal(t, 300, budget.Period)
+	assert.Equal(t, 1000, budget.Limit)
+	assert.Equal(t, 42, budget.Remaining)
+	assert.Equal(t, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), budget.Until)
+	assert.True(t, budget.Low())
+
+	legacy := http.Header{}
+	legacy.Set("X-Ratelimit-Remaining", "500")
+	budget, ok = parseRateLimitHeader(legacy)
+	require.True(t, ok)
+	assert.Equal(t, 500, budget.Remaining)
+	assert.False(t, budget.Low())
+
+	_, ok = parseRateLimitHeader(http.Header{})
+	assert.False(t, ok)
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	h := http.Header{}
+	h.Set("Retry-After", "7")
+	delay, ok := retryAfter(h, now)
+	assert.True(t, ok)
+	assert.Equal(t, 7*time.Second, delay)
+
+	h.Set("Retry-After", now.Add(30*time.Second).Format(http.TimeFormat))
+	delay, ok = retryAfter(h, now)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, delay)
+
+	h.Set("Retry-After", "soon")
+	_, ok = retryAfter(h, now)
+	assert.False(t, ok)
+}
+
+func TestBackoffDelay(t *testing.T) {
+	for attempt := 0; attempt < 10; attempt++ {
+		delay := backoffDelay(attempt)
+		assert.Greater(t, delay, time.Duration(0))
+		assert.LessOrEqual(t, delay, rateLimitMaxDelay)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	bucket := newTokenBucket(traktWriteLimit, traktWritePeriod)
+
+	assert.Zero(t, bucket.reserve(), "the first write is not delayed")
+	delay := bucket.reserve()
+	assert.Greater(t, delay, 900*time.Millisecond, "the second write waits for the next second")
+	assert.LessOrEqual(t, delay, time.Second)
+
+	bucket = newTokenBucket(traktGetLimit, traktGetPeriod)
+	bucket.drain(time.Now().Add(time.Minute))
+	assert.Greater(t, bucket.reserve(), 59*time.Second, "an exhausted budget waits until the reset")
+}
+
+func TestRateLimitTransport_RetriesTooManyRequests(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ratelimit", `{"name":"AUTHED_API_GET_LIMIT","period":300,"limit":1000,"remaining":999,"until":"2030-01-01T00:00:00Z"}`)
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	transport := newTestRateLimitTransport()
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	budget, ok := transport.Limiter.Budget(http.MethodGet)
+	require.True(t, ok)
+	assert.Equal(t, 999, budget.Remaining)
+	assert.Len(t, transport.Limiter.Budgets(), 1)
+}
+
+func TestRateLimitTransport_RewindsBody(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	transport := newTestRateLimitTransport()
+	// Writes are paced to one per second, start with a full bucket for the retry
+	transport.Limiter.write = newTokenBucket(10, time.Second)
+
+	resp, err := (&http.Client{Transport: transport}).Post(server.URL, "application/json", strings.NewReader(`{"a":1}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, []string{`{"a":1}`, `{"a":1}`}, bodies)
+}
+
+func TestClient_RateLimitExhausted(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{Trakt: config.TraktConfig{
+		ClientID:    "client",
+		AccessToken: "token",
+		APIBaseURL:  server.URL,
+	}}
+	client := NewClient(cfg, &MockLogger{})
+	client.httpClient.Transport = newTestRateLimitTransport()
+
+	_, err := client.GetWatchlist()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rate limit exceeded")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls), "one request and two transport retries, no client retries")
+}
+
+func TestRateLimitTransport_RetryAfterLongerThanTimeout(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	// The wait for Retry-After is longer than the timeout of each attempt
+	transport := newTestRateLimitTransport()
+	transport.Timeout = 300 * time.Millisecond
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestRateLimitTransport_AttemptTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	transport := newTestRateLimitTransport()
+	transport.Timeout = 50 * time.Millisecond
+	_, err := (&http.Client{Transport: transport}).Get(server.URL)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -13,6 +14,7 @@ import (
 const (
 	maxRetries       = 3
 	retryInterval    = time.Second
+	requestTimeout   = 30 * time.Second
 	APIVersion       = "2"
 	AuthHeaderPrefix = "Bearer "
 )
@@ -30,13 +32,19 @@ type TokenManager interface {
 	GetValidAccessToken() (string, error)
 }
 
+// ContextTokenManager is a TokenManager whose token refresh can be cancelled
+type ContextTokenManager interface {
+	TokenManager
+	GetValidAccessTokenContext(ctx context.Context) (string, error)
+}
+
 // NewClient creates a new Trakt API client
 func NewClient(cfg *config.Config, log logger.Logger) *Client {
 	return &Client{
 		config: cfg,
 		logger: log,
 		httpClient: &http.Client{
-			Timeout: time.Second * 30,
+			Transport: newHTTPTransport(cfg, nil, log, requestTimeout),
 		},
 	}
 }
@@ -46,13 +54,17 @@ func NewClientWithTokenManager(cfg *config.Config, log logger.Logger, tokenMgr T
 	return &Client{
 		config:       cfg,
 		logger:       log,
-		httpClient:   &http.Client{Timeout: time.Second * 30},
+		httpClient:   &http.Client{Transport: newHTTPTransport(cfg, nil, log, requestTimeout)},
 		tokenManager: tokenMgr,
 	}
 }
 
-// makeRequest makes an HTTP request with retries and automatic token refresh
+// makeRequest makes an HTTP request with retries and automatic token refresh.
+// Retries, backoff waits and token refreshes stop as soon as the request
+// context is done.
 func (c *Client) makeRequest(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
 	// Set authentication header
 	if err := c.setAuthHeader(req); err != nil {
 		return nil, fmt.Errorf("failed to set auth header: %w", err)
@@ -65,18 +77,28 @@ func (c *Client) makeRequest(req *http.Request) (*http.Response, error) {
 				"attempt": attempt + 1,
 				"max":     maxRetries,
 			})
-			time.Sleep(retryInterval * time.Duration(attempt))
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(retryInterval * time.Duration(attempt)):
+			}
 		}
 
 		// Clone the request for retry attempts
-		reqClone := req.Clone(req.Context())
+		reqClone := req.Clone(ctx)
 		if err := c.setAuthHeader(reqClone); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
 			lastErr = fmt.Errorf("failed to set auth header on retry: %w", err)
 			continue
 		}
 
 		resp, err := c.httpClient.Do(reqClone)
 		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
 			lastErr = fmt.Errorf("request failed: %w", err)
 			continue
 		}
@@ -87,12 +109,12 @@ func (c *Client) makeRequest(req *http.Request) (*http.Response, error) {
 			c.logger.Info("api.token_expired_refreshing", nil)
 			
 			// Try to refresh token and retry once
-			if _, err := c.tokenManager.GetValidAccessToken(); err != nil {
+			if _, err := c.accessToken(ctx); err != nil {
 				return nil, fmt.Errorf("token refresh failed: %w", err)
 			}
 			
 			// Retry the request with new token
-			reqRetry := req.Clone(req.Context())
+			reqRetry := req.Clone(ctx)
 			if err := c.setAuthHeader(reqRetry); err != nil {
 				return nil, fmt.Errorf("failed to set refreshed auth header: %w", err)
 			}
@@ -111,6 +133,12 @@ func (c *Client) makeRequest(req *http.Request) (*http.Response, error) {
 			return retryResp, nil
 		}
 
+		// The transport already waited and retried rate limited requests
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+			resp.Body.Close()
+			return nil, fmt.Errorf("rate limit exceeded: status %d", resp.StatusCode)
+		}
+
 		// Only retry on server errors (5xx)
 		if resp.StatusCode >= 500 {
 			resp.Body.Close()
@@ -134,7 +162,7 @@ func (c *Client) setAuthHeader(req *http.Request) error {
 	// Get access token
 	var accessToken string
 	if c.tokenManager != nil {
-		token, err := c.tokenManager.GetValidAccessToken()
+		token, err := c.accessToken(req.Context())
 		if err != nil {
 			return fmt.Errorf("failed to get valid access token: %w", err)
 		}
@@ -151,6 +179,15 @@ func (c *Client) setAuthHeader(req *http.Request) error {
 	return nil
 }
 
+// accessToken returns a valid access token from the token manager, passing
+// ctx along when the token manager supports cancellation
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	if ctm, ok := c.tokenManager.(ContextTokenManager); ok {
+		return ctm.GetValidAccessTokenContext(ctx)
+	}
+	return c.tokenManager.GetValidAccessToken()
+}
+
 // addExtendedInfo adds the extended parameter to the URL if it's configured
 func (c *Client) addExtendedInfo(endpoint string) string {
 	// Safety checks
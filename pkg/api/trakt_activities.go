@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Dataset names shared by the exports and the last activities
+const (
+	DatasetWatched    = "watched"
+	DatasetCollection = "collection"
+	DatasetShows      = "shows"
+	DatasetRatings    = "ratings"
+	DatasetWatchlist  = "watchlist"
+)
+
+// MovieActivities holds the last change of each movie category
+type MovieActivities struct {
+	WatchedAt     time.Time `json:"watched_at"`
+	CollectedAt   time.Time `json:"collected_at"`
+	RatedAt       time.Time `json:"rated_at"`
+	WatchlistedAt time.Time `json:"watchlisted_at"`
+}
+
+// EpisodeActivities holds the last change of each episode category
+type EpisodeActivities struct {
+	WatchedAt   time.Time `json:"watched_at"`
+	CollectedAt time.Time `json:"collected_at"`
+	RatedAt     time.Time `json:"rated_at"`
+}
+
+// ShowActivities holds the last change of each show category
+type ShowActivities struct {
+	RatedAt       time.Time `json:"rated_at"`
+	WatchlistedAt time.Time `json:"watchlisted_at"`
+}
+
+// LastActivities is the response of /sync/last_activities, telling when
+// each part of the user's data last changed
+type LastActivities struct {
+	All      time.Time         `json:"all"`
+	Movies   MovieActivities   `json:"movies"`
+	Episodes EpisodeActivities `json:"episodes"`
+	Shows    ShowActivities    `json:"shows"`
+}
+
+// DatasetActivity returns when the data behind an export dataset last
+// changed. Datasets that include ratings also change when a rating does.
+func (a *LastActivities) DatasetActivity(dataset string) (time.Time, bool) {
+	switch dataset {
+	case DatasetWatched:
+		return latest(a.Movies.WatchedAt, a.Movies.RatedAt), true
+	case DatasetCollection:
+		return a.Movies.CollectedAt, true
+	case DatasetShows:
+		return latest(a.Episodes.WatchedAt, a.Episodes.RatedAt, a.Shows.RatedAt), true
+	case DatasetRatings:
+		return a.Movies.RatedAt, true
+	case DatasetWatchlist:
+		return a.Movies.WatchlistedAt, true
+	}
+	return time.Time{}, false
+}
+
+func latest(times ...time.Time) time.Time {
+	var result time.Time
+	for _, t := range times {
+		if t.After(result) {
+			result = t
+		}
+	}
+	return result
+}
+
+// GetLastActivities retrieves when each part of the user's data last changed
+func (c *Client) GetLastActivities() (*LastActivities, error) {
+	return c.GetLastActivitiesContext(context.Background())
+}
+
+// GetLastActivitiesContext is like GetLastActivities but stops when ctx is done
+func (c *Client) GetLastActivitiesContext(ctx context.Context) (*LastActivities, error) {
+	endpoint := c.config.Trakt.APIBaseURL + "/sync/last_activities"
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		c.logger.Error("errors.api_request_failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.makeRequest(req)
+	if err != nil {
+		c.logger.Error("errors.api_request_failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		c.logger.Error("errors.api_request_failed", map[string]interface{}{
+			"status": resp.StatusCode,
+		})
+		return nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
+	}
+
+	var activities LastActivities
+	if err := json.NewDecoder(resp.Body).Decode(&activities); err != nil {
+		c.logger.Error("errors.api_response_parse_failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	c.logger.Debug("api.last_activities_fetched", map[string]interface{}{
+		"all": activities.All.Format(time.RFC3339),
+	})
+	return &activities, nil
+}
+
+// GetLastActivitiesConcurrent retrieves the last activities. They are never
+// served from the in-memory cache since they decide what needs fetching.
+func (c *OptimizedClient) GetLastActivitiesConcurrent(ctx context.Context) (*LastActivities, error) {
+	body, _, err := c.fetch(ctx, c.resolveEndpoint("/sync/last_activities"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last activities: %w", err)
+	}
+
+	var activities LastActivities
+	if err := json.Unmarshal(body, &activities); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &activities, nil
+}
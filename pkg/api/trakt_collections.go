@@ -1,10 +1,10 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strconv"
 )
 
 // WatchlistMovie represents a movie in the user's watchlist
@@ -16,8 +16,13 @@ type WatchlistMovie struct {
 
 // GetWatchlist retrieves the user's movie watchlist from Trakt
 func (c *Client) GetWatchlist() ([]WatchlistMovie, error) {
+	return c.GetWatchlistContext(context.Background())
+}
+
+// GetWatchlistContext is like GetWatchlist but stops when ctx is done
+func (c *Client) GetWatchlistContext(ctx context.Context) ([]WatchlistMovie, error) {
 	endpoint := c.addExtendedInfo(c.config.Trakt.APIBaseURL + "/sync/watchlist/movies")
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		c.logger.Error("errors.api_request_failed", map[string]interface{}{
 			"error": err.Error(),
@@ -36,16 +41,6 @@ func (c *Client) GetWatchlist() ([]WatchlistMovie, error) {
 	}
 	defer resp.Body.Close()
 
-	// Handle rate limiting
-	if limit := resp.Header.Get("X-Ratelimit-Remaining"); limit != "" {
-		remaining, _ := strconv.Atoi(limit)
-		if remaining < 100 {
-			c.logger.Warn("api.rate_limit_warning", map[string]interface{}{
-				"remaining": remaining,
-			})
-		}
-	}
-
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
 		var errorResp map[string]string
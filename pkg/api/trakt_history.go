@@ -1,10 +1,10 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strconv"
 )
 
 // HistoryItem represents a single watch event from the user's watch history
@@ -24,16 +24,29 @@ type MovieHistoryResponse struct {
 
 // GetMovieHistory retrieves the user's complete movie watch history from Trakt
 func (c *Client) GetMovieHistory() ([]HistoryItem, error) {
+	return c.GetMovieHistoryContext(context.Background())
+}
+
+// GetMovieHistoryContext is like GetMovieHistory but stops when ctx is done
+func (c *Client) GetMovieHistoryContext(ctx context.Context) ([]HistoryItem, error) {
 	var allHistory []HistoryItem
 	page := 1
 	limit := 100
 
 	for {
+		if err := ctx.Err(); err != nil {
+			c.logger.Warn("api.history_pagination_cancelled", map[string]interface{}{
+				"page":  page,
+				"count": len(allHistory),
+			})
+			return nil, fmt.Errorf("movie history retrieval cancelled: %w", err)
+		}
+
 		endpoint := fmt.Sprintf("%s/sync/history/movies?page=%d&limit=%d",
 			c.config.Trakt.APIBaseURL, page, limit)
 		endpoint = c.addExtendedInfo(endpoint)
 
-		req, err := http.NewRequest("GET", endpoint, nil)
+		req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 		if err != nil {
 			c.logger.Error("errors.api_request_failed", map[string]interface{}{
 				"error": err.Error(),
@@ -50,16 +63,6 @@ func (c *Client) GetMovieHistory() ([]HistoryItem, error) {
 		}
 		defer resp.Body.Close()
 
-		// Handle rate limiting
-		if limitHeader := resp.Header.Get("X-Ratelimit-Remaining"); limitHeader != "" {
-			remaining, _ := strconv.Atoi(limitHeader)
-			if remaining < 100 {
-				c.logger.Warn("api.rate_limit_warning", map[string]interface{}{
-					"remaining": remaining,
-				})
-			}
-		}
-
 		// Check response status
 		if resp.StatusCode != http.StatusOK {
 			var errorResp map[string]string
@@ -1,10 +1,10 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strconv"
 )
 
 // MovieIDs represents the various IDs associated with a movie
@@ -56,8 +56,13 @@ type Rating struct {
 
 // GetWatchedMovies retrieves the list of watched movies from Trakt
 func (c *Client) GetWatchedMovies() ([]Movie, error) {
+	return c.GetWatchedMoviesContext(context.Background())
+}
+
+// GetWatchedMoviesContext is like GetWatchedMovies but stops when ctx is done
+func (c *Client) GetWatchedMoviesContext(ctx context.Context) ([]Movie, error) {
 	endpoint := c.addExtendedInfo(c.config.Trakt.APIBaseURL + "/sync/watched/movies")
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		c.logger.Error("errors.api_request_failed", map[string]interface{}{
 			"error": err.Error(),
@@ -76,16 +81,6 @@ func (c *Client) GetWatchedMovies() ([]Movie, error) {
 	}
 	defer resp.Body.Close()
 
-	// Handle rate limiting
-	if limit := resp.Header.Get("X-Ratelimit-Remaining"); limit != "" {
-		remaining, _ := strconv.Atoi(limit)
-		if remaining < 100 {
-			c.logger.Warn("api.rate_limit_warning", map[string]interface{}{
-				"remaining": remaining,
-			})
-		}
-	}
-
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
 		var errorResp map[string]string
@@ -113,8 +108,13 @@ func (c *Client) GetWatchedMovies() ([]Movie, error) {
 
 // GetCollectionMovies retrieves the list of movies in the user's collection from Trakt
 func (c *Client) GetCollectionMovies() ([]CollectionMovie, error) {
+	return c.GetCollectionMoviesContext(context.Background())
+}
+
+// GetCollectionMoviesContext is like GetCollectionMovies but stops when ctx is done
+func (c *Client) GetCollectionMoviesContext(ctx context.Context) ([]CollectionMovie, error) {
 	endpoint := c.addExtendedInfo(c.config.Trakt.APIBaseURL + "/sync/collection/movies")
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		c.logger.Error("errors.api_request_failed", map[string]interface{}{
 			"error": err.Error(),
@@ -133,16 +133,6 @@ func (c *Client) GetCollectionMovies() ([]CollectionMovie, error) {
 	}
 	defer resp.Body.Close()
 
-	// Handle rate limiting
-	if limit := resp.Header.Get("X-Ratelimit-Remaining"); limit != "" {
-		remaining, _ := strconv.Atoi(limit)
-		if remaining < 100 {
-			c.logger.Warn("api.rate_limit_warning", map[string]interface{}{
-				"remaining": remaining,
-			})
-		}
-	}
-
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
 		var errorResp map[string]string
@@ -173,8 +163,13 @@ func (c *Client) GetCollectionMovies() ([]CollectionMovie, error) {
 
 // GetRatings retrieves the user's ratings from Trakt
 func (c *Client) GetRatings() ([]Rating, error) {
+	return c.GetRatingsContext(context.Background())
+}
+
+// GetRatingsContext is like GetRatings but stops when ctx is done
+func (c *Client) GetRatingsContext(ctx context.Context) ([]Rating, error) {
 	endpoint := c.addExtendedInfo(c.config.Trakt.APIBaseURL + "/sync/ratings/movies")
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		c.logger.Error("errors.api_request_failed", map[string]interface{}{
 			"error": err.Error(),
@@ -193,16 +188,6 @@ func (c *Client) GetRatings() ([]Rating, error) {
 	}
 	defer resp.Body.Close()
 
-	// Handle rate limiting
-	if limit := resp.Header.Get("X-Ratelimit-Remaining"); limit != "" {
-		remaining, _ := strconv.Atoi(limit)
-		if remaining < 100 {
-			c.logger.Warn("api.rate_limit_warning", map[string]interface{}{
-				"remaining": remaining,
-			})
-		}
-	}
-
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
 		var errorResp map[string]string
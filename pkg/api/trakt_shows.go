@@ -1,10 +1,10 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strconv"
 )
 
 // ShowIDs represents the various IDs associated with a show
@@ -88,8 +88,13 @@ type EpisodeRating struct {
 
 // GetWatchedShows retrieves the list of watched shows from Trakt
 func (c *Client) GetWatchedShows() ([]WatchedShow, error) {
+	return c.GetWatchedShowsContext(context.Background())
+}
+
+// GetWatchedShowsContext is like GetWatchedShows but stops when ctx is done
+func (c *Client) GetWatchedShowsContext(ctx context.Context) ([]WatchedShow, error) {
 	endpoint := c.addExtendedInfo(c.config.Trakt.APIBaseURL + "/sync/watched/shows")
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		c.logger.Error("errors.api_request_failed", map[string]interface{}{
 			"error": err.Error(),
@@ -108,16 +113,6 @@ func (c *Client) GetWatchedShows() ([]WatchedShow, error) {
 	}
 	defer resp.Body.Close()
 
-	// Handle rate limiting
-	if limit := resp.Header.Get("X-Ratelimit-Remaining"); limit != "" {
-		remaining, _ := strconv.Atoi(limit)
-		if remaining < 100 {
-			c.logger.Warn("api.rate_limit_warning", map[string]interface{}{
-				"remaining": remaining,
-			})
-		}
-	}
-
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
 		var errorResp map[string]string
@@ -148,8 +143,13 @@ func (c *Client) GetWatchedShows() ([]WatchedShow, error) {
 
 // GetShowRatings retrieves the user's TV show ratings from Trakt
 func (c *Client) GetShowRatings() ([]ShowRating, error) {
+	return c.GetShowRatingsContext(context.Background())
+}
+
+// GetShowRatingsContext is like GetShowRatings but stops when ctx is done
+func (c *Client) GetShowRatingsContext(ctx context.Context) ([]ShowRating, error) {
 	endpoint := c.addExtendedInfo(c.config.Trakt.APIBaseURL + "/sync/ratings/shows")
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		c.logger.Error("errors.api_request_failed", map[string]interface{}{
 			"error": err.Error(),
@@ -168,16 +168,6 @@ func (c *Client) GetShowRatings() ([]ShowRating, error) {
 	}
 	defer resp.Body.Close()
 
-	// Handle rate limiting
-	if limit := resp.Header.Get("X-Ratelimit-Remaining"); limit != "" {
-		remaining, _ := strconv.Atoi(limit)
-		if remaining < 100 {
-			c.logger.Warn("api.rate_limit_warning", map[string]interface{}{
-				"remaining": remaining,
-			})
-		}
-	}
-
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
 		var errorResp map[string]string
@@ -208,8 +198,13 @@ func (c *Client) GetShowRatings() ([]ShowRating, error) {
 
 // GetEpisodeRatings retrieves the user's TV episode ratings from Trakt
 func (c *Client) GetEpisodeRatings() ([]EpisodeRating, error) {
+	return c.GetEpisodeRatingsContext(context.Background())
+}
+
+// GetEpisodeRatingsContext is like GetEpisodeRatings but stops when ctx is done
+func (c *Client) GetEpisodeRatingsContext(ctx context.Context) ([]EpisodeRating, error) {
 	endpoint := c.addExtendedInfo(c.config.Trakt.APIBaseURL + "/sync/ratings/episodes")
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		c.logger.Error("errors.api_request_failed", map[string]interface{}{
 			"error": err.Error(),
@@ -228,16 +223,6 @@ func (c *Client) GetEpisodeRatings() ([]EpisodeRating, error) {
 	}
 	defer resp.Body.Close()
 
-	// Handle rate limiting
-	if limit := resp.Header.Get("X-Ratelimit-Remaining"); limit != "" {
-		remaining, _ := strconv.Atoi(limit)
-		if remaining < 100 {
-			c.logger.Warn("api.rate_limit_warning", map[string]interface{}{
-				"remaining": remaining,
-			})
-		}
-	}
-
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
 		var errorResp map[string]string
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -1167,4 +1169,174 @@ func TestGetConfig(t *testing.T) {
 	if returnedConfig != expectedConfig {
 		t.Errorf("Expected config to be the same as what was passed in")
 	}
-} 
\ No newline at end of file
+} 
+
+func TestVerifyClientID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/movies/trending", r.URL.Path)
+		if r.Header.Get("trakt-api-key") != "valid-id" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	assert.NoError(t, VerifyClientID(server.Client(), server.URL, "valid-id"))
+
+	err := VerifyClientID(server.Client(), server.URL+"/", "invalid-id")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "rejected")
+}
+
+// contextTokenManager records the context passed to token refreshes
+type contextTokenManager struct {
+	ctx context.Context
+}
+
+func (m *contextTokenManager) GetValidAccessToken() (string, error) {
+	return "test_token", nil
+}
+
+func (m *contextTokenManager) GetValidAccessTokenContext(ctx context.Context) (string, error) {
+	m.ctx = ctx
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return "test_token", nil
+}
+
+// TestGetWatchedMoviesContextCancelled tests that cancelling the context stops the retries
+func TestGetWatchedMoviesContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		cancel()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Trakt: config.TraktConfig{
+			ClientID:    "test_client_id",
+			AccessToken: "test_access_token",
+			APIBaseURL:  server.URL,
+		},
+	}
+	client := NewClient(cfg, &MockLogger{})
+
+	start := time.Now()
+	_, err := client.GetWatchedMoviesContext(ctx)
+	assert.True(t, errors.Is(err, context.Canceled), "expected context.Canceled, got %v", err)
+	assert.Equal(t, 1, requests)
+	assert.Less(t, time.Since(start), retryInterval)
+}
+
+// TestGetMovieHistoryContextStopsPagination tests that cancellation stops the pagination loop
+func TestGetMovieHistoryContextStopsPagination(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	page := make([]HistoryItem, 100)
+	for i := range page {
+		page[i] = HistoryItem{ID: i, Action: "watch", Type: "movie"}
+	}
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 2 {
+			cancel()
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Trakt: config.TraktConfig{
+			ClientID:    "test_client_id",
+			AccessToken: "test_access_token",
+			APIBaseURL:  server.URL,
+		},
+	}
+	client := NewClient(cfg, &MockLogger{})
+
+	history, err := client.GetMovieHistoryContext(ctx)
+	assert.True(t, errors.Is(err, context.Canceled), "expected context.Canceled, got %v", err)
+	assert.Nil(t, history)
+	assert.Equal(t, 2, requests)
+}
+
+// TestClientPassesContextToTokenManager tests that token refreshes receive the request context
+func TestClientPassesContextToTokenManager(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test_token", r.Header.Get("Authorization"))
+		json.NewEncoder(w).Encode([]Rating{})
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Trakt: config.TraktConfig{
+			ClientID:   "test_client_id",
+			APIBaseURL: server.URL,
+		},
+	}
+	tokenMgr := &contextTokenManager{}
+	client := NewClientWithTokenManager(cfg, &MockLogger{}, tokenMgr)
+
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "export")
+	_, err := client.GetRatingsContext(ctx)
+	assert.NoError(t, err)
+	if assert.NotNil(t, tokenMgr.ctx) {
+		assert.Equal(t, "export", tokenMgr.ctx.Value(ctxKey{}))
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.GetRatingsContext(cancelled)
+	assert.True(t, errors.Is(err, context.Canceled), "expected context.Canceled, got %v", err)
+}
+
+// TestGetLastActivities tests parsing the last activities and mapping them to datasets
+func TestGetLastActivities(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/sync/last_activities", r.URL.Path)
+		w.Write([]byte(`{
+			"all": "2025-03-01T10:00:00.000Z",
+			"movies": {"watched_at": "2025-02-01T10:00:00.000Z", "rated_at": "2025-03-01T10:00:00.000Z", "collected_at": "2025-01-01T10:00:00.000Z", "watchlisted_at": "2024-12-01T10:00:00.000Z"},
+			"episodes": {"watched_at": "2025-01-15T10:00:00.000Z", "rated_at": "2024-01-01T10:00:00.000Z"},
+			"shows": {"rated_at": "2024-06-01T10:00:00.000Z"}
+		}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Trakt: config.TraktConfig{
+			ClientID:    "test_client_id",
+			AccessToken: "test_access_token",
+			APIBaseURL:  server.URL,
+		},
+	}
+	client := NewClient(cfg, &MockLogger{})
+
+	activities, err := client.GetLastActivities()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Watched movies include ratings, so the later rating counts
+	watched, ok := activities.DatasetActivity(DatasetWatched)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC), watched)
+
+	collection, _ := activities.DatasetActivity(DatasetCollection)
+	assert.Equal(t, time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC), collection)
+
+	shows, _ := activities.DatasetActivity(DatasetShows)
+	assert.Equal(t, time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC), shows)
+
+	_, ok = activities.DatasetActivity("unknown")
+	assert.False(t, ok)
+}
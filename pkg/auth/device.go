@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrDeviceCodeExpired is returned when the user did not approve the device in time
+	ErrDeviceCodeExpired = errors.New("device code expired")
+	// ErrDeviceCodeDenied is returned when the user denied the authorization
+	ErrDeviceCodeDenied = errors.New("authorization denied by user")
+	// ErrDeviceCodeInvalid is returned for unknown or already used device codes
+	ErrDeviceCodeInvalid = errors.New("device code is invalid or already used")
+)
+
+var (
+	// deviceSlowDownStep is added to the polling interval on each slow_down response
+	deviceSlowDownStep = 5 * time.Second
+	// deviceDefaultInterval and deviceDefaultExpiry apply when the device
+	// code response leaves the interval or lifetime out
+	deviceDefaultInterval = 5 * time.Second
+	deviceDefaultExpiry   = 600 * time.Second
+)
+
+// deviceMaxPollFailures bounds the consecutive polls failing on network errors
+const deviceMaxPollFailures = 5
+
+// DeviceCode is the response of the device code request
+type DeviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURL string `json:"verification_url"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// ExpiresAt returns when the device code stops being valid, counted from
+// issuedAt. A code without lifetime is given the usual ten minutes.
+func (d *DeviceCode) ExpiresAt(issuedAt time.Time) time.Time {
+	if d.ExpiresIn <= 0 {
+		return issuedAt.Add(deviceDefaultExpiry)
+	}
+	return issuedAt.Add(time.Duration(d.ExpiresIn) * time.Second)
+}
+
+// RequestDeviceCode starts the device flow and returns the code to show the user
+func (o *OAuthManager) RequestDeviceCode() (*DeviceCode, error) {
+	body, status, err := o.postJSON(context.Background(), "/oauth/device/code", map[string]string{
+		"client_id": o.config.Trakt.ClientID,
+	})
+	if err != nil {
+		o.logger.Error("oauth.device_code_request_failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil, fmt.Errorf("device code request failed: %w", err)
+	}
+
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("device code request failed with status %d: %s", status, string(body))
+	}
+
+	var code DeviceCode
+	if err := json.Unmarshal(body, &code); err != nil {
+		return nil, fmt.Errorf("failed to parse device code response: %w", err)
+	}
+	if code.DeviceCode == "" || code.UserCode == "" {
+		return nil, fmt.Errorf("device code response is missing the device or user code")
+	}
+
+	o.logger.Info("oauth.device_code_issued", map[string]interface{}{
+		"verification_url": code.VerificationURL,
+		"expires_in":       code.ExpiresIn,
+		"interval":         code.Interval,
+	})
+
+	return &code, nil
+}
+
+// PollDeviceToken polls for the token until the user approves the device,
+// the code expires, polls keep failing or ctx is cancelled. The advertised
+// interval, 5 seconds by default, is honoured and increased whenever Trakt
+// asks to slow down.
+func (o *OAuthManager) PollDeviceToken(ctx context.Context, code *DeviceCode) (*TokenResponse, error) {
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = deviceDefaultInterval
+	}
+	deadline := code.ExpiresAt(time.Now())
+	failures := 0
+
+	payload := map[string]string{
+		"code":          code.DeviceCode,
+		"client_id":     o.config.Trakt.ClientID,
+		"client_secret": o.config.Trakt.ClientSecret,
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+
+		if time.Now().After(deadline) {
+			return nil, ErrDeviceCodeExpired
+		}
+
+		body, status, err := o.postJSON(ctx, "/oauth/device/token", payload)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			failures++
+			o.logger.Warn("oauth.device_poll_failed", map[string]interface{}{
+				"error":    err.Error(),
+				"failures": failures,
+			})
+			if failures >= deviceMaxPollFailures {
+				return nil, fmt.Errorf("device token polling failed %d times in a row: %w", failures, err)
+			}
+			continue
+		}
+		failures = 0
+
+		switch deviceTokenState(status, body) {
+		case "authorized":
+			var tokenResp TokenResponse
+			if err := json.Unmarshal(body, &tokenResp); err != nil {
+				return nil, fmt.Errorf("failed to parse token response: %w", err)
+			}
+			tokenResp.CreatedAt = time.Now().Unix()
+
+			o.logger.Info("oauth.device_token_success", map[string]interface{}{
+				"token_type": tokenResp.TokenType,
+				"expires_in": tokenResp.ExpiresIn,
+				"scope":      tokenResp.Scope,
+			})
+			return &tokenResp, nil
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			interval += deviceSlowDownStep
+			o.logger.Warn("oauth.device_poll_slow_down", map[string]interface{}{
+				"interval": interval.String(),
+			})
+		case "expired_token":
+			return nil, ErrDeviceCodeExpired
+		case "access_denied":
+			return nil, ErrDeviceCodeDenied
+		case "invalid_grant":
+			return nil, ErrDeviceCodeInvalid
+		default:
+			return nil, fmt.Errorf("device token request failed with status %d: %s", status, string(body))
+		}
+	}
+}
+
+// deviceTokenState maps a device token response to its RFC 8628 state.
+// Trakt signals the state with status codes; standard error bodies are
+// honoured too.
+func deviceTokenState(status int, body []byte) string {
+	if status == http.StatusOK {
+		return "authorized"
+	}
+
+	var tokenError TokenError
+	if json.Unmarshal(body, &tokenError) == nil && tokenError.Error != "" {
+		switch tokenError.Error {
+		case "authorization_pending", "slow_down", "expired_token", "access_denied", "invalid_grant":
+			return tokenError.Error
+		}
+	}
+
+	switch status {
+	case http.StatusBadRequest:
+		return "authorization_pending"
+	case http.StatusTooManyRequests:
+		return "slow_down"
+	case http.StatusGone:
+		return "expired_token"
+	case http.StatusTeapot:
+		return "access_denied"
+	case http.StatusNotFound, http.StatusConflict:
+		return "invalid_grant"
+	}
+	return ""
+}
+
+// postJSON posts a JSON payload to a Trakt API path and returns the response
+// body and status. The request is aborted when ctx is done.
+func (o *OAuthManager) postJSON(ctx context.Context, path string, payload interface{}) ([]byte, int, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.apiURL(path), bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read response body: %w", err)
+	}
+	return body, resp.StatusCode, nil
+}
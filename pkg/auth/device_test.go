@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDeviceTestManager(serverURL string) *OAuthManager {
+	cfg := &config.Config{
+		Trakt: config.TraktConfig{
+			ClientID:     "test-client-id",
+			ClientSecret: "test-client-secret",
+			APIBaseURL:   serverURL,
+		},
+	}
+	return NewOAuthManager(cfg, logger.NewLogger())
+}
+
+// fastDevicePolling shortens the polling intervals for the test
+func fastDevicePolling(t *testing.T) {
+	slowDown, interval, expiry := deviceSlowDownStep, deviceDefaultInterval, deviceDefaultExpiry
+	deviceSlowDownStep, deviceDefaultInterval = time.Millisecond, time.Millisecond
+	t.Cleanup(func() {
+		deviceSlowDownStep, deviceDefaultInterval, deviceDefaultExpiry = slowDown, interval, expiry
+	})
+}
+
+func TestOAuthManager_RequestDeviceCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/oauth/device/code", r.URL.Path)
+		var payload map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, "test-client-id", payload["client_id"])
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"device_code":"dev123","user_code":"5055CC52","verification_url":"https://trakt.tv/activate","expires_in":600,"interval":5}`))
+	}))
+	defer server.Close()
+
+	code, err := newDeviceTestManager(server.URL).RequestDeviceCode()
+	require.NoError(t, err)
+	assert.Equal(t, "dev123", code.DeviceCode)
+	assert.Equal(t, "5055CC52", code.UserCode)
+	assert.Equal(t, "https://trakt.tv/activate", code.VerificationURL)
+	assert.Equal(t, 5, code.Interval)
+}
+
+func TestOAuthManager_PollDeviceToken(t *testing.T) {
+	fastDevicePolling(t)
+
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/oauth/device/token", r.URL.Path)
+		var payload map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, "dev123", payload["code"])
+		assert.Equal(t, "test-client-secret", payload["client_secret"])
+
+		switch atomic.AddInt32(&polls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusBadRequest)
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 3:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"slow_down"}`))
+		default:
+			w.Write([]byte(`{"access_token":"access","token_type":"bearer","expires_in":7776000,"refresh_token":"refresh","scope":"public"}`))
+		}
+	}))
+	defer server.Close()
+
+	token, err := newDeviceTestManager(server.URL).PollDeviceToken(context.Background(), &DeviceCode{DeviceCode: "dev123", ExpiresIn: 600})
+	require.NoError(t, err)
+	assert.Equal(t, "access", token.AccessToken)
+	assert.Equal(t, "refresh", token.RefreshToken)
+	assert.NotZero(t, token.CreatedAt)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&polls))
+}
+
+func TestOAuthManager_PollDeviceToken_Failures(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   error
+	}{
+		{"expired", http.StatusGone, "", ErrDeviceCodeExpired},
+		{"denied", http.StatusTeapot, "", ErrDeviceCodeDenied},
+		{"already used", http.StatusConflict, "", ErrDeviceCodeInvalid},
+		{"unknown code", http.StatusNotFound, "", ErrDeviceCodeInvalid},
+		{"standard expired error", http.StatusBadRequest, `{"error":"expired_token"}`, ErrDeviceCodeExpired},
+	}
+
+	fastDevicePolling(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := newDeviceTestManager(server.URL).PollDeviceToken(context.Background(), &DeviceCode{DeviceCode: "dev123"})
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestOAuthManager_PollDeviceToken_Cancelled(t *testing.T) {
+	fastDevicePolling(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := newDeviceTestManager(server.URL).PollDeviceToken(ctx, &DeviceCode{DeviceCode: "dev123"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestOAuthManager_PollDeviceToken_CancelledInFlight(t *testing.T) {
+	fastDevicePolling(t)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Hang until the test is over
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := newDeviceTestManager(server.URL).PollDeviceToken(ctx, &DeviceCode{DeviceCode: "dev123"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestOAuthManager_PollDeviceToken_Defaults(t *testing.T) {
+	fastDevicePolling(t)
+
+	// Without interval or lifetime, the code expires after the default lifetime
+	deviceDefaultExpiry = 50 * time.Millisecond
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	_, err := newDeviceTestManager(server.URL).PollDeviceToken(context.Background(), &DeviceCode{DeviceCode: "dev123"})
+	assert.ErrorIs(t, err, ErrDeviceCodeExpired)
+
+	// Polls failing on network errors give up
+	server.Close()
+	_, err = newDeviceTestManager(server.URL).PollDeviceToken(context.Background(), &DeviceCode{DeviceCode: "dev123", ExpiresIn: 600})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed 5 times in a row")
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
@@ -8,15 +9,17 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security"
 )
 
 const (
-	traktAuthURL  = "https://trakt.tv/oauth/authorize"
-	traktTokenURL = "https://api.trakt.tv/oauth/token"
+	traktAuthURL      = "https://trakt.tv/oauth/authorize"
+	defaultAPIBaseURL = "https://api.trakt.tv"
 )
 
 type OAuthManager struct {
@@ -87,7 +90,7 @@ func (o *OAuthManager) ExchangeCodeForToken(code, state, expectedState string) (
 		"grant_type":    {"authorization_code"},
 	}
 
-	resp, err := o.client.PostForm(traktTokenURL, data)
+	resp, err := o.client.PostForm(o.apiURL("/oauth/token"), data)
 	if err != nil {
 		o.logger.Error("oauth.exchange_request_failed", map[string]interface{}{
 			"error": err.Error(),
@@ -131,6 +134,11 @@ func (o *OAuthManager) ExchangeCodeForToken(code, state, expectedState string) (
 }
 
 func (o *OAuthManager) RefreshToken(refreshToken string) (*TokenResponse, error) {
+	return o.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext is like RefreshToken but aborts the request when ctx is done
+func (o *OAuthManager) RefreshTokenContext(ctx context.Context, refreshToken string) (*TokenResponse, error) {
 	o.logger.Info("oauth.refreshing_token", nil)
 
 	data := url.Values{
@@ -140,7 +148,13 @@ func (o *OAuthManager) RefreshToken(refreshToken string) (*TokenResponse, error)
 		"grant_type":    {"refresh_token"},
 	}
 
-	resp, err := o.client.PostForm(traktTokenURL, data)
+	req, err := http.NewRequestWithContext(ctx, "POST", o.apiURL("/oauth/token"), strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refresh request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := o.client.Do(req)
 	if err != nil {
 		o.logger.Error("oauth.refresh_request_failed", map[string]interface{}{
 			"error": err.Error(),
@@ -184,7 +198,7 @@ func (o *OAuthManager) RefreshToken(refreshToken string) (*TokenResponse, error)
 }
 
 func (o *OAuthManager) ValidateToken(accessToken string) error {
-	req, err := http.NewRequest("GET", "https://api.trakt.tv/users/settings", nil)
+	req, err := http.NewRequest("GET", o.apiURL("/users/settings"), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create validation request: %w", err)
 	}
@@ -211,6 +225,33 @@ func (o *OAuthManager) ValidateToken(accessToken string) error {
 	return nil
 }
 
+// RevokeToken revokes an access token at Trakt, together with the refresh
+// token issued with it
+func (o *OAuthManager) RevokeToken(accessToken string) error {
+	body, status, err := o.postJSON(context.Background(), "/oauth/revoke", map[string]string{
+		"token":         accessToken,
+		"client_id":     o.config.Trakt.ClientID,
+		"client_secret": o.config.Trakt.ClientSecret,
+	})
+	if err != nil {
+		o.logger.Error("oauth.revoke_request_failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return fmt.Errorf("token revocation request failed: %w", err)
+	}
+
+	if status != http.StatusOK {
+		var tokenError TokenError
+		if err := json.Unmarshal(body, &tokenError); err != nil || tokenError.Error == "" {
+			return fmt.Errorf("token revocation failed with status %d", status)
+		}
+		return fmt.Errorf("token revocation failed: %s - %s", tokenError.Error, tokenError.ErrorDescription)
+	}
+
+	o.logger.Info("oauth.token_revoked", nil)
+	return nil
+}
+
 func (o *OAuthManager) IsTokenExpired(token *TokenResponse) bool {
 	if token.ExpiresIn == 0 {
 		return false
@@ -229,6 +270,15 @@ func (o *OAuthManager) GetTokenExpiryTime(token *TokenResponse) time.Time {
 	return time.Unix(token.CreatedAt, 0).Add(time.Duration(token.ExpiresIn) * time.Second)
 }
 
+// apiURL joins path to the configured Trakt API base URL
+func (o *OAuthManager) apiURL(path string) string {
+	baseURL := o.config.Trakt.APIBaseURL
+	if baseURL == "" {
+		baseURL = defaultAPIBaseURL
+	}
+	return strings.TrimSuffix(baseURL, "/") + path
+}
+
 func (o *OAuthManager) generateState() (string, error) {
 	b := make([]byte, 32)
 	if _, err := rand.Read(b); err != nil {
@@ -249,6 +299,15 @@ func (o *OAuthManager) StartLocalCallbackServer() (string, chan string, chan err
 	server := &http.Server{
 		Addr: fmt.Sprintf(":%d", port),
 	}
+	
+	// Answer over HTTPS like the web server when TLS is configured
+	if o.config.Security.TLS.Enabled() {
+		serverTLS, err := security.NewServerTLS(o.config.Security.TLS)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("failed to set up TLS: %w", err)
+		}
+		server.TLSConfig = serverTLS.Config
+	}
 
 	http.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
 		code := r.URL.Query().Get("code")
@@ -310,12 +369,18 @@ func (o *OAuthManager) StartLocalCallbackServer() (string, chan string, chan err
 	})
 
 	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if server.TLSConfig != nil {
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			errChan <- fmt.Errorf("callback server error: %w", err)
 		}
 	}()
 
-	callbackURL := fmt.Sprintf("http://localhost:%d/callback", port)
+	callbackURL := fmt.Sprintf("%s://localhost:%d/callback", o.config.Security.TLS.Scheme(), port)
 	o.logger.Info("oauth.callback_server_started", map[string]interface{}{
 		"callback_url": callbackURL,
 		"port":         port,
@@ -0,0 +1,285 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring/alerts"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/retry/backoff"
+)
+
+const (
+	refresherSource        = "token_refresher"
+	defaultRefreshInterval = 15 * time.Minute
+	// rotationReminderInterval limits rotation reminders to one a day
+	rotationReminderInterval = 24 * time.Hour
+	// tokenAlertInterval limits repeated token expiring and refresh failure
+	// alerts to one a day for each level
+	tokenAlertInterval = 24 * time.Hour
+)
+
+// TokenListener is notified whenever the background refresher changes or
+// fails to change the stored token. realtime.StatusBroadcaster implements it.
+type TokenListener interface {
+	TokenUpdated()
+}
+
+// Refresher renews the stored token in the background well before it
+// expires, instead of waiting for the next API request to find it expired
+type Refresher struct {
+	tokenManager  *TokenManager
+	logger        logger.Logger
+	alertManager  *alerts.AlertManager
+	listeners     []TokenListener
+	refreshBefore time.Duration
+	warnBefore    time.Duration
+	maxAge        time.Duration
+	remindedAt    time.Time
+	alertedAt     map[string]time.Time
+	interval      time.Duration
+	backoff       *backoff.ExponentialBackoff
+	mutex         sync.Mutex
+	cancel        context.CancelFunc
+	done          chan struct{}
+}
+
+// NewRefresher creates a background refresher for the token manager using
+// the refresh_before, expiry_warning_days and credential_max_age settings
+// of the [auth] section
+func NewRefresher(tm *TokenManager, cfg *config.Config, log logger.Logger) *Refresher {
+	refreshBefore := cfg.Auth.RefreshBefore
+	if refreshBefore <= 0 {
+		refreshBefore = 24 * time.Hour
+	}
+
+	return &Refresher{
+		tokenManager:  tm,
+		logger:        log,
+		refreshBefore: refreshBefore,
+		warnBefore:    time.Duration(cfg.Auth.ExpiryWarningDays) * 24 * time.Hour,
+		maxAge:        cfg.Auth.CredentialMaxAge,
+		interval:      defaultRefreshInterval,
+		backoff:       backoff.NewExponentialBackoff(30*time.Second, 10*time.Minute, 2.0, true, 5),
+	}
+}
+
+// SetAlertManager sets the alert manager used to report refresh failures
+// and tokens about to expire
+func (r *Refresher) SetAlertManager(am *alerts.AlertManager) {
+	r.alertManager = am
+}
+
+// AddListener registers a listener notified after each token change
+func (r *Refresher) AddListener(l TokenListener) {
+	r.listeners = append(r.listeners, l)
+}
+
+// Start checks the token immediately and then periodically until Stop is
+// called or ctx is cancelled
+func (r *Refresher) Start(ctx context.Context) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	r.cancel = cancel
+	r.done = make(chan struct{})
+
+	r.logger.Info("token.refresher_started", map[string]interface{}{
+		"refresh_before": r.refreshBefore.String(),
+		"interval":       r.interval.String(),
+	})
+
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			if err := r.Check(ctx); err != nil && ctx.Err() == nil {
+				r.logger.Error("token.background_refresh_failed", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops the background refresher and waits for it to exit
+func (r *Refresher) Stop() {
+	r.mutex.Lock()
+	cancel, done := r.cancel, r.done
+	r.cancel = nil
+	r.mutex.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+	r.logger.Info("token.refresher_stopped", nil)
+}
+
+// Check runs a single refresh pass: the token is renewed when it expires
+// within refreshBefore, and an alert is raised when the renewal fails,
+// when a token without refresh token is about to expire or when the
+// credentials are due for rotation
+func (r *Refresher) Check(ctx context.Context) error {
+	r.checkCredentialAge(ctx)
+
+	status, err := r.tokenManager.GetTokenStatus()
+	if err != nil {
+		return fmt.Errorf("failed to get token status: %w", err)
+	}
+
+	// Legacy tokens without expiry never need refreshing
+	if !status.HasToken || status.ExpiresAt.IsZero() {
+		return nil
+	}
+
+	remaining := time.Until(status.ExpiresAt)
+
+	if !status.HasRefreshToken {
+		if r.warnBefore > 0 && remaining <= r.warnBefore {
+			level := monitoring.AlertLevelWarning
+			if remaining <= 0 {
+				level = monitoring.AlertLevelCritical
+			}
+			r.sendThrottledAlert(ctx, alerts.EventTokenExpiring, level, "Trakt token expiring",
+				fmt.Sprintf("The Trakt access token expires on %s and has no refresh token. Run the 'auth' command to re-authenticate.",
+					status.ExpiresAt.Format("2006-01-02 15:04")),
+				map[string]interface{}{"expires_at": status.ExpiresAt, "expires_in": remaining.Round(time.Minute).String()})
+		}
+		return nil
+	}
+
+	if remaining > r.refreshBefore {
+		r.alertedAt = nil
+		return nil
+	}
+
+	if err := r.refreshWithBackoff(ctx); err != nil {
+		r.sendThrottledAlert(ctx, alerts.EventTokenRefreshFailed, monitoring.AlertLevelError, "Trakt token refresh failed",
+			fmt.Sprintf("The Trakt access token could not be refreshed and expires on %s: %s. Run the 'auth' command if the refresh token was revoked.",
+				status.ExpiresAt.Format("2006-01-02 15:04"), err),
+			map[string]interface{}{"expires_at": status.ExpiresAt, "error": err.Error()})
+		r.notify()
+		return err
+	}
+
+	r.alertedAt = nil
+	r.notify()
+	return nil
+}
+
+// checkCredentialAge reminds, at most once a day, to rotate credentials
+// older than maxAge
+func (r *Refresher) checkCredentialAge(ctx context.Context) {
+	if r.maxAge <= 0 || time.Since(r.remindedAt) < rotationReminderInterval {
+		return
+	}
+
+	rotatedAt, err := r.tokenManager.CredentialsRotatedAt()
+	if err != nil {
+		r.logger.Warn("token.rotation_check_failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	age := time.Since(rotatedAt)
+	if age < r.maxAge {
+		return
+	}
+
+	r.remindedAt = time.Now()
+	r.sendAlert(ctx, alerts.EventRotationDue, monitoring.AlertLevelWarning, "Trakt credentials rotation due",
+		fmt.Sprintf("The Trakt client secret and token were last rotated on %s, %d days ago. Run the 'credentials rotate' command to replace them.",
+			rotatedAt.Format("2006-01-02"), int(age.Hours()/24)),
+		map[string]interface{}{"rotated_at": rotatedAt, "age": age.Round(time.Hour).String(), "max_age": r.maxAge.String()})
+}
+
+// refreshWithBackoff refreshes the token, retrying transient failures
+func (r *Refresher) refreshWithBackoff(ctx context.Context) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = r.tokenManager.RefreshTokenContext(ctx); err == nil {
+			r.logger.Info("token.background_refresh_success", map[string]interface{}{
+				"attempts": attempt + 1,
+			})
+			return nil
+		}
+
+		if !r.backoff.ShouldRetry(attempt) {
+			return err
+		}
+
+		delay := r.backoff.CalculateDelay(attempt)
+		r.logger.Warn("token.background_refresh_retry", map[string]interface{}{
+			"attempt": attempt + 1,
+			"delay":   delay.String(),
+			"error":   err.Error(),
+		})
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (r *Refresher) notify() {
+	for _, l := range r.listeners {
+		l.TokenUpdated()
+	}
+}
+
+// sendThrottledAlert sends an alert unless the same event was sent at the
+// same level within tokenAlertInterval, so that a token stuck in the
+// warning window does not raise an alert on every pass
+func (r *Refresher) sendThrottledAlert(ctx context.Context, event string, level monitoring.AlertLevel, title, message string, metadata map[string]interface{}) {
+	key := event + "/" + string(level)
+	if time.Since(r.alertedAt[key]) < tokenAlertInterval {
+		return
+	}
+	if r.alertedAt == nil {
+		r.alertedAt = make(map[string]time.Time)
+	}
+	r.alertedAt[key] = time.Now()
+	r.sendAlert(ctx, event, level, title, message, metadata)
+}
+
+func (r *Refresher) sendAlert(ctx context.Context, event string, level monitoring.AlertLevel, title, message string, metadata map[string]interface{}) {
+	r.logger.Warn("token.alert", map[string]interface{}{
+		"level":   string(level),
+		"message": message,
+	})
+
+	if r.alertManager == nil {
+		return
+	}
+
+	alert := r.alertManager.CreateAlert(level, title, message, refresherSource, metadata)
+	alert.Event = event
+	if err := r.alertManager.SendAlert(ctx, alert); err != nil {
+		r.logger.Error("token.alert_failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring/alerts"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/retry/backoff"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/keyring"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingChannel struct {
+	mutex  sync.Mutex
+	alerts []monitoring.Alert
+}
+
+func (c *recordingChannel) SendAlert(ctx context.Context, alert monitoring.Alert) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.alerts = append(c.alerts, alert)
+	return nil
+}
+
+func (c *recordingChannel) Name() string    { return "recording" }
+func (c *recordingChannel) IsEnabled() bool { return true }
+
+type countingListener struct {
+	updates int32
+}
+
+func (l *countingListener) TokenUpdated() {
+	atomic.AddInt32(&l.updates, 1)
+}
+
+func newTestRefresher(t *testing.T, serverURL string, token *TokenResponse) (*Refresher, *TokenManager, *recordingChannel, *countingListener) {
+	t.Helper()
+
+	cfg := createTestConfig()
+	cfg.Trakt.APIBaseURL = serverURL
+	cfg.Auth.RefreshBefore = 24 * time.Hour
+	cfg.Auth.ExpiryWarningDays = 3
+
+	keyringMgr, err := keyring.NewManager(keyring.MemoryBackend)
+	require.NoError(t, err)
+
+	log := logger.NewLogger()
+	tm := NewTokenManager(cfg, log, keyringMgr)
+	require.NoError(t, tm.StoreToken(token))
+
+	channel := &recordingChannel{}
+	alertManager := alerts.NewAlertManager(logrus.New(), monitoring.AlertsConfig{})
+	alertManager.AddChannel(channel)
+
+	listener := &countingListener{}
+	r := NewRefresher(tm, cfg, log)
+	r.SetAlertManager(alertManager)
+	r.AddListener(listener)
+	r.backoff = backoff.NewExponentialBackoff(time.Millisecond, time.Millisecond, 1.0, false, 2)
+
+	return r, tm, channel, listener
+}
+
+func expiringToken(in time.Duration, refreshToken string) *TokenResponse {
+	return &TokenResponse{
+		AccessToken:  "old_access_token",
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(in.Seconds()),
+		CreatedAt:    time.Now().Unix(),
+	}
+}
+
+func TestRefresher_RefreshesBeforeExpiry(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/oauth/token", r.URL.Path)
+		// The first attempt fails to exercise the backoff
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"access_token":"new_access_token","refresh_token":"new_refresh_token","token_type":"Bearer","expires_in":7776000}`))
+	}))
+	defer server.Close()
+
+	r, tm, channel, listener := newTestRefresher(t, server.URL, expiringToken(2*time.Hour, "refresh_token"))
+
+	require.NoError(t, r.Check(context.Background()))
+
+	accessToken, err := tm.GetValidAccessToken()
+	require.NoError(t, err)
+	assert.Equal(t, "new_access_token", accessToken)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&listener.updates))
+	assert.Empty(t, channel.alerts)
+}
+
+func TestRefresher_SkipsFreshToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected refresh request")
+	}))
+	defer server.Close()
+
+	r, _, channel, listener := newTestRefresher(t, server.URL, expiringToken(30*24*time.Hour, "refresh_token"))
+
+	require.NoError(t, r.Check(context.Background()))
+	assert.Zero(t, atomic.LoadInt32(&listener.updates))
+	assert.Empty(t, channel.alerts)
+}
+
+func TestRefresher_AlertsWhenRefreshFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"invalid_grant","error_description":"The refresh token is revoked"}`))
+	}))
+	defer server.Close()
+
+	r, _, channel, listener := newTestRefresher(t, server.URL, expiringToken(2*time.Hour, "revoked_token"))
+
+	assert.Error(t, r.Check(context.Background()))
+	require.Len(t, channel.alerts, 1)
+	assert.Equal(t, monitoring.AlertLevelError, channel.alerts[0].Level)
+	assert.Equal(t, refresherSource, channel.alerts[0].Source)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&listener.updates))
+}
+
+func TestRefresher_AlertsWithoutRefreshToken(t *testing.T) {
+	r, _, channel, _ := newTestRefresher(t, "http://127.0.0.1:0", expiringToken(48*time.Hour, ""))
+
+	require.NoError(t, r.Check(context.Background()))
+	require.Len(t, channel.alerts, 1)
+	assert.Equal(t, monitoring.AlertLevelWarning, channel.alerts[0].Level)
+}
+
+func TestRefresher_ThrottlesRepeatedAlerts(t *testing.T) {
+	r, _, channel, _ := newTestRefresher(t, "http://127.0.0.1:0", expiringToken(48*time.Hour, ""))
+
+	require.NoError(t, r.Check(context.Background()))
+	require.NoError(t, r.Check(context.Background()))
+	assert.Len(t, channel.alerts, 1)
+}
+
+func TestRefresher_ResetsAlertsAfterRefresh(t *testing.T) {
+	var fail int32 = 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&fail) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"access_token":"new_access_token","refresh_token":"new_refresh_token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	r, _, channel, _ := newTestRefresher(t, server.URL, expiringToken(2*time.Hour, "refresh_token"))
+
+	assert.Error(t, r.Check(context.Background()))
+	assert.Error(t, r.Check(context.Background()))
+	require.Len(t, channel.alerts, 1)
+
+	// A successful refresh re-arms the failure alert
+	atomic.StoreInt32(&fail, 0)
+	require.NoError(t, r.Check(context.Background()))
+	atomic.StoreInt32(&fail, 1)
+	assert.Error(t, r.Check(context.Background()))
+	assert.Len(t, channel.alerts, 2)
+}
+
+func TestRefresher_StartStop(t *testing.T) {
+	r, _, _, _ := newTestRefresher(t, "http://127.0.0.1:0", expiringToken(30*24*time.Hour, "refresh_token"))
+
+	r.Start(context.Background())
+	r.Start(context.Background())
+	r.Stop()
+	r.Stop()
+}
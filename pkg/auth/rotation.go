@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/keyring"
+)
+
+// credentialsRotatedKey holds the RFC 3339 time of the last credentials rotation
+const credentialsRotatedKey = "credentials_rotated_at"
+
+// RevokeToken revokes the stored access token at Trakt and clears it from
+// the keyring. It does nothing when no token is stored.
+func (tm *TokenManager) RevokeToken() error {
+	tm.mutex.RLock()
+	token, err := tm.getCurrentToken()
+	tm.mutex.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to get current token: %w", err)
+	}
+	if token == nil {
+		return nil
+	}
+
+	if err := tm.oauthManager.RevokeToken(token.AccessToken); err != nil {
+		return err
+	}
+
+	return tm.ClearToken()
+}
+
+// CredentialsRotatedAt returns when the credentials were last rotated. The
+// first call on an installation that never rotated them records the
+// current time, so that the age of existing credentials counts from there.
+func (tm *TokenManager) CredentialsRotatedAt() (time.Time, error) {
+	value, err := tm.keyringMgr.Retrieve(credentialsRotatedKey)
+	if err == keyring.ErrCredentialNotFound {
+		now := time.Now().UTC().Truncate(time.Second)
+		if err := tm.keyringMgr.Store(credentialsRotatedKey, now.Format(time.RFC3339)); err != nil {
+			// Read-only backends such as env cannot keep the baseline
+			tm.logger.Debug("token.rotation_baseline_not_stored", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		return now, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get rotation time: %w", err)
+	}
+
+	rotatedAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid rotation time %q: %w", value, err)
+	}
+	return rotatedAt, nil
+}
+
+// MarkCredentialsRotated records a completed credentials rotation
+func (tm *TokenManager) MarkCredentialsRotated(at time.Time) error {
+	if err := tm.keyringMgr.Store(credentialsRotatedKey, at.UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("failed to store rotation time: %w", err)
+	}
+	tm.logger.Info("token.credentials_rotated", map[string]interface{}{
+		"rotated_at": at,
+	})
+	return nil
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring/alerts"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/keyring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenManager_RevokeToken(t *testing.T) {
+	var revoked map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/oauth/revoke", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&revoked))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := createTestConfig()
+	cfg.Trakt.APIBaseURL = server.URL
+	keyringMgr, err := keyring.NewManager(keyring.MemoryBackend)
+	require.NoError(t, err)
+	tm := NewTokenManager(cfg, logger.NewLogger(), keyringMgr)
+
+	// Nothing to revoke without token
+	require.NoError(t, tm.RevokeToken())
+	assert.Nil(t, revoked)
+
+	require.NoError(t, tm.StoreToken(expiringToken(24*time.Hour, "refresh_token")))
+	require.NoError(t, tm.RevokeToken())
+	assert.Equal(t, map[string]string{
+		"token":         "old_access_token",
+		"client_id":     "test_client_id",
+		"client_secret": "test_client_secret",
+	}, revoked)
+
+	status, err := tm.GetTokenStatus()
+	require.NoError(t, err)
+	assert.False(t, status.HasToken)
+}
+
+func TestTokenManager_RevokeTokenFailureKeepsToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"invalid_client","error_description":"Client authentication failed"}`))
+	}))
+	defer server.Close()
+
+	cfg := createTestConfig()
+	cfg.Trakt.APIBaseURL = server.URL
+	keyringMgr, err := keyring.NewManager(keyring.MemoryBackend)
+	require.NoError(t, err)
+	tm := NewTokenManager(cfg, logger.NewLogger(), keyringMgr)
+	require.NoError(t, tm.StoreToken(expiringToken(24*time.Hour, "refresh_token")))
+
+	err = tm.RevokeToken()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid_client")
+
+	status, err := tm.GetTokenStatus()
+	require.NoError(t, err)
+	assert.True(t, status.HasToken)
+}
+
+func TestTokenManager_CredentialsRotatedAt(t *testing.T) {
+	keyringMgr, err := keyring.NewManager(keyring.MemoryBackend)
+	require.NoError(t, err)
+	tm := NewTokenManager(createTestConfig(), logger.NewLogger(), keyringMgr)
+
+	// The first check records a baseline
+	baseline, err := tm.CredentialsRotatedAt()
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), baseline, 2*time.Second)
+	again, err := tm.CredentialsRotatedAt()
+	require.NoError(t, err)
+	assert.True(t, baseline.Equal(again))
+
+	rotatedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, tm.MarkCredentialsRotated(rotatedAt))
+	got, err := tm.CredentialsRotatedAt()
+	require.NoError(t, err)
+	assert.True(t, rotatedAt.Equal(got))
+
+	// Clearing the token keeps the rotation time
+	require.NoError(t, tm.ClearToken())
+	got, err = tm.CredentialsRotatedAt()
+	require.NoError(t, err)
+	assert.True(t, rotatedAt.Equal(got))
+}
+
+func TestRefresher_RemindsRotation(t *testing.T) {
+	r, tm, channel, _ := newTestRefresher(t, "http://127.0.0.1:0", expiringToken(30*24*time.Hour, "refresh_token"))
+	r.maxAge = 90 * 24 * time.Hour
+
+	require.NoError(t, tm.MarkCredentialsRotated(time.Now().Add(-10*24*time.Hour)))
+	require.NoError(t, r.Check(context.Background()))
+	assert.Empty(t, channel.alerts)
+
+	require.NoError(t, tm.MarkCredentialsRotated(time.Now().Add(-100*24*time.Hour)))
+	require.NoError(t, r.Check(context.Background()))
+	require.Len(t, channel.alerts, 1)
+	assert.Equal(t, monitoring.AlertLevelWarning, channel.alerts[0].Level)
+	assert.Equal(t, alerts.EventRotationDue, channel.alerts[0].Event)
+	assert.Contains(t, channel.alerts[0].Message, "100 days ago")
+
+	// Reminders are sent once a day
+	require.NoError(t, r.Check(context.Background()))
+	assert.Len(t, channel.alerts, 1)
+}
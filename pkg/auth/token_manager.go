@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"sync"
@@ -47,6 +48,12 @@ func NewTokenManager(cfg *config.Config, log logger.Logger, keyringMgr *keyring.
 }
 
 func (tm *TokenManager) GetValidAccessToken() (string, error) {
+	return tm.GetValidAccessTokenContext(context.Background())
+}
+
+// GetValidAccessTokenContext is like GetValidAccessToken but aborts a
+// needed token refresh when ctx is done
+func (tm *TokenManager) GetValidAccessTokenContext(ctx context.Context) (string, error) {
 	tm.mutex.Lock()
 	defer tm.mutex.Unlock()
 
@@ -79,7 +86,7 @@ func (tm *TokenManager) GetValidAccessToken() (string, error) {
 		return "", fmt.Errorf("token expired and no refresh token available, re-authentication required")
 	}
 
-	refreshedToken, err := tm.oauthManager.RefreshToken(token.RefreshToken)
+	refreshedToken, err := tm.oauthManager.RefreshTokenContext(ctx, token.RefreshToken)
 	if err != nil {
 		tm.logger.Error("token.refresh_failed", map[string]interface{}{
 			"error": err.Error(),
@@ -170,6 +177,11 @@ func (tm *TokenManager) GetTokenStatus() (*TokenStatus, error) {
 }
 
 func (tm *TokenManager) RefreshToken() error {
+	return tm.RefreshTokenContext(context.Background())
+}
+
+// RefreshTokenContext is like RefreshToken but aborts the request when ctx is done
+func (tm *TokenManager) RefreshTokenContext(ctx context.Context) error {
 	tm.mutex.Lock()
 	defer tm.mutex.Unlock()
 
@@ -186,7 +198,7 @@ func (tm *TokenManager) RefreshToken() error {
 		return fmt.Errorf("no refresh token available")
 	}
 
-	refreshedToken, err := tm.oauthManager.RefreshToken(token.RefreshToken)
+	refreshedToken, err := tm.oauthManager.RefreshTokenContext(ctx, token.RefreshToken)
 	if err != nil {
 		return fmt.Errorf("failed to refresh token: %w", err)
 	}
@@ -2,13 +2,19 @@ package config
 
 import (
 	"fmt"
+	"net/url"
+	"strings"
+	"time"
 
-	"github.com/BurntSushi/toml"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring/alerts"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring/tracing"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security"
 )
 
 // Config holds all configuration settings
 type Config struct {
+	Version   int             `toml:"version"`
 	Trakt     TraktConfig     `toml:"trakt"`
 	Letterboxd LetterboxdConfig `toml:"letterboxd"`
 	Export    ExportConfig    `toml:"export"`
@@ -16,6 +22,11 @@ type Config struct {
 	I18n      I18nConfig      `toml:"i18n"`
 	Security  security.Config `toml:"security"`
 	Auth      AuthConfig      `toml:"auth"`
+	Schedule  ScheduleConfig  `toml:"schedule"`
+	Alerts    monitoring.AlertsConfig `toml:"alerts"`
+	Client    ClientConfig    `toml:"client"`
+	Monitoring monitoring.MonitoringConfig `toml:"monitoring"`
+	Tracing   tracing.TracingConfig   `toml:"tracing"`
 }
 
 // TraktConfig holds Trakt.tv API configuration
@@ -44,12 +55,30 @@ type ExportConfig struct {
 	DateFormat  string `toml:"date_format"`
 	Timezone    string `toml:"timezone"`
 	HistoryMode string `toml:"history_mode"` // "aggregated" or "individual"
+	// SkipUnchanged skips datasets without Trakt activity since their last export
+	SkipUnchanged bool `toml:"skip_unchanged"`
+	// Signing signs the export files so their integrity can be verified
+	Signing SigningConfig `toml:"signing"`
+}
+
+// SigningConfig holds the Ed25519 signing of export files
+type SigningConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Mode is "files" for a detached .sig next to each export file, or
+	// "manifest" for a signed manifest of each export directory
+	Mode string `toml:"mode"`
+	// KeyName is the keyring entry holding the private key
+	KeyName string `toml:"key_name"`
 }
 
 // LoggingConfig holds logging settings
 type LoggingConfig struct {
 	Level string `toml:"level"`
 	File  string `toml:"file"`
+	// Privacy redacts titles, user IDs, tokens and IP addresses from logs
+	Privacy bool `toml:"privacy"`
+	// RawLogDownload lets /api/logs/download serve the log file unredacted
+	RawLogDownload bool `toml:"raw_log_download"`
 }
 
 // I18nConfig holds internationalization settings
@@ -65,27 +94,47 @@ type AuthConfig struct {
 	CallbackPort   int    `toml:"callback_port"`
 	UseOAuth       bool   `toml:"use_oauth"`
 	AutoRefresh    bool   `toml:"auto_refresh"`
+	// RefreshBefore is how long before expiry the server renews the token in the background
+	RefreshBefore time.Duration `toml:"refresh_before"`
+	// ExpiryWarningDays raises an alert this many days before a token without refresh token expires
+	ExpiryWarningDays int `toml:"expiry_warning_days"`
+	// CredentialMaxAge is how long the client secret and token may be used before a rotation reminder
+	CredentialMaxAge time.Duration `toml:"credential_max_age"`
 }
 
-// LoadConfig reads the config file and returns a Config struct
-func LoadConfig(path string) (*Config, error) {
-	var config Config
-	if _, err := toml.DecodeFile(path, &config); err != nil {
-		return nil, fmt.Errorf("failed to decode config file: %w", err)
-	}
-
-	// Set defaults before validation
-	config.SetDefaults()
+// ScheduleConfig holds the default export schedule used by the schedule and server commands
+type ScheduleConfig struct {
+	Cron       string `toml:"cron"`        // standard 5-field cron expression, empty disables
+	ExportType string `toml:"export_type"` // watched, collection, shows, ratings, watchlist, all
+	ExportMode string `toml:"export_mode"` // normal, initial, complete
+}
 
-	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
-	}
+// ClientConfig selects the capabilities of the Trakt API client used for exports
+type ClientConfig struct {
+	EnableCaching bool `toml:"enable_caching"`
+	EnableMetrics bool `toml:"enable_metrics"`
+	// WorkerPoolSize is the number of pages fetched concurrently, 1 uses the sequential client
+	WorkerPoolSize int           `toml:"worker_pool_size"`
+	CacheCapacity  int           `toml:"cache_capacity"`
+	CacheTTL       time.Duration `toml:"cache_ttl"`
+	RequestTimeout time.Duration `toml:"request_timeout"`
+	// PersistentCache keeps responses in CacheDir between runs and revalidates them with ETags
+	PersistentCache bool   `toml:"persistent_cache"`
+	CacheDir        string `toml:"cache_dir"`
+}
 
-	return &config, nil
+// LoadConfig reads the config file and returns a Config struct. Includes from
+// conf.d and ETL_ environment variables are layered on top of the file.
+func LoadConfig(path string) (*Config, error) {
+	return NewLoader(path).Load()
 }
 
 // Validate checks if the configuration is valid
 func (c *Config) Validate() error {
+	if c.Version > CurrentVersion {
+		return fmt.Errorf("config version %d is newer than the supported version %d", c.Version, CurrentVersion)
+	}
+
 	if err := c.Trakt.Validate(); err != nil {
 		return fmt.Errorf("trakt config: %w", err)
 	}
@@ -114,6 +163,18 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("auth config: %w", err)
 	}
 
+	if err := c.Client.Validate(); err != nil {
+		return fmt.Errorf("client config: %w", err)
+	}
+
+	if err := c.validateMonitoring(); err != nil {
+		return fmt.Errorf("monitoring config: %w", err)
+	}
+
+	if err := alerts.ValidateConfig(c.Alerts); err != nil {
+		return fmt.Errorf("alerts config: %w", err)
+	}
+
 	return nil
 }
 
@@ -151,6 +212,9 @@ func (c *ExportConfig) Validate() error {
 			return fmt.Errorf("invalid history_mode: %s (must be 'aggregated' or 'individual')", c.HistoryMode)
 		}
 	}
+	if c.Signing.Mode != "" && c.Signing.Mode != "files" && c.Signing.Mode != "manifest" {
+		return fmt.Errorf("invalid signing mode: %s (must be 'files' or 'manifest')", c.Signing.Mode)
+	}
 	// If timezone is empty, we'll use UTC as default, so no error needed
 	return nil
 }
@@ -195,10 +259,72 @@ func (c *AuthConfig) Validate() error {
 	if c.CallbackPort == 0 {
 		c.CallbackPort = 8080
 	}
+	if c.RefreshBefore < 0 {
+		return fmt.Errorf("refresh_before must not be negative")
+	}
+	if c.ExpiryWarningDays < 0 {
+		return fmt.Errorf("expiry_warning_days must not be negative")
+	}
+	if c.CredentialMaxAge < 0 {
+		return fmt.Errorf("credential_max_age must not be negative")
+	}
 	
 	return nil
 }
 
+// Validate checks if the Client configuration is valid
+func (c *ClientConfig) Validate() error {
+	if c.WorkerPoolSize < 0 {
+		return fmt.Errorf("worker_pool_size must not be negative")
+	}
+	if c.CacheCapacity < 0 {
+		return fmt.Errorf("cache_capacity must not be negative")
+	}
+	if c.CacheTTL < 0 {
+		return fmt.Errorf("cache_ttl must not be negative")
+	}
+	if c.RequestTimeout < 0 {
+		return fmt.Errorf("request_timeout must not be negative")
+	}
+	if c.PersistentCache && c.CacheDir == "" {
+		return fmt.Errorf("cache_dir is required when persistent_cache is enabled")
+	}
+	return nil
+}
+
+// validateMonitoring checks the [monitoring] and [tracing] sections
+func (c *Config) validateMonitoring() error {
+	m := c.Monitoring
+	if m.MetricsPort < 0 || m.MetricsPort > 65535 {
+		return fmt.Errorf("metrics_port must be between 0 and 65535")
+	}
+	if m.MetricsPath != "" && !strings.HasPrefix(m.MetricsPath, "/") {
+		return fmt.Errorf("metrics_path must start with /")
+	}
+	if m.Enabled && m.MetricsPort != 0 && m.MetricsPort == c.Auth.CallbackPort {
+		return fmt.Errorf("metrics_port must differ from auth.callback_port, use 0 to serve metrics on the web server")
+	}
+	if c.Tracing.SamplingRate < 0 || c.Tracing.SamplingRate > 1 {
+		return fmt.Errorf("tracing sampling_rate must be between 0 and 1")
+	}
+	if !tracing.IsValidExporter(c.Tracing.Exporter) {
+		return fmt.Errorf("invalid tracing exporter: %s", c.Tracing.Exporter)
+	}
+	if c.Tracing.Exporter == tracing.ExporterFile && c.Tracing.FilePath == "" {
+		return fmt.Errorf("tracing file_path is required by the file exporter")
+	}
+	if m.PushgatewayURL != "" {
+		u, err := url.Parse(m.PushgatewayURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("pushgateway_url must be an http(s) URL")
+		}
+	}
+	if m.TextfilePath != "" && !strings.HasSuffix(m.TextfilePath, ".prom") {
+		return fmt.Errorf("textfile_path must end with .prom to be read by node_exporter")
+	}
+	return nil
+}
+
 // SetDefaults sets default values for the configuration
 func (c *Config) SetDefaults() {
 	// Trakt defaults
@@ -245,6 +371,12 @@ func (c *Config) SetDefaults() {
 	if c.Export.HistoryMode == "" {
 		c.Export.HistoryMode = "aggregated"
 	}
+	if c.Export.Signing.Mode == "" {
+		c.Export.Signing.Mode = "files"
+	}
+	if c.Export.Signing.KeyName == "" {
+		c.Export.Signing.KeyName = "export_signing_key"
+	}
 
 	// Logging defaults
 	if c.Logging.Level == "" {
@@ -266,13 +398,169 @@ func (c *Config) SetDefaults() {
 	}
 
 	// Auth defaults
-	if c.Auth.RedirectURI == "" {
-		c.Auth.RedirectURI = "http://localhost:8080/callback"
-	}
 	if c.Auth.CallbackPort == 0 {
 		c.Auth.CallbackPort = 8080
 	}
+	if c.Auth.RedirectURI == "" {
+		c.Auth.RedirectURI = c.defaultRedirectURI()
+	}
+	if c.Auth.RefreshBefore == 0 {
+		c.Auth.RefreshBefore = 24 * time.Hour
+	}
+	if c.Auth.ExpiryWarningDays == 0 {
+		c.Auth.ExpiryWarningDays = 3
+	}
+	if c.Auth.CredentialMaxAge == 0 {
+		c.Auth.CredentialMaxAge = 90 * 24 * time.Hour
+	}
 	// OAuth is enabled by default
 	c.Auth.UseOAuth = true
 	c.Auth.AutoRefresh = true
-} 
\ No newline at end of file
+
+	// Client defaults
+	if c.Client.WorkerPoolSize == 0 {
+		c.Client.WorkerPoolSize = 4
+	}
+	if c.Client.CacheCapacity == 0 {
+		c.Client.CacheCapacity = 1000
+	}
+	if c.Client.CacheTTL == 0 {
+		c.Client.CacheTTL = time.Hour
+	}
+	if c.Client.RequestTimeout == 0 {
+		c.Client.RequestTimeout = 30 * time.Second
+	}
+	if c.Client.CacheDir == "" {
+		c.Client.CacheDir = "./cache"
+	}
+
+	// Monitoring defaults
+	if c.Monitoring.MetricsPath == "" {
+		c.Monitoring.MetricsPath = "/metrics"
+	}
+	if c.Monitoring.PushgatewayJob == "" {
+		c.Monitoring.PushgatewayJob = "export_trakt"
+	}
+	if c.Tracing.ServiceName == "" {
+		c.Tracing.ServiceName = "export-trakt-letterboxd"
+	}
+	if c.Tracing.Environment == "" {
+		c.Tracing.Environment = "production"
+	}
+	if c.Tracing.SamplingRate == 0 {
+		c.Tracing.SamplingRate = 0.1
+	}
+
+	// Audit log defaults
+	audit := security.DefaultSecurityConfig().Audit
+	if c.Security.Audit.MaxFileSizeMB == 0 {
+		c.Security.Audit.MaxFileSizeMB = audit.MaxFileSizeMB
+	}
+	if c.Security.Audit.MaxFiles == 0 {
+		c.Security.Audit.MaxFiles = audit.MaxFiles
+	}
+	if c.Security.Audit.CheckpointInterval == 0 {
+		c.Security.Audit.CheckpointInterval = audit.CheckpointInterval
+	}
+
+	// Web server rate limit defaults
+	webRateLimit := security.DefaultWebRateLimitConfig()
+	if c.Security.WebRateLimit.RequestsPerMinute == 0 {
+		c.Security.WebRateLimit.RequestsPerMinute = webRateLimit.RequestsPerMinute
+	}
+	if c.Security.WebRateLimit.BurstCapacity == 0 {
+		c.Security.WebRateLimit.BurstCapacity = webRateLimit.BurstCapacity
+	}
+	if c.Security.WebRateLimit.Routes == nil {
+		c.Security.WebRateLimit.Routes = webRateLimit.Routes
+	}
+	if c.Security.WebRateLimit.ExemptPaths == nil {
+		c.Security.WebRateLimit.ExemptPaths = webRateLimit.ExemptPaths
+	}
+	if c.Security.WebRateLimit.AuthPaths == nil {
+		c.Security.WebRateLimit.AuthPaths = webRateLimit.AuthPaths
+	}
+	if c.Security.WebRateLimit.MaxFailures == 0 {
+		c.Security.WebRateLimit.MaxFailures = webRateLimit.MaxFailures
+	}
+	if c.Security.WebRateLimit.FailureWindow == 0 {
+		c.Security.WebRateLimit.FailureWindow = webRateLimit.FailureWindow
+	}
+	if c.Security.WebRateLimit.BanDuration == 0 {
+		c.Security.WebRateLimit.BanDuration = webRateLimit.BanDuration
+	}
+
+	// TLS defaults
+	tlsConfig := security.DefaultTLSConfig()
+	if c.Security.TLS.Mode == "" {
+		c.Security.TLS.Mode = tlsConfig.Mode
+	}
+	if c.Security.TLS.SelfSignedDir == "" {
+		c.Security.TLS.SelfSignedDir = tlsConfig.SelfSignedDir
+	}
+	if c.Security.TLS.ACME.CacheDir == "" {
+		c.Security.TLS.ACME.CacheDir = tlsConfig.ACME.CacheDir
+	}
+	c.deriveRedirectURI()
+
+	// File keyring defaults
+	fileKeyring := security.DefaultFileKeyringConfig()
+	if c.Security.FileKeyring.Path == "" {
+		c.Security.FileKeyring.Path = fileKeyring.Path
+	}
+	if c.Security.FileKeyring.KeySource == "" {
+		c.Security.FileKeyring.KeySource = fileKeyring.KeySource
+	}
+	if c.Security.FileKeyring.KeyFile == "" {
+		c.Security.FileKeyring.KeyFile = fileKeyring.KeyFile
+	}
+	if c.Security.FileKeyring.PassphraseEnv == "" {
+		c.Security.FileKeyring.PassphraseEnv = fileKeyring.PassphraseEnv
+	}
+
+	// Secrets directory and Vault keyring defaults
+	if c.Security.DirKeyring.Path == "" {
+		c.Security.DirKeyring.Path = security.DefaultDirKeyringConfig().Path
+	}
+	vaultKeyring := security.DefaultVaultKeyringConfig()
+	if c.Security.VaultKeyring.TokenEnv == "" {
+		c.Security.VaultKeyring.TokenEnv = vaultKeyring.TokenEnv
+	}
+	if c.Security.VaultKeyring.Mount == "" {
+		c.Security.VaultKeyring.Mount = vaultKeyring.Mount
+	}
+	if c.Security.VaultKeyring.Path == "" {
+		c.Security.VaultKeyring.Path = vaultKeyring.Path
+	}
+	if c.Security.VaultKeyring.KVVersion == 0 {
+		c.Security.VaultKeyring.KVVersion = vaultKeyring.KVVersion
+	}
+	if c.Security.VaultKeyring.Timeout == 0 {
+		c.Security.VaultKeyring.Timeout = vaultKeyring.Timeout
+	}
+} 
+
+// defaultRedirectURI points at the first ACME domain when the web server gets
+// its certificates from an ACME CA, at localhost otherwise
+func (c *Config) defaultRedirectURI() string {
+	acmeConfig := c.Security.TLS.ACME
+	if strings.ToLower(c.Security.TLS.Mode) != security.TLSModeACME || len(acmeConfig.Domains) == 0 {
+		return "http://localhost:8080/callback"
+	}
+	if c.Auth.CallbackPort == 443 {
+		return fmt.Sprintf("https://%s/callback", acmeConfig.Domains[0])
+	}
+	return fmt.Sprintf("https://%s:%d/callback", acmeConfig.Domains[0], c.Auth.CallbackPort)
+}
+
+// deriveRedirectURI serves the OAuth callback over HTTPS when the web server
+// terminates TLS. An http redirect URI is switched to https, keeping its host
+// and port, since the HTTPS listener replaces the plain one on callback_port.
+func (c *Config) deriveRedirectURI() {
+	if !c.Security.TLS.Enabled() {
+		return
+	}
+	if strings.HasPrefix(c.Auth.RedirectURI, "http://") {
+		c.Auth.RedirectURI = "https://" + strings.TrimPrefix(c.Auth.RedirectURI, "http://")
+	}
+}
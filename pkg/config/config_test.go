@@ -121,7 +121,10 @@ output_format = "json"
 		},
 		{
 			name: "missing required fields",
+			// Current schema version, so no migration fills in the missing sections
 			configData: `
+version = 3
+
 [trakt]
 api_base_url = "https://api.trakt.tv"
 `,
@@ -249,6 +252,33 @@ func TestConfigValidation(t *testing.T) {
 			expectError: true,
 			errorMsg:    "i18n config: language is required",
 		},
+		{
+			name: "invalid signing mode",
+			config: Config{
+				Trakt: TraktConfig{
+					APIBaseURL: "https://api.trakt.tv",
+				},
+				Letterboxd: LetterboxdConfig{
+					ExportDir: "exports",
+				},
+				Export: ExportConfig{
+					Format:     "csv",
+					DateFormat: "2006-01-02",
+					Signing:    SigningConfig{Enabled: true, Mode: "bundle"},
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+				I18n: I18nConfig{
+					DefaultLanguage: "en",
+					Language:       "en",
+					LocalesDir:    "locales",
+				},
+				Security: security.DefaultSecurityConfig(),
+			},
+			expectError: true,
+			errorMsg:    "export config: invalid signing mode: bundle (must be 'files' or 'manifest')",
+		},
 		{
 			name: "valid config",
 			config: Config{
@@ -290,4 +320,75 @@ func TestConfigValidation(t *testing.T) {
 			}
 		})
 	}
-} 
\ No newline at end of file
+} 
+
+func TestMonitoringValidation(t *testing.T) {
+	newConfig := func() Config {
+		return Config{
+			Trakt:      TraktConfig{APIBaseURL: "https://api.trakt.tv"},
+			Letterboxd: LetterboxdConfig{ExportDir: "exports"},
+			Export:     ExportConfig{Format: "csv", DateFormat: "2006-01-02"},
+			Logging:    LoggingConfig{Level: "info"},
+			I18n:       I18nConfig{DefaultLanguage: "en", Language: "en", LocalesDir: "locales"},
+			Security:   security.DefaultSecurityConfig(),
+		}
+	}
+
+	cfg := newConfig()
+	cfg.Monitoring.PushgatewayURL = "http://pushgateway:9091"
+	cfg.Monitoring.TextfilePath = "/var/lib/node_exporter/export_trakt.prom"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	cfg = newConfig()
+	cfg.Monitoring.PushgatewayURL = "pushgateway:9091"
+	if err := cfg.Validate(); err == nil || err.Error() != "monitoring config: pushgateway_url must be an http(s) URL" {
+		t.Errorf("Expected pushgateway_url error, got %v", err)
+	}
+
+	cfg = newConfig()
+	cfg.Monitoring.TextfilePath = "/var/lib/node_exporter/export_trakt.txt"
+	if err := cfg.Validate(); err == nil || err.Error() != "monitoring config: textfile_path must end with .prom to be read by node_exporter" {
+		t.Errorf("Expected textfile_path error, got %v", err)
+	}
+
+	cfg = newConfig()
+	cfg.Tracing.Exporter = "zipkin"
+	if err := cfg.Validate(); err == nil || err.Error() != "monitoring config: invalid tracing exporter: zipkin" {
+		t.Errorf("Expected tracing exporter error, got %v", err)
+	}
+
+	cfg = newConfig()
+	cfg.Tracing.Exporter = "file"
+	if err := cfg.Validate(); err == nil || err.Error() != "monitoring config: tracing file_path is required by the file exporter" {
+		t.Errorf("Expected tracing file_path error, got %v", err)
+	}
+}
+
+func TestRedirectURIFollowsTLS(t *testing.T) {
+	tests := []struct {
+		name string
+		auth AuthConfig
+		tls  security.TLSConfig
+		want string
+	}{
+		{"plain HTTP", AuthConfig{RedirectURI: "http://192.168.1.24:8089/callback"}, security.TLSConfig{}, "http://192.168.1.24:8089/callback"},
+		{"self signed", AuthConfig{RedirectURI: "http://192.168.1.24:8089/callback"}, security.TLSConfig{Mode: security.TLSModeSelfSigned}, "https://192.168.1.24:8089/callback"},
+		{"already https", AuthConfig{RedirectURI: "https://trakt.example.com/callback"}, security.TLSConfig{Mode: security.TLSModeFiles}, "https://trakt.example.com/callback"},
+		{"acme default", AuthConfig{CallbackPort: 8443}, security.TLSConfig{Mode: security.TLSModeACME, ACME: security.ACMEConfig{Domains: []string{"trakt.example.com"}}}, "https://trakt.example.com:8443/callback"},
+		{"acme on 443", AuthConfig{CallbackPort: 443}, security.TLSConfig{Mode: security.TLSModeACME, ACME: security.ACMEConfig{Domains: []string{"trakt.example.com"}}}, "https://trakt.example.com/callback"},
+		{"default without TLS", AuthConfig{}, security.TLSConfig{}, "http://localhost:8080/callback"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Auth: tt.auth}
+			cfg.Security.TLS = tt.tls
+			cfg.SetDefaults()
+			if cfg.Auth.RedirectURI != tt.want {
+				t.Errorf("Expected redirect URI %s, got %s", tt.want, cfg.Auth.RedirectURI)
+			}
+		})
+	}
+}
@@ -0,0 +1,526 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/keyring"
+)
+
+const (
+	// DefaultEnvPrefix is the prefix of environment variables overriding config fields
+	DefaultEnvPrefix = "ETL_"
+	// DefaultIncludeDir is the include directory, relative to the base config file
+	DefaultIncludeDir = "conf.d"
+	// redactedValue replaces secret values when the configuration is displayed
+	redactedValue = "<redacted>"
+)
+
+// Layer identifies the configuration layer a value comes from
+type Layer string
+
+const (
+	// LayerDefault marks values set by SetDefaults or left at their zero value
+	LayerDefault Layer = "default"
+	// LayerFile marks values read from the base config file
+	LayerFile Layer = "file"
+	// LayerInclude marks values read from a conf.d include file
+	LayerInclude Layer = "include"
+	// LayerEnv marks values read from an ETL_ environment variable
+	LayerEnv Layer = "env"
+	// LayerFlag marks values set on the command line
+	LayerFlag Layer = "flag"
+)
+
+// Origin describes where a single configuration value comes from
+type Origin struct {
+	Layer  Layer
+	Source string // file path, environment variable or flag name
+	Secret bool   // value was resolved from a secret reference
+}
+
+// String returns a human readable description of the origin
+func (o Origin) String() string {
+	s := string(o.Layer)
+	if o.Source != "" {
+		s += ":" + o.Source
+	}
+	if o.Secret {
+		s += " (secret)"
+	}
+	return s
+}
+
+// SecretResolver resolves secret:// and file:// references found in config values
+type SecretResolver interface {
+	Resolve(ref string) (string, error)
+}
+
+// Entry is a single effective configuration value
+type Entry struct {
+	Key    string
+	Value  string // TOML formatted, redacted when sensitive
+	Origin Origin
+}
+
+// Loader builds a Config from layered sources: defaults, the base file,
+// conf.d includes, ETL_ environment variables and command line overrides.
+// Secret references are resolved once all layers have been merged.
+type Loader struct {
+	Path        string
+	IncludeDir  string
+	EnvPrefix   string
+	Overrides   map[string]string // dotted key (e.g. "export.timezone") to raw value
+	Resolver    SecretResolver
+	NewResolver func(*Config) (SecretResolver, error)
+
+	config    *Config
+	origins   map[string]Origin
+	files     []string
+	migration *MigrationResult
+}
+
+// NewLoader creates a loader for the given base config file
+func NewLoader(path string) *Loader {
+	return &Loader{
+		Path:       path,
+		IncludeDir: DefaultIncludeDir,
+		EnvPrefix:  DefaultEnvPrefix,
+		Overrides:  make(map[string]string),
+	}
+}
+
+// Load merges all configuration layers, resolves secrets and validates the result
+func (l *Loader) Load() (*Config, error) {
+	var cfg Config
+	l.origins = make(map[string]Origin)
+	l.files = nil
+
+	if err := l.decodeBaseFile(&cfg); err != nil {
+		return nil, err
+	}
+
+	includes, err := l.includeFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range includes {
+		if err := l.decodeFile(file, LayerInclude, &cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := l.applyEnv(&cfg); err != nil {
+		return nil, err
+	}
+
+	if err := l.applyOverrides(&cfg); err != nil {
+		return nil, err
+	}
+
+	// Set defaults before validation
+	cfg.SetDefaults()
+
+	if err := l.resolveSecrets(&cfg); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	l.config = &cfg
+	return &cfg, nil
+}
+
+// Files returns the config files that were read, in load order
+func (l *Loader) Files() []string {
+	return l.files
+}
+
+// Migration returns the in-memory schema migration applied to the base file
+func (l *Loader) Migration() *MigrationResult {
+	return l.migration
+}
+
+// Origin returns the origin of the value stored under the given dotted key
+func (l *Loader) Origin(key string) Origin {
+	if origin, ok := l.origins[key]; ok {
+		return origin
+	}
+	return Origin{Layer: LayerDefault}
+}
+
+// Effective returns every configuration value with its origin, secrets redacted
+func (l *Loader) Effective() []Entry {
+	if l.config == nil {
+		return nil
+	}
+
+	var entries []Entry
+	walkFields(reflect.ValueOf(l.config).Elem(), "", func(key string, field reflect.Value) {
+		origin := l.Origin(key)
+		value := formatValue(field)
+		if origin.Secret || (isSensitiveKey(key) && !field.IsZero()) {
+			value = strconv.Quote(redactedValue)
+		}
+		entries = append(entries, Entry{Key: key, Value: value, Origin: origin})
+	})
+	return entries
+}
+
+// WriteEffective writes the merged configuration as TOML, optionally
+// annotating each value with its origin
+func (l *Loader) WriteEffective(w io.Writer, withOrigins bool) error {
+	section := ""
+	for _, entry := range l.Effective() {
+		table, name := splitKey(entry.Key)
+		if table != section {
+			if section != "" || table != "" {
+				if _, err := fmt.Fprintf(w, "\n[%s]\n", table); err != nil {
+					return err
+				}
+			}
+			section = table
+		}
+
+		line := fmt.Sprintf("%s = %s", name, entry.Value)
+		if withOrigins {
+			line = fmt.Sprintf("%-50s # %s", line, entry.Origin)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeBaseFile decodes the base file, upgrading older schema versions in memory
+func (l *Loader) decodeBaseFile(cfg *Config) error {
+	doc := make(map[string]interface{})
+	if _, err := toml.DecodeFile(l.Path, &doc); err != nil {
+		return fmt.Errorf("failed to decode config file: %w", err)
+	}
+
+	migration, err := Migrate(doc)
+	if err != nil {
+		return fmt.Errorf("failed to migrate config file: %w", err)
+	}
+	l.migration = migration
+	if !migration.Changed() {
+		return l.decodeFile(l.Path, LayerFile, cfg)
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode migrated config: %w", err)
+	}
+	meta, err := toml.Decode(buf.String(), cfg)
+	if err != nil {
+		return fmt.Errorf("failed to decode migrated config: %w", err)
+	}
+	l.recordKeys(meta, LayerFile, l.Path)
+	return nil
+}
+
+// decodeFile decodes a TOML file on top of cfg and records the keys it defines
+func (l *Loader) decodeFile(path string, layer Layer, cfg *Config) error {
+	meta, err := toml.DecodeFile(path, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to decode config file %s: %w", path, err)
+	}
+	l.recordKeys(meta, layer, path)
+	return nil
+}
+
+// recordKeys records the origin of every value defined in a decoded file
+func (l *Loader) recordKeys(meta toml.MetaData, layer Layer, path string) {
+	for _, key := range meta.Keys() {
+		if meta.Type(key...) == "Hash" {
+			continue
+		}
+		l.origins[key.String()] = Origin{Layer: layer, Source: path}
+	}
+	l.files = append(l.files, path)
+}
+
+// includeFiles lists the *.toml files of the include directory in lexical order
+func (l *Loader) includeFiles() ([]string, error) {
+	if l.IncludeDir == "" {
+		return nil, nil
+	}
+
+	dir := l.IncludeDir
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(l.Path), dir)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.toml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list include directory %s: %w", dir, err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// legacyEnvVars are the variables of earlier versions still read as part of
+// the env layer, below the prefixed variables, in this order
+var legacyEnvVars = []struct {
+	name  string
+	key   string
+	value func(raw string) (string, bool)
+}{
+	{"EXPORT_VERBOSE", "logging.level", func(raw string) (string, bool) { return "debug", raw == "true" }},
+	{"LOG_LEVEL", "logging.level", func(raw string) (string, bool) { return raw, raw != "" }},
+}
+
+// applyEnv overrides every field that has a matching prefixed environment
+// variable, after the legacy variables
+func (l *Loader) applyEnv(cfg *Config) error {
+	if l.EnvPrefix == "" {
+		return nil
+	}
+
+	fields := make(map[string]reflect.Value)
+	walkFields(reflect.ValueOf(cfg).Elem(), "", func(key string, field reflect.Value) {
+		fields[key] = field
+	})
+	for _, legacy := range legacyEnvVars {
+		value, ok := legacy.value(os.Getenv(legacy.name))
+		if !ok {
+			continue
+		}
+		if err := setField(fields[legacy.key], value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", legacy.name, err)
+		}
+		l.origins[legacy.key] = Origin{Layer: LayerEnv, Source: legacy.name}
+	}
+
+	var firstErr error
+	walkFields(reflect.ValueOf(cfg).Elem(), "", func(key string, field reflect.Value) {
+		name := EnvName(l.EnvPrefix, key)
+		raw, ok := os.LookupEnv(name)
+		if !ok || firstErr != nil {
+			return
+		}
+		if err := setField(field, raw); err != nil {
+			firstErr = fmt.Errorf("invalid value for %s: %w", name, err)
+			return
+		}
+		l.origins[key] = Origin{Layer: LayerEnv, Source: name}
+	})
+	return firstErr
+}
+
+// applyOverrides applies command line overrides given as dotted keys
+func (l *Loader) applyOverrides(cfg *Config) error {
+	if len(l.Overrides) == 0 {
+		return nil
+	}
+
+	fields := make(map[string]reflect.Value)
+	walkFields(reflect.ValueOf(cfg).Elem(), "", func(key string, field reflect.Value) {
+		fields[key] = field
+	})
+
+	keys := make([]string, 0, len(l.Overrides))
+	for key := range l.Overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field, ok := fields[key]
+		if !ok {
+			return fmt.Errorf("unknown configuration key: %s", key)
+		}
+		if err := setField(field, l.Overrides[key]); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+		l.origins[key] = Origin{Layer: LayerFlag, Source: "--set " + key}
+	}
+	return nil
+}
+
+// resolveSecrets replaces secret references in string fields with their values
+func (l *Loader) resolveSecrets(cfg *Config) error {
+	resolver := l.Resolver
+	var firstErr error
+
+	walkFields(reflect.ValueOf(cfg).Elem(), "", func(key string, field reflect.Value) {
+		if firstErr != nil || field.Kind() != reflect.String || !keyring.IsReference(field.String()) {
+			return
+		}
+
+		if resolver == nil {
+			var err error
+			if resolver, err = l.newResolver(cfg); err != nil {
+				firstErr = fmt.Errorf("failed to create secret resolver for %s: %w", key, err)
+				return
+			}
+		}
+
+		value, err := resolver.Resolve(field.String())
+		if err != nil {
+			firstErr = fmt.Errorf("failed to resolve secret for %s: %w", key, err)
+			return
+		}
+		field.SetString(value)
+
+		origin := l.Origin(key)
+		origin.Secret = true
+		l.origins[key] = origin
+	})
+	return firstErr
+}
+
+// newResolver builds a resolver from the merged configuration
+func (l *Loader) newResolver(cfg *Config) (SecretResolver, error) {
+	if l.NewResolver != nil {
+		return l.NewResolver(cfg)
+	}
+	return keyring.NewManager(keyring.BackendName(cfg.Security.KeyringBackend))
+}
+
+// EnvName returns the environment variable overriding the given dotted key
+func EnvName(prefix, key string) string {
+	return prefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// walkFields calls fn for every leaf field carrying a toml tag, with its dotted key
+func walkFields(v reflect.Value, prefix string, fn func(key string, field reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := strings.Split(sf.Tag.Get("toml"), ",")[0]
+		if tag == "" || tag == "-" || !sf.IsExported() {
+			continue
+		}
+
+		key := tag
+		if prefix != "" {
+			key = prefix + "." + tag
+		}
+
+		field := v.Field(i)
+		switch {
+		case field.Kind() == reflect.Struct && field.Type() != reflect.TypeOf(time.Time{}):
+			walkFields(field, key, fn)
+		case field.Kind() == reflect.Map:
+			// Maps (e.g. per-service rate limits) can only be set from files
+			continue
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Struct:
+			// So can arrays of tables (e.g. alert routes)
+			continue
+		default:
+			fn(key, field)
+		}
+	}
+}
+
+// setField parses raw into the field according to its type
+func setField(field reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported list type %s", field.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// formatValue renders a field as a TOML value
+func formatValue(field reflect.Value) string {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		return strconv.Quote(time.Duration(field.Int()).String())
+	}
+	if field.Type() == reflect.TypeOf(os.FileMode(0)) {
+		return fmt.Sprintf("0o%o", field.Uint())
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		return strconv.Quote(field.String())
+	case reflect.Slice:
+		items := make([]string, field.Len())
+		for i := range items {
+			items[i] = formatValue(field.Index(i))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return fmt.Sprintf("%v", field.Interface())
+	}
+}
+
+// splitKey splits a dotted key into its table and field name
+func splitKey(key string) (string, string) {
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		return key[:i], key[i+1:]
+	}
+	return "", key
+}
+
+// isSensitiveKey reports whether a key holds a credential that must never be displayed
+func isSensitiveKey(key string) bool {
+	_, name := splitKey(key)
+	switch name {
+	case "client_secret", "access_token", "refresh_token", "password", "encryption_key":
+		return true
+	}
+	return strings.HasSuffix(name, "_secret") || strings.HasSuffix(name, "_password") || strings.HasSuffix(name, "_token")
+}
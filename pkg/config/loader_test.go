@@ -0,0 +1,202 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const baseConfigData = `
+[trakt]
+client_id = "file_client_id"
+api_base_url = "https://api.trakt.tv"
+
+[export]
+timezone = "Europe/Paris"
+
+[logging]
+level = "info"
+
+[security]
+keyring_backend = "env"
+
+[security.audit]
+log_level = "info"
+retention_days = 90
+output_format = "json"
+`
+
+func writeTestConfig(t *testing.T, dir, name, data string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+	return path
+}
+
+func TestLoader_Layering(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := writeTestConfig(t, tmpDir, "config.toml", baseConfigData)
+	writeTestConfig(t, tmpDir, "conf.d/10-export.toml", "[export]\ntimezone = \"Asia/Tokyo\"\nhistory_mode = \"individual\"\n")
+	writeTestConfig(t, tmpDir, "conf.d/20-logging.toml", "[logging]\nlevel = \"warn\"\n")
+
+	t.Setenv("ETL_LOGGING_LEVEL", "error")
+	t.Setenv("ETL_SECURITY_AUDIT_RETENTION_DAYS", "30")
+	t.Setenv("ETL_SECURITY_HTTPS_TIMEOUT", "45s")
+	t.Setenv("ETL_SECURITY_HTTPS_ALLOWED_HOSTS", "api.trakt.tv, trakt.tv")
+
+	loader := NewLoader(configPath)
+	loader.Overrides["export.history_mode"] = "aggregated"
+
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if cfg.Trakt.ClientID != "file_client_id" {
+		t.Errorf("Expected ClientID from file, got '%s'", cfg.Trakt.ClientID)
+	}
+	if cfg.Export.Timezone != "Asia/Tokyo" {
+		t.Errorf("Expected timezone from include, got '%s'", cfg.Export.Timezone)
+	}
+	if cfg.Logging.Level != "error" {
+		t.Errorf("Expected log level from env, got '%s'", cfg.Logging.Level)
+	}
+	if cfg.Export.HistoryMode != "aggregated" {
+		t.Errorf("Expected history mode from flag, got '%s'", cfg.Export.HistoryMode)
+	}
+	if cfg.Security.Audit.RetentionDays != 30 {
+		t.Errorf("Expected retention days 30, got %d", cfg.Security.Audit.RetentionDays)
+	}
+	if cfg.Security.HTTPS.Timeout != 45*time.Second {
+		t.Errorf("Expected HTTPS timeout 45s, got %s", cfg.Security.HTTPS.Timeout)
+	}
+	if len(cfg.Security.HTTPS.AllowedHosts) != 2 || cfg.Security.HTTPS.AllowedHosts[1] != "trakt.tv" {
+		t.Errorf("Expected two allowed hosts, got %v", cfg.Security.HTTPS.AllowedHosts)
+	}
+
+	origins := map[string]Layer{
+		"trakt.client_id":               LayerFile,
+		"export.timezone":               LayerInclude,
+		"logging.level":                 LayerEnv,
+		"export.history_mode":           LayerFlag,
+		"export.format":                 LayerDefault,
+		"security.audit.retention_days": LayerEnv,
+	}
+	for key, want := range origins {
+		if got := loader.Origin(key).Layer; got != want {
+			t.Errorf("Expected origin %s for %s, got %s", want, key, got)
+		}
+	}
+
+	if files := loader.Files(); len(files) != 3 {
+		t.Errorf("Expected 3 loaded files, got %v", files)
+	}
+}
+
+func TestLoader_LegacyEnv(t *testing.T) {
+	configPath := writeTestConfig(t, t.TempDir(), "config.toml", baseConfigData)
+
+	t.Setenv("EXPORT_VERBOSE", "true")
+	loader := NewLoader(configPath)
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Logging.Level != "debug" || loader.Origin("logging.level").Source != "EXPORT_VERBOSE" {
+		t.Errorf("Expected debug level from EXPORT_VERBOSE, got '%s' from %+v", cfg.Logging.Level, loader.Origin("logging.level"))
+	}
+
+	// LOG_LEVEL wins over EXPORT_VERBOSE, and the ETL_ variable over both
+	t.Setenv("LOG_LEVEL", "warn")
+	if cfg, err = NewLoader(configPath).Load(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Logging.Level != "warn" {
+		t.Errorf("Expected log level from LOG_LEVEL, got '%s'", cfg.Logging.Level)
+	}
+
+	t.Setenv("ETL_LOGGING_LEVEL", "error")
+	loader = NewLoader(configPath)
+	if cfg, err = loader.Load(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Logging.Level != "error" || loader.Origin("logging.level").Source != "ETL_LOGGING_LEVEL" {
+		t.Errorf("Expected log level from ETL_LOGGING_LEVEL, got '%s'", cfg.Logging.Level)
+	}
+}
+
+func TestLoader_InvalidValues(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := writeTestConfig(t, tmpDir, "config.toml", baseConfigData)
+
+	t.Run("invalid env value", func(t *testing.T) {
+		t.Setenv("ETL_SECURITY_AUDIT_RETENTION_DAYS", "ninety")
+		if _, err := NewLoader(configPath).Load(); err == nil || !strings.Contains(err.Error(), "ETL_SECURITY_AUDIT_RETENTION_DAYS") {
+			t.Errorf("Expected error naming the variable, got %v", err)
+		}
+	})
+
+	t.Run("unknown override key", func(t *testing.T) {
+		loader := NewLoader(configPath)
+		loader.Overrides["export.unknown"] = "value"
+		if _, err := loader.Load(); err == nil {
+			t.Error("Expected error for unknown key, got nil")
+		}
+	})
+}
+
+func TestLoader_SecretReferences(t *testing.T) {
+	tmpDir := t.TempDir()
+	secretPath := writeTestConfig(t, tmpDir, "secrets/trakt_secret", "s3cr3t\n")
+	configPath := writeTestConfig(t, tmpDir, "config.toml", baseConfigData)
+
+	t.Setenv("ETL_TRAKT_CLIENT_SECRET", "file://"+secretPath)
+	t.Setenv("ETL_TRAKT_ACCESS_TOKEN", "secret://keyring/access_token")
+	t.Setenv("TRAKT_ACCESS_TOKEN", "token-from-keyring")
+
+	loader := NewLoader(configPath)
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if cfg.Trakt.ClientSecret != "s3cr3t" {
+		t.Errorf("Expected secret from file, got '%s'", cfg.Trakt.ClientSecret)
+	}
+	if cfg.Trakt.AccessToken != "token-from-keyring" {
+		t.Errorf("Expected token from env keyring, got '%s'", cfg.Trakt.AccessToken)
+	}
+	if !loader.Origin("trakt.client_secret").Secret {
+		t.Error("Expected client_secret origin to be marked secret")
+	}
+
+	var buf bytes.Buffer
+	if err := loader.WriteEffective(&buf, true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	output := buf.String()
+	if strings.Contains(output, "s3cr3t") || strings.Contains(output, "token-from-keyring") {
+		t.Errorf("Effective config leaks secrets:\n%s", output)
+	}
+	if !strings.Contains(output, "[security.audit]") || !strings.Contains(output, "env:ETL_TRAKT_CLIENT_SECRET (secret)") {
+		t.Errorf("Effective config missing sections or origins:\n%s", output)
+	}
+}
+
+func TestLoader_UnresolvableSecret(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := writeTestConfig(t, tmpDir, "config.toml", baseConfigData)
+
+	t.Setenv("ETL_TRAKT_CLIENT_SECRET", "file://"+filepath.Join(tmpDir, "missing"))
+	if _, err := NewLoader(configPath).Load(); err == nil {
+		t.Error("Expected error for missing secret file, got nil")
+	}
+}
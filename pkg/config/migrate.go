@@ -0,0 +1,235 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// CurrentVersion is the config schema version written by this release
+const CurrentVersion = 3
+
+// Migration upgrades a raw config document from one schema version to the next
+type Migration struct {
+	From        int
+	Description string
+	// Apply mutates the document and returns a description of each change made
+	Apply func(doc map[string]interface{}) []string
+}
+
+// MigrationStep records the changes made by a single migration
+type MigrationStep struct {
+	From        int
+	To          int
+	Description string
+	Changes     []string
+}
+
+// MigrationResult describes a complete upgrade of a config document
+type MigrationResult struct {
+	FromVersion int
+	ToVersion   int
+	Steps       []MigrationStep
+	BackupPath  string
+}
+
+// Changed reports whether the document was upgraded
+func (r *MigrationResult) Changed() bool {
+	return r.FromVersion != r.ToVersion
+}
+
+var migrations = make(map[int]Migration)
+
+// RegisterMigration adds a migration to the registry. It panics if a
+// migration from the same version is already registered.
+func RegisterMigration(m Migration) {
+	if _, exists := migrations[m.From]; exists {
+		panic(fmt.Sprintf("config: migration from version %d already registered", m.From))
+	}
+	migrations[m.From] = m
+}
+
+func init() {
+	RegisterMigration(Migration{
+		From:        0,
+		Description: "add export.history_mode introduced with individual watch history",
+		Apply: func(doc map[string]interface{}) []string {
+			var changes []string
+			// "aggregated" is the behavior of releases without history modes
+			if setDefault(table(doc, "export"), "history_mode", "aggregated") {
+				changes = append(changes, `set export.history_mode = "aggregated"`)
+			}
+			return changes
+		},
+	})
+
+	RegisterMigration(Migration{
+		From:        1,
+		Description: "add the [auth] section for OAuth authentication",
+		Apply: func(doc map[string]interface{}) []string {
+			auth := table(doc, "auth")
+			defaults := []struct {
+				key   string
+				value interface{}
+			}{
+				{"use_oauth", true},
+				{"auto_refresh", true},
+				{"redirect_uri", "http://localhost:8080/callback"},
+				{"callback_port", int64(8080)},
+			}
+
+			var changes []string
+			for _, d := range defaults {
+				if setDefault(auth, d.key, d.value) {
+					changes = append(changes, fmt.Sprintf("set auth.%s = %#v", d.key, d.value))
+				}
+			}
+			return changes
+		},
+	})
+
+	RegisterMigration(Migration{
+		From:        2,
+		Description: "add the [security] section with credential encryption on and the optional features off, as before it existed",
+		Apply: func(doc map[string]interface{}) []string {
+			sec := table(doc, "security")
+			audit := table(sec, "audit")
+			defaults := []struct {
+				table map[string]interface{}
+				name  string
+				key   string
+				value interface{}
+			}{
+				{sec, "security", "encryption_enabled", true},
+				{sec, "security", "keyring_backend", "env"},
+				{sec, "security", "audit_logging", false},
+				{sec, "security", "rate_limit_enabled", false},
+				{sec, "security", "require_https", false},
+				{audit, "security.audit", "log_level", "info"},
+				{audit, "security.audit", "retention_days", int64(90)},
+				{audit, "security.audit", "include_sensitive", false},
+				{audit, "security.audit", "output_format", "json"},
+			}
+
+			var changes []string
+			for _, d := range defaults {
+				if setDefault(d.table, d.key, d.value) {
+					changes = append(changes, fmt.Sprintf("set %s.%s = %#v", d.name, d.key, d.value))
+				}
+			}
+			return changes
+		},
+	})
+}
+
+// Migrate upgrades a raw config document to CurrentVersion in place
+func Migrate(doc map[string]interface{}) (*MigrationResult, error) {
+	version, err := documentVersion(doc)
+	if err != nil {
+		return nil, err
+	}
+	if version > CurrentVersion {
+		return nil, fmt.Errorf("config version %d is newer than the supported version %d", version, CurrentVersion)
+	}
+
+	result := &MigrationResult{FromVersion: version, ToVersion: version}
+	for v := version; v < CurrentVersion; v++ {
+		m, ok := migrations[v]
+		if !ok {
+			return nil, fmt.Errorf("no migration registered from config version %d", v)
+		}
+
+		result.Steps = append(result.Steps, MigrationStep{
+			From:        v,
+			To:          v + 1,
+			Description: m.Description,
+			Changes:     m.Apply(doc),
+		})
+		result.ToVersion = v + 1
+	}
+
+	if result.Changed() {
+		doc["version"] = int64(result.ToVersion)
+	}
+	return result, nil
+}
+
+// MigrateFile upgrades the config file at path. Without write it only
+// reports the changes; with write the original is backed up next to it
+// and replaced by the migrated document.
+func MigrateFile(path string, write bool) (*MigrationResult, error) {
+	doc := make(map[string]interface{})
+	if _, err := toml.DecodeFile(path, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode config file %s: %w", path, err)
+	}
+
+	result, err := Migrate(doc)
+	if err != nil {
+		return nil, err
+	}
+	if !write || !result.Changed() {
+		return result, nil
+	}
+
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat config file: %w", err)
+	}
+
+	result.BackupPath = fmt.Sprintf("%s.v%d.%s.bak", path, result.FromVersion, time.Now().Format("20060102-150405"))
+	if err := os.WriteFile(result.BackupPath, original, info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Migrated from config version %d to %d on %s\n", result.FromVersion, result.ToVersion, time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&buf, "# The original file, including its comments, is saved as %s\n\n", result.BackupPath)
+	if err := toml.NewEncoder(&buf).Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode migrated config: %w", err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write migrated config: %w", err)
+	}
+	return result, nil
+}
+
+// documentVersion reads the version key of a raw document, 0 when absent
+func documentVersion(doc map[string]interface{}) (int, error) {
+	raw, ok := doc["version"]
+	if !ok {
+		return 0, nil
+	}
+	v, ok := raw.(int64)
+	if !ok || v < 0 {
+		return 0, fmt.Errorf("invalid config version: %v", raw)
+	}
+	return int(v), nil
+}
+
+// table returns the named sub-table of doc, creating it when missing
+func table(doc map[string]interface{}, name string) map[string]interface{} {
+	if t, ok := doc[name].(map[string]interface{}); ok {
+		return t
+	}
+	t := make(map[string]interface{})
+	doc[name] = t
+	return t
+}
+
+// setDefault sets key to value unless it is already present
+func setDefault(t map[string]interface{}, key string, value interface{}) bool {
+	if _, exists := t[key]; exists {
+		return false
+	}
+	t[key] = value
+	return true
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+const legacyConfigData = `
+[trakt]
+client_id = "legacy_client_id"
+api_base_url = "https://api.trakt.tv"
+
+[export]
+format = "csv"
+date_format = "2006-01-02"
+
+[logging]
+level = "info"
+`
+
+func TestMigrate(t *testing.T) {
+	doc := make(map[string]interface{})
+	if _, err := toml.Decode(legacyConfigData, &doc); err != nil {
+		t.Fatalf("Failed to decode legacy config: %v", err)
+	}
+
+	result, err := Migrate(doc)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.FromVersion != 0 || result.ToVersion != CurrentVersion {
+		t.Errorf("Expected migration 0 -> %d, got %d -> %d", CurrentVersion, result.FromVersion, result.ToVersion)
+	}
+	if len(result.Steps) != CurrentVersion {
+		t.Errorf("Expected %d steps, got %d", CurrentVersion, len(result.Steps))
+	}
+	if doc["version"] != int64(CurrentVersion) {
+		t.Errorf("Expected version key %d, got %v", CurrentVersion, doc["version"])
+	}
+
+	export := doc["export"].(map[string]interface{})
+	if export["history_mode"] != "aggregated" {
+		t.Errorf("Expected history_mode to be added, got %v", export["history_mode"])
+	}
+	security := doc["security"].(map[string]interface{})
+	if security["encryption_enabled"] != true || security["audit_logging"] != false {
+		t.Errorf("Expected encryption on and audit logging off, got %v", security)
+	}
+	audit := security["audit"].(map[string]interface{})
+	if audit["retention_days"] != int64(90) {
+		t.Errorf("Expected audit retention days to be added, got %v", audit["retention_days"])
+	}
+
+	// Running again is a no-op
+	again, err := Migrate(doc)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if again.Changed() {
+		t.Error("Expected second migration to be a no-op")
+	}
+}
+
+func TestMigrate_KeepsExistingValues(t *testing.T) {
+	doc := map[string]interface{}{
+		"export": map[string]interface{}{"history_mode": "individual"},
+		"auth":   map[string]interface{}{"callback_port": int64(8089)},
+	}
+
+	result, err := Migrate(doc)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if doc["export"].(map[string]interface{})["history_mode"] != "individual" {
+		t.Error("Expected existing history_mode to be kept")
+	}
+	if doc["auth"].(map[string]interface{})["callback_port"] != int64(8089) {
+		t.Error("Expected existing callback_port to be kept")
+	}
+	if len(result.Steps[0].Changes) != 0 {
+		t.Errorf("Expected no changes in first step, got %v", result.Steps[0].Changes)
+	}
+}
+
+func TestMigrate_NewerVersion(t *testing.T) {
+	doc := map[string]interface{}{"version": int64(CurrentVersion + 1)}
+	if _, err := Migrate(doc); err == nil {
+		t.Error("Expected error for newer config version, got nil")
+	}
+}
+
+func TestMigrateFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.toml")
+	if err := os.WriteFile(configPath, []byte(legacyConfigData), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	// Dry run leaves the file untouched
+	result, err := MigrateFile(configPath, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.Changed() || result.BackupPath != "" {
+		t.Errorf("Expected pending changes without backup, got %+v", result)
+	}
+	data, _ := os.ReadFile(configPath)
+	if string(data) != legacyConfigData {
+		t.Error("Dry run modified the config file")
+	}
+
+	result, err = MigrateFile(configPath, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	backup, err := os.ReadFile(result.BackupPath)
+	if err != nil || string(backup) != legacyConfigData {
+		t.Errorf("Expected backup with original content, got %v", err)
+	}
+
+	data, _ = os.ReadFile(configPath)
+	if !strings.Contains(string(data), "version = 3") {
+		t.Errorf("Expected migrated file to carry the version, got:\n%s", data)
+	}
+
+	// The migrated file loads without further migration
+	loader := NewLoader(configPath)
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Failed to load migrated config: %v", err)
+	}
+	if loader.Migration().Changed() {
+		t.Error("Expected migrated file to be current")
+	}
+	if cfg.Trakt.ClientID != "legacy_client_id" || cfg.Version != CurrentVersion {
+		t.Errorf("Unexpected migrated config: %+v", cfg.Trakt)
+	}
+}
+
+func TestLoader_MigratesLegacyFileInMemory(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.toml")
+	if err := os.WriteFile(configPath, []byte(legacyConfigData), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	loader := NewLoader(configPath)
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Expected legacy config to load, got %v", err)
+	}
+	if !loader.Migration().Changed() {
+		t.Error("Expected in-memory migration to be reported")
+	}
+	if cfg.Security.KeyringBackend != "env" {
+		t.Errorf("Expected migrated keyring backend, got '%s'", cfg.Security.KeyringBackend)
+	}
+
+	data, _ := os.ReadFile(configPath)
+	if string(data) != legacyConfigData {
+		t.Error("Loading must not rewrite the config file")
+	}
+}
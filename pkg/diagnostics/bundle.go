@@ -0,0 +1,165 @@
+// Package diagnostics builds support bundles that can be shared when
+// reporting an issue. Every text file of a bundle is redacted with the log
+// privacy rules, so the bundle holds no titles, user IDs, tokens or IPs.
+package diagnostics
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+)
+
+// Bundle writes a zip archive of redacted diagnostics files
+type Bundle struct {
+	zip     *zip.Writer
+	files   []string
+	created time.Time
+}
+
+// NewBundle creates a bundle written to w
+func NewBundle(w io.Writer) *Bundle {
+	return &Bundle{
+		zip:     zip.NewWriter(w),
+		created: time.Now(),
+	}
+}
+
+// AddText adds a text file, redacting it line by line
+func (b *Bundle) AddText(name, content string) error {
+	return b.AddReader(name, strings.NewReader(content))
+}
+
+// AddReader adds the text read from r, redacting it line by line
+func (b *Bundle) AddReader(name string, r io.Reader) error {
+	w, err := b.create(name)
+	if err != nil {
+		return err
+	}
+	if err := logger.CopyRedacted(w, r); err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	return nil
+}
+
+// AddJSON adds v as an indented JSON file, redacting string values
+func (b *Bundle) AddJSON(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+
+	var fields interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	if object, ok := fields.(map[string]interface{}); ok {
+		fields = logger.RedactFields(object)
+	}
+
+	data, err = json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+
+	w, err := b.create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// AddLogTail adds the last lines of a log file. A missing file is recorded in
+// the bundle rather than failing it.
+func (b *Bundle) AddLogTail(name, path string, lines int) error {
+	tail, err := TailLines(path, lines)
+	if err != nil {
+		return b.AddText(name, fmt.Sprintf("# %s unavailable: %v\n", path, err))
+	}
+	return b.AddText(name, strings.Join(tail, "\n"))
+}
+
+// Files returns the names of the files added so far
+func (b *Bundle) Files() []string {
+	return append([]string(nil), b.files...)
+}
+
+// Close writes the README of the bundle and finishes the archive
+func (b *Bundle) Close() error {
+	files := b.Files()
+	sort.Strings(files)
+
+	var readme strings.Builder
+	fmt.Fprintf(&readme, "Export Trakt 4 Letterboxd diagnostics bundle\n")
+	fmt.Fprintf(&readme, "Created: %s\n\n", b.created.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&readme, "Titles, user IDs, tokens, e-mail and IP addresses and home directories\n")
+	fmt.Fprintf(&readme, "were replaced by placeholders such as %s, %s, %s and %s.\n",
+		logger.RedactedTitle, logger.RedactedUser, logger.RedactedToken, logger.RedactedIP)
+	fmt.Fprintf(&readme, "Secrets were removed from the configuration.\n\nFiles:\n")
+	for _, file := range files {
+		fmt.Fprintf(&readme, "  %s\n", file)
+	}
+
+	w, err := b.zip.Create("README.txt")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, readme.String()); err != nil {
+		return err
+	}
+	return b.zip.Close()
+}
+
+// create starts a new file of the archive
+func (b *Bundle) create(name string) (io.Writer, error) {
+	w, err := b.zip.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: b.created,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	b.files = append(b.files, name)
+	return w, nil
+}
+
+// TailLines returns the last n lines of a file
+func TailLines(path string, n int) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if n <= 0 {
+		return nil, nil
+	}
+
+	// Keep the last n lines in a ring
+	ring := make([]string, n)
+	count := 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		ring[count%n] = scanner.Text()
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if count <= n {
+		return ring[:count], nil
+	}
+	start := count % n
+	return append(ring[start:], ring[:start]...), nil
+}
@@ -0,0 +1,120 @@
+package diagnostics
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readBundle(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Failed to open bundle: %v", err)
+	}
+
+	files := make(map[string]string)
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", file.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file.Name, err)
+		}
+		files[file.Name] = string(content)
+	}
+	return files
+}
+
+func TestBundle(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "export.log")
+	logContent := "old line\nexported title=\"Heat\" for user=johnd from 192.168.1.20\n"
+	if err := os.WriteFile(logFile, []byte(logContent), 0600); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+
+	var buf bytes.Buffer
+	bundle := NewBundle(&buf)
+	if err := bundle.AddText("versions.txt", "Export Trakt 4 Letterboxd dev\n"); err != nil {
+		t.Fatalf("AddText failed: %v", err)
+	}
+	if err := bundle.AddLogTail("logs/app.log", logFile, 1); err != nil {
+		t.Fatalf("AddLogTail failed: %v", err)
+	}
+	if err := bundle.AddLogTail("logs/missing.log", filepath.Join(t.TempDir(), "missing.log"), 10); err != nil {
+		t.Fatalf("AddLogTail of a missing file failed: %v", err)
+	}
+	if err := bundle.AddJSON("health.json", map[string]interface{}{"status": "healthy", "access_token": "abc"}); err != nil {
+		t.Fatalf("AddJSON failed: %v", err)
+	}
+	if err := bundle.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	files := readBundle(t, buf.Bytes())
+
+	appLog := files["logs/app.log"]
+	if strings.Contains(appLog, "old line") {
+		t.Error("Expected only the last log line")
+	}
+	for _, secret := range []string{"Heat", "johnd", "192.168.1.20"} {
+		if strings.Contains(appLog, secret) {
+			t.Errorf("Expected %q to be redacted from %q", secret, appLog)
+		}
+	}
+	if !strings.Contains(files["logs/missing.log"], "unavailable") {
+		t.Errorf("Expected a note for the missing log, got %q", files["logs/missing.log"])
+	}
+	if strings.Contains(files["health.json"], "abc") || !strings.Contains(files["health.json"], "healthy") {
+		t.Errorf("Expected a redacted health report, got %q", files["health.json"])
+	}
+	for _, name := range []string{"versions.txt", "logs/app.log", "health.json"} {
+		if !strings.Contains(files["README.txt"], name) {
+			t.Errorf("Expected README to list %s", name)
+		}
+	}
+}
+
+func TestTailLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lines.log")
+	var content strings.Builder
+	for i := 1; i <= 10; i++ {
+		fmt.Fprintf(&content, "line %d\n", i)
+	}
+	if err := os.WriteFile(path, []byte(content.String()), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{3, []string{"line 8", "line 9", "line 10"}},
+		{20, nil},
+		{0, nil},
+	}
+
+	for _, tt := range tests {
+		lines, err := TailLines(path, tt.n)
+		if err != nil {
+			t.Fatalf("TailLines(%d) failed: %v", tt.n, err)
+		}
+		if tt.n == 20 {
+			if len(lines) != 10 || lines[0] != "line 1" {
+				t.Errorf("Expected every line, got %v", lines)
+			}
+			continue
+		}
+		if strings.Join(lines, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("TailLines(%d) = %v, want %v", tt.n, lines, tt.want)
+		}
+	}
+}
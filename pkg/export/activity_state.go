@@ -0,0 +1,85 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ActivityStateFile is the name of the state file kept in the export directory
+const ActivityStateFile = ".export_state.json"
+
+// DatasetState records the last successful export of a dataset
+type DatasetState struct {
+	// ActivityAt is the Trakt activity timestamp the export was based on
+	ActivityAt time.Time `json:"activity_at"`
+	ExportedAt time.Time `json:"exported_at"`
+	// Settings fingerprints the configuration affecting the exported file
+	Settings string `json:"settings"`
+}
+
+// ActivityState tracks, per dataset, which Trakt activity was last exported so
+// that unchanged datasets can be skipped
+type ActivityState struct {
+	path     string
+	Datasets map[string]DatasetState `json:"datasets"`
+}
+
+// LoadActivityState reads the state file, returning an empty state when it doesn't exist
+func LoadActivityState(path string) (*ActivityState, error) {
+	state := &ActivityState{path: path, Datasets: make(map[string]DatasetState)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export state: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse export state: %w", err)
+	}
+	if state.Datasets == nil {
+		state.Datasets = make(map[string]DatasetState)
+	}
+	return state, nil
+}
+
+// Unchanged reports whether the dataset was already exported for this
+// activity timestamp with the same settings
+func (s *ActivityState) Unchanged(dataset string, activityAt time.Time, settings string) bool {
+	previous, ok := s.Datasets[dataset]
+	if !ok || activityAt.IsZero() {
+		return false
+	}
+	return previous.Settings == settings && !activityAt.After(previous.ActivityAt)
+}
+
+// Record stores a successful export of the dataset and saves the state
+func (s *ActivityState) Record(dataset string, activityAt time.Time, settings string) error {
+	s.Datasets[dataset] = DatasetState{
+		ActivityAt: activityAt,
+		ExportedAt: time.Now(),
+		Settings:   settings,
+	}
+	return s.save()
+}
+
+func (s *ActivityState) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create export state directory: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write export state: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,30 @@
+package export
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivityStateUnchanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ActivityStateFile)
+	activityAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	state, err := LoadActivityState(path)
+	require.NoError(t, err)
+	assert.False(t, state.Unchanged("ratings", activityAt, "csv"))
+
+	require.NoError(t, state.Record("ratings", activityAt, "csv"))
+
+	// The state survives between runs
+	state, err = LoadActivityState(path)
+	require.NoError(t, err)
+	assert.True(t, state.Unchanged("ratings", activityAt, "csv"))
+	assert.False(t, state.Unchanged("ratings", activityAt.Add(time.Minute), "csv"), "new activity")
+	assert.False(t, state.Unchanged("ratings", activityAt, "json"), "changed settings")
+	assert.False(t, state.Unchanged("watchlist", activityAt, "csv"), "never exported")
+	assert.False(t, state.Unchanged("ratings", time.Time{}, "csv"), "unknown activity")
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/api"
@@ -20,8 +21,16 @@ const (
 
 // LetterboxdExporter handles the export of movies to Letterboxd format
 type LetterboxdExporter struct {
-	config *config.Config
-	log    logger.Logger
+	config  *config.Config
+	log     logger.Logger
+	metrics MetricsRecorder
+
+	itemsMutex sync.Mutex
+	items      map[string]int
+
+	signer       ArtifactSigner
+	signMutex    sync.Mutex
+	pendingFiles map[string][]string
 }
 
 // NewLetterboxdExporter creates a new Letterboxd exporter
@@ -1,6 +1,7 @@
 package export
 
 import (
+	"context"
 	"encoding/csv"
 	"fmt"
 	"os"
@@ -13,7 +14,16 @@ import (
 )
 
 // ExportMovies exports the given movies to a CSV file in Letterboxd format
-func (e *LetterboxdExporter) ExportMovies(movies []api.Movie, client *api.Client) error {
+func (e *LetterboxdExporter) ExportMovies(movies []api.Movie, client api.TraktAPIClient) error {
+	return e.ExportMoviesContext(context.Background(), movies, client)
+}
+
+// ExportMoviesContext is like ExportMovies but stops fetching ratings when ctx is done
+func (e *LetterboxdExporter) ExportMoviesContext(ctx context.Context, movies []api.Movie, client api.TraktAPIClient) (err error) {
+	defer e.recordExport("watched", time.Now(), &err)
+	ctx, span := startWriteSpan(ctx, "watched")
+	defer endWriteSpan(span, &err)
+
 	// Get export directory
 	exportDir, err := e.getExportDir()
 	if err != nil {
@@ -39,6 +49,8 @@ func (e *LetterboxdExporter) ExportMovies(movies []api.Movie, client *api.Client
 	}
 	filePath := filepath.Join(exportDir, filename)
 
+	defer e.signExport(filePath, &err)
+
 	// Create export file
 	file, err := os.Create(filePath)
 	if err != nil {
@@ -49,6 +61,7 @@ func (e *LetterboxdExporter) ExportMovies(movies []api.Movie, client *api.Client
 		return fmt.Errorf("failed to create export file: %w", err)
 	}
 	defer file.Close()
+	setWriteFile(ctx, filePath)
 
 	writer := csv.NewWriter(file)
 	defer writer.Flush()
@@ -62,8 +75,10 @@ func (e *LetterboxdExporter) ExportMovies(movies []api.Movie, client *api.Client
 	// Get ratings for movies
 	var ratings []api.Rating
 	if client != nil {
-		ratingsData, err := client.GetRatings()
-		if err != nil {
+		ratingsData, err := client.GetRatingsContext(ctx)
+		if err != nil && ctx.Err() != nil {
+			return fmt.Errorf("export cancelled: %w", ctx.Err())
+		} else if err != nil {
 			e.log.Warn("export.ratings_fetch_failed", map[string]interface{}{
 				"error": err.Error(),
 			})
@@ -146,6 +161,8 @@ func (e *LetterboxdExporter) ExportMovies(movies []api.Movie, client *api.Client
 		}
 	}
 
+	e.recordMovies("watched", len(movies))
+
 	e.log.Info("export.export_complete", map[string]interface{}{
 		"count": len(movies),
 		"path":  filePath,
@@ -154,7 +171,16 @@ func (e *LetterboxdExporter) ExportMovies(movies []api.Movie, client *api.Client
 }
 
 // ExportMovieHistory exports the user's complete movie watch history to a CSV file with individual watch events
-func (e *LetterboxdExporter) ExportMovieHistory(history []api.HistoryItem, apiClient *api.Client) error {
+func (e *LetterboxdExporter) ExportMovieHistory(history []api.HistoryItem, apiClient api.TraktAPIClient) error {
+	return e.ExportMovieHistoryContext(context.Background(), history, apiClient)
+}
+
+// ExportMovieHistoryContext is like ExportMovieHistory but stops fetching ratings when ctx is done
+func (e *LetterboxdExporter) ExportMovieHistoryContext(ctx context.Context, history []api.HistoryItem, apiClient api.TraktAPIClient) (err error) {
+	defer e.recordExport("history", time.Now(), &err)
+	ctx, span := startWriteSpan(ctx, "history")
+	defer endWriteSpan(span, &err)
+
 	// Get export directory
 	exportDir, err := e.getExportDir()
 	if err != nil {
@@ -178,6 +204,8 @@ func (e *LetterboxdExporter) ExportMovieHistory(history []api.HistoryItem, apiCl
 	}
 	filePath := filepath.Join(exportDir, filename)
 
+	defer e.signExport(filePath, &err)
+
 	file, err := os.Create(filePath)
 	if err != nil {
 		e.log.Error("errors.file_create_failed", map[string]interface{}{
@@ -187,6 +215,7 @@ func (e *LetterboxdExporter) ExportMovieHistory(history []api.HistoryItem, apiCl
 		return fmt.Errorf("failed to create export file: %w", err)
 	}
 	defer file.Close()
+	setWriteFile(ctx, filePath)
 
 	writer := csv.NewWriter(file)
 	defer writer.Flush()
@@ -200,7 +229,11 @@ func (e *LetterboxdExporter) ExportMovieHistory(history []api.HistoryItem, apiCl
 	// Get ratings if available
 	movieRatings := make(map[string]string)
 	if apiClient != nil {
-		if ratings, err := apiClient.GetRatings(); err == nil {
+		ratings, err := apiClient.GetRatingsContext(ctx)
+		if err != nil && ctx.Err() != nil {
+			return fmt.Errorf("export cancelled: %w", ctx.Err())
+		}
+		if err == nil {
 			for _, rating := range ratings {
 				if rating.Movie.IDs.IMDB != "" {
 					movieRatings[rating.Movie.IDs.IMDB] = strconv.Itoa(int(rating.Rating))
@@ -299,6 +332,8 @@ func (e *LetterboxdExporter) ExportMovieHistory(history []api.HistoryItem, apiCl
 		}
 	}
 
+	e.recordMovies("history", len(history))
+
 	e.log.Info("export.history_export_complete", map[string]interface{}{
 		"count": len(history),
 		"path":  filePath,
@@ -308,6 +343,15 @@ func (e *LetterboxdExporter) ExportMovieHistory(history []api.HistoryItem, apiCl
 
 // ExportCollectionMovies exports the user's movie collection to a CSV file in Letterboxd format
 func (e *LetterboxdExporter) ExportCollectionMovies(movies []api.CollectionMovie) error {
+	return e.ExportCollectionMoviesContext(context.Background(), movies)
+}
+
+// ExportCollectionMoviesContext is like ExportCollectionMovies and traces the file write as a child of ctx
+func (e *LetterboxdExporter) ExportCollectionMoviesContext(ctx context.Context, movies []api.CollectionMovie) (err error) {
+	defer e.recordExport("collection", time.Now(), &err)
+	ctx, span := startWriteSpan(ctx, "collection")
+	defer endWriteSpan(span, &err)
+
 	// Get export directory
 	exportDir, err := e.getExportDir()
 	if err != nil {
@@ -333,6 +377,8 @@ func (e *LetterboxdExporter) ExportCollectionMovies(movies []api.CollectionMovie
 	}
 	filePath := filepath.Join(exportDir, filename)
 
+	defer e.signExport(filePath, &err)
+
 	file, err := os.Create(filePath)
 	if err != nil {
 		e.log.Error("errors.file_create_failed", map[string]interface{}{
@@ -342,6 +388,7 @@ func (e *LetterboxdExporter) ExportCollectionMovies(movies []api.CollectionMovie
 		return fmt.Errorf("failed to create export file: %w", err)
 	}
 	defer file.Close()
+	setWriteFile(ctx, filePath)
 
 	writer := csv.NewWriter(file)
 	defer writer.Flush()
@@ -378,6 +425,8 @@ func (e *LetterboxdExporter) ExportCollectionMovies(movies []api.CollectionMovie
 		}
 	}
 
+	e.recordMovies("collection", len(movies))
+
 	e.log.Info("export.collection_export_complete", map[string]interface{}{
 		"count": len(movies),
 		"path":  filePath,
@@ -389,6 +438,15 @@ func (e *LetterboxdExporter) ExportCollectionMovies(movies []api.CollectionMovie
 // The format matches the official Letterboxd import format with columns:
 // Title, Year, imdbID, tmdbID, WatchedDate, Rating10, Rewatch
 func (e *LetterboxdExporter) ExportLetterboxdFormat(movies []api.Movie, ratings []api.Rating) error {
+	return e.ExportLetterboxdFormatContext(context.Background(), movies, ratings)
+}
+
+// ExportLetterboxdFormatContext is like ExportLetterboxdFormat and traces the file write as a child of ctx
+func (e *LetterboxdExporter) ExportLetterboxdFormatContext(ctx context.Context, movies []api.Movie, ratings []api.Rating) (err error) {
+	defer e.recordExport("letterboxd", time.Now(), &err)
+	ctx, span := startWriteSpan(ctx, "letterboxd")
+	defer endWriteSpan(span, &err)
+
 	// Get export directory
 	exportDir, err := e.getExportDir()
 	if err != nil {
@@ -410,6 +468,8 @@ func (e *LetterboxdExporter) ExportLetterboxdFormat(movies []api.Movie, ratings
 	}
 	filePath := filepath.Join(exportDir, filename)
 
+	defer e.signExport(filePath, &err)
+
 	file, err := os.Create(filePath)
 	if err != nil {
 		e.log.Error("errors.file_create_failed", map[string]interface{}{
@@ -419,6 +479,7 @@ func (e *LetterboxdExporter) ExportLetterboxdFormat(movies []api.Movie, ratings
 		return fmt.Errorf("failed to create export file: %w", err)
 	}
 	defer file.Close()
+	setWriteFile(ctx, filePath)
 
 	writer := csv.NewWriter(file)
 	defer writer.Flush()
@@ -486,6 +547,8 @@ func (e *LetterboxdExporter) ExportLetterboxdFormat(movies []api.Movie, ratings
 		}
 	}
 
+	e.recordMovies("letterboxd", len(movies))
+
 	e.log.Info("export.letterboxd_export_complete", map[string]interface{}{
 		"count": len(movies),
 		"path":  filePath,
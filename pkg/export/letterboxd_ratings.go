@@ -1,6 +1,7 @@
 package export
 
 import (
+	"context"
 	"encoding/csv"
 	"fmt"
 	"os"
@@ -13,6 +14,15 @@ import (
 
 // ExportRatings exports the user's movie ratings to a CSV file in Letterboxd format
 func (e *LetterboxdExporter) ExportRatings(ratings []api.Rating) error {
+	return e.ExportRatingsContext(context.Background(), ratings)
+}
+
+// ExportRatingsContext is like ExportRatings and traces the file write as a child of ctx
+func (e *LetterboxdExporter) ExportRatingsContext(ctx context.Context, ratings []api.Rating) (err error) {
+	defer e.recordExport("ratings", time.Now(), &err)
+	ctx, span := startWriteSpan(ctx, "ratings")
+	defer endWriteSpan(span, &err)
+
 	// Get export directory
 	exportDir, err := e.getExportDir()
 	if err != nil {
@@ -38,6 +48,8 @@ func (e *LetterboxdExporter) ExportRatings(ratings []api.Rating) error {
 	}
 	filePath := filepath.Join(exportDir, filename)
 
+	defer e.signExport(filePath, &err)
+
 	file, err := os.Create(filePath)
 	if err != nil {
 		e.log.Error("errors.file_create_failed", map[string]interface{}{
@@ -47,6 +59,7 @@ func (e *LetterboxdExporter) ExportRatings(ratings []api.Rating) error {
 		return fmt.Errorf("failed to create export file: %w", err)
 	}
 	defer file.Close()
+	setWriteFile(ctx, filePath)
 
 	writer := csv.NewWriter(file)
 	defer writer.Flush()
@@ -86,6 +99,8 @@ func (e *LetterboxdExporter) ExportRatings(ratings []api.Rating) error {
 		}
 	}
 
+	e.recordRatings(ratings)
+
 	e.log.Info("export.ratings_export_complete", map[string]interface{}{
 		"count": len(ratings),
 		"path":  filePath,
@@ -95,6 +110,15 @@ func (e *LetterboxdExporter) ExportRatings(ratings []api.Rating) error {
 
 // ExportWatchlist exports the user's movie watchlist to a CSV file in Letterboxd format
 func (e *LetterboxdExporter) ExportWatchlist(watchlist []api.WatchlistMovie) error {
+	return e.ExportWatchlistContext(context.Background(), watchlist)
+}
+
+// ExportWatchlistContext is like ExportWatchlist and traces the file write as a child of ctx
+func (e *LetterboxdExporter) ExportWatchlistContext(ctx context.Context, watchlist []api.WatchlistMovie) (err error) {
+	defer e.recordExport("watchlist", time.Now(), &err)
+	ctx, span := startWriteSpan(ctx, "watchlist")
+	defer endWriteSpan(span, &err)
+
 	// Get export directory
 	exportDir, err := e.getExportDir()
 	if err != nil {
@@ -120,6 +144,8 @@ func (e *LetterboxdExporter) ExportWatchlist(watchlist []api.WatchlistMovie) err
 	}
 	filePath := filepath.Join(exportDir, filename)
 
+	defer e.signExport(filePath, &err)
+
 	file, err := os.Create(filePath)
 	if err != nil {
 		e.log.Error("errors.file_create_failed", map[string]interface{}{
@@ -129,6 +155,7 @@ func (e *LetterboxdExporter) ExportWatchlist(watchlist []api.WatchlistMovie) err
 		return fmt.Errorf("failed to create export file: %w", err)
 	}
 	defer file.Close()
+	setWriteFile(ctx, filePath)
 
 	writer := csv.NewWriter(file)
 	defer writer.Flush()
@@ -162,6 +189,11 @@ func (e *LetterboxdExporter) ExportWatchlist(watchlist []api.WatchlistMovie) err
 		}
 	}
 
+	if e.metrics != nil {
+		e.metrics.RecordWatchlistExported("movie", len(watchlist))
+	}
+	e.recordItems("watchlist", len(watchlist))
+
 	e.log.Info("export.watchlist_export_complete", map[string]interface{}{
 		"count": len(watchlist),
 		"path":  filePath,
@@ -1,6 +1,7 @@
 package export
 
 import (
+	"context"
 	"encoding/csv"
 	"fmt"
 	"os"
@@ -13,6 +14,15 @@ import (
 
 // ExportShows exports the user's watched shows to a CSV file
 func (e *LetterboxdExporter) ExportShows(shows []api.WatchedShow) error {
+	return e.ExportShowsContext(context.Background(), shows)
+}
+
+// ExportShowsContext is like ExportShows and traces the file write as a child of ctx
+func (e *LetterboxdExporter) ExportShowsContext(ctx context.Context, shows []api.WatchedShow) (err error) {
+	defer e.recordExport("shows", time.Now(), &err)
+	ctx, span := startWriteSpan(ctx, "shows")
+	defer endWriteSpan(span, &err)
+
 	// Get export directory
 	exportDir, err := e.getExportDir()
 	if err != nil {
@@ -32,6 +42,8 @@ func (e *LetterboxdExporter) ExportShows(shows []api.WatchedShow) error {
 	}
 	filePath := filepath.Join(exportDir, filename)
 
+	defer e.signExport(filePath, &err)
+
 	file, err := os.Create(filePath)
 	if err != nil {
 		e.log.Error("errors.file_create_failed", map[string]interface{}{
@@ -40,6 +52,7 @@ func (e *LetterboxdExporter) ExportShows(shows []api.WatchedShow) error {
 		return fmt.Errorf("failed to create export file: %w", err)
 	}
 	defer file.Close()
+	setWriteFile(ctx, filePath)
 
 	writer := csv.NewWriter(file)
 	defer writer.Flush()
@@ -152,6 +165,8 @@ func (e *LetterboxdExporter) ExportShows(shows []api.WatchedShow) error {
 		}
 	}
 
+	e.recordItems("shows", episodeCount)
+
 	e.log.Info("export.shows_export_complete", map[string]interface{}{
 		"shows":    len(shows),
 		"episodes": episodeCount,
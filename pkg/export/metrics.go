@@ -0,0 +1,95 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/api"
+)
+
+// MetricsRecorder receives the outcome of exports. The Prometheus metrics of
+// the telemetry manager implement it.
+type MetricsRecorder interface {
+	RecordExport(exportType, status, format string, duration time.Duration)
+	RecordExportError(exportType, errorCode, component string)
+	RecordMoviesExported(category, status string, count int)
+	RecordRatingsExported(ratingValue string, count int)
+	RecordWatchlistExported(itemType string, count int)
+	RecordItemsExported(exportType string, count int)
+}
+
+// SetMetrics makes the exporter report every export to m
+func (e *LetterboxdExporter) SetMetrics(m MetricsRecorder) {
+	e.metrics = m
+}
+
+// recordExport reports the duration and status of an export. It is deferred
+// with a pointer to the named error of the export function.
+func (e *LetterboxdExporter) recordExport(exportType string, start time.Time, err *error) {
+	if e.metrics == nil {
+		return
+	}
+
+	status := "success"
+	if *err != nil {
+		status = "error"
+		errorCode := "failed"
+		if errors.Is(*err, context.Canceled) || errors.Is(*err, context.DeadlineExceeded) {
+			errorCode = "cancelled"
+		}
+		e.metrics.RecordExportError(exportType, errorCode, "letterboxd_exporter")
+	}
+	e.metrics.RecordExport(exportType, status, "csv", time.Since(start))
+}
+
+// recordItems counts the items written by an export
+func (e *LetterboxdExporter) recordItems(exportType string, count int) {
+	e.itemsMutex.Lock()
+	if e.items == nil {
+		e.items = make(map[string]int)
+	}
+	e.items[exportType] += count
+	e.itemsMutex.Unlock()
+
+	if e.metrics != nil {
+		e.metrics.RecordItemsExported(exportType, count)
+	}
+}
+
+// ExportedItems returns the number of items written per export type since
+// the exporter was created, e.g. for the notification of a finished run
+func (e *LetterboxdExporter) ExportedItems() map[string]int {
+	e.itemsMutex.Lock()
+	defer e.itemsMutex.Unlock()
+
+	items := make(map[string]int, len(e.items))
+	for exportType, count := range e.items {
+		items[exportType] = count
+	}
+	return items
+}
+
+func (e *LetterboxdExporter) recordMovies(category string, count int) {
+	if e.metrics != nil {
+		e.metrics.RecordMoviesExported(category, "success", count)
+	}
+	e.recordItems(category, count)
+}
+
+// recordRatings counts the exported ratings per rating value
+func (e *LetterboxdExporter) recordRatings(ratings []api.Rating) {
+	e.recordItems("ratings", len(ratings))
+	if e.metrics == nil {
+		return
+	}
+
+	counts := make(map[int]int)
+	for _, r := range ratings {
+		counts[int(r.Rating)]++
+	}
+	for value, count := range counts {
+		e.metrics.RecordRatingsExported(strconv.Itoa(value), count)
+	}
+}
@@ -0,0 +1,120 @@
+package export
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/api"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMetrics is a MetricsRecorder keeping the recorded values
+type recordingMetrics struct {
+	exports   map[string]string
+	errors    map[string]string
+	movies    map[string]int
+	ratings   map[string]int
+	watchlist int
+	items     map[string]int
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{
+		exports: make(map[string]string),
+		errors:  make(map[string]string),
+		movies:  make(map[string]int),
+		ratings: make(map[string]int),
+		items:   make(map[string]int),
+	}
+}
+
+func (m *recordingMetrics) RecordExport(exportType, status, format string, duration time.Duration) {
+	m.exports[exportType] = status
+}
+
+func (m *recordingMetrics) RecordExportError(exportType, errorCode, component string) {
+	m.errors[exportType] = errorCode
+}
+
+func (m *recordingMetrics) RecordMoviesExported(category, status string, count int) {
+	m.movies[category] += count
+}
+
+func (m *recordingMetrics) RecordRatingsExported(ratingValue string, count int) {
+	m.ratings[ratingValue] += count
+}
+
+func (m *recordingMetrics) RecordWatchlistExported(itemType string, count int) {
+	m.watchlist += count
+}
+
+func (m *recordingMetrics) RecordItemsExported(exportType string, count int) {
+	m.items[exportType] += count
+}
+
+func TestExporterRecordsMetrics(t *testing.T) {
+	cfg := &config.Config{
+		Letterboxd: config.LetterboxdConfig{ExportDir: t.TempDir()},
+		Export:     config.ExportConfig{Format: "csv", DateFormat: "2006-01-02"},
+	}
+	exporter := NewLetterboxdExporter(cfg, &MockLogger{})
+	metrics := newRecordingMetrics()
+	exporter.SetMetrics(metrics)
+
+	ratings := []api.Rating{
+		{Movie: api.MovieInfo{Title: "A", Year: 2020}, Rating: 8},
+		{Movie: api.MovieInfo{Title: "B", Year: 2021}, Rating: 8},
+		{Movie: api.MovieInfo{Title: "C", Year: 2022}, Rating: 10},
+	}
+	require.NoError(t, exporter.ExportRatings(ratings))
+	assert.Equal(t, "success", metrics.exports["ratings"])
+	assert.Equal(t, map[string]int{"8": 2, "10": 1}, metrics.ratings)
+
+	collection := []api.CollectionMovie{{Movie: api.MovieInfo{Title: "A", Year: 2020}}}
+	require.NoError(t, exporter.ExportCollectionMovies(collection))
+	assert.Equal(t, "success", metrics.exports["collection"])
+	assert.Equal(t, 1, metrics.movies["collection"])
+
+	watchlist := []api.WatchlistMovie{{Movie: api.MovieInfo{Title: "D", Year: 2023}}}
+	require.NoError(t, exporter.ExportWatchlist(watchlist))
+	assert.Equal(t, 1, metrics.watchlist)
+	assert.Equal(t, map[string]int{"ratings": 3, "collection": 1, "watchlist": 1}, metrics.items)
+}
+
+func TestExporterRecordsFailedExport(t *testing.T) {
+	metrics := newRecordingMetrics()
+	exporter := NewLetterboxdExporter(&config.Config{}, &MockLogger{})
+	exporter.SetMetrics(metrics)
+
+	var err error = errors.New("disk full")
+	exporter.recordExport("shows", time.Now(), &err)
+
+	assert.Equal(t, "error", metrics.exports["shows"])
+	assert.Equal(t, "failed", metrics.errors["shows"])
+}
+
+func TestExporterCountsItemsWithoutMetrics(t *testing.T) {
+	cfg := &config.Config{
+		Letterboxd: config.LetterboxdConfig{ExportDir: t.TempDir()},
+		Export:     config.ExportConfig{Format: "csv", DateFormat: "2006-01-02"},
+	}
+	exporter := NewLetterboxdExporter(cfg, &MockLogger{})
+	assert.Empty(t, exporter.ExportedItems())
+
+	ratings := []api.Rating{{Movie: api.MovieInfo{Title: "A", Year: 2020}, Rating: 8}}
+	require.NoError(t, exporter.ExportRatings(ratings))
+	watchlist := []api.WatchlistMovie{
+		{Movie: api.MovieInfo{Title: "B", Year: 2021}},
+		{Movie: api.MovieInfo{Title: "C", Year: 2022}},
+	}
+	require.NoError(t, exporter.ExportWatchlist(watchlist))
+
+	items := exporter.ExportedItems()
+	assert.Equal(t, map[string]int{"ratings": 1, "watchlist": 2}, items)
+
+	items["ratings"] = 100
+	assert.Equal(t, 1, exporter.ExportedItems()["ratings"], "ExportedItems must return a copy")
+}
@@ -0,0 +1,83 @@
+package export
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// ArtifactSigner signs the files written by exports. The signer of the
+// signing package implements it.
+type ArtifactSigner interface {
+	SignFile(path string) (string, error)
+	SignManifest(dir string, files []string) (string, error)
+}
+
+// SetSigner makes the exporter sign every export file with s, following
+// the signing mode of the configuration
+func (e *LetterboxdExporter) SetSigner(s ArtifactSigner) {
+	e.signer = s
+}
+
+// signExport signs the file of a successful export, or records it for the
+// manifest of its directory in manifest mode. It is deferred with a pointer
+// to the named error of the export function before the file is created, so
+// that it runs once the file is flushed and closed.
+func (e *LetterboxdExporter) signExport(path string, err *error) {
+	if e.signer == nil || *err != nil {
+		return
+	}
+
+	if e.config.Export.Signing.Mode == "manifest" {
+		e.signMutex.Lock()
+		defer e.signMutex.Unlock()
+		if e.pendingFiles == nil {
+			e.pendingFiles = make(map[string][]string)
+		}
+		dir, name := filepath.Dir(path), filepath.Base(path)
+		for _, pending := range e.pendingFiles[dir] {
+			if pending == name {
+				return
+			}
+		}
+		e.pendingFiles[dir] = append(e.pendingFiles[dir], name)
+		return
+	}
+
+	sigPath, signErr := e.signer.SignFile(path)
+	if signErr != nil {
+		*err = fmt.Errorf("failed to sign export file: %w", signErr)
+		return
+	}
+	e.log.Info("export.file_signed", map[string]interface{}{
+		"path":      path,
+		"signature": sigPath,
+	})
+}
+
+// SignManifests writes and signs the manifest of each directory that
+// received export files since the last call. It does nothing unless a
+// signer is set in manifest mode.
+func (e *LetterboxdExporter) SignManifests() error {
+	e.signMutex.Lock()
+	defer e.signMutex.Unlock()
+
+	dirs := make([]string, 0, len(e.pendingFiles))
+	for dir := range e.pendingFiles {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		path, err := e.signer.SignManifest(dir, e.pendingFiles[dir])
+		if err != nil {
+			return fmt.Errorf("failed to sign export manifest: %w", err)
+		}
+		e.log.Info("export.manifest_signed", map[string]interface{}{
+			"path":  path,
+			"files": len(e.pendingFiles[dir]),
+		})
+		delete(e.pendingFiles, dir)
+	}
+	return nil
+}
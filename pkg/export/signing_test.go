@@ -0,0 +1,69 @@
+package export
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/api"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/signing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSignedExporter(t *testing.T, mode string) (*LetterboxdExporter, *signing.Signer, string) {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer := signing.NewSigner(key)
+
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Letterboxd: config.LetterboxdConfig{ExportDir: dir},
+		Export: config.ExportConfig{
+			Format:     "csv",
+			DateFormat: "2006-01-02",
+			Signing:    config.SigningConfig{Enabled: true, Mode: mode},
+		},
+	}
+	exporter := NewLetterboxdExporter(cfg, &MockLogger{})
+	exporter.SetSigner(signer)
+	return exporter, signer, dir
+}
+
+func TestExportSignsFiles(t *testing.T) {
+	exporter, signer, dir := newSignedExporter(t, "files")
+
+	require.NoError(t, exporter.ExportWatchlist([]api.WatchlistMovie{
+		{Movie: api.MovieInfo{Title: "Heat", Year: 1995}},
+	}))
+
+	path := filepath.Join(dir, "watchlist-export-test.csv")
+	assert.NoError(t, signing.VerifyFile(signer.PublicKey(), path))
+	assert.NoError(t, exporter.SignManifests())
+	assert.NoFileExists(t, filepath.Join(dir, signing.ManifestFile))
+}
+
+func TestExportSignsManifest(t *testing.T) {
+	exporter, signer, dir := newSignedExporter(t, "manifest")
+
+	require.NoError(t, exporter.ExportWatchlist([]api.WatchlistMovie{
+		{Movie: api.MovieInfo{Title: "Heat", Year: 1995}},
+	}))
+	require.NoError(t, exporter.ExportRatings([]api.Rating{
+		{Movie: api.MovieInfo{Title: "Heat", Year: 1995}, Rating: 9},
+	}))
+
+	// Files are only signed through the manifest
+	assert.NoFileExists(t, filepath.Join(dir, "watchlist-export-test.csv"+signing.SignatureExt))
+	require.NoError(t, exporter.SignManifests())
+
+	manifest, results, err := signing.VerifyManifest(signer.PublicKey(), dir)
+	require.NoError(t, err)
+	assert.Len(t, manifest.Files, 2)
+	for _, result := range results {
+		assert.NoError(t, result.Err, result.Name)
+	}
+}
@@ -0,0 +1,36 @@
+package export
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the export spans
+const tracerName = "github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/export"
+
+// startWriteSpan starts the span of an export file write. It uses the global
+// tracer provider, a no-op while tracing is disabled.
+func startWriteSpan(ctx context.Context, exportType string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, "export.write "+exportType,
+		trace.WithAttributes(attribute.String("export.type", exportType)),
+	)
+}
+
+// endWriteSpan ends the span with the outcome of the export. It is deferred
+// with a pointer to the named error of the export function.
+func endWriteSpan(span trace.Span, err *error) {
+	if *err != nil {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
+
+// setWriteFile records the file written by the export on its span
+func setWriteFile(ctx context.Context, path string) {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("file.path", path))
+}
@@ -0,0 +1,45 @@
+package export
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/api"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestExporterTracesFileWrites(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	exportDir := t.TempDir()
+	cfg := &config.Config{
+		Letterboxd: config.LetterboxdConfig{ExportDir: exportDir, RatingsFilename: "ratings.csv"},
+		Export:     config.ExportConfig{Format: "csv", DateFormat: "2006-01-02"},
+	}
+	exporter := NewLetterboxdExporter(cfg, &MockLogger{})
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "export.job")
+	ratings := []api.Rating{{Movie: api.MovieInfo{Title: "A", Year: 2020}, Rating: 8}}
+	require.NoError(t, exporter.ExportRatingsContext(ctx, ratings))
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	write := spans[0]
+	assert.Equal(t, "export.write ratings", write.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), write.Parent().SpanID())
+	assert.Contains(t, write.Attributes(), attribute.String("file.path", filepath.Join(exportDir, "ratings.csv")))
+	assert.Equal(t, codes.Unset, write.Status().Code)
+}
@@ -123,6 +123,7 @@ type StandardLogger struct {
 	translator  Translator
 	fileWriter  *os.File
 	isQuietMode bool
+	privacyMode bool
 }
 
 // NewLogger creates a new logger instance
@@ -167,6 +168,11 @@ func (l *StandardLogger) translate(messageID string, data map[string]interface{}
 		data = make(map[string]interface{})
 	}
 	
+	// Keep titles, user IDs and tokens out of translated messages
+	if l.privacyMode {
+		data = RedactFields(data)
+	}
+	
 	return l.translator.Translate(messageID, data)
 }
 
@@ -245,6 +251,30 @@ func (l *StandardLogger) Debugf(messageID string, data map[string]interface{}) {
 	l.Logger.Debug(l.translate(messageID, data))
 }
 
+// SetPrivacyMode redacts titles, user IDs, tokens, IP addresses and home
+// directories from every message and field, see RedactString
+func (l *StandardLogger) SetPrivacyMode(enabled bool) {
+	if enabled && !l.privacyMode {
+		l.AddHook(redactionHook{})
+	} else if !enabled && l.privacyMode {
+		hooks := make(logrus.LevelHooks)
+		for level, levelHooks := range l.Hooks {
+			for _, hook := range levelHooks {
+				if _, ok := hook.(redactionHook); !ok {
+					hooks[level] = append(hooks[level], hook)
+				}
+			}
+		}
+		l.ReplaceHooks(hooks)
+	}
+	l.privacyMode = enabled
+}
+
+// PrivacyMode reports whether messages are redacted
+func (l *StandardLogger) PrivacyMode() bool {
+	return l.privacyMode
+}
+
 // SetLogLevel sets the logging level
 func (l *StandardLogger) SetLogLevel(level string) {
 	switch level {
@@ -0,0 +1,213 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/validation"
+	"github.com/sirupsen/logrus"
+)
+
+// Placeholders replacing personal data in privacy mode
+const (
+	RedactedTitle = "[TITLE]"
+	RedactedUser  = "[USER]"
+	RedactedToken = "[TOKEN]"
+	RedactedIP    = "[IP]"
+	RedactedEmail = "[EMAIL]"
+)
+
+// redactedKeys maps field names, lower case, to the placeholder of their values
+var redactedKeys = map[string]string{
+	"title":          RedactedTitle,
+	"titles":         RedactedTitle,
+	"original_title": RedactedTitle,
+	"movie":          RedactedTitle,
+	"show":           RedactedTitle,
+	"episode":        RedactedTitle,
+	"user":           RedactedUser,
+	"username":       RedactedUser,
+	"user_id":        RedactedUser,
+	"user_slug":      RedactedUser,
+	"slug":           RedactedTitle,
+	"email":          RedactedEmail,
+	"token":          RedactedToken,
+	"access_token":   RedactedToken,
+	"refresh_token":  RedactedToken,
+	"client_id":      RedactedToken,
+	"client_secret":  RedactedToken,
+	"secret":         RedactedToken,
+	"password":       RedactedToken,
+	"api_key":        RedactedToken,
+	"authorization":  RedactedToken,
+	"code":           RedactedToken,
+	"state":          RedactedToken,
+	"ip":             RedactedIP,
+	"client_ip":      RedactedIP,
+	"remote_ip":      RedactedIP,
+	"remote_addr":    RedactedIP,
+}
+
+var (
+	bearerPattern = regexp.MustCompile(`(?i)\b(bearer)\s+[A-Za-z0-9._~+/=-]+`)
+	jwtPattern    = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	// Query parameters carrying OAuth secrets
+	queryPattern = regexp.MustCompile(`(?i)([?&](?:code|state|access_token|refresh_token|client_secret|client_id|token)=)[^&\s"']+`)
+	// key=value and key: value pairs of redacted keys, quoted or not. code and
+	// state are left to queryPattern since "exit code: 1" is no secret.
+	pairPattern   = regexp.MustCompile(`(?i)\b(title|original_title|movie|show|episode|username|user_id|user_slug|user|slug|email|access_token|refresh_token|client_secret|client_id|token|secret|password|api_key)\b("?\s*[:=]\s*)("[^"]*"|'[^']*'|[^\s,;&)"']+)`)
+	traktUser     = regexp.MustCompile(`(?i)(/users/)[^/\s?#"']+`)
+	homeDir       = regexp.MustCompile(`(/home/|/Users/)[^/\s"']+`)
+	windowsHome   = regexp.MustCompile(`(?i)([a-z]:\\Users\\)[^\\\s"']+`)
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	ipv4Candidate = regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}\b`)
+	ipv6Candidate = regexp.MustCompile(`[0-9A-Fa-f]*:[0-9A-Fa-f]*:[0-9A-Fa-f:.]*`)
+	hexToken      = regexp.MustCompile(`\b[0-9A-Fa-f]{32,}\b`)
+)
+
+// RedactString removes viewing history and personal data from free text. The
+// text is first made safe for a single log line with SanitizeForLog, then
+// titles, user IDs, tokens, e-mail and IP addresses and home directories are
+// replaced by placeholders. Loopback addresses are kept.
+func RedactString(input string) string {
+	if input == "" {
+		return input
+	}
+
+	output := validation.SanitizeForLog(input)
+	output = bearerPattern.ReplaceAllString(output, "$1 "+RedactedToken)
+	output = jwtPattern.ReplaceAllString(output, RedactedToken)
+	output = queryPattern.ReplaceAllString(output, "${1}"+RedactedToken)
+	output = pairPattern.ReplaceAllStringFunc(output, redactPair)
+	output = traktUser.ReplaceAllString(output, "${1}"+RedactedUser)
+	output = homeDir.ReplaceAllString(output, "${1}"+RedactedUser)
+	output = windowsHome.ReplaceAllString(output, "${1}"+RedactedUser)
+	output = emailPattern.ReplaceAllString(output, RedactedEmail)
+	output = ipv4Candidate.ReplaceAllStringFunc(output, redactIP)
+	output = ipv6Candidate.ReplaceAllStringFunc(output, redactIP)
+	output = hexToken.ReplaceAllString(output, RedactedToken)
+	return output
+}
+
+// redactPair replaces the value of a key=value pair, keeping its quotes
+func redactPair(match string) string {
+	parts := pairPattern.FindStringSubmatch(match)
+	placeholder := redactedKeys[strings.ToLower(parts[1])]
+	value := parts[3]
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+		placeholder = value[:1] + placeholder + value[:1]
+	}
+	return parts[1] + parts[2] + placeholder
+}
+
+// redactIP replaces addresses other than loopback and unspecified ones
+func redactIP(candidate string) string {
+	ip := net.ParseIP(candidate)
+	if ip == nil || ip.IsLoopback() || ip.IsUnspecified() {
+		return candidate
+	}
+	return RedactedIP
+}
+
+// RedactFields returns a copy of log fields with personal data removed. String
+// values of known keys, such as title, user_id, access_token or remote_addr,
+// are replaced by a placeholder; other strings and errors go through
+// RedactString. Counts and other values are kept.
+func RedactFields(data map[string]interface{}) map[string]interface{} {
+	if data == nil {
+		return nil
+	}
+
+	redacted := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		redacted[key] = redactValue(key, value)
+	}
+	return redacted
+}
+
+func redactValue(key string, value interface{}) interface{} {
+	placeholder, sensitive := redactedKeys[strings.ToLower(key)]
+
+	switch v := value.(type) {
+	case string:
+		if sensitive && v != "" {
+			return placeholder
+		}
+		return RedactString(v)
+	case []string:
+		values := make([]string, len(v))
+		for i, item := range v {
+			values[i] = redactValue(key, item).(string)
+		}
+		return values
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, item := range v {
+			values[i] = redactValue(key, item)
+		}
+		return values
+	case map[string]interface{}:
+		return RedactFields(v)
+	case error:
+		if sensitive {
+			return placeholder
+		}
+		return RedactString(v.Error())
+	case fmt.Stringer:
+		if sensitive {
+			return placeholder
+		}
+		return RedactString(v.String())
+	default:
+		return value
+	}
+}
+
+// RedactLine redacts one line of a log file. JSON lines, written by the JSON
+// formatter or the audit log, are redacted field by field.
+func RedactLine(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "{") {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(trimmed), &fields); err == nil {
+			if data, err := json.Marshal(RedactFields(fields)); err == nil {
+				return string(data)
+			}
+		}
+	}
+	return RedactString(line)
+}
+
+// CopyRedacted copies a log file line by line, redacting each line
+func CopyRedacted(dst io.Writer, src io.Reader) error {
+	scanner := bufio.NewScanner(src)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		if _, err := fmt.Fprintln(dst, RedactLine(scanner.Text())); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// redactionHook redacts the message and fields of every entry in privacy mode
+type redactionHook struct{}
+
+// Levels implements logrus.Hook
+func (redactionHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (redactionHook) Fire(entry *logrus.Entry) error {
+	entry.Message = RedactString(entry.Message)
+	for key, value := range entry.Data {
+		entry.Data[key] = redactValue(key, value)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRedactString(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "quoted title",
+			input:   `exported title="The Matrix" year=1999`,
+			want:    []string{`title="[TITLE]"`, "year=1999"},
+			notWant: []string{"Matrix"},
+		},
+		{
+			name:    "bearer token",
+			input:   "Authorization: Bearer abc.def-123",
+			want:    []string{"Bearer [TOKEN]"},
+			notWant: []string{"abc.def-123"},
+		},
+		{
+			name:    "oauth query",
+			input:   "GET /callback?code=s3cr3t&state=xyz HTTP/1.1",
+			want:    []string{"code=[TOKEN]", "state=[TOKEN]"},
+			notWant: []string{"s3cr3t", "xyz"},
+		},
+		{
+			name:    "trakt user path",
+			input:   "GET https://api.trakt.tv/users/johnd/watched/movies",
+			want:    []string{"/users/[USER]/watched"},
+			notWant: []string{"johnd"},
+		},
+		{
+			name:  "addresses",
+			input: "client 192.168.1.20 and fd00::24 on 127.0.0.1",
+			want:  []string{"client [IP] and [IP] on 127.0.0.1"},
+		},
+		{
+			name:    "home directory and email",
+			input:   "wrote /home/alice/exports/watched.csv for alice@example.com",
+			want:    []string{"/home/[USER]/exports/watched.csv", "[EMAIL]"},
+			notWant: []string{"alice"},
+		},
+		{
+			name:  "exit code kept",
+			input: "exit code: 1 after 3 retries",
+			want:  []string{"exit code: 1 after 3 retries"},
+		},
+		{
+			name:    "newlines",
+			input:   "line one\nFake entry title=Forged",
+			want:    []string{"line one Fake entry"},
+			notWant: []string{"Forged"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RedactString(tt.input)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("Expected %q in %q", want, got)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("Expected %q to be redacted from %q", notWant, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRedactFields(t *testing.T) {
+	fields := map[string]interface{}{
+		"title":       "Inception",
+		"count":       42,
+		"user_id":     "johnd",
+		"remote_addr": "10.0.0.5:4431",
+		"error":       errors.New("request for /users/johnd failed"),
+		"output":      "Exporting title=\"Dune\"",
+		"nested":      map[string]interface{}{"access_token": "abc"},
+		"titles":      []string{"Heat", "Ronin"},
+	}
+
+	redacted := RedactFields(fields)
+
+	expected := map[string]interface{}{
+		"title":       RedactedTitle,
+		"count":       42,
+		"user_id":     RedactedUser,
+		"remote_addr": RedactedIP,
+		"error":       "request for /users/[USER] failed",
+		"output":      `Exporting title="[TITLE]"`,
+	}
+	for key, want := range expected {
+		if redacted[key] != want {
+			t.Errorf("Expected %s to be %v, got %v", key, want, redacted[key])
+		}
+	}
+	if nested := redacted["nested"].(map[string]interface{}); nested["access_token"] != RedactedToken {
+		t.Errorf("Expected nested token to be redacted, got %v", nested["access_token"])
+	}
+	if titles := redacted["titles"].([]string); titles[0] != RedactedTitle || titles[1] != RedactedTitle {
+		t.Errorf("Expected every title to be redacted, got %v", titles)
+	}
+	if fields["title"] != "Inception" {
+		t.Error("Expected the original fields to be left unchanged")
+	}
+}
+
+func TestRedactLineJSON(t *testing.T) {
+	line := `{"level":"info","msg":"watched Heat","title":"Heat","client_ip":"203.0.113.9","items":3}`
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(RedactLine(line)), &fields); err != nil {
+		t.Fatalf("Expected a JSON line, got error: %v", err)
+	}
+	if fields["title"] != RedactedTitle || fields["client_ip"] != RedactedIP {
+		t.Errorf("Expected title and IP to be redacted, got %v", fields)
+	}
+	if fields["items"] != float64(3) {
+		t.Errorf("Expected counts to be kept, got %v", fields["items"])
+	}
+}
+
+func TestCopyRedacted(t *testing.T) {
+	var buf bytes.Buffer
+	input := "first user=johnd\nsecond token=abcdef\n"
+	if err := CopyRedacted(&buf, strings.NewReader(input)); err != nil {
+		t.Fatalf("CopyRedacted failed: %v", err)
+	}
+
+	want := "first user=[USER]\nsecond token=[TOKEN]\n"
+	if buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestPrivacyMode(t *testing.T) {
+	log := NewLogger().(*StandardLogger)
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	log.SetTranslator(&MockTranslator{translations: map[string]string{
+		"export.movie": "Exported {title} for {user}",
+	}})
+
+	log.SetPrivacyMode(true)
+	if !log.PrivacyMode() {
+		t.Fatal("Expected privacy mode to be enabled")
+	}
+	log.Info("export.movie", map[string]interface{}{"title": "Alien", "user": "johnd"})
+	if output := buf.String(); strings.Contains(output, "Alien") || strings.Contains(output, "johnd") {
+		t.Errorf("Expected title and user to be redacted, got %q", output)
+	}
+
+	buf.Reset()
+	log.SetPrivacyMode(false)
+	log.Info("export.movie", map[string]interface{}{"title": "Alien", "user": "johnd"})
+	if output := buf.String(); !strings.Contains(output, "Exported Alien for johnd") {
+		t.Errorf("Expected the full message without privacy mode, got %q", output)
+	}
+}
@@ -48,6 +48,9 @@ func NewStructuredLogger(config monitoring.LoggingConfig) (*StructuredLogger, er
 	// Set log level
 	sl.configureLevel()
 
+	// Redact personal data from messages and fields
+	sl.SetPrivacyMode(config.Privacy)
+
 	// Add correlation ID hook if enabled
 	if config.CorrelationID {
 		sl.AddHook(&CorrelationIDHook{})
@@ -138,9 +141,13 @@ func (sl *StructuredLogger) AddHook(hook logrus.Hook) {
 	sl.hooks = append(sl.hooks, hook)
 }
 
-// WithCorrelationID creates a context with a correlation ID
+// WithCorrelationID creates a context with a correlation ID. Within a trace,
+// the trace ID is used so that logs and spans can be matched.
 func (sl *StructuredLogger) WithCorrelationID(ctx context.Context) context.Context {
 	correlationID := uuid.New().String()
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		correlationID = spanContext.TraceID().String()
+	}
 	return context.WithValue(ctx, CorrelationIDKey, correlationID)
 }
 
@@ -158,9 +165,11 @@ func (sl *StructuredLogger) WithField(key string, value interface{}) *logrus.Ent
 func (sl *StructuredLogger) WithContext(ctx context.Context) *logrus.Entry {
 	entry := sl.Logger.WithContext(ctx)
 	
-	// Add correlation ID if present
+	// Add correlation ID if present, falling back to the trace ID
 	if correlationID := ctx.Value(CorrelationIDKey); correlationID != nil {
 		entry = entry.WithField("correlation_id", correlationID)
+	} else if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		entry = entry.WithField("correlation_id", spanContext.TraceID().String())
 	}
 	
 	// Add trace information if present
@@ -231,6 +240,8 @@ func (hook *CorrelationIDHook) Fire(entry *logrus.Entry) error {
 	if entry.Context != nil {
 		if correlationID := entry.Context.Value(CorrelationIDKey); correlationID != nil {
 			entry.Data["correlation_id"] = correlationID
+		} else if spanContext := trace.SpanContextFromContext(entry.Context); spanContext.IsValid() {
+			entry.Data["correlation_id"] = spanContext.TraceID().String()
 		}
 	}
 	return nil
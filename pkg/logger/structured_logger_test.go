@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring"
+)
+
+func TestWithCorrelationIDUsesTraceID(t *testing.T) {
+	sl, err := NewStructuredLogger(monitoring.LoggingConfig{Level: "info", Format: "json", Output: "stdout", CorrelationID: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Outside a trace a new correlation ID is generated
+	ctx := sl.WithCorrelationID(context.Background())
+	if id, _ := ctx.Value(CorrelationIDKey).(string); id == "" {
+		t.Error("Expected a correlation ID")
+	}
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID})
+	tracedCtx := trace.ContextWithSpanContext(context.Background(), spanContext)
+
+	ctx = sl.WithCorrelationID(tracedCtx)
+	if id := ctx.Value(CorrelationIDKey); id != traceID.String() {
+		t.Errorf("Expected correlation ID %s, got %v", traceID, id)
+	}
+
+	// Log entries of a traced context without correlation ID get the trace ID
+	entry := sl.WithContext(tracedCtx)
+	if entry.Data["correlation_id"] != traceID.String() {
+		t.Errorf("Expected correlation_id field %s, got %v", traceID, entry.Data["correlation_id"])
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring"
@@ -23,6 +25,7 @@ type AlertManager struct {
 	rateLimitMutex    sync.RWMutex
 	alertHistory      []monitoring.Alert
 	alertHistoryMutex sync.RWMutex
+	templates         map[string]*template.Template
 }
 
 // NewAlertManager creates a new alert manager
@@ -64,6 +67,27 @@ func NewAlertManager(logger *logrus.Logger, config monitoring.AlertsConfig) *Ale
 		am.AddChannel(slackChannel)
 	}
 
+	// Channels configured by URL, in name order
+	names := make([]string, 0, len(config.Channels))
+	for name := range config.Channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		channel, err := ParseChannelURL(logger, name, config.Channels[name])
+		if err != nil {
+			logger.WithError(err).WithField("channel", name).Error("Invalid notification channel URL")
+			continue
+		}
+		am.AddChannel(channel)
+	}
+
+	templates, err := parseTemplates(config.Templates)
+	if err != nil {
+		logger.WithError(err).Error("Invalid alert templates, using the default messages")
+	}
+	am.templates = templates
+
 	return am
 }
 
@@ -84,7 +108,8 @@ func (am *AlertManager) RemoveChannel(channelName string) {
 	}
 }
 
-// SendAlert sends an alert through all enabled channels
+// SendAlert sends an alert through the enabled channels its routes select,
+// or all enabled channels when no route is configured
 func (am *AlertManager) SendAlert(ctx context.Context, alert monitoring.Alert) error {
 	// Check rate limiting
 	if am.isRateLimited(alert) {
@@ -96,12 +121,20 @@ func (am *AlertManager) SendAlert(ctx context.Context, alert monitoring.Alert) e
 		return nil
 	}
 
+	if tmpl, ok := am.templates[alert.Event]; ok {
+		if message, err := renderMessage(tmpl, alert); err != nil {
+			am.logger.WithError(err).WithField("event", alert.Event).Warn("Failed to render alert template")
+		} else {
+			alert.Message = message
+		}
+	}
+
 	// Add to alert history
 	am.addToHistory(alert)
 
-	// Send through all enabled channels
+	// Send through the routed channels
 	var errors []string
-	for _, channel := range am.channels {
+	for _, channel := range am.routedChannels(alert) {
 		if !channel.IsEnabled() {
 			continue
 		}
@@ -128,6 +161,31 @@ func (am *AlertManager) SendAlert(ctx context.Context, alert monitoring.Alert) e
 	return nil
 }
 
+// routedChannels returns the channels of the routes matching alert
+func (am *AlertManager) routedChannels(alert monitoring.Alert) []monitoring.NotificationChannel {
+	if len(am.config.Routes) == 0 {
+		return am.channels
+	}
+
+	selected := make(map[string]bool)
+	for _, route := range am.config.Routes {
+		if !routeMatches(route, alert) {
+			continue
+		}
+		for _, name := range route.Channels {
+			selected[name] = true
+		}
+	}
+
+	var channels []monitoring.NotificationChannel
+	for _, channel := range am.channels {
+		if selected[channel.Name()] {
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
+
 // CreateAlert creates a new alert
 func (am *AlertManager) CreateAlert(level monitoring.AlertLevel, title, message, source string, metadata map[string]interface{}) monitoring.Alert {
 	return monitoring.Alert{
@@ -144,38 +202,64 @@ func (am *AlertManager) CreateAlert(level monitoring.AlertLevel, title, message,
 
 // SendExportAlert sends an alert for export events
 func (am *AlertManager) SendExportAlert(ctx context.Context, exportType, status string, duration time.Duration, err error) {
+	am.SendExportSummary(ctx, ExportSummary{ExportType: exportType, Duration: duration, Err: err})
+}
+
+// SendExportSummary sends an alert for a finished export run, with the
+// number of items written when it succeeded
+func (am *AlertManager) SendExportSummary(ctx context.Context, summary ExportSummary) error {
 	var alert monitoring.Alert
+	duration := summary.Duration.Round(time.Millisecond)
 
+	total := 0
+	for _, count := range summary.Items {
+		total += count
+	}
 	metadata := map[string]interface{}{
-		"export_type": exportType,
+		"export_type": summary.ExportType,
 		"duration":    duration.String(),
+		"items":       total,
+		"counts":      summary.Items,
+		"skipped":     summary.Skipped,
+	}
+	if summary.Mode != "" {
+		metadata["mode"] = summary.Mode
 	}
 
-	if err != nil {
-		metadata["error"] = err.Error()
+	if summary.Err != nil {
+		metadata["error"] = summary.Err.Error()
 		alert = am.CreateAlert(
 			monitoring.AlertLevelError,
-			fmt.Sprintf("Export Failed: %s", exportType),
-			fmt.Sprintf("Export of type %s failed after %v: %v", exportType, duration, err),
+			fmt.Sprintf("Export Failed: %s", summary.ExportType),
+			fmt.Sprintf("Export of type %s failed after %v: %v", summary.ExportType, duration, summary.Err),
 			"exporter",
 			metadata,
 		)
+		alert.Event = EventExportFailed
 	} else {
 		level := monitoring.AlertLevelInfo
-		if duration > 5*time.Minute {
+		if summary.Duration > 5*time.Minute {
 			level = monitoring.AlertLevelWarning
 		}
 
+		message := fmt.Sprintf("Export of type %s completed successfully in %v", summary.ExportType, duration)
+		if len(summary.Items) > 0 {
+			message += fmt.Sprintf(": %d items (%s)", total, formatCounts(summary.Items))
+		}
+		if len(summary.Skipped) > 0 {
+			message += fmt.Sprintf(", unchanged: %s", strings.Join(summary.Skipped, ", "))
+		}
 		alert = am.CreateAlert(
 			level,
-			fmt.Sprintf("Export Completed: %s", exportType),
-			fmt.Sprintf("Export of type %s completed successfully in %v", exportType, duration),
+			fmt.Sprintf("Export Completed: %s", summary.ExportType),
+			message,
 			"exporter",
 			metadata,
 		)
+		alert.Event = EventExportSucceeded
 	}
 
-	am.SendAlert(ctx, alert)
+	return am.SendAlert(ctx, alert)
 }
 
 // SendHealthAlert sends an alert for health check events
@@ -208,6 +292,7 @@ func (am *AlertManager) SendHealthAlert(ctx context.Context, component string, s
 			"status":    string(status),
 		},
 	)
+	alert.Event = EventHealthChanged
 
 	am.SendAlert(ctx, alert)
 }
@@ -0,0 +1,391 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring"
+	"github.com/sirupsen/logrus"
+)
+
+// NtfyConfig represents ntfy configuration
+type NtfyConfig struct {
+	Name     string
+	TopicURL string // e.g. https://ntfy.sh/my-exports
+	Username string
+	Password string
+	Token    string
+	Priority int // overrides the priority derived from the alert level
+}
+
+// NtfyChannel implements ntfy notifications
+type NtfyChannel struct {
+	logger *logrus.Logger
+	config NtfyConfig
+	client *http.Client
+}
+
+// NewNtfyChannel creates a new ntfy notification channel
+func NewNtfyChannel(logger *logrus.Logger, config NtfyConfig) *NtfyChannel {
+	return &NtfyChannel{
+		logger: logger,
+		config: config,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// SendAlert publishes an alert to the ntfy topic
+func (nc *NtfyChannel) SendAlert(ctx context.Context, alert monitoring.Alert) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", nc.config.TopicURL, strings.NewReader(alert.Message))
+	if err != nil {
+		return fmt.Errorf("failed to create ntfy request: %w", err)
+	}
+
+	priority := nc.config.Priority
+	if priority == 0 {
+		priority = map[monitoring.AlertLevel]int{
+			monitoring.AlertLevelInfo:     3,
+			monitoring.AlertLevelWarning:  4,
+			monitoring.AlertLevelError:    4,
+			monitoring.AlertLevelCritical: 5,
+		}[alert.Level]
+	}
+	req.Header.Set("Title", alert.Title)
+	req.Header.Set("Tags", string(alert.Level)+","+alert.Source)
+	if priority != 0 {
+		req.Header.Set("Priority", strconv.Itoa(priority))
+	}
+	switch {
+	case nc.config.Token != "":
+		req.Header.Set("Authorization", "Bearer "+nc.config.Token)
+	case nc.config.Username != "":
+		req.SetBasicAuth(nc.config.Username, nc.config.Password)
+	}
+
+	return send(nc.client, req, "ntfy")
+}
+
+// Name returns the channel name
+func (nc *NtfyChannel) Name() string {
+	return channelName(nc.config.Name, "ntfy")
+}
+
+// IsEnabled returns true if the channel is enabled
+func (nc *NtfyChannel) IsEnabled() bool {
+	return nc.config.TopicURL != ""
+}
+
+// GotifyConfig represents Gotify configuration
+type GotifyConfig struct {
+	Name      string
+	ServerURL string // e.g. https://gotify.example.com
+	AppToken  string
+	Priority  int // overrides the priority derived from the alert level
+}
+
+// GotifyChannel implements Gotify notifications
+type GotifyChannel struct {
+	logger *logrus.Logger
+	config GotifyConfig
+	client *http.Client
+}
+
+// NewGotifyChannel creates a new Gotify notification channel
+func NewGotifyChannel(logger *logrus.Logger, config GotifyConfig) *GotifyChannel {
+	return &GotifyChannel{
+		logger: logger,
+		config: config,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// SendAlert sends an alert as a Gotify message
+func (gc *GotifyChannel) SendAlert(ctx context.Context, alert monitoring.Alert) error {
+	priority := gc.config.Priority
+	if priority == 0 {
+		priority = map[monitoring.AlertLevel]int{
+			monitoring.AlertLevelInfo:     2,
+			monitoring.AlertLevelWarning:  5,
+			monitoring.AlertLevelError:    8,
+			monitoring.AlertLevelCritical: 10,
+		}[alert.Level]
+	}
+
+	payload := map[string]interface{}{
+		"title":    alert.Title,
+		"message":  alert.Message,
+		"priority": priority,
+	}
+	req, err := newJSONRequest(ctx, "POST", strings.TrimSuffix(gc.config.ServerURL, "/")+"/message", payload)
+	if err != nil {
+		return fmt.Errorf("failed to create Gotify request: %w", err)
+	}
+	req.Header.Set("X-Gotify-Key", gc.config.AppToken)
+
+	return send(gc.client, req, "Gotify")
+}
+
+// Name returns the channel name
+func (gc *GotifyChannel) Name() string {
+	return channelName(gc.config.Name, "gotify")
+}
+
+// IsEnabled returns true if the channel is enabled
+func (gc *GotifyChannel) IsEnabled() bool {
+	return gc.config.ServerURL != "" && gc.config.AppToken != ""
+}
+
+// DiscordConfig represents Discord webhook configuration
+type DiscordConfig struct {
+	Name       string
+	WebhookURL string // https://discord.com/api/webhooks/<id>/<token>
+	Username   string
+}
+
+// DiscordChannel implements Discord webhook notifications
+type DiscordChannel struct {
+	logger *logrus.Logger
+	config DiscordConfig
+	client *http.Client
+}
+
+// NewDiscordChannel creates a new Discord notification channel
+func NewDiscordChannel(logger *logrus.Logger, config DiscordConfig) *DiscordChannel {
+	return &DiscordChannel{
+		logger: logger,
+		config: config,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// SendAlert posts an alert as a Discord embed
+func (dc *DiscordChannel) SendAlert(ctx context.Context, alert monitoring.Alert) error {
+	color := map[monitoring.AlertLevel]int{
+		monitoring.AlertLevelInfo:     0x2ecc71,
+		monitoring.AlertLevelWarning:  0xf1c40f,
+		monitoring.AlertLevelError:    0xe74c3c,
+		monitoring.AlertLevelCritical: 0x992d22,
+	}[alert.Level]
+
+	payload := map[string]interface{}{
+		"embeds": []map[string]interface{}{
+			{
+				"title":       fmt.Sprintf("%s %s", levelEmoji(alert.Level), alert.Title),
+				"description": alert.Message,
+				"color":       color,
+				"timestamp":   alert.Timestamp.Format(time.RFC3339),
+				"footer": map[string]string{
+					"text": fmt.Sprintf("%s · %s", alert.Source, alert.Level),
+				},
+			},
+		},
+	}
+	if dc.config.Username != "" {
+		payload["username"] = dc.config.Username
+	}
+
+	req, err := newJSONRequest(ctx, "POST", dc.config.WebhookURL, payload)
+	if err != nil {
+		return fmt.Errorf("failed to create Discord request: %w", err)
+	}
+
+	return send(dc.client, req, "Discord")
+}
+
+// Name returns the channel name
+func (dc *DiscordChannel) Name() string {
+	return channelName(dc.config.Name, "discord")
+}
+
+// IsEnabled returns true if the channel is enabled
+func (dc *DiscordChannel) IsEnabled() bool {
+	return dc.config.WebhookURL != ""
+}
+
+// TelegramConfig represents Telegram bot configuration
+type TelegramConfig struct {
+	Name     string
+	APIURL   string // defaults to https://api.telegram.org
+	BotToken string
+	ChatIDs  []string
+}
+
+// TelegramChannel implements Telegram bot notifications
+type TelegramChannel struct {
+	logger *logrus.Logger
+	config TelegramConfig
+	client *http.Client
+}
+
+// NewTelegramChannel creates a new Telegram notification channel
+func NewTelegramChannel(logger *logrus.Logger, config TelegramConfig) *TelegramChannel {
+	if config.APIURL == "" {
+		config.APIURL = "https://api.telegram.org"
+	}
+	return &TelegramChannel{
+		logger: logger,
+		config: config,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// SendAlert sends an alert to every configured chat
+func (tc *TelegramChannel) SendAlert(ctx context.Context, alert monitoring.Alert) error {
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimSuffix(tc.config.APIURL, "/"), tc.config.BotToken)
+	text := fmt.Sprintf("%s %s\n\n%s", levelEmoji(alert.Level), alert.Title, alert.Message)
+
+	for _, chatID := range tc.config.ChatIDs {
+		payload := map[string]interface{}{
+			"chat_id":                  chatID,
+			"text":                     text,
+			"disable_web_page_preview": true,
+		}
+		req, err := newJSONRequest(ctx, "POST", endpoint, payload)
+		if err != nil {
+			return fmt.Errorf("failed to create Telegram request: %w", err)
+		}
+		if err := send(tc.client, req, "Telegram"); err != nil {
+			return fmt.Errorf("chat %s: %w", chatID, err)
+		}
+	}
+	return nil
+}
+
+// Name returns the channel name
+func (tc *TelegramChannel) Name() string {
+	return channelName(tc.config.Name, "telegram")
+}
+
+// IsEnabled returns true if the channel is enabled
+func (tc *TelegramChannel) IsEnabled() bool {
+	return tc.config.BotToken != "" && len(tc.config.ChatIDs) > 0
+}
+
+// MatrixConfig represents Matrix configuration
+type MatrixConfig struct {
+	Name          string
+	HomeserverURL string // e.g. https://matrix.org
+	AccessToken   string
+	RoomID        string // e.g. !abcdef:matrix.org
+}
+
+// MatrixChannel implements Matrix room notifications
+type MatrixChannel struct {
+	logger *logrus.Logger
+	config MatrixConfig
+	client *http.Client
+}
+
+// NewMatrixChannel creates a new Matrix notification channel
+func NewMatrixChannel(logger *logrus.Logger, config MatrixConfig) *MatrixChannel {
+	return &MatrixChannel{
+		logger: logger,
+		config: config,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// SendAlert sends an alert as a notice to the Matrix room
+func (mc *MatrixChannel) SendAlert(ctx context.Context, alert monitoring.Alert) error {
+	// The transaction ID makes retries of the same alert idempotent
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		strings.TrimSuffix(mc.config.HomeserverURL, "/"),
+		url.PathEscape(mc.config.RoomID),
+		url.PathEscape(alert.ID))
+
+	payload := map[string]interface{}{
+		"msgtype": "m.notice",
+		"body":    fmt.Sprintf("%s %s\n\n%s", levelEmoji(alert.Level), alert.Title, alert.Message),
+	}
+	req, err := newJSONRequest(ctx, "PUT", endpoint, payload)
+	if err != nil {
+		return fmt.Errorf("failed to create Matrix request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+mc.config.AccessToken)
+
+	return send(mc.client, req, "Matrix")
+}
+
+// Name returns the channel name
+func (mc *MatrixChannel) Name() string {
+	return channelName(mc.config.Name, "matrix")
+}
+
+// IsEnabled returns true if the channel is enabled
+func (mc *MatrixChannel) IsEnabled() bool {
+	return mc.config.HomeserverURL != "" && mc.config.AccessToken != "" && mc.config.RoomID != ""
+}
+
+// newJSONRequest creates a request with payload as JSON body
+func newJSONRequest(ctx context.Context, method, endpoint string, payload interface{}) (*http.Request, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// send performs a notification request and fails on an error status
+func send(client *http.Client, req *http.Request, service string) error {
+	req.Header.Set("User-Agent", "Export-Trakt-Monitor/1.0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URLs of Discord and Telegram carry their token, keep it out of logs
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to send %s notification: %w", service, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("%s returned status %d", service, resp.StatusCode)
+	}
+	return nil
+}
+
+func channelName(name, fallback string) string {
+	if name != "" {
+		return name
+	}
+	return fallback
+}
+
+func levelEmoji(level monitoring.AlertLevel) string {
+	switch level {
+	case monitoring.AlertLevelCritical:
+		return "🚨"
+	case monitoring.AlertLevelError:
+		return "❌"
+	case monitoring.AlertLevelWarning:
+		return "⚠️"
+	default:
+		return "ℹ️"
+	}
+}
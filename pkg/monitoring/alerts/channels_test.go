@@ -0,0 +1,158 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capturedRequest is a request received by a test notification service
+type capturedRequest struct {
+	method string
+	path   string
+	header http.Header
+	body   string
+}
+
+func newCaptureServer(t *testing.T, status int) (*httptest.Server, *[]capturedRequest) {
+	var requests []capturedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		requests = append(requests, capturedRequest{
+			method: r.Method,
+			path:   r.URL.Path,
+			header: r.Header.Clone(),
+			body:   string(body),
+		})
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func testAlert() monitoring.Alert {
+	return monitoring.Alert{
+		ID:        "exporter_1",
+		Level:     monitoring.AlertLevelError,
+		Title:     "Export Failed: all",
+		Message:   "Export of type all failed after 2s: 503",
+		Timestamp: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Source:    "exporter",
+	}
+}
+
+func decodeJSON(t *testing.T, body string) map[string]interface{} {
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(body), &payload))
+	return payload
+}
+
+func TestNtfyChannel(t *testing.T) {
+	server, requests := newCaptureServer(t, http.StatusOK)
+	channel := NewNtfyChannel(logrus.New(), NtfyConfig{Name: "phone", TopicURL: server.URL + "/exports", Token: "tk_secret"})
+
+	assert.Equal(t, "phone", channel.Name())
+	assert.True(t, channel.IsEnabled())
+	require.NoError(t, channel.SendAlert(context.Background(), testAlert()))
+
+	require.Len(t, *requests, 1)
+	req := (*requests)[0]
+	assert.Equal(t, "POST", req.method)
+	assert.Equal(t, "/exports", req.path)
+	assert.Equal(t, "Export Failed: all", req.header.Get("Title"))
+	assert.Equal(t, "4", req.header.Get("Priority"))
+	assert.Equal(t, "error,exporter", req.header.Get("Tags"))
+	assert.Equal(t, "Bearer tk_secret", req.header.Get("Authorization"))
+	assert.Equal(t, "Export of type all failed after 2s: 503", req.body)
+}
+
+func TestGotifyChannel(t *testing.T) {
+	server, requests := newCaptureServer(t, http.StatusOK)
+	channel := NewGotifyChannel(logrus.New(), GotifyConfig{ServerURL: server.URL + "/gotify/", AppToken: "app"})
+
+	assert.Equal(t, "gotify", channel.Name())
+	require.NoError(t, channel.SendAlert(context.Background(), testAlert()))
+
+	require.Len(t, *requests, 1)
+	req := (*requests)[0]
+	assert.Equal(t, "/gotify/message", req.path)
+	assert.Equal(t, "app", req.header.Get("X-Gotify-Key"))
+	payload := decodeJSON(t, req.body)
+	assert.Equal(t, "Export Failed: all", payload["title"])
+	assert.Equal(t, float64(8), payload["priority"])
+}
+
+func TestDiscordChannel(t *testing.T) {
+	server, requests := newCaptureServer(t, http.StatusNoContent)
+	channel := NewDiscordChannel(logrus.New(), DiscordConfig{WebhookURL: server.URL + "/api/webhooks/1/abc", Username: "Trakt"})
+
+	require.NoError(t, channel.SendAlert(context.Background(), testAlert()))
+
+	require.Len(t, *requests, 1)
+	payload := decodeJSON(t, (*requests)[0].body)
+	assert.Equal(t, "Trakt", payload["username"])
+	embed := payload["embeds"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "❌ Export Failed: all", embed["title"])
+	assert.Equal(t, "Export of type all failed after 2s: 503", embed["description"])
+	assert.Equal(t, "2026-01-02T03:04:05Z", embed["timestamp"])
+}
+
+func TestTelegramChannel(t *testing.T) {
+	server, requests := newCaptureServer(t, http.StatusOK)
+	channel := NewTelegramChannel(logrus.New(), TelegramConfig{APIURL: server.URL, BotToken: "123:ABC", ChatIDs: []string{"42", "-100"}})
+
+	assert.Equal(t, "telegram", channel.Name())
+	require.NoError(t, channel.SendAlert(context.Background(), testAlert()))
+
+	require.Len(t, *requests, 2)
+	for i, chatID := range []string{"42", "-100"} {
+		assert.Equal(t, "/bot123:ABC/sendMessage", (*requests)[i].path)
+		payload := decodeJSON(t, (*requests)[i].body)
+		assert.Equal(t, chatID, payload["chat_id"])
+		assert.Equal(t, "❌ Export Failed: all\n\nExport of type all failed after 2s: 503", payload["text"])
+	}
+}
+
+func TestMatrixChannel(t *testing.T) {
+	server, requests := newCaptureServer(t, http.StatusOK)
+	channel := NewMatrixChannel(logrus.New(), MatrixConfig{HomeserverURL: server.URL, AccessToken: "syt_token", RoomID: "!room:example.org"})
+
+	require.NoError(t, channel.SendAlert(context.Background(), testAlert()))
+
+	require.Len(t, *requests, 1)
+	req := (*requests)[0]
+	assert.Equal(t, "PUT", req.method)
+	assert.Equal(t, "/_matrix/client/v3/rooms/!room:example.org/send/m.room.message/exporter_1", req.path)
+	assert.Equal(t, "Bearer syt_token", req.header.Get("Authorization"))
+	payload := decodeJSON(t, req.body)
+	assert.Equal(t, "m.notice", payload["msgtype"])
+	assert.True(t, strings.HasPrefix(payload["body"].(string), "❌ Export Failed: all"))
+}
+
+func TestChannelErrorStatus(t *testing.T) {
+	server, _ := newCaptureServer(t, http.StatusUnauthorized)
+	channel := NewDiscordChannel(logrus.New(), DiscordConfig{WebhookURL: server.URL})
+
+	err := channel.SendAlert(context.Background(), testAlert())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Discord returned status 401")
+}
+
+func TestChannelErrorHidesToken(t *testing.T) {
+	channel := NewTelegramChannel(logrus.New(), TelegramConfig{APIURL: "http://127.0.0.1:1", BotToken: "123:SECRET", ChatIDs: []string{"42"}})
+
+	err := channel.SendAlert(context.Background(), testAlert())
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "SECRET")
+}
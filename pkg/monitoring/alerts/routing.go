@@ -0,0 +1,113 @@
+package alerts
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring"
+)
+
+// Alert events, the keys of the [alerts.templates] section
+const (
+	EventExportSucceeded    = "export_succeeded"
+	EventExportFailed       = "export_failed"
+	EventTokenExpiring      = "token_expiring"
+	EventTokenRefreshFailed = "token_refresh_failed"
+	EventHealthChanged      = "health_changed"
+	EventRotationDue        = "credential_rotation_due"
+)
+
+var events = []string{EventExportSucceeded, EventExportFailed, EventTokenExpiring, EventTokenRefreshFailed, EventHealthChanged, EventRotationDue}
+
+// ExportSummary describes a finished export run
+type ExportSummary struct {
+	ExportType string
+	Mode       string
+	Duration   time.Duration
+	Items      map[string]int // items written per export type
+	Skipped    []string       // datasets unchanged since the last export
+	Err        error
+}
+
+// parseTemplates parses the message templates of the [alerts.templates]
+// section, keyed by event
+func parseTemplates(sources map[string]string) (map[string]*template.Template, error) {
+	templates := make(map[string]*template.Template, len(sources))
+	for event, source := range sources {
+		if !isEvent(event) {
+			return nil, fmt.Errorf("unknown template event %s, expected one of %s", event, strings.Join(events, ", "))
+		}
+		tmpl, err := template.New(event).Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %w", event, err)
+		}
+		templates[event] = tmpl
+	}
+	return templates, nil
+}
+
+// renderMessage returns the message of alert rendered with the template of
+// its event. Templates see the alert metadata along with title, message,
+// level, source and time.
+func renderMessage(tmpl *template.Template, alert monitoring.Alert) (string, error) {
+	data := make(map[string]interface{}, len(alert.Metadata)+5)
+	for key, value := range alert.Metadata {
+		data[key] = value
+	}
+	data["title"] = alert.Title
+	data["message"] = alert.Message
+	data["level"] = string(alert.Level)
+	data["source"] = alert.Source
+	data["time"] = alert.Timestamp
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// routeMatches reports whether an alert matches the levels and sources of route
+func routeMatches(route monitoring.AlertRoute, alert monitoring.Alert) bool {
+	return matchesAny(route.Levels, string(alert.Level)) && matchesAny(route.Sources, alert.Source)
+}
+
+func matchesAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func isEvent(name string) bool {
+	for _, event := range events {
+		if event == name {
+			return true
+		}
+	}
+	return false
+}
+
+// formatCounts renders item counts as "movies: 12, ratings: 3"
+func formatCounts(items map[string]int) string {
+	types := make([]string, 0, len(items))
+	for exportType := range items {
+		types = append(types, exportType)
+	}
+	sort.Strings(types)
+
+	parts := make([]string, len(types))
+	for i, exportType := range types {
+		parts[i] = fmt.Sprintf("%s: %d", exportType, items[exportType])
+	}
+	return strings.Join(parts, ", ")
+}
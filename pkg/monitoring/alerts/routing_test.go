@@ -0,0 +1,99 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertManager_Routes(t *testing.T) {
+	am := NewAlertManager(logrus.New(), monitoring.AlertsConfig{
+		Routes: []monitoring.AlertRoute{
+			{Levels: []string{"error", "critical"}, Channels: []string{"pager"}},
+			{Sources: []string{"token_refresher"}, Channels: []string{"chat"}},
+		},
+	})
+	pager := &MockNotificationChannel{name: "pager", enabled: true}
+	chat := &MockNotificationChannel{name: "chat", enabled: true}
+	am.AddChannel(pager)
+	am.AddChannel(chat)
+
+	send := func(level monitoring.AlertLevel, source string) {
+		require.NoError(t, am.SendAlert(context.Background(), am.CreateAlert(level, string(level)+source, "", source, nil)))
+	}
+	send(monitoring.AlertLevelInfo, "exporter")
+	send(monitoring.AlertLevelError, "exporter")
+	send(monitoring.AlertLevelWarning, "token_refresher")
+	send(monitoring.AlertLevelCritical, "token_refresher")
+
+	assert.Len(t, pager.sentAlerts, 2)
+	assert.Len(t, chat.sentAlerts, 2)
+	assert.Equal(t, monitoring.AlertLevelCritical, chat.sentAlerts[1].Level)
+	assert.Len(t, am.GetAlertHistory(), 4, "unrouted alerts are still recorded")
+}
+
+func TestAlertManager_SendExportSummary(t *testing.T) {
+	am := NewAlertManager(logrus.New(), monitoring.AlertsConfig{})
+	channel := &MockNotificationChannel{name: "test", enabled: true}
+	am.AddChannel(channel)
+
+	err := am.SendExportSummary(context.Background(), ExportSummary{
+		ExportType: "all",
+		Mode:       "normal",
+		Duration:   1500 * time.Millisecond,
+		Items:      map[string]int{"watched": 12, "ratings": 3},
+		Skipped:    []string{"watchlist"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, channel.sentAlerts, 1)
+	alert := channel.sentAlerts[0]
+	assert.Equal(t, EventExportSucceeded, alert.Event)
+	assert.Equal(t, "Export of type all completed successfully in 1.5s: 15 items (ratings: 3, watched: 12), unchanged: watchlist", alert.Message)
+	assert.Equal(t, 15, alert.Metadata["items"])
+
+	require.NoError(t, am.SendExportSummary(context.Background(), ExportSummary{ExportType: "all", Err: errors.New("HTTP 503")}))
+	assert.Equal(t, EventExportFailed, channel.sentAlerts[1].Event)
+	assert.Equal(t, monitoring.AlertLevelError, channel.sentAlerts[1].Level)
+}
+
+func TestAlertManager_Templates(t *testing.T) {
+	am := NewAlertManager(logrus.New(), monitoring.AlertsConfig{
+		Templates: map[string]string{
+			EventExportSucceeded: "{{.export_type}}: {{.items}} items{{range $type, $n := .counts}} {{$type}}={{$n}}{{end}}",
+			EventExportFailed:    "{{.level}} from {{.source}}: {{.error}}",
+			EventTokenExpiring:   "Token expires in {{.expires_in}}",
+		},
+	})
+	channel := &MockNotificationChannel{name: "test", enabled: true}
+	am.AddChannel(channel)
+
+	require.NoError(t, am.SendExportSummary(context.Background(), ExportSummary{
+		ExportType: "all",
+		Items:      map[string]int{"watched": 2, "ratings": 1},
+	}))
+	require.NoError(t, am.SendExportSummary(context.Background(), ExportSummary{ExportType: "all", Err: errors.New("boom")}))
+	am.SendExportAlert(context.Background(), "movies", "success", time.Second, nil)
+
+	require.Len(t, channel.sentAlerts, 3)
+	assert.Equal(t, "all: 3 items ratings=1 watched=2", channel.sentAlerts[0].Message)
+	assert.Equal(t, "error from exporter: boom", channel.sentAlerts[1].Message)
+	assert.Equal(t, "movies: 0 items", channel.sentAlerts[2].Message)
+
+	alert := am.CreateAlert(monitoring.AlertLevelWarning, "Trakt token expiring", "original", "token_refresher",
+		map[string]interface{}{"expires_in": "71h0m0s"})
+	alert.Event = EventTokenExpiring
+	require.NoError(t, am.SendAlert(context.Background(), alert))
+	assert.Equal(t, "Token expires in 71h0m0s", channel.sentAlerts[3].Message)
+
+	alert.Event = ""
+	alert.Title = "Trakt token refresh failed"
+	require.NoError(t, am.SendAlert(context.Background(), alert))
+	assert.Equal(t, "original", channel.sentAlerts[4].Message, "alerts without template keep their message")
+}
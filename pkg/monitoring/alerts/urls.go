@@ -0,0 +1,234 @@
+package alerts
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring"
+	"github.com/sirupsen/logrus"
+)
+
+// Channel URL schemes, following the Apprise syntax. The trailing s selects
+// HTTPS for self-hosted services.
+//
+//	ntfy://topic                           ntfy.sh
+//	ntfy[s]://[user:password@]host/topic   ?token=...&priority=1-5
+//	gotify[s]://host[/path]/apptoken       ?priority=0-10
+//	discord://webhook_id/webhook_token     ?username=...
+//	tgram://bot_token/chat_id[/chat_id]
+//	matrix[s]://access_token@host/room_id
+//	slack://token_a/token_b/token_c
+//	json[s]://host/path                    JSON webhook
+var channelSchemes = []string{"ntfy", "ntfys", "gotify", "gotifys", "discord", "tgram", "telegram", "matrix", "matrixs", "slack", "json", "jsons"}
+
+// ParseChannelURL creates the notification channel described by rawURL,
+// named name for alert routes
+func ParseChannelURL(logger *logrus.Logger, name, rawURL string) (monitoring.NotificationChannel, error) {
+	scheme, rest, ok := strings.Cut(strings.TrimSpace(rawURL), "://")
+	if !ok {
+		return nil, fmt.Errorf("missing scheme, expected one of %s", strings.Join(channelSchemes, ", "))
+	}
+	scheme = strings.ToLower(scheme)
+
+	// Telegram bot tokens contain a colon, which url.Parse takes for a port
+	if scheme == "tgram" || scheme == "telegram" {
+		parts := splitPath(rest)
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("expected tgram://bot_token/chat_id")
+		}
+		return NewTelegramChannel(logger, TelegramConfig{Name: name, BotToken: parts[0], ChatIDs: parts[1:]}), nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		// The error would repeat the URL and its credentials
+		return nil, fmt.Errorf("malformed %s URL", scheme)
+	}
+	httpScheme := "http"
+	switch scheme {
+	case "ntfys", "gotifys", "matrixs", "jsons":
+		httpScheme = "https"
+	}
+	path := splitPath(u.Path)
+	query := u.Query()
+
+	switch scheme {
+	case "ntfy", "ntfys":
+		config := NtfyConfig{Name: name, Token: query.Get("token")}
+		switch {
+		case u.Host != "" && len(path) == 0:
+			config.TopicURL = "https://ntfy.sh/" + u.Host
+		case u.Host != "" && len(path) == 1:
+			config.TopicURL = fmt.Sprintf("%s://%s/%s", httpScheme, u.Host, path[0])
+		default:
+			return nil, fmt.Errorf("expected %s://[host/]topic", scheme)
+		}
+		if u.User != nil {
+			config.Username = u.User.Username()
+			config.Password, _ = u.User.Password()
+		}
+		if config.Priority, err = queryInt(query, "priority", 1, 5); err != nil {
+			return nil, err
+		}
+		return NewNtfyChannel(logger, config), nil
+
+	case "gotify", "gotifys":
+		if u.Host == "" || len(path) == 0 {
+			return nil, fmt.Errorf("expected %s://host/apptoken", scheme)
+		}
+		config := GotifyConfig{
+			Name:      name,
+			ServerURL: fmt.Sprintf("%s://%s", httpScheme, u.Host),
+			AppToken:  path[len(path)-1],
+		}
+		if len(path) > 1 {
+			config.ServerURL += "/" + strings.Join(path[:len(path)-1], "/")
+		}
+		if config.Priority, err = queryInt(query, "priority", 0, 10); err != nil {
+			return nil, err
+		}
+		return NewGotifyChannel(logger, config), nil
+
+	case "discord":
+		if u.Host == "" || len(path) != 1 {
+			return nil, fmt.Errorf("expected discord://webhook_id/webhook_token")
+		}
+		return NewDiscordChannel(logger, DiscordConfig{
+			Name:       name,
+			WebhookURL: fmt.Sprintf("https://discord.com/api/webhooks/%s/%s", u.Host, path[0]),
+			Username:   query.Get("username"),
+		}), nil
+
+	case "matrix", "matrixs":
+		if u.User == nil || u.Host == "" || len(path) != 1 {
+			return nil, fmt.Errorf("expected %s://access_token@host/room_id", scheme)
+		}
+		if u.Fragment != "" {
+			return nil, fmt.Errorf("room aliases are not supported, use the room ID (!id:server)")
+		}
+		return NewMatrixChannel(logger, MatrixConfig{
+			Name:          name,
+			HomeserverURL: fmt.Sprintf("%s://%s", httpScheme, u.Host),
+			AccessToken:   u.User.Username(),
+			RoomID:        path[0],
+		}), nil
+
+	case "slack":
+		if u.Host == "" || len(path) != 2 {
+			return nil, fmt.Errorf("expected slack://token_a/token_b/token_c")
+		}
+		return &namedChannel{
+			NotificationChannel: NewSlackChannel(logger, SlackConfig{
+				WebhookURL: fmt.Sprintf("https://hooks.slack.com/services/%s/%s/%s", u.Host, path[0], path[1]),
+				Username:   "Export Trakt Monitor",
+			}),
+			name: channelName(name, "slack"),
+		}, nil
+
+	case "json", "jsons":
+		if u.Host == "" {
+			return nil, fmt.Errorf("expected %s://host/path", scheme)
+		}
+		target := *u
+		target.Scheme = httpScheme
+		return &namedChannel{
+			NotificationChannel: NewWebhookChannel(logger, target.String()),
+			name:                channelName(name, "webhook"),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported scheme %s, expected one of %s", scheme, strings.Join(channelSchemes, ", "))
+}
+
+// ValidateConfig checks the channel URLs, routes and templates of an
+// [alerts] section
+func ValidateConfig(config monitoring.AlertsConfig) error {
+	names := make([]string, 0, len(config.Channels))
+	for name := range config.Channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	logger := logrus.New()
+	for _, name := range names {
+		if _, err := ParseChannelURL(logger, name, config.Channels[name]); err != nil {
+			return fmt.Errorf("channel %s: %w", name, err)
+		}
+	}
+
+	for i, route := range config.Routes {
+		if len(route.Channels) == 0 {
+			return fmt.Errorf("route %d has no channels", i+1)
+		}
+		for _, level := range route.Levels {
+			if !isAlertLevel(level) {
+				return fmt.Errorf("route %d: invalid level %s", i+1, level)
+			}
+		}
+		for _, channel := range route.Channels {
+			if _, ok := config.Channels[channel]; !ok && !isBuiltinChannel(config, channel) {
+				return fmt.Errorf("route %d: unknown channel %s", i+1, channel)
+			}
+		}
+	}
+
+	_, err := parseTemplates(config.Templates)
+	return err
+}
+
+// namedChannel renames a channel, so that routes can tell apart several
+// channels of the same kind
+type namedChannel struct {
+	monitoring.NotificationChannel
+	name string
+}
+
+// Name returns the channel name
+func (nc *namedChannel) Name() string {
+	return nc.name
+}
+
+func isBuiltinChannel(config monitoring.AlertsConfig, name string) bool {
+	switch name {
+	case "webhook":
+		return config.WebhookURL != ""
+	case "email":
+		return config.EmailEnabled
+	case "slack":
+		return config.SlackEnabled
+	}
+	return false
+}
+
+func isAlertLevel(level string) bool {
+	switch monitoring.AlertLevel(level) {
+	case monitoring.AlertLevelInfo, monitoring.AlertLevelWarning, monitoring.AlertLevelError, monitoring.AlertLevelCritical:
+		return true
+	}
+	return false
+}
+
+func splitPath(path string) []string {
+	var parts []string
+	for _, part := range strings.Split(path, "/") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+func queryInt(query url.Values, key string, min, max int) (int, error) {
+	raw := query.Get(key)
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("%s must be between %d and %d", key, min, max)
+	}
+	return n, nil
+}
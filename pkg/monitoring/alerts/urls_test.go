@@ -0,0 +1,131 @@
+package alerts
+
+import (
+	"testing"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChannelURL(t *testing.T) {
+	logger := logrus.New()
+
+	t.Run("ntfy.sh topic", func(t *testing.T) {
+		channel, err := ParseChannelURL(logger, "phone", "ntfy://my-exports?priority=5")
+		require.NoError(t, err)
+		ntfy := channel.(*NtfyChannel)
+		assert.Equal(t, "phone", ntfy.Name())
+		assert.Equal(t, "https://ntfy.sh/my-exports", ntfy.config.TopicURL)
+		assert.Equal(t, 5, ntfy.config.Priority)
+	})
+
+	t.Run("self-hosted ntfy", func(t *testing.T) {
+		channel, err := ParseChannelURL(logger, "", "ntfys://bob:pw@ntfy.example.com/exports?token=tk")
+		require.NoError(t, err)
+		ntfy := channel.(*NtfyChannel)
+		assert.Equal(t, "ntfy", ntfy.Name())
+		assert.Equal(t, "https://ntfy.example.com/exports", ntfy.config.TopicURL)
+		assert.Equal(t, "bob", ntfy.config.Username)
+		assert.Equal(t, "pw", ntfy.config.Password)
+		assert.Equal(t, "tk", ntfy.config.Token)
+	})
+
+	t.Run("gotify", func(t *testing.T) {
+		channel, err := ParseChannelURL(logger, "gotify", "gotify://push.local:8080/sub/AbCd")
+		require.NoError(t, err)
+		gotify := channel.(*GotifyChannel)
+		assert.Equal(t, "http://push.local:8080/sub", gotify.config.ServerURL)
+		assert.Equal(t, "AbCd", gotify.config.AppToken)
+	})
+
+	t.Run("discord", func(t *testing.T) {
+		channel, err := ParseChannelURL(logger, "team", "discord://1234/tok_en?username=Trakt")
+		require.NoError(t, err)
+		discord := channel.(*DiscordChannel)
+		assert.Equal(t, "https://discord.com/api/webhooks/1234/tok_en", discord.config.WebhookURL)
+		assert.Equal(t, "Trakt", discord.config.Username)
+	})
+
+	t.Run("telegram token with colon", func(t *testing.T) {
+		channel, err := ParseChannelURL(logger, "tg", "tgram://123456:ABC-def/42/-100")
+		require.NoError(t, err)
+		telegram := channel.(*TelegramChannel)
+		assert.Equal(t, "123456:ABC-def", telegram.config.BotToken)
+		assert.Equal(t, []string{"42", "-100"}, telegram.config.ChatIDs)
+		assert.Equal(t, "https://api.telegram.org", telegram.config.APIURL)
+	})
+
+	t.Run("matrix", func(t *testing.T) {
+		channel, err := ParseChannelURL(logger, "room", "matrixs://syt_token@matrix.org/!abc:matrix.org")
+		require.NoError(t, err)
+		matrix := channel.(*MatrixChannel)
+		assert.Equal(t, "https://matrix.org", matrix.config.HomeserverURL)
+		assert.Equal(t, "syt_token", matrix.config.AccessToken)
+		assert.Equal(t, "!abc:matrix.org", matrix.config.RoomID)
+	})
+
+	t.Run("slack and json keep their name", func(t *testing.T) {
+		channel, err := ParseChannelURL(logger, "ops", "slack://T000/B000/XXXX")
+		require.NoError(t, err)
+		assert.Equal(t, "ops", channel.Name())
+		slack := channel.(*namedChannel).NotificationChannel.(*SlackChannel)
+		assert.Equal(t, "https://hooks.slack.com/services/T000/B000/XXXX", slack.config.WebhookURL)
+
+		channel, err = ParseChannelURL(logger, "", "jsons://hooks.example.com/alerts?key=1")
+		require.NoError(t, err)
+		assert.Equal(t, "webhook", channel.Name())
+		webhook := channel.(*namedChannel).NotificationChannel.(*WebhookChannel)
+		assert.Equal(t, "https://hooks.example.com/alerts?key=1", webhook.webhookURL)
+	})
+
+	invalid := map[string]string{
+		"no scheme":          "ntfy.sh/topic",
+		"unknown scheme":     "pagerduty://key",
+		"discord no token":   "discord://1234",
+		"telegram no chat":   "tgram://123:ABC",
+		"matrix no token":    "matrix://matrix.org/!abc:matrix.org",
+		"matrix alias":       "matrix://tok@matrix.org/#room:matrix.org",
+		"ntfy bad priority":  "ntfy://topic?priority=9",
+		"gotify no apptoken": "gotify://push.local",
+	}
+	for name, rawURL := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseChannelURL(logger, "x", rawURL)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	valid := monitoring.AlertsConfig{
+		WebhookURL: "https://hooks.example.com",
+		Channels:   map[string]string{"phone": "ntfy://exports"},
+		Routes: []monitoring.AlertRoute{
+			{Levels: []string{"error", "critical"}, Channels: []string{"phone", "webhook"}},
+		},
+		Templates: map[string]string{EventExportSucceeded: "{{.items}} items"},
+	}
+	require.NoError(t, ValidateConfig(valid))
+
+	tests := map[string]func(c *monitoring.AlertsConfig){
+		"invalid url":      func(c *monitoring.AlertsConfig) { c.Channels["bad"] = "discord://1" },
+		"route level":      func(c *monitoring.AlertsConfig) { c.Routes[0].Levels = []string{"fatal"} },
+		"route channel":    func(c *monitoring.AlertsConfig) { c.Routes[0].Channels = []string{"pager"} },
+		"disabled builtin": func(c *monitoring.AlertsConfig) { c.Routes[0].Channels = []string{"slack"} },
+		"empty route":      func(c *monitoring.AlertsConfig) { c.Routes[0].Channels = nil },
+		"template event":   func(c *monitoring.AlertsConfig) { c.Templates["export_started"] = "x" },
+		"template syntax":  func(c *monitoring.AlertsConfig) { c.Templates[EventExportFailed] = "{{.error" },
+	}
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := valid
+			c.Channels = map[string]string{"phone": "ntfy://exports"}
+			c.Routes = []monitoring.AlertRoute{{Levels: []string{"error"}, Channels: []string{"phone"}}}
+			c.Templates = map[string]string{}
+			mutate(&c)
+			assert.Error(t, ValidateConfig(c))
+		})
+	}
+}
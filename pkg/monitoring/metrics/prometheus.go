@@ -23,13 +23,23 @@ type PrometheusMetrics struct {
 	apiCallsTotal       *prometheus.CounterVec
 	apiCallDuration     *prometheus.HistogramVec
 	apiCallErrors       *prometheus.CounterVec
+	rateLimitLimit      *prometheus.GaugeVec
+	rateLimitRemaining  *prometheus.GaugeVec
+	rateLimitReset      *prometheus.GaugeVec
 	
 	// Business metrics
 	moviesExported      *prometheus.CounterVec
 	ratingsExported     *prometheus.CounterVec
 	watchlistExported   *prometheus.CounterVec
+	itemsExported       *prometheus.CounterVec
 	cacheHitRate        *prometheus.GaugeVec
 	
+	// Run metrics, published by one-shot runs
+	lastRunTimestamp     prometheus.Gauge
+	lastSuccessTimestamp prometheus.Gauge
+	lastRunDuration      prometheus.Gauge
+	lastRunSuccess       prometheus.Gauge
+	
 	// System metrics
 	goroutinesCount     prometheus.Gauge
 	memoryUsage         *prometheus.GaugeVec
@@ -100,6 +110,30 @@ func NewPrometheusMetrics(logger *logrus.Logger) *PrometheusMetrics {
 			[]string{"service", "endpoint", "error_type"},
 		),
 		
+		rateLimitLimit: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "export_trakt_api_rate_limit",
+				Help: "Request limit per period reported by the Trakt API",
+			},
+			[]string{"name"},
+		),
+		
+		rateLimitRemaining: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "export_trakt_api_rate_limit_remaining",
+				Help: "Requests remaining in the current Trakt API rate limit period",
+			},
+			[]string{"name"},
+		),
+		
+		rateLimitReset: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "export_trakt_api_rate_limit_reset_time_seconds",
+				Help: "Unix time at which the Trakt API rate limit period resets",
+			},
+			[]string{"name"},
+		),
+		
 		// Business metrics
 		moviesExported: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
@@ -125,6 +159,14 @@ func NewPrometheusMetrics(logger *logrus.Logger) *PrometheusMetrics {
 			[]string{"item_type"},
 		),
 		
+		itemsExported: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "export_trakt_items_exported_total",
+				Help: "Total number of items written per export type",
+			},
+			[]string{"type"},
+		),
+		
 		cacheHitRate: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "export_trakt_cache_hit_rate",
@@ -133,6 +175,35 @@ func NewPrometheusMetrics(logger *logrus.Logger) *PrometheusMetrics {
 			[]string{"cache_type"},
 		),
 		
+		// Run metrics
+		lastRunTimestamp: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "export_trakt_last_run_timestamp_seconds",
+				Help: "Unix time at which the last export run finished",
+			},
+		),
+		
+		lastSuccessTimestamp: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: LastSuccessMetric,
+				Help: "Unix time at which the last successful export run finished",
+			},
+		),
+		
+		lastRunDuration: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "export_trakt_last_run_duration_seconds",
+				Help: "Duration of the last export run in seconds",
+			},
+		),
+		
+		lastRunSuccess: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "export_trakt_last_run_success",
+				Help: "Whether the last export run succeeded (1=success, 0=failure)",
+			},
+		),
+		
 		// System metrics
 		goroutinesCount: prometheus.NewGauge(
 			prometheus.GaugeOpts{
@@ -196,10 +267,18 @@ func (pm *PrometheusMetrics) RegisterMetrics() error {
 		pm.apiCallsTotal,
 		pm.apiCallDuration,
 		pm.apiCallErrors,
+		pm.rateLimitLimit,
+		pm.rateLimitRemaining,
+		pm.rateLimitReset,
 		pm.moviesExported,
 		pm.ratingsExported,
 		pm.watchlistExported,
+		pm.itemsExported,
 		pm.cacheHitRate,
+		pm.lastRunTimestamp,
+		pm.lastSuccessTimestamp,
+		pm.lastRunDuration,
+		pm.lastRunSuccess,
 		pm.goroutinesCount,
 		pm.memoryUsage,
 		pm.cpuUsage,
@@ -294,6 +373,33 @@ func (pm *PrometheusMetrics) RecordWatchlistExported(itemType string, count int)
 	pm.watchlistExported.WithLabelValues(itemType).Add(float64(count))
 }
 
+// RecordItemsExported records the items written by an export
+func (pm *PrometheusMetrics) RecordItemsExported(exportType string, count int) {
+	pm.itemsExported.WithLabelValues(exportType).Add(float64(count))
+}
+
+// RecordRun records the outcome of a complete export run. The last success
+// timestamp is only set by successful runs.
+func (pm *PrometheusMetrics) RecordRun(success bool, duration time.Duration, finishedAt time.Time) {
+	pm.lastRunTimestamp.Set(float64(finishedAt.Unix()))
+	pm.lastRunDuration.Set(duration.Seconds())
+	if success {
+		pm.lastRunSuccess.Set(1)
+		pm.lastSuccessTimestamp.Set(float64(finishedAt.Unix()))
+	} else {
+		pm.lastRunSuccess.Set(0)
+	}
+}
+
+// UpdateRateLimit records the rate limit budget reported by the Trakt API
+func (pm *PrometheusMetrics) UpdateRateLimit(name string, limit, remaining int, reset time.Time) {
+	pm.rateLimitLimit.WithLabelValues(name).Set(float64(limit))
+	pm.rateLimitRemaining.WithLabelValues(name).Set(float64(remaining))
+	if !reset.IsZero() {
+		pm.rateLimitReset.WithLabelValues(name).Set(float64(reset.Unix()))
+	}
+}
+
 // UpdateCacheHitRate updates the cache hit rate
 func (pm *PrometheusMetrics) UpdateCacheHitRate(cacheType string, hitRate float64) {
 	pm.cacheHitRate.WithLabelValues(cacheType).Set(hitRate)
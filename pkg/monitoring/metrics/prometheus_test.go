@@ -126,6 +126,20 @@ func TestPrometheusMetrics_RecordAPIError(t *testing.T) {
 	assert.Equal(t, float64(1), metric)
 }
 
+func TestPrometheusMetrics_UpdateRateLimit(t *testing.T) {
+	logger := logrus.New()
+	pm := NewPrometheusMetrics(logger)
+	err := pm.RegisterMetrics()
+	require.NoError(t, err)
+	
+	reset := time.Unix(1700000000, 0)
+	pm.UpdateRateLimit("AUTHED_API_GET_LIMIT", 1000, 420, reset)
+	
+	assert.Equal(t, float64(1000), testutil.ToFloat64(pm.rateLimitLimit.WithLabelValues("AUTHED_API_GET_LIMIT")))
+	assert.Equal(t, float64(420), testutil.ToFloat64(pm.rateLimitRemaining.WithLabelValues("AUTHED_API_GET_LIMIT")))
+	assert.Equal(t, float64(1700000000), testutil.ToFloat64(pm.rateLimitReset.WithLabelValues("AUTHED_API_GET_LIMIT")))
+}
+
 func TestPrometheusMetrics_RecordMoviesExported(t *testing.T) {
 	logger := logrus.New()
 	pm := NewPrometheusMetrics(logger)
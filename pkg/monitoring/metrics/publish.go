@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// LastSuccessMetric is the gauge to alert on when exports stop succeeding,
+// e.g. time() - export_trakt_last_success_timestamp_seconds > 48 * 3600
+const LastSuccessMetric = "export_trakt_last_success_timestamp_seconds"
+
+// PushToGateway pushes all metrics to a Pushgateway under job. Metrics are
+// added rather than replaced, so a failed run keeps the last success
+// timestamp pushed by the previous successful one.
+func (pm *PrometheusMetrics) PushToGateway(ctx context.Context, url, job string) error {
+	pusher := push.New(url, job).Gatherer(pm.publishedGatherer())
+	if err := pusher.AddContext(ctx); err != nil {
+		return fmt.Errorf("failed to push metrics to %s: %w", url, err)
+	}
+	return nil
+}
+
+// WriteTextfile writes all metrics to a node_exporter textfile collector
+// file. The file is replaced atomically; when no run succeeded yet, the last
+// success timestamp of the previous file is kept.
+func (pm *PrometheusMetrics) WriteTextfile(path string) error {
+	families, err := pm.publishedGatherer().Gather()
+	if err != nil {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+	if !hasFamily(families, LastSuccessMetric) {
+		if previous := readFamily(path, LastSuccessMetric); previous != nil {
+			families = append(families, previous)
+		}
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create textfile directory: %w", err)
+	}
+	// node_exporter only reads *.prom files, so the temporary file is skipped
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create textfile: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	encoder := expfmt.NewEncoder(tmp, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, family := range families {
+		if err := encoder.Encode(family); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to encode metrics: %w", err)
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write textfile: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write textfile: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write textfile: %w", err)
+	}
+	return nil
+}
+
+// publishedGatherer gathers the registry without the last success timestamp
+// while no run has succeeded, so publishing never resets it to zero
+func (pm *PrometheusMetrics) publishedGatherer() prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		families, err := pm.registry.Gather()
+		if err != nil {
+			return nil, err
+		}
+
+		published := families[:0]
+		for _, family := range families {
+			if family.GetName() == LastSuccessMetric && unsetGauge(family) {
+				continue
+			}
+			published = append(published, family)
+		}
+		return published, nil
+	})
+}
+
+func unsetGauge(family *dto.MetricFamily) bool {
+	for _, metric := range family.GetMetric() {
+		if metric.GetGauge().GetValue() != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func hasFamily(families []*dto.MetricFamily, name string) bool {
+	for _, family := range families {
+		if family.GetName() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// readFamily returns a metric family of an existing textfile, or nil
+func readFamily(path, name string) *dto.MetricFamily {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(file)
+	if err != nil {
+		return nil
+	}
+	return families[name]
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRegisteredMetrics(t *testing.T) *PrometheusMetrics {
+	pm := NewPrometheusMetrics(logrus.New())
+	require.NoError(t, pm.RegisterMetrics())
+	return pm
+}
+
+func TestWriteTextfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export_trakt.prom")
+	finished := time.Unix(1700000000, 0)
+
+	pm := newRegisteredMetrics(t)
+	pm.RecordItemsExported("watched", 12)
+	pm.RecordAPIError("trakt", "/sync/watched/movies", "server_error")
+	pm.RecordRun(true, 3*time.Second, finished)
+	require.NoError(t, pm.WriteTextfile(path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	content := string(data)
+	assert.Contains(t, content, `export_trakt_items_exported_total{type="watched"} 12`)
+	assert.Contains(t, content, `export_trakt_api_call_errors_total{endpoint="/sync/watched/movies",error_type="server_error",service="trakt"} 1`)
+	assert.Contains(t, content, "export_trakt_last_run_duration_seconds 3")
+	assert.Contains(t, content, "export_trakt_last_success_timestamp_seconds 1.7e+09")
+
+	// A failed run of a new process keeps the previous success timestamp
+	failed := newRegisteredMetrics(t)
+	failed.RecordRun(false, time.Second, finished.Add(time.Hour))
+	require.NoError(t, failed.WriteTextfile(path))
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	content = string(data)
+	assert.Contains(t, content, "export_trakt_last_run_success 0")
+	assert.Contains(t, content, "export_trakt_last_success_timestamp_seconds 1.7e+09")
+
+	// No temporary files are left next to the textfile
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestPushToGateway(t *testing.T) {
+	var method, path, body string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		path = r.URL.Path
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer gateway.Close()
+
+	pm := newRegisteredMetrics(t)
+	pm.RecordRun(false, time.Second, time.Now())
+	require.NoError(t, pm.PushToGateway(context.Background(), gateway.URL, "export_trakt"))
+
+	// Metrics are added to the group so the last success timestamp survives
+	assert.Equal(t, http.MethodPost, method)
+	assert.Equal(t, "/metrics/job/export_trakt", path)
+	assert.Contains(t, body, "export_trakt_last_run_success")
+	assert.False(t, strings.Contains(body, LastSuccessMetric), "unset last success timestamp should not be pushed")
+}
+
+func TestPushToGatewayError(t *testing.T) {
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer gateway.Close()
+
+	pm := newRegisteredMetrics(t)
+	err := pm.PushToGateway(context.Background(), gateway.URL, "export_trakt")
+	assert.Error(t, err)
+}
@@ -0,0 +1,125 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Span exporters selected by TracingConfig.Exporter
+const (
+	ExporterNone     = "none"
+	ExporterOTLPHTTP = "otlp-http"
+	ExporterOTLPGRPC = "otlp-grpc"
+	ExporterStdout   = "stdout"
+	ExporterFile     = "file"
+	// ExporterJaeger uses the deprecated Jaeger collector protocol; Jaeger
+	// accepts OTLP natively since 1.35
+	ExporterJaeger = "jaeger"
+)
+
+// IsValidExporter reports whether name is a known span exporter. An empty
+// name selects Jaeger when jaeger_endpoint is set and none otherwise.
+func IsValidExporter(name string) bool {
+	switch name {
+	case "", ExporterNone, ExporterOTLPHTTP, ExporterOTLPGRPC, ExporterStdout, ExporterFile, ExporterJaeger:
+		return true
+	}
+	return false
+}
+
+// exporterName resolves the configured exporter, keeping configurations
+// that only set jaeger_endpoint working
+func exporterName(config TracingConfig) string {
+	if config.Exporter != "" {
+		return config.Exporter
+	}
+	if config.JaegerEndpoint != "" {
+		return ExporterJaeger
+	}
+	return ExporterNone
+}
+
+// newSpanExporter creates the exporter of the configuration. It returns a
+// nil exporter for "none", and the file to close on shutdown for "file".
+func newSpanExporter(ctx context.Context, config TracingConfig) (tracesdk.SpanExporter, io.Closer, error) {
+	switch name := exporterName(config); name {
+	case ExporterNone:
+		return nil, nil, nil
+
+	case ExporterOTLPHTTP:
+		var opts []otlptracehttp.Option
+		if config.OTLPEndpoint != "" {
+			if strings.Contains(config.OTLPEndpoint, "://") {
+				opts = append(opts, otlptracehttp.WithEndpointURL(config.OTLPEndpoint))
+			} else {
+				opts = append(opts, otlptracehttp.WithEndpoint(config.OTLPEndpoint))
+			}
+		}
+		if config.OTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(config.OTLPHeaders) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(config.OTLPHeaders))
+		}
+		exp, err := otlptracehttp.New(ctx, opts...)
+		return exp, nil, err
+
+	case ExporterOTLPGRPC:
+		var opts []otlptracegrpc.Option
+		if config.OTLPEndpoint != "" {
+			if strings.Contains(config.OTLPEndpoint, "://") {
+				opts = append(opts, otlptracegrpc.WithEndpointURL(config.OTLPEndpoint))
+			} else {
+				opts = append(opts, otlptracegrpc.WithEndpoint(config.OTLPEndpoint))
+			}
+		}
+		if config.OTLPInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		if len(config.OTLPHeaders) > 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(config.OTLPHeaders))
+		}
+		exp, err := otlptracegrpc.New(ctx, opts...)
+		return exp, nil, err
+
+	case ExporterStdout:
+		exp, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		return exp, nil, err
+
+	case ExporterFile:
+		if config.FilePath == "" {
+			return nil, nil, fmt.Errorf("file exporter requires file_path")
+		}
+		if err := os.MkdirAll(filepath.Dir(config.FilePath), 0755); err != nil {
+			return nil, nil, fmt.Errorf("failed to create trace file directory: %w", err)
+		}
+		// One JSON span per line, appended across runs
+		file, err := os.OpenFile(config.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		return exp, file, nil
+
+	case ExporterJaeger:
+		exp, err := jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(config.JaegerEndpoint)))
+		return exp, nil, err
+
+	default:
+		return nil, nil, fmt.Errorf("unknown span exporter %q", name)
+	}
+}
@@ -0,0 +1,100 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exporterTestConfig(exporter string) TracingConfig {
+	return TracingConfig{
+		Enabled:        true,
+		ServiceName:    "test-service",
+		ServiceVersion: "1.0.0",
+		Environment:    "test",
+		SamplingRate:   1.0,
+		Exporter:       exporter,
+	}
+}
+
+func TestExporterName(t *testing.T) {
+	assert.Equal(t, ExporterNone, exporterName(TracingConfig{}))
+	assert.Equal(t, ExporterJaeger, exporterName(TracingConfig{JaegerEndpoint: "http://localhost:14268/api/traces"}))
+	assert.Equal(t, ExporterOTLPHTTP, exporterName(TracingConfig{Exporter: ExporterOTLPHTTP, JaegerEndpoint: "http://localhost:14268/api/traces"}))
+
+	assert.True(t, IsValidExporter(""))
+	assert.True(t, IsValidExporter(ExporterOTLPGRPC))
+	assert.False(t, IsValidExporter("zipkin"))
+}
+
+func TestFileExporter(t *testing.T) {
+	config := exporterTestConfig(ExporterFile)
+	config.FilePath = filepath.Join(t.TempDir(), "traces", "spans.json")
+
+	tracer, err := NewOpenTelemetryTracer(logrus.New(), config)
+	require.NoError(t, err)
+
+	_, endSpan := tracer.StartSpan(context.Background(), "export.job")
+	endSpan()
+	require.NoError(t, tracer.Close())
+
+	data, err := os.ReadFile(config.FilePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"Name":"export.job"`)
+}
+
+func TestFileExporterRequiresPath(t *testing.T) {
+	_, err := NewOpenTelemetryTracer(logrus.New(), exporterTestConfig(ExporterFile))
+	assert.Error(t, err)
+}
+
+func TestOTLPHTTPExporter(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	config := exporterTestConfig(ExporterOTLPHTTP)
+	config.OTLPEndpoint = collector.URL + "/v1/traces"
+
+	tracer, err := NewOpenTelemetryTracer(logrus.New(), config)
+	require.NoError(t, err)
+
+	_, endSpan := tracer.StartSpan(context.Background(), "export.job")
+	endSpan()
+	require.NoError(t, tracer.Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"/v1/traces"}, paths)
+}
+
+func TestOTLPGRPCExporter(t *testing.T) {
+	config := exporterTestConfig(ExporterOTLPGRPC)
+	config.OTLPEndpoint = "localhost:4317"
+	config.OTLPInsecure = true
+
+	// The gRPC connection is established lazily
+	tracer, err := NewOpenTelemetryTracer(logrus.New(), config)
+	require.NoError(t, err)
+	assert.NotNil(t, tracer.provider)
+	assert.NoError(t, tracer.Close())
+}
+
+func TestUnknownExporter(t *testing.T) {
+	_, err := NewOpenTelemetryTracer(logrus.New(), exporterTestConfig("zipkin"))
+	assert.Error(t, err)
+}
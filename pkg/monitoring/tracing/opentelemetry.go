@@ -3,12 +3,12 @@ package tracing
 import (
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
-	"go.opentelemetry.io/otel/exporters/jaeger"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
@@ -16,14 +16,22 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
-// TracingConfig represents tracing configuration
+// TracingConfig represents tracing configuration. Exporter is one of none,
+// otlp-http, otlp-grpc, stdout, file or jaeger. OTLPEndpoint is host:port or
+// a URL; the standard OTEL_EXPORTER_OTLP_* variables apply when it is empty.
+// The file exporter appends the spans to FilePath as JSON.
 type TracingConfig struct {
-	Enabled         bool    `toml:"enabled"`
-	ServiceName     string  `toml:"service_name"`
-	ServiceVersion  string  `toml:"service_version"`
-	Environment     string  `toml:"environment"`
-	JaegerEndpoint  string  `toml:"jaeger_endpoint"`
-	SamplingRate    float64 `toml:"sampling_rate"`
+	Enabled        bool              `toml:"enabled"`
+	ServiceName    string            `toml:"service_name"`
+	ServiceVersion string            `toml:"service_version"`
+	Environment    string            `toml:"environment"`
+	Exporter       string            `toml:"exporter"`
+	OTLPEndpoint   string            `toml:"otlp_endpoint"`
+	OTLPInsecure   bool              `toml:"otlp_insecure"`
+	OTLPHeaders    map[string]string `toml:"otlp_headers"`
+	FilePath       string            `toml:"file_path"`
+	JaegerEndpoint string            `toml:"jaeger_endpoint"`
+	SamplingRate   float64           `toml:"sampling_rate"`
 }
 
 // OpenTelemetryTracer implements distributed tracing using OpenTelemetry
@@ -33,6 +41,7 @@ type OpenTelemetryTracer struct {
 	tracer       trace.Tracer
 	provider     *tracesdk.TracerProvider
 	serviceName  string
+	output       io.Closer
 }
 
 // NewOpenTelemetryTracer creates a new OpenTelemetry tracer
@@ -45,19 +54,16 @@ func NewOpenTelemetryTracer(logger *logrus.Logger, config TracingConfig) (*OpenT
 		}, nil
 	}
 
-	// Create Jaeger exporter
-	var exp tracesdk.SpanExporter
-	var err error
-	
-	if config.JaegerEndpoint != "" {
-		exp, err = jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(config.JaegerEndpoint)))
-		if err != nil {
-			logger.WithError(err).Error("Failed to create Jaeger exporter")
-			return nil, fmt.Errorf("failed to create Jaeger exporter: %w", err)
-		}
-		logger.WithField("endpoint", config.JaegerEndpoint).Info("Jaeger exporter initialized")
+	// Create span exporter
+	exp, output, err := newSpanExporter(context.Background(), config)
+	if err != nil {
+		logger.WithError(err).Error("Failed to create span exporter")
+		return nil, fmt.Errorf("failed to create %s exporter: %w", exporterName(config), err)
+	}
+	if exp != nil {
+		logger.WithField("exporter", exporterName(config)).Info("Span exporter initialized")
 	} else {
-		logger.Info("No Jaeger endpoint configured, tracing will be collected but not exported")
+		logger.Info("No span exporter configured, tracing will be collected but not exported")
 	}
 
 	// Create resource
@@ -72,22 +78,29 @@ func NewOpenTelemetryTracer(logger *logrus.Logger, config TracingConfig) (*OpenT
 	)
 	if err != nil {
 		logger.WithError(err).Error("Failed to create tracing resource")
+		if output != nil {
+			output.Close()
+		}
 		return nil, fmt.Errorf("failed to create resource: %w", err)
 	}
 
+	// Follow the sampling decision of a propagated parent, such as the web
+	// server that started the export
+	sampler := tracesdk.ParentBased(tracesdk.TraceIDRatioBased(config.SamplingRate))
+
 	// Create trace provider
 	var tp *tracesdk.TracerProvider
 	if exp != nil {
 		tp = tracesdk.NewTracerProvider(
 			tracesdk.WithBatcher(exp),
 			tracesdk.WithResource(res),
-			tracesdk.WithSampler(tracesdk.TraceIDRatioBased(config.SamplingRate)),
+			tracesdk.WithSampler(sampler),
 		)
 	} else {
 		// Create provider without exporter for local development
 		tp = tracesdk.NewTracerProvider(
 			tracesdk.WithResource(res),
-			tracesdk.WithSampler(tracesdk.TraceIDRatioBased(config.SamplingRate)),
+			tracesdk.WithSampler(sampler),
 		)
 	}
 
@@ -115,6 +128,7 @@ func NewOpenTelemetryTracer(logger *logrus.Logger, config TracingConfig) (*OpenT
 		tracer:      tracer,
 		provider:    tp,
 		serviceName: config.ServiceName,
+		output:      output,
 	}, nil
 }
 
@@ -239,12 +253,19 @@ func (ott *OpenTelemetryTracer) GetSpanID(ctx context.Context) string {
 	return span.SpanContext().SpanID().String()
 }
 
-// Close shuts down the tracer provider
+// Close shuts down the tracer provider, flushing the pending spans
 func (ott *OpenTelemetryTracer) Close() error {
+	var err error
 	if ott.provider != nil {
-		return ott.provider.Shutdown(context.Background())
+		err = ott.provider.Shutdown(context.Background())
 	}
-	return nil
+	if ott.output != nil {
+		if closeErr := ott.output.Close(); err == nil {
+			err = closeErr
+		}
+		ott.output = nil
+	}
+	return err
 }
 
 // TraceExportOperation traces an export operation
@@ -75,6 +75,8 @@ type Alert struct {
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
 	Resolved    bool                  `json:"resolved"`
 	ResolvedAt  *time.Time            `json:"resolved_at,omitempty"`
+	// Event names what happened, e.g. export_failed, and selects the message template
+	Event       string                `json:"event,omitempty"`
 }
 
 // NotificationChannel interface for sending alerts
@@ -92,6 +94,10 @@ type MonitoringConfig struct {
 	HealthChecksEnabled bool `toml:"health_checks_enabled"`
 	MetricsPort       int    `toml:"metrics_port"`
 	MetricsPath       string `toml:"metrics_path"`
+	// Publishing of one-shot runs, which are too short to be scraped
+	PushgatewayURL    string `toml:"pushgateway_url"`
+	PushgatewayJob    string `toml:"pushgateway_job"`
+	TextfilePath      string `toml:"textfile_path"`
 }
 
 // LoggingConfig represents enhanced logging configuration
@@ -104,6 +110,7 @@ type LoggingConfig struct {
 	MaxSizeMB       int    `toml:"max_size_mb"`
 	MaxBackups      int    `toml:"max_backups"`
 	CorrelationID   bool   `toml:"correlation_id"`
+	Privacy         bool   `toml:"privacy"`
 }
 
 // AlertsConfig represents alerting configuration
@@ -112,4 +119,20 @@ type AlertsConfig struct {
 	EmailEnabled    bool   `toml:"email_enabled"`
 	SlackEnabled    bool   `toml:"slack_enabled"`
 	RateLimitMinutes int   `toml:"rate_limit_minutes"`
+	// Channels maps a channel name to a notification URL such as
+	// ntfys://ntfy.sh/topic or discord://id/token
+	Channels        map[string]string `toml:"channels"`
+	// Routes restrict which channels receive an alert; without routes every
+	// channel receives every alert
+	Routes          []AlertRoute      `toml:"routes"`
+	// Templates override the message of an alert per event, e.g. export_succeeded
+	Templates       map[string]string `toml:"templates"`
+}
+
+// AlertRoute sends the alerts matching its levels and sources to its
+// channels. Empty levels or sources match any alert.
+type AlertRoute struct {
+	Levels   []string `toml:"levels"`
+	Sources  []string `toml:"sources"`
+	Channels []string `toml:"channels"`
 } 
\ No newline at end of file
@@ -0,0 +1,394 @@
+package cache
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	diskIndexFile   = "index.json"
+	diskEntrySuffix = ".json.gz"
+	// Hits and misses only change counters and LRU times, so they are
+	// saved in batches rather than on every request
+	diskIndexFlushUpdates  = 100
+	diskIndexFlushInterval = 30 * time.Second
+)
+
+// DiskCacheConfig holds configuration for the persistent HTTP response cache
+type DiskCacheConfig struct {
+	Dir        string
+	MaxEntries int // Maximum number of stored responses (0 = no limit)
+}
+
+// DiskEntry is a stored HTTP response together with its validators
+type DiskEntry struct {
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	StoredAt   time.Time   `json:"stored_at"`
+}
+
+// ETag returns the entity tag of the stored response
+func (e *DiskEntry) ETag() string {
+	return e.Header.Get("ETag")
+}
+
+// LastModified returns the Last-Modified date of the stored response
+func (e *DiskEntry) LastModified() string {
+	return e.Header.Get("Last-Modified")
+}
+
+// diskIndexEntry describes one stored response in the index
+type diskIndexEntry struct {
+	URL      string    `json:"url"`
+	Size     int64     `json:"size"`
+	StoredAt time.Time `json:"stored_at"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// diskIndex is persisted next to the entries so stats and eviction don't
+// need to read every entry
+type diskIndex struct {
+	Entries map[string]*diskIndexEntry `json:"entries"`
+	Hits    int64                      `json:"hits"`
+	Misses  int64                      `json:"misses"`
+}
+
+// DiskCache is a directory of gzip-compressed HTTP responses with an index.
+// Unlike the in-memory caches it survives between runs, so responses can be
+// revalidated with conditional requests instead of being downloaded again.
+type DiskCache struct {
+	mutex      sync.Mutex
+	dir        string
+	maxEntries int
+	index      diskIndex
+	pending    int // hits and misses not saved yet
+	savedAt    time.Time
+}
+
+// DiskCacheStats provides statistics about the persistent cache
+type DiskCacheStats struct {
+	Dir       string    `json:"dir"`
+	Entries   int       `json:"entries"`
+	SizeBytes int64     `json:"size_bytes"`
+	Hits      int64     `json:"hits"`
+	Misses    int64     `json:"misses"`
+	HitRatio  float64   `json:"hit_ratio"`
+	Oldest    time.Time `json:"oldest,omitempty"`
+	Newest    time.Time `json:"newest,omitempty"`
+}
+
+// NewDiskCache opens the cache directory, creating it when needed
+func NewDiskCache(config DiskCacheConfig) (*DiskCache, error) {
+	if config.Dir == "" {
+		return nil, fmt.Errorf("cache directory is required")
+	}
+	if err := os.MkdirAll(config.Dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	c := &DiskCache{
+		dir:        config.Dir,
+		maxEntries: config.MaxEntries,
+		index:      diskIndex{Entries: make(map[string]*diskIndexEntry)},
+		savedAt:    time.Now(),
+	}
+
+	data, err := os.ReadFile(filepath.Join(config.Dir, diskIndexFile))
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read cache index: %w", err)
+	default:
+		// A corrupt index only loses the bookkeeping, the entries are rebuilt on use
+		var index diskIndex
+		if json.Unmarshal(data, &index) == nil && index.Entries != nil {
+			c.index = index
+		}
+	}
+
+	return c, nil
+}
+
+// DiskCacheKey returns the cache key of a URL for one account, so responses
+// of different accounts never mix. The account must stay the same across
+// token refreshes for responses to be revalidated.
+func DiskCacheKey(account, url string) string {
+	sum := sha256.Sum256([]byte(account + "\n" + url))
+	return hex.EncodeToString(sum[:])
+}
+
+// Dir returns the cache directory
+func (c *DiskCache) Dir() string {
+	return c.dir
+}
+
+// Get reads a stored response
+func (c *DiskCache) Get(key string) (*DiskEntry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, err := c.readEntry(key)
+	if err != nil {
+		if _, indexed := c.index.Entries[key]; indexed {
+			delete(c.index.Entries, key)
+			c.saveIndex()
+		}
+		return nil, false
+	}
+
+	// Entries written by another process are picked up into the index
+	if _, indexed := c.index.Entries[key]; !indexed {
+		c.index.Entries[key] = &diskIndexEntry{
+			URL:      entry.URL,
+			Size:     c.entrySize(key),
+			StoredAt: entry.StoredAt,
+			LastUsed: entry.StoredAt,
+		}
+	}
+
+	return entry, true
+}
+
+// Set stores a response, evicting the least recently used entries beyond MaxEntries
+func (c *DiskCache) Set(key string, entry *DiskEntry) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if entry.StoredAt.IsZero() {
+		entry.StoredAt = time.Now()
+	}
+
+	size, err := c.writeEntry(key, entry)
+	if err != nil {
+		return err
+	}
+
+	c.index.Entries[key] = &diskIndexEntry{
+		URL:      entry.URL,
+		Size:     size,
+		StoredAt: entry.StoredAt,
+		LastUsed: time.Now(),
+	}
+	c.evict()
+
+	return c.saveIndex()
+}
+
+// RecordHit marks a stored response as served after revalidation
+func (c *DiskCache) RecordHit(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.index.Hits++
+	if item, ok := c.index.Entries[key]; ok {
+		item.LastUsed = time.Now()
+	}
+	c.recordUpdate()
+}
+
+// RecordMiss counts a response that had to be downloaded
+func (c *DiskCache) RecordMiss() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.index.Misses++
+	c.recordUpdate()
+}
+
+// Flush saves the hits and misses recorded since the index was last saved
+func (c *DiskCache) Flush() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.pending == 0 {
+		return nil
+	}
+	return c.saveIndex()
+}
+
+// recordUpdate saves the index once enough hits and misses are pending
+func (c *DiskCache) recordUpdate() {
+	c.pending++
+	if c.pending >= diskIndexFlushUpdates || time.Since(c.savedAt) >= diskIndexFlushInterval {
+		c.saveIndex()
+	}
+}
+
+// Clear removes every stored response and resets the statistics. It returns
+// the number of removed entries.
+func (c *DiskCache) Clear() (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	removed := 0
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), diskEntrySuffix) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.dir, file.Name())); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to remove cache entry: %w", err)
+		}
+		removed++
+	}
+
+	c.index = diskIndex{Entries: make(map[string]*diskIndexEntry)}
+	return removed, c.saveIndex()
+}
+
+// Stats returns cache statistics
+func (c *DiskCache) Stats() DiskCacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stats := DiskCacheStats{
+		Dir:     c.dir,
+		Entries: len(c.index.Entries),
+		Hits:    c.index.Hits,
+		Misses:  c.index.Misses,
+	}
+
+	for _, item := range c.index.Entries {
+		stats.SizeBytes += item.Size
+		if stats.Oldest.IsZero() || item.StoredAt.Before(stats.Oldest) {
+			stats.Oldest = item.StoredAt
+		}
+		if item.StoredAt.After(stats.Newest) {
+			stats.Newest = item.StoredAt
+		}
+	}
+
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+
+	return stats
+}
+
+// evict removes the least recently used entries beyond the configured maximum
+func (c *DiskCache) evict() {
+	if c.maxEntries <= 0 || len(c.index.Entries) <= c.maxEntries {
+		return
+	}
+
+	keys := make([]string, 0, len(c.index.Entries))
+	for key := range c.index.Entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return c.index.Entries[keys[i]].LastUsed.Before(c.index.Entries[keys[j]].LastUsed)
+	})
+
+	for _, key := range keys[:len(keys)-c.maxEntries] {
+		os.Remove(c.entryPath(key))
+		delete(c.index.Entries, key)
+	}
+}
+
+func (c *DiskCache) entryPath(key string) string {
+	return filepath.Join(c.dir, key+diskEntrySuffix)
+}
+
+func (c *DiskCache) entrySize(key string) int64 {
+	info, err := os.Stat(c.entryPath(key))
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+func (c *DiskCache) readEntry(key string) (*DiskEntry, error) {
+	file, err := os.Open(c.entryPath(key))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var entry DiskEntry
+	if err := json.NewDecoder(reader).Decode(&entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// writeEntry writes through a temporary file so readers never see a partial entry
+func (c *DiskCache) writeEntry(key string, entry *DiskEntry) (int64, error) {
+	tmp, err := os.CreateTemp(c.dir, key+".tmp-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create cache entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := gzip.NewWriter(tmp)
+	if err := json.NewEncoder(writer).Encode(entry); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("failed to compress cache entry: %w", err)
+	}
+
+	info, err := tmp.Stat()
+	if err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+
+	if err := os.Rename(tmp.Name(), c.entryPath(key)); err != nil {
+		return 0, fmt.Errorf("failed to store cache entry: %w", err)
+	}
+	return info.Size(), nil
+}
+
+func (c *DiskCache) saveIndex() error {
+	data, err := json.Marshal(c.index)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(c.dir, diskIndexFile+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(c.dir, diskIndexFile)); err != nil {
+		return err
+	}
+	c.pending = 0
+	c.savedAt = time.Now()
+	return nil
+}
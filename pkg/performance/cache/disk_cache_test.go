@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDiskCacheSetGetAcrossInstances(t *testing.T) {
+	dir := t.TempDir()
+
+	cache, err := NewDiskCache(DiskCacheConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Failed to create disk cache: %v", err)
+	}
+
+	header := http.Header{}
+	header.Set("ETag", `"abc"`)
+	key := DiskCacheKey("user", "https://api.trakt.tv/sync/watchlist/movies")
+	if err := cache.Set(key, &DiskEntry{URL: "https://api.trakt.tv/sync/watchlist/movies", StatusCode: 200, Header: header, Body: []byte(`[]`)}); err != nil {
+		t.Fatalf("Failed to store entry: %v", err)
+	}
+	cache.RecordHit(key)
+	if err := cache.Flush(); err != nil {
+		t.Fatalf("Failed to flush disk cache: %v", err)
+	}
+
+	// A new instance, as in the next run, sees the stored entry and stats
+	reopened, err := NewDiskCache(DiskCacheConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Failed to reopen disk cache: %v", err)
+	}
+
+	entry, found := reopened.Get(key)
+	if !found {
+		t.Fatal("Expected to find stored entry")
+	}
+	if entry.ETag() != `"abc"` || string(entry.Body) != `[]` {
+		t.Errorf("Unexpected entry: etag %q body %q", entry.ETag(), entry.Body)
+	}
+
+	stats := reopened.Stats()
+	if stats.Entries != 1 || stats.Hits != 1 || stats.SizeBytes == 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	if _, found := reopened.Get(DiskCacheKey("other user", entry.URL)); found {
+		t.Error("Expected entries to be separated per user")
+	}
+}
+
+func TestDiskCacheEvictionAndClear(t *testing.T) {
+	cache, err := NewDiskCache(DiskCacheConfig{Dir: t.TempDir(), MaxEntries: 2})
+	if err != nil {
+		t.Fatalf("Failed to create disk cache: %v", err)
+	}
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := cache.Set(key, &DiskEntry{URL: key, StatusCode: 200, Header: http.Header{}}); err != nil {
+			t.Fatalf("Failed to store entry: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, found := cache.Get("a"); found {
+		t.Error("Expected least recently used entry to be evicted")
+	}
+	if stats := cache.Stats(); stats.Entries != 2 {
+		t.Errorf("Expected 2 entries, got %d", stats.Entries)
+	}
+
+	removed, err := cache.Clear()
+	if err != nil {
+		t.Fatalf("Failed to clear cache: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Expected 2 removed entries, got %d", removed)
+	}
+	if _, found := cache.Get("b"); found {
+		t.Error("Expected cache to be empty after clear")
+	}
+}
+
+func TestDiskCacheBatchesStats(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewDiskCache(DiskCacheConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Failed to create disk cache: %v", err)
+	}
+
+	// Misses are kept in memory until the batch is full or flushed
+	cache.RecordMiss()
+	reopened, err := NewDiskCache(DiskCacheConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Failed to reopen disk cache: %v", err)
+	}
+	if stats := reopened.Stats(); stats.Misses != 0 {
+		t.Errorf("Expected the miss to be pending, got %+v", stats)
+	}
+
+	for i := 1; i < diskIndexFlushUpdates; i++ {
+		cache.RecordMiss()
+	}
+	reopened, err = NewDiskCache(DiskCacheConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Failed to reopen disk cache: %v", err)
+	}
+	if stats := reopened.Stats(); stats.Misses != diskIndexFlushUpdates {
+		t.Errorf("Expected a full batch to be saved, got %+v", stats)
+	}
+
+	cache.RecordMiss()
+	if err := cache.Flush(); err != nil {
+		t.Fatalf("Failed to flush disk cache: %v", err)
+	}
+	reopened, err = NewDiskCache(DiskCacheConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Failed to reopen disk cache: %v", err)
+	}
+	if stats := reopened.Stats(); stats.Misses != diskIndexFlushUpdates+1 {
+		t.Errorf("Expected Flush to save the pending miss, got %+v", stats)
+	}
+}
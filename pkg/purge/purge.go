@@ -0,0 +1,337 @@
+// Package purge removes the local data kept about the configured Trakt
+// account: exports, cached API responses, checkpoints, logs and traces, the
+// OAuth token and keyring entries. Files are overwritten before they are
+// removed and a final audit record lists what was purged.
+package purge
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/auth"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/resilience/checkpoints"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/audit"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/keyring"
+)
+
+// Categories of purged data, in purge order
+const (
+	CategoryExports     = "exports"
+	CategoryCache       = "cache"
+	CategoryCheckpoints = "checkpoints"
+	CategoryHistory     = "history"
+	CategoryToken       = "token"
+	CategoryKeyring     = "keyring"
+)
+
+// DefaultKeptKeys are keyring entries of the Trakt application rather than
+// of the account, kept so the instance stays usable
+var DefaultKeptKeys = []string{"client_id", "client_secret"}
+
+// Options controls a purge
+type Options struct {
+	// DryRun lists what would be removed without removing anything
+	DryRun bool
+	// SkipRevoke clears the token without revoking it at Trakt
+	SkipRevoke bool
+	// Origin and RemoteAddr describe who asked for the purge in the audit log
+	Origin     string
+	RemoteAddr string
+}
+
+// CategoryResult lists what was removed from one category
+type CategoryResult struct {
+	Name    string   `json:"name"`
+	Removed []string `json:"removed"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// Report describes a purge
+type Report struct {
+	DryRun     bool              `json:"dry_run"`
+	Revoked    bool              `json:"revoked"`
+	Categories []*CategoryResult `json:"categories"`
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt time.Time         `json:"finished_at"`
+}
+
+// Removed returns the number of removed files and entries
+func (r *Report) Removed() int {
+	count := 0
+	for _, category := range r.Categories {
+		count += len(category.Removed)
+	}
+	return count
+}
+
+// Failed returns the number of items that could not be removed
+func (r *Report) Failed() int {
+	count := 0
+	for _, category := range r.Categories {
+		count += len(category.Errors)
+	}
+	return count
+}
+
+// Category returns the result of a category
+func (r *Report) Category(name string) *CategoryResult {
+	for _, category := range r.Categories {
+		if category.Name == name {
+			return category
+		}
+	}
+	return nil
+}
+
+// Purger removes the local data of the configured account
+type Purger struct {
+	cfg          *config.Config
+	log          logger.Logger
+	security     *security.Manager
+	keyringMgr   *keyring.Manager
+	tokenManager *auth.TokenManager
+	keep         map[string]bool
+	mutex        sync.Mutex
+}
+
+// NewPurger creates a purger. Keyring entries listed in DefaultKeptKeys and
+// keep are left in place.
+func NewPurger(cfg *config.Config, log logger.Logger, securityManager *security.Manager, keyringMgr *keyring.Manager, tokenManager *auth.TokenManager, keep ...string) *Purger {
+	p := &Purger{
+		cfg:          cfg,
+		log:          log,
+		security:     securityManager,
+		keyringMgr:   keyringMgr,
+		tokenManager: tokenManager,
+		keep:         make(map[string]bool),
+	}
+	for _, key := range append(append([]string(nil), DefaultKeptKeys...), keep...) {
+		p.keep[key] = true
+	}
+	return p
+}
+
+// Purge removes the local data of the account, or only lists it in a dry
+// run. Failures are recorded in the report and do not stop the purge.
+func (p *Purger) Purge(opts Options) *Report {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if opts.Origin == "" {
+		opts.Origin = "cli"
+	}
+
+	report := &Report{DryRun: opts.DryRun, StartedAt: time.Now()}
+
+	report.Categories = append(report.Categories,
+		p.purgeTree(CategoryExports, p.cfg.Letterboxd.ExportDir, opts.DryRun),
+		p.purgeTree(CategoryCache, p.cfg.Client.CacheDir, opts.DryRun),
+		p.purgeTree(CategoryCheckpoints, checkpoints.DefaultConfig().CheckpointDir, opts.DryRun),
+		p.purgeFiles(CategoryHistory, p.historyFiles(), opts.DryRun),
+	)
+
+	token, revoked := p.purgeToken(opts)
+	report.Revoked = revoked
+	report.Categories = append(report.Categories, token, p.purgeKeyring(opts.DryRun))
+
+	report.FinishedAt = time.Now()
+	if !opts.DryRun {
+		p.recordPurge(report, opts)
+	}
+	return report
+}
+
+// historyFiles returns the log and trace files recording past exports
+func (p *Purger) historyFiles() []string {
+	var files []string
+	for _, path := range []string{p.cfg.Logging.File, p.cfg.Tracing.FilePath} {
+		if path == "" {
+			continue
+		}
+		// Include rotated files such as export.log.1
+		matches, _ := filepath.Glob(path + ".*")
+		files = append(files, path)
+		files = append(files, matches...)
+	}
+	return files
+}
+
+// purgeTree removes every file below dir, then the emptied subdirectories.
+// dir itself is kept.
+func (p *Purger) purgeTree(category, dir string, dryRun bool) *CategoryResult {
+	result := &CategoryResult{Name: category, Removed: []string{}}
+	if dir == "" {
+		return result
+	}
+
+	var files, dirs []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir {
+				dirs = append(dirs, path)
+			}
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		result.Errors = append(result.Errors, err.Error())
+	}
+
+	p.deleteFiles(result, files, dryRun)
+
+	// Deepest directories first
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, sub := range dirs {
+		if !dryRun {
+			os.Remove(sub) // only succeeds when empty
+		}
+	}
+	return result
+}
+
+// purgeFiles removes the given files when they exist
+func (p *Purger) purgeFiles(category string, paths []string, dryRun bool) *CategoryResult {
+	result := &CategoryResult{Name: category, Removed: []string{}}
+	var files []string
+	for _, path := range paths {
+		if info, err := os.Lstat(path); err == nil && !info.IsDir() {
+			files = append(files, path)
+		}
+	}
+	p.deleteFiles(result, files, dryRun)
+	return result
+}
+
+// deleteFiles securely deletes files, recording the outcome of each
+func (p *Purger) deleteFiles(result *CategoryResult, files []string, dryRun bool) {
+	for _, file := range files {
+		if dryRun {
+			result.Removed = append(result.Removed, file)
+			continue
+		}
+		if err := p.security.SecureDeleteFile(file); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", file, err))
+			continue
+		}
+		result.Removed = append(result.Removed, file)
+	}
+}
+
+// purgeToken revokes the token at Trakt and clears it from the keyring. The
+// token is cleared even when the revocation fails. It reports whether the
+// token was revoked.
+func (p *Purger) purgeToken(opts Options) (*CategoryResult, bool) {
+	result := &CategoryResult{Name: CategoryToken, Removed: []string{}}
+
+	status, err := p.tokenManager.GetTokenStatus()
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result, false
+	}
+	if !status.HasToken {
+		return result, false
+	}
+	if opts.DryRun {
+		result.Removed = append(result.Removed, "trakt_token")
+		return result, false
+	}
+
+	revoked := false
+	if !opts.SkipRevoke {
+		if err := p.tokenManager.RevokeToken(); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("revocation at Trakt failed: %v", err))
+		} else {
+			revoked = true
+		}
+	}
+	if err := p.tokenManager.ClearToken(); err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result, revoked
+	}
+	result.Removed = append(result.Removed, "trakt_token")
+	return result, revoked
+}
+
+// purgeKeyring deletes the keyring entries not listed as kept
+func (p *Purger) purgeKeyring(dryRun bool) *CategoryResult {
+	result := &CategoryResult{Name: CategoryKeyring, Removed: []string{}}
+
+	keys, err := p.keyringMgr.List()
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("failed to list keyring entries: %v", err))
+		return result
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if p.keep[key] {
+			continue
+		}
+		if dryRun {
+			result.Removed = append(result.Removed, key)
+			continue
+		}
+		if err := p.keyringMgr.Delete(key); err != nil && err != keyring.ErrCredentialNotFound {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		result.Removed = append(result.Removed, key)
+	}
+	return result
+}
+
+// recordPurge writes the final audit record and log entry of a purge. Only
+// counts are recorded, not the purged paths.
+func (p *Purger) recordPurge(report *Report, opts Options) {
+	result := "success"
+	if report.Failed() > 0 {
+		result = "partial"
+	}
+
+	// The audit log masks fields named like tokens and keys, hence "oauth"
+	// and "stored_entries"
+	details := map[string]interface{}{
+		"origin":         opts.Origin,
+		"revoked":        report.Revoked,
+		"failures":       report.Failed(),
+		"exports":        len(report.Category(CategoryExports).Removed),
+		"cache":          len(report.Category(CategoryCache).Removed),
+		"checkpoints":    len(report.Category(CategoryCheckpoints).Removed),
+		"history":        len(report.Category(CategoryHistory).Removed),
+		"oauth":          len(report.Category(CategoryToken).Removed),
+		"stored_entries": len(report.Category(CategoryKeyring).Removed),
+	}
+
+	if auditLog := p.security.AuditLogger(); auditLog != nil {
+		auditLog.LogEvent(audit.AuditEvent{
+			EventType:  audit.DataPurge,
+			Severity:   audit.SeverityHigh,
+			Source:     opts.Origin,
+			Target:     "local_data",
+			Action:     "purge",
+			Result:     result,
+			Message:    fmt.Sprintf("Purged %d items of local data, %d failures", report.Removed(), report.Failed()),
+			Details:    details,
+			RemoteAddr: opts.RemoteAddr,
+		})
+	}
+
+	p.log.Info("purge.completed", map[string]interface{}{
+		"removed":  report.Removed(),
+		"failures": report.Failed(),
+		"origin":   opts.Origin,
+	})
+}
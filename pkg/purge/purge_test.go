@@ -0,0 +1,206 @@
+package purge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/auth"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/keyring"
+)
+
+// testPurge holds a purger working in a temporary directory
+type testPurge struct {
+	purger     *Purger
+	security   *security.Manager
+	keyringMgr *keyring.Manager
+	tokens     *auth.TokenManager
+	revokes    int
+}
+
+// newTestPurge populates a temporary working directory with the local data
+// of an account. The Trakt revoke endpoint answers revokeStatus.
+func newTestPurge(t *testing.T, revokeStatus int) *testPurge {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	tp := &testPurge{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth/revoke" {
+			tp.revokes++
+		}
+		w.WriteHeader(revokeStatus)
+	}))
+	t.Cleanup(server.Close)
+
+	files := map[string]string{
+		"exports/export_2026-10-18_12-00/watched.csv": "Title,Year\nHeat,1995\n",
+		"exports/.export_state.json":                  "{}",
+		"cache/0123abcd.json.gz":                      "cached response",
+		"cache/index.json":                            `{"entries":{}}`,
+		"checkpoints/checkpoint_export.json":          "{}",
+		"logs/export.log":                             "exported Heat\n",
+		"logs/export.log.1":                           "exported Ronin\n",
+		"logs/traces.json":                            "{}\n",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{
+		Trakt: config.TraktConfig{
+			ClientID:     "client",
+			ClientSecret: "secret",
+			APIBaseURL:   server.URL,
+		},
+		Auth:       config.AuthConfig{RedirectURI: "http://localhost:8080/callback", CallbackPort: 8080},
+		Letterboxd: config.LetterboxdConfig{ExportDir: "exports"},
+		Client:     config.ClientConfig{CacheDir: "cache"},
+		Logging:    config.LoggingConfig{File: "logs/export.log"},
+	}
+	cfg.Tracing.FilePath = "logs/traces.json"
+
+	tp.security, err = security.NewManager(security.Config{
+		KeyringBackend: "env",
+		AuditLogging:   true,
+		Audit:          security.AuditConfig{LogLevel: "info", OutputFormat: "json"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create security manager: %v", err)
+	}
+	t.Cleanup(func() { tp.security.Close() })
+
+	tp.keyringMgr, err = keyring.NewManager(keyring.MemoryBackend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"client_id", "client_secret", "audit_checkpoint_key", "credentials_rotated_at"} {
+		tp.keyringMgr.Store(key, "value")
+	}
+
+	log := logger.NewLogger()
+	tp.tokens = auth.NewTokenManager(cfg, log, tp.keyringMgr)
+	if err := tp.tokens.StoreToken(&auth.TokenResponse{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		TokenType:    "Bearer",
+		ExpiresIn:    7776000,
+		CreatedAt:    time.Now().Unix(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tp.purger = NewPurger(cfg, log, tp.security, tp.keyringMgr, tp.tokens, "audit_checkpoint_key")
+	return tp
+}
+
+func TestPurgeDryRun(t *testing.T) {
+	tp := newTestPurge(t, http.StatusOK)
+
+	report := tp.purger.Purge(Options{DryRun: true})
+
+	expected := map[string]int{
+		CategoryExports:     2,
+		CategoryCache:       2,
+		CategoryCheckpoints: 1,
+		CategoryHistory:     3,
+		CategoryToken:       1,
+	}
+	for name, count := range expected {
+		if got := len(report.Category(name).Removed); got != count {
+			t.Errorf("Expected %d %s, got %d: %v", count, name, got, report.Category(name).Removed)
+		}
+	}
+	if tp.revokes != 0 {
+		t.Error("Expected no revocation in a dry run")
+	}
+	if _, err := os.Stat("exports/export_2026-10-18_12-00/watched.csv"); err != nil {
+		t.Error("Expected a dry run to keep the files")
+	}
+	if status, _ := tp.tokens.GetTokenStatus(); !status.HasToken {
+		t.Error("Expected a dry run to keep the token")
+	}
+}
+
+func TestPurge(t *testing.T) {
+	tp := newTestPurge(t, http.StatusOK)
+
+	report := tp.purger.Purge(Options{})
+
+	if report.Failed() != 0 {
+		t.Fatalf("Expected no failures, got %+v", report.Categories)
+	}
+	if !report.Revoked || tp.revokes != 1 {
+		t.Errorf("Expected the token to be revoked once, got %v and %d calls", report.Revoked, tp.revokes)
+	}
+
+	// Files and emptied directories are gone, the configured directories stay
+	for _, path := range []string{"exports/export_2026-10-18_12-00", "exports/.export_state.json", "cache/index.json", "checkpoints/checkpoint_export.json", "logs/export.log", "logs/export.log.1", "logs/traces.json"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed", path)
+		}
+	}
+	if _, err := os.Stat("exports"); err != nil {
+		t.Error("Expected the export directory to be kept")
+	}
+
+	// Application credentials and the audit key are kept
+	for _, key := range []string{"client_id", "client_secret", "audit_checkpoint_key"} {
+		if _, err := tp.keyringMgr.Retrieve(key); err != nil {
+			t.Errorf("Expected %s to be kept", key)
+		}
+	}
+	keys, _ := tp.keyringMgr.List()
+	if len(keys) != 3 {
+		t.Errorf("Expected only the kept entries, got %v", keys)
+	}
+	if status, _ := tp.tokens.GetTokenStatus(); status.HasToken {
+		t.Error("Expected the token to be cleared")
+	}
+
+	// The audit log records the purge
+	data, err := os.ReadFile(filepath.FromSlash(security.AuditLogFile))
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if !strings.Contains(string(data), `"audit_event_type":"data_purge"`) || !strings.Contains(string(data), `"detail_stored_entries":1`) {
+		t.Errorf("Expected a data_purge audit record, got %s", data)
+	}
+}
+
+func TestPurgeRevocationFailure(t *testing.T) {
+	tp := newTestPurge(t, http.StatusUnauthorized)
+
+	report := tp.purger.Purge(Options{})
+
+	if report.Revoked {
+		t.Error("Expected the revocation to fail")
+	}
+	token := report.Category(CategoryToken)
+	if len(token.Errors) != 1 || len(token.Removed) != 1 {
+		t.Errorf("Expected the token to be cleared despite the failed revocation, got %+v", token)
+	}
+	if status, _ := tp.tokens.GetTokenStatus(); status.HasToken {
+		t.Error("Expected the token to be cleared")
+	}
+}
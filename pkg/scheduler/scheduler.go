@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -14,19 +15,28 @@ import (
 	"github.com/robfig/cron/v3"
 )
 
+// exportStopGracePeriod is how long an interrupted export may take to stop
+// before it is killed
+const exportStopGracePeriod = 30 * time.Second
+
 // Scheduler manages the scheduling of export jobs
 type Scheduler struct {
 	config *config.Config
 	log    logger.Logger
 	cron   *cron.Cron
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // NewScheduler creates a new scheduler
 func NewScheduler(cfg *config.Config, log logger.Logger) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Scheduler{
 		config: cfg,
 		log:    log,
 		cron:   cron.New(),
+		ctx:    ctx,
+		cancel: cancel,
 	}
 }
 
@@ -34,20 +44,29 @@ func NewScheduler(cfg *config.Config, log logger.Logger) *Scheduler {
 func (s *Scheduler) Start() error {
 	// Get schedule from environment variable
 	schedule := os.Getenv("EXPORT_SCHEDULE")
+	if schedule == "" {
+		schedule = s.config.Schedule.Cron
+	}
 	if schedule == "" {
 		s.log.Info("scheduler.no_schedule_defined", map[string]interface{}{
-			"message": "No EXPORT_SCHEDULE environment variable defined. Scheduler will not run.",
+			"message": "No EXPORT_SCHEDULE environment variable or schedule.cron setting defined. Scheduler will not run.",
 		})
 		return nil
 	}
 
 	// Get export mode and type from environment variables or use defaults
 	exportMode := os.Getenv("EXPORT_MODE")
+	if exportMode == "" {
+		exportMode = s.config.Schedule.ExportMode
+	}
 	if exportMode == "" {
 		exportMode = "complete" // Default to complete mode
 	}
 
 	exportType := os.Getenv("EXPORT_TYPE")
+	if exportType == "" {
+		exportType = s.config.Schedule.ExportType
+	}
 	if exportType == "" {
 		exportType = "all" // Default to export all
 	}
@@ -96,10 +115,11 @@ func (s *Scheduler) Start() error {
 	return nil
 }
 
-// Stop gracefully stops the scheduler
+// Stop gracefully stops the scheduler, interrupting a running export
 func (s *Scheduler) Stop() {
 	if s.cron != nil {
 		s.log.Info("scheduler.stopping", nil)
+		s.cancel()
 		ctx := s.cron.Stop()
 		<-ctx.Done()
 		s.log.Info("scheduler.stopped", nil)
@@ -113,8 +133,12 @@ func (s *Scheduler) runExport(mode, exportType string) {
 		"type": exportType,
 	})
 
-	// Create command to run export
-	cmd := exec.Command(os.Args[0], "export", "--mode", mode, "--export", exportType)
+	// Create command to run export, interrupted when the scheduler stops
+	cmd := exec.CommandContext(s.ctx, os.Args[0], "export", "--mode", mode, "--export", exportType)
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = exportStopGracePeriod
 	
 	// Get output
 	output, err := cmd.CombinedOutput()
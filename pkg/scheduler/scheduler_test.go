@@ -82,6 +82,22 @@ func TestScheduler_Start_InvalidSchedule(t *testing.T) {
 	}
 }
 
+func TestScheduler_Start_ConfigSchedule(t *testing.T) {
+	// Planification non valide définie uniquement dans la configuration
+	cfg := &config.Config{Schedule: config.ScheduleConfig{Cron: "invalid-schedule"}}
+	log := &MockLogger{}
+
+	os.Unsetenv("EXPORT_SCHEDULE")
+
+	// Créer un nouveau scheduler
+	sched := NewScheduler(cfg, log)
+
+	// La planification de la configuration doit être utilisée, et donc rejetée
+	if err := sched.Start(); err == nil {
+		t.Error("Start() aurait dû retourner une erreur pour schedule.cron invalide")
+	}
+}
+
 func TestScheduler_Start_ValidSchedule(t *testing.T) {
 	// Créer une configuration et un logger mock
 	cfg := &config.Config{}
@@ -0,0 +1,325 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Fields added to the entries of the hash chain
+const (
+	fieldSeq       = "seq"
+	fieldPrevHash  = "prev_hash"
+	fieldSignature = "signature"
+)
+
+// headSuffix names the file recording the last entry of a log, so that
+// truncation can be detected
+const headSuffix = ".head"
+
+// textSeqPattern finds the sequence number of text formatted entries
+var textSeqPattern = regexp.MustCompile(`\bseq=(\d+)`)
+
+// chain links each entry to the previous one: entries carry a sequence
+// number and the SHA-256 hash of the previous line. Checkpoint entries and
+// the head file are signed with an HMAC key, so that a rewritten chain
+// cannot pass verification.
+type chain struct {
+	mutex           sync.Mutex
+	seq             uint64
+	lastHash        string
+	key             []byte
+	interval        int
+	sinceCheckpoint int
+	headPath        string
+	lock            *os.File // serializes processes writing the same log
+	pending         *pendingEntry
+}
+
+// pendingEntry is the entry formatted last, committed to the chain once
+// its line is written
+type pendingEntry struct {
+	seq        uint64
+	hash       string
+	checkpoint bool
+}
+
+// head records the last entry written to a log
+type head struct {
+	Seq       uint64 `json:"seq"`
+	Hash      string `json:"hash"`
+	Signature string `json:"signature,omitempty"`
+	// SignedSeq and SignedHash record the last entry covered by Signature
+	// once entries were written without key after it
+	SignedSeq  uint64 `json:"signed_seq,omitempty"`
+	SignedHash string `json:"signed_hash,omitempty"`
+}
+
+// signedEntry returns the sequence number and hash of the entry covered by
+// the signature of the head
+func (h *head) signedEntry() (uint64, string) {
+	if h.SignedHash != "" {
+		return h.SignedSeq, h.SignedHash
+	}
+	return h.Seq, h.Hash
+}
+
+// hashLine returns the hex SHA-256 hash of a log line, without its newline
+func hashLine(line []byte) string {
+	sum := sha256.Sum256(bytes.TrimRight(line, "\r\n"))
+	return hex.EncodeToString(sum[:])
+}
+
+// sign returns the hex HMAC-SHA256 of the parts, joined by colons
+func sign(key []byte, parts ...string) string {
+	mac := hmac.New(sha256.New, key)
+	for i, part := range parts {
+		if i > 0 {
+			mac.Write([]byte(":"))
+		}
+		mac.Write([]byte(part))
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// checkpointSignature signs a checkpoint entry, which vouches for every
+// entry up to the one hashed in prevHash
+func checkpointSignature(key []byte, seq uint64, prevHash string) string {
+	return sign(key, "checkpoint", strconv.FormatUint(seq, 10), prevHash)
+}
+
+// headSignature signs the head file
+func headSignature(key []byte, seq uint64, hash string) string {
+	return sign(key, "head", strconv.FormatUint(seq, 10), hash)
+}
+
+// validSignature compares a signature in constant time
+func validSignature(signature, expected string) bool {
+	return hmac.Equal([]byte(signature), []byte(expected))
+}
+
+// setKey sets the key signing checkpoints and the head file
+func (c *chain) setKey(key []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.key = key
+}
+
+// checkpointDue reports whether enough entries were written since the last
+// checkpoint. Without key, no checkpoint is written.
+func (c *chain) checkpointDue(force bool) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.key == nil || c.sinceCheckpoint == 0 {
+		return false
+	}
+	return force || (c.interval > 0 && c.sinceCheckpoint >= c.interval)
+}
+
+// resume continues the chain of an existing log, given its last line
+func (c *chain) resume(lastLine []byte) {
+	if len(bytes.TrimSpace(lastLine)) == 0 {
+		return
+	}
+	c.lastHash = hashLine(lastLine)
+
+	var entry struct {
+		Seq uint64 `json:"seq"`
+	}
+	if err := json.Unmarshal(lastLine, &entry); err == nil {
+		c.seq = entry.Seq
+	} else if m := textSeqPattern.FindSubmatch(lastLine); m != nil {
+		c.seq, _ = strconv.ParseUint(string(m[1]), 10, 64)
+	}
+}
+
+// acquire locks the log against other processes and catches up with the
+// entries they wrote, as recorded in the head file
+func (c *chain) acquire() {
+	if c.lock == nil {
+		return
+	}
+	if err := lockFile(c.lock); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to lock audit log: %v\n", err)
+	}
+	if h, err := readHead(strings.TrimSuffix(c.headPath, headSuffix)); err == nil && h.Seq >= c.seq {
+		c.seq = h.Seq
+		c.lastHash = h.Hash
+	}
+}
+
+// release unlocks the log once the entry formatted last was written
+func (c *chain) release() {
+	if c.lock != nil {
+		unlockFile(c.lock)
+	}
+}
+
+// writeHead records the last entry, signed when a key is set. Without key,
+// the signature of the previous head is kept, so that the entries signed
+// by an earlier writer stay covered.
+func (c *chain) writeHead() error {
+	if c.headPath == "" {
+		return nil
+	}
+	h := head{Seq: c.seq, Hash: c.lastHash}
+	if c.key != nil {
+		h.Signature = headSignature(c.key, h.Seq, h.Hash)
+	} else if prev, err := readHead(strings.TrimSuffix(c.headPath, headSuffix)); err == nil && prev.Signature != "" {
+		h.SignedSeq, h.SignedHash = prev.signedEntry()
+		h.Signature = prev.Signature
+	}
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+
+	tmp := c.headPath + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.headPath)
+}
+
+// readHead reads the head file of a log
+func readHead(logPath string) (*head, error) {
+	data, err := os.ReadFile(logPath + headSuffix)
+	if err != nil {
+		return nil, err
+	}
+	var h head
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("invalid head file: %w", err)
+	}
+	return &h, nil
+}
+
+// chainFormatter adds the chain fields to each entry before formatting it
+// with the configured formatter. logrus formats and writes entries under
+// the same lock, so the chain follows the order of the file.
+type chainFormatter struct {
+	logrus.Formatter
+	chain *chain
+}
+
+func (f *chainFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	c := f.chain
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// The lock is released by chainWriter once the line is written
+	c.acquire()
+	seq := c.seq + 1
+	data := make(logrus.Fields, len(entry.Data)+3)
+	for key, value := range entry.Data {
+		data[key] = value
+	}
+	data[fieldSeq] = seq
+	data[fieldPrevHash] = c.lastHash
+
+	checkpoint := data["audit_event_type"] == string(AuditCheckpoint)
+	if checkpoint && c.key != nil {
+		data[fieldSignature] = checkpointSignature(c.key, seq, c.lastHash)
+	}
+
+	chained := *entry
+	chained.Data = data
+	line, err := f.Formatter.Format(&chained)
+	if err != nil {
+		c.release()
+		return nil, err
+	}
+
+	c.pending = &pendingEntry{seq: seq, hash: hashLine(line), checkpoint: checkpoint}
+	return line, nil
+}
+
+// commit advances the chain to the pending entry and records it in the
+// head file
+func (c *chain) commit() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	p := c.pending
+	if p == nil {
+		return
+	}
+	c.pending = nil
+	c.seq = p.seq
+	c.lastHash = p.hash
+	if p.checkpoint {
+		c.sinceCheckpoint = 0
+	} else {
+		c.sinceCheckpoint++
+	}
+	if err := c.writeHead(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write audit log head: %v\n", err)
+	}
+}
+
+// discard drops the pending entry when its line could not be written
+func (c *chain) discard() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.pending = nil
+}
+
+// chainWriter writes the lines formatted by chainFormatter, commits them to
+// the chain once written and releases the lock taken while formatting them
+type chainWriter struct {
+	io.Writer
+	chain *chain
+}
+
+func (w *chainWriter) Write(p []byte) (int, error) {
+	defer w.chain.release()
+	n, err := w.Writer.Write(p)
+	if err != nil {
+		w.chain.discard()
+		return n, err
+	}
+	w.chain.commit()
+	return n, nil
+}
+
+// lastLine returns the last non-empty line of a file, reading at most its
+// final 64 KiB
+func lastLine(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	offset := info.Size() - 64*1024
+	if offset < 0 {
+		offset = 0
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	data = bytes.TrimRight(data, "\r\n")
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+		data = data[i+1:]
+	}
+	return data, nil
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package audit
+
+import "os"
+
+// lockFile is a no-op where advisory locks are not available: processes
+// writing the same log at once may then break its hash chain
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -31,6 +32,7 @@ const (
 	DataEncrypt   EventType = "data_encrypt"
 	DataDecrypt   EventType = "data_decrypt"
 	DataAccess    EventType = "data_access"
+	DataPurge     EventType = "data_purge"
 	
 	// System events
 	SystemStart     EventType = "system_start"
@@ -43,6 +45,9 @@ const (
 	RateLimitHit      EventType = "rate_limit_hit"
 	PermissionDenied  EventType = "permission_denied"
 	IntrusionAttempt  EventType = "intrusion_attempt"
+
+	// Audit log events
+	AuditCheckpoint EventType = "audit_checkpoint"
 )
 
 // Severity represents the severity level of an audit event
@@ -71,26 +76,37 @@ type AuditEvent struct {
 	UserAgent   string                 `json:"user_agent,omitempty"`
 	SessionID   string                 `json:"session_id,omitempty"`
 	RequestID   string                 `json:"request_id,omitempty"`
+	Seq         uint64                 `json:"seq,omitempty"` // position in the hash chain, set when read back from the log
 }
 
-// Logger provides structured audit logging capabilities
+// defaultCheckpointInterval is the number of entries between two signed checkpoints
+const defaultCheckpointInterval = 100
+
+// Logger provides structured audit logging capabilities. Entries form a
+// hash chain, see Verify.
 type Logger struct {
 	logger          *logrus.Logger
 	outputFormat    string
 	includeSensitive bool
-	logFile         *os.File
+	logFile         *rotatingFile
+	maxFileSize     int64
+	maxFiles        int
 	retention       time.Duration
+	chain           *chain
+	statsMutex      sync.Mutex
+	stats           Stats
 }
 
 // Config holds audit logger configuration
 type Config struct {
-	LogLevel         string        // debug, info, warn, error
-	OutputFormat     string        // json, text
-	LogFile          string        // Path to log file
-	IncludeSensitive bool          // Whether to include sensitive information
-	RetentionDays    int           // How many days to retain logs
-	MaxFileSize      int64         // Maximum size per log file in bytes
-	MaxFiles         int           // Maximum number of log files to keep
+	LogLevel           string // debug, info, warn, error
+	OutputFormat       string // json, text
+	LogFile            string // Path to log file
+	IncludeSensitive   bool   // Whether to include sensitive information
+	RetentionDays      int    // How many days to retain logs
+	MaxFileSize        int64  // Maximum size per log file in bytes, 0 disables rotation
+	MaxFiles           int    // Maximum number of log files to keep
+	CheckpointInterval int    // Entries between signed checkpoints, see SetCheckpointKey
 }
 
 // NewLogger creates a new audit logger with the specified configuration
@@ -122,12 +138,21 @@ func NewLogger(config Config) (*Logger, error) {
 		})
 	}
 
+	checkpointInterval := config.CheckpointInterval
+	if checkpointInterval <= 0 {
+		checkpointInterval = defaultCheckpointInterval
+	}
 	auditLogger := &Logger{
 		logger:           logger,
 		outputFormat:     config.OutputFormat,
 		includeSensitive: config.IncludeSensitive,
+		maxFileSize:      config.MaxFileSize,
+		maxFiles:         config.MaxFiles,
 		retention:        time.Duration(config.RetentionDays) * 24 * time.Hour,
+		chain:            &chain{interval: checkpointInterval},
+		stats:            NewStats(),
 	}
+	logger.SetFormatter(&chainFormatter{Formatter: logger.Formatter, chain: auditLogger.chain})
 
 	// Set up file output if specified
 	if config.LogFile != "" {
@@ -147,18 +172,58 @@ func (l *Logger) setupFileOutput(logFile string) error {
 		return fmt.Errorf("failed to create log directory: %w", err)
 	}
 
+	// Continue the hash chain of the last file written
+	files := LogFiles(logFile)
+	for i := len(files) - 1; i >= 0; i-- {
+		if line, err := lastLine(files[i]); err == nil && len(line) > 0 {
+			l.chain.resume(line)
+			break
+		}
+	}
+	l.chain.headPath = logFile + headSuffix
+
+	lock, err := os.OpenFile(logFile+".lock", os.O_CREATE|os.O_RDWR, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to open log lock file: %w", err)
+	}
+	l.chain.lock = lock
+
 	// Open log file with secure permissions
-	file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	file, err := openRotatingFile(logFile, l.maxFileSize, l.maxFiles)
 	if err != nil {
+		lock.Close()
 		return fmt.Errorf("failed to open log file: %w", err)
 	}
 
 	l.logFile = file
-	l.logger.SetOutput(file)
+	l.logger.SetOutput(&chainWriter{Writer: file, chain: l.chain})
 
 	return nil
 }
 
+// SetCheckpointKey sets the HMAC key signing the checkpoints written every
+// CheckpointInterval entries and the head file. Without key, entries are
+// chained but an attacker able to rewrite the whole file goes unnoticed.
+func (l *Logger) SetCheckpointKey(key []byte) {
+	l.chain.setKey(key)
+}
+
+// writeCheckpoint appends a signed checkpoint entry, whatever the log level
+func (l *Logger) writeCheckpoint() {
+	level := logrus.InfoLevel
+	if !l.logger.IsLevelEnabled(level) {
+		level = l.logger.GetLevel()
+	}
+
+	l.logger.WithFields(logrus.Fields{
+		"audit_event_type": string(AuditCheckpoint),
+		"severity":         string(SeverityLow),
+		"source":           "audit_logger",
+		"action":           "checkpoint",
+		"result":           "success",
+	}).WithTime(time.Now().UTC()).Log(level, "Audit log checkpoint")
+}
+
 // LogEvent logs a security audit event
 func (l *Logger) LogEvent(event AuditEvent) {
 	// Set timestamp if not provided
@@ -211,17 +276,25 @@ func (l *Logger) LogEvent(event AuditEvent) {
 	}
 
 	// Log based on severity
+	level := logrus.InfoLevel
 	switch event.Severity {
 	case SeverityCritical:
-		entry.Error(event.Message)
+		level = logrus.ErrorLevel
 	case SeverityHigh:
-		entry.Warn(event.Message)
-	case SeverityMedium:
-		entry.Info(event.Message)
+		level = logrus.WarnLevel
 	case SeverityLow:
-		entry.Debug(event.Message)
-	default:
-		entry.Info(event.Message)
+		level = logrus.DebugLevel
+	}
+	entry.Log(level, event.Message)
+
+	if l.logger.IsLevelEnabled(level) {
+		l.statsMutex.Lock()
+		l.stats.add(event)
+		l.statsMutex.Unlock()
+	}
+
+	if l.chain.checkpointDue(false) {
+		l.writeCheckpoint()
 	}
 }
 
@@ -462,9 +535,18 @@ func (l *Logger) CleanupOldLogs() error {
 
 // Close closes the audit logger and any open files
 func (l *Logger) Close() error {
+	// Sign the entries written since the last checkpoint
+	if l.chain.checkpointDue(true) {
+		l.writeCheckpoint()
+	}
+
 	if l.logFile != nil {
 		err := l.logFile.Close()
 		l.logFile = nil
+		if l.chain.lock != nil {
+			l.chain.lock.Close()
+			l.chain.lock = nil
+		}
 		return err
 	}
 	return nil
@@ -472,15 +554,24 @@ func (l *Logger) Close() error {
 
 // SetOutput sets a custom output writer for the logger
 func (l *Logger) SetOutput(w io.Writer) {
-	l.logger.SetOutput(w)
+	l.logger.SetOutput(&chainWriter{Writer: w, chain: l.chain})
 }
 
-// GetMetrics returns audit logging metrics
+// GetMetrics returns audit logging metrics, including the events logged
+// since the logger was created by type, severity and result
 func (l *Logger) GetMetrics() map[string]interface{} {
-	return map[string]interface{}{
+	metrics := map[string]interface{}{
 		"output_format":      l.outputFormat,
 		"include_sensitive":  l.includeSensitive,
 		"retention_hours":    l.retention.Hours(),
 		"log_level":          l.logger.Level.String(),
 	}
+
+	l.statsMutex.Lock()
+	for key, value := range l.stats.Metrics() {
+		metrics[key] = value
+	}
+	l.statsMutex.Unlock()
+
+	return metrics
 } 
\ No newline at end of file
@@ -0,0 +1,355 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query filters the events returned by Search. Empty fields match every
+// event.
+type Query struct {
+	EventTypes         []EventType
+	Severities         []Severity
+	Since              time.Time // inclusive
+	Until              time.Time // exclusive
+	RemoteAddr         string    // matches addresses starting with it, such as "192.168."
+	Result             string
+	IncludeCheckpoints bool
+	Offset             int
+	Limit              int // 0 returns every matching event
+}
+
+// Stats counts audit events by type, severity and result
+type Stats struct {
+	Total      int               `json:"total"`
+	ByType     map[EventType]int `json:"by_type"`
+	BySeverity map[Severity]int  `json:"by_severity"`
+	ByResult   map[string]int    `json:"by_result"`
+}
+
+// NewStats returns empty counters
+func NewStats() Stats {
+	return Stats{
+		ByType:     make(map[EventType]int),
+		BySeverity: make(map[Severity]int),
+		ByResult:   make(map[string]int),
+	}
+}
+
+func (s *Stats) add(event AuditEvent) {
+	s.Total++
+	s.ByType[event.EventType]++
+	s.BySeverity[event.Severity]++
+	s.ByResult[event.Result]++
+}
+
+// SearchResult is the result of Search
+type SearchResult struct {
+	Events  []AuditEvent `json:"events"` // newest first
+	Total   int          `json:"total"`  // matching events, regardless of Offset and Limit
+	Stats   Stats        `json:"stats"`  // counters of the matching events
+	Files   []string     `json:"files"`
+	Skipped int          `json:"skipped"` // lines that are not JSON entries
+}
+
+// Matches reports whether an event passes the filters of the query
+func (q Query) Matches(event AuditEvent) bool {
+	if event.EventType == AuditCheckpoint && !q.IncludeCheckpoints {
+		return false
+	}
+	if len(q.EventTypes) > 0 && !containsEventType(q.EventTypes, event.EventType) {
+		return false
+	}
+	if len(q.Severities) > 0 && !containsSeverity(q.Severities, event.Severity) {
+		return false
+	}
+	if !q.Since.IsZero() && event.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !event.Timestamp.Before(q.Until) {
+		return false
+	}
+	if q.RemoteAddr != "" && !strings.HasPrefix(event.RemoteAddr, q.RemoteAddr) {
+		return false
+	}
+	if q.Result != "" && !strings.EqualFold(event.Result, q.Result) {
+		return false
+	}
+	return true
+}
+
+func containsEventType(types []EventType, eventType EventType) bool {
+	for _, t := range types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func containsSeverity(severities []Severity, severity Severity) bool {
+	for _, s := range severities {
+		if s == severity {
+			return true
+		}
+	}
+	return false
+}
+
+// Search returns the events of the JSON audit log at path and its rotated
+// files that match the query, newest first. Lines that are not JSON entries,
+// such as text formatted ones, are skipped and counted.
+func Search(path string, q Query) (*SearchResult, error) {
+	result := &SearchResult{Stats: NewStats()}
+
+	files := LogFiles(path)
+	for i := len(files) - 1; i >= 0; i-- {
+		events, skipped, err := readEvents(files[i])
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Files = append(result.Files, files[i])
+		result.Skipped += skipped
+
+		for j := len(events) - 1; j >= 0; j-- {
+			event := events[j]
+			if !q.Matches(event) {
+				continue
+			}
+			if result.Total >= q.Offset && (q.Limit <= 0 || len(result.Events) < q.Limit) {
+				result.Events = append(result.Events, event)
+			}
+			result.Total++
+			result.Stats.add(event)
+		}
+	}
+
+	return result, nil
+}
+
+// readEvents parses the entries of a log file, in file order
+func readEvents(file string) ([]AuditEvent, int, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	var (
+		events  []AuditEvent
+		skipped int
+	)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		event, err := ParseEvent(line)
+		if err != nil {
+			skipped++
+			continue
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	return events, skipped, nil
+}
+
+// ParseEvent maps a JSON audit log entry back to the event it was written
+// from. Details are restored from the detail_ fields.
+func ParseEvent(line []byte) (AuditEvent, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return AuditEvent{}, fmt.Errorf("not a JSON entry: %w", err)
+	}
+	if _, ok := fields["audit_event_type"]; !ok {
+		return AuditEvent{}, fmt.Errorf("not an audit event")
+	}
+
+	str := func(key string) string {
+		s, _ := fields[key].(string)
+		return s
+	}
+
+	event := AuditEvent{
+		EventType:  EventType(str("audit_event_type")),
+		Severity:   Severity(str("severity")),
+		UserID:     str("user_id"),
+		Source:     str("source"),
+		Target:     str("target"),
+		Action:     str("action"),
+		Result:     str("result"),
+		Message:    str("message"),
+		RemoteAddr: str("remote_addr"),
+		UserAgent:  str("user_agent"),
+		SessionID:  str("session_id"),
+		RequestID:  str("request_id"),
+	}
+	if ts := str("timestamp"); ts != "" {
+		t, err := time.Parse(time.RFC3339Nano, ts)
+		if err != nil {
+			return AuditEvent{}, fmt.Errorf("invalid timestamp: %w", err)
+		}
+		event.Timestamp = t
+	}
+	if seq, ok := fields[fieldSeq].(float64); ok {
+		event.Seq = uint64(seq)
+	}
+	for key, value := range fields {
+		if name := strings.TrimPrefix(key, "detail_"); name != key {
+			if event.Details == nil {
+				event.Details = make(map[string]interface{})
+			}
+			event.Details[name] = value
+		}
+	}
+
+	return event, nil
+}
+
+// ParseTime parses a time filter: an RFC 3339 time, a local date and time
+// such as 2025-01-31T18:00 or 2025-01-31, or a duration ago such as 24h
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected RFC 3339, YYYY-MM-DD[THH:MM] or a duration such as 24h", value)
+}
+
+// ParseSeverities parses a comma separated list of severities
+func ParseSeverities(value string) ([]Severity, error) {
+	var severities []Severity
+	for _, s := range splitList(value) {
+		switch severity := Severity(strings.ToLower(s)); severity {
+		case SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical:
+			severities = append(severities, severity)
+		default:
+			return nil, fmt.Errorf("invalid severity %q, expected low, medium, high or critical", s)
+		}
+	}
+	return severities, nil
+}
+
+// ParseEventTypes parses a comma separated list of event types
+func ParseEventTypes(value string) []EventType {
+	var types []EventType
+	for _, t := range splitList(value) {
+		types = append(types, EventType(strings.ToLower(t)))
+	}
+	return types
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// csvHeader lists the columns written by WriteCSV
+var csvHeader = []string{
+	"timestamp", "seq", "event_type", "severity", "source", "action", "result", "message",
+	"user_id", "target", "remote_addr", "user_agent", "session_id", "request_id", "details",
+}
+
+// WriteCSV writes events as CSV with a header row. Details are written as
+// a JSON object.
+func WriteCSV(w io.Writer, events []AuditEvent) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, event := range events {
+		details := ""
+		if len(event.Details) > 0 {
+			data, err := json.Marshal(event.Details)
+			if err != nil {
+				return err
+			}
+			details = string(data)
+		}
+		seq := ""
+		if event.Seq > 0 {
+			seq = strconv.FormatUint(event.Seq, 10)
+		}
+		record := []string{
+			event.Timestamp.Format(time.RFC3339Nano), seq, string(event.EventType), string(event.Severity),
+			event.Source, event.Action, event.Result, event.Message,
+			event.UserID, event.Target, event.RemoteAddr, event.UserAgent, event.SessionID, event.RequestID, details,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON writes events as an indented JSON array
+func WriteJSON(w io.Writer, events []AuditEvent) error {
+	if events == nil {
+		events = []AuditEvent{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(events)
+}
+
+// Metrics returns the counters in the form used by Logger.GetMetrics
+func (s Stats) Metrics() map[string]interface{} {
+	return map[string]interface{}{
+		"events_total":       s.Total,
+		"events_by_type":     copyCounts(s.ByType),
+		"events_by_severity": copyCounts(s.BySeverity),
+		"events_by_result":   copyCounts(s.ByResult),
+	}
+}
+
+func copyCounts[K ~string](counts map[K]int) map[string]int {
+	copied := make(map[string]int, len(counts))
+	for key, count := range counts {
+		copied[string(key)] = count
+	}
+	return copied
+}
+
+// SortedKeys returns the keys of counts, most frequent first
+func SortedKeys[K ~string](counts map[K]int) []K {
+	keys := make([]K, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
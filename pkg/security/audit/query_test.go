@@ -0,0 +1,259 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSearchLog logs a fixed set of events one minute apart, ending at base
+func writeSearchLog(t *testing.T, logFile string, base time.Time, config Config) {
+	t.Helper()
+	config.LogLevel = "debug"
+	config.OutputFormat = "json"
+	config.LogFile = logFile
+
+	logger, err := NewLogger(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.SetCheckpointKey(testCheckpointKey)
+
+	events := []AuditEvent{
+		{EventType: AuthSuccess, Severity: SeverityLow, Source: "web", Action: "login", Result: "success", RemoteAddr: "192.168.1.10"},
+		{EventType: AuthFailure, Severity: SeverityHigh, Source: "web", Action: "login", Result: "failed", RemoteAddr: "10.0.0.5"},
+		{EventType: RateLimitHit, Severity: SeverityMedium, Source: "api", Action: "request", Result: "blocked", RemoteAddr: "10.0.0.5"},
+		{EventType: AuthFailure, Severity: SeverityCritical, Source: "web", Action: "login", Result: "failed", RemoteAddr: "192.168.1.20",
+			Details: map[string]interface{}{"attempts": 5}},
+		{EventType: DataExport, Severity: SeverityMedium, Source: "export", Action: "movies", Result: "success"},
+	}
+	for i, event := range events {
+		event.Timestamp = base.Add(time.Duration(i-len(events)+1) * time.Minute)
+		event.Message = string(event.EventType) + " event"
+		logger.LogEvent(event)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSearchFilters(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	writeSearchLog(t, logFile, base, Config{})
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []EventType
+	}{
+		{"everything", Query{}, []EventType{DataExport, AuthFailure, RateLimitHit, AuthFailure, AuthSuccess}},
+		{"event type", Query{EventTypes: []EventType{AuthFailure}}, []EventType{AuthFailure, AuthFailure}},
+		{"severities", Query{Severities: []Severity{SeverityHigh, SeverityCritical}}, []EventType{AuthFailure, AuthFailure}},
+		{"time range", Query{Since: base.Add(-3 * time.Minute), Until: base.Add(-time.Minute)}, []EventType{RateLimitHit, AuthFailure}},
+		{"remote address prefix", Query{RemoteAddr: "192.168."}, []EventType{AuthFailure, AuthSuccess}},
+		{"result", Query{Result: "FAILED"}, []EventType{AuthFailure, AuthFailure}},
+		{"combined", Query{RemoteAddr: "10.0.0.5", Result: "blocked"}, []EventType{RateLimitHit}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Search(logFile, tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Total != len(tt.want) || len(result.Events) != len(tt.want) {
+				t.Fatalf("Expected %d events, got %d of %d", len(tt.want), len(result.Events), result.Total)
+			}
+			for i, event := range result.Events {
+				if event.EventType != tt.want[i] {
+					t.Errorf("Event %d: expected %s, got %s", i, tt.want[i], event.EventType)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchRestoresEvents(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	writeSearchLog(t, logFile, base, Config{})
+
+	result, err := Search(logFile, Query{Severities: []Severity{SeverityCritical}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Events) != 1 {
+		t.Fatalf("Expected one critical event, got %d", len(result.Events))
+	}
+	event := result.Events[0]
+	if !event.Timestamp.Equal(base.Add(-time.Minute)) || event.Source != "web" || event.Action != "login" ||
+		event.RemoteAddr != "192.168.1.20" || event.Message != "auth_failure event" || event.Seq != 4 {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	if event.Details["attempts"] != float64(5) {
+		t.Errorf("Expected details to be restored, got %v", event.Details)
+	}
+
+	// Checkpoints are only returned on request
+	result, err = Search(logFile, Query{IncludeCheckpoints: true, EventTypes: []EventType{AuditCheckpoint}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 1 {
+		t.Errorf("Expected the closing checkpoint, got %d events", result.Total)
+	}
+}
+
+func TestSearchPaginatesRotatedFiles(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	writeTestLog(t, logFile, 30, Config{MaxFileSize: 2048, MaxFiles: 10})
+	if files := LogFiles(logFile); len(files) < 3 {
+		t.Fatalf("Expected rotated files, got %v", files)
+	}
+
+	var seqs []uint64
+	for offset := 0; ; offset += 7 {
+		result, err := Search(logFile, Query{Offset: offset, Limit: 7})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Total != 30 {
+			t.Fatalf("Expected 30 matching events, got %d", result.Total)
+		}
+		if len(result.Events) == 0 {
+			break
+		}
+		for _, event := range result.Events {
+			seqs = append(seqs, event.Seq)
+		}
+	}
+
+	if len(seqs) != 30 {
+		t.Fatalf("Expected 30 events across pages, got %d", len(seqs))
+	}
+	for i := 1; i < len(seqs); i++ {
+		if seqs[i] >= seqs[i-1] {
+			t.Fatalf("Expected newest events first, got seq %d after %d", seqs[i], seqs[i-1])
+		}
+	}
+}
+
+func TestSearchStats(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	writeSearchLog(t, logFile, time.Now().UTC(), Config{})
+
+	result, err := Search(logFile, Query{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := result.Stats
+	if stats.Total != 5 || stats.ByType[AuthFailure] != 2 || stats.BySeverity[SeverityMedium] != 2 || stats.ByResult["success"] != 2 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	if keys := SortedKeys(stats.ByType); keys[0] != AuthFailure {
+		t.Errorf("Expected the most frequent type first, got %v", keys)
+	}
+}
+
+func TestLoggerMetricsCountEvents(t *testing.T) {
+	logger, err := NewLogger(Config{LogLevel: "info", OutputFormat: "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.SetOutput(&bytes.Buffer{})
+
+	logger.LogAuthEvent(AuthFailure, "user", "failed", "10.0.0.5")
+	logger.LogAuthEvent(AuthFailure, "user", "failed", "10.0.0.5")
+	// Low severity events are below the info level and not written
+	logger.LogEvent(AuditEvent{EventType: DataAccess, Severity: SeverityLow, Result: "success"})
+
+	metrics := logger.GetMetrics()
+	if metrics["events_total"] != 2 {
+		t.Errorf("Expected 2 events, got %v", metrics["events_total"])
+	}
+	if byType := metrics["events_by_type"].(map[string]int); byType[string(AuthFailure)] != 2 {
+		t.Errorf("Unexpected counts by type %v", byType)
+	}
+}
+
+func TestWriteCSVAndJSON(t *testing.T) {
+	events := []AuditEvent{{
+		Timestamp:  time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+		Seq:        42,
+		EventType:  AuthFailure,
+		Severity:   SeverityHigh,
+		Source:     "web",
+		Action:     "login",
+		Result:     "failed",
+		Message:    "Authentication failed, bad password",
+		RemoteAddr: "10.0.0.5",
+		Details:    map[string]interface{}{"attempts": 3},
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, events); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || len(records[1]) != len(csvHeader) {
+		t.Fatalf("Unexpected CSV %v", records)
+	}
+	row := records[1]
+	if row[0] != "2025-03-01T12:00:00Z" || row[1] != "42" || row[2] != "auth_failure" || row[7] != events[0].Message || row[14] != `{"attempts":3}` {
+		t.Errorf("Unexpected CSV row %v", row)
+	}
+
+	buf.Reset()
+	if err := WriteJSON(&buf, events); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []AuditEvent
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 1 || decoded[0].Seq != 42 || decoded[0].RemoteAddr != "10.0.0.5" {
+		t.Errorf("Unexpected JSON %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteJSON(&buf, nil); err != nil || buf.String() != "[]\n" {
+		t.Errorf("Expected an empty array, got %q (%v)", buf.String(), err)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2025-02-28T10:00:00Z", time.Date(2025, 2, 28, 10, 0, 0, 0, time.UTC)},
+		{"2025-02-28T10:30", time.Date(2025, 2, 28, 10, 30, 0, 0, time.Local)},
+		{"2025-02-28", time.Date(2025, 2, 28, 0, 0, 0, 0, time.Local)},
+		{"24h", now.Add(-24 * time.Hour)},
+	}
+	for _, tt := range tests {
+		got, err := ParseTime(tt.value, now)
+		if err != nil {
+			t.Errorf("ParseTime(%q): %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%q) = %v, expected %v", tt.value, got, tt.want)
+		}
+	}
+
+	if _, err := ParseTime("yesterday", now); err == nil {
+		t.Error("Expected an error for an invalid time")
+	}
+	if _, err := ParseSeverities("high,urgent"); err == nil {
+		t.Error("Expected an error for an invalid severity")
+	}
+}
@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// rotatingFile is an append-only log file. Once a write would make it
+// larger than maxSize, it is renamed to <path>.1, older files shift to
+// <path>.2 and so on, and only maxFiles files are kept in total.
+type rotatingFile struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+// openRotatingFile opens path for appending. A maxSize of 0 disables rotation.
+func openRotatingFile(path string, maxSize int64, maxFiles int) (*rotatingFile, error) {
+	f := &rotatingFile{path: path, maxSize: maxSize, maxFiles: maxFiles}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// Write appends p, rotating the file first when needed. Entries are never
+// split across files.
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	if err := f.reopenIfMoved(); err != nil {
+		return 0, err
+	}
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, fmt.Errorf("failed to rotate audit log: %w", err)
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+
+	if f.maxFiles <= 1 {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return f.open()
+	}
+
+	if err := os.Remove(rotatedName(f.path, f.maxFiles-1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := f.maxFiles - 2; i >= 1; i-- {
+		if err := os.Rename(rotatedName(f.path, i), rotatedName(f.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(f.path, rotatedName(f.path, 1)); err != nil {
+		return err
+	}
+	return f.open()
+}
+
+// reopenIfMoved reopens path when another process rotated the file
+func (f *rotatingFile) reopenIfMoved() error {
+	current, err := f.file.Stat()
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(f.path)
+	if err == nil && os.SameFile(current, info) {
+		return nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	f.file.Close()
+	return f.open()
+}
+
+// Name returns the path of the current file
+func (f *rotatingFile) Name() string {
+	return f.path
+}
+
+func (f *rotatingFile) Close() error {
+	return f.file.Close()
+}
+
+func rotatedName(path string, i int) string {
+	return path + "." + strconv.Itoa(i)
+}
+
+// LogFiles returns the rotated files of the log at path, oldest first,
+// followed by path itself
+func LogFiles(path string) []string {
+	matches, _ := filepath.Glob(path + ".*")
+	indexes := make(map[int]string)
+	var order []int
+	for _, match := range matches {
+		i, err := strconv.Atoi(strings.TrimPrefix(match, path+"."))
+		if err != nil || i < 1 {
+			continue
+		}
+		indexes[i] = match
+		order = append(order, i)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(order)))
+
+	files := make([]string, 0, len(order)+1)
+	for _, i := range order {
+		files = append(files, indexes[i])
+	}
+	return append(files, path)
+}
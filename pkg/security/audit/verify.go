@@ -0,0 +1,191 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Problem kinds reported by Verify
+const (
+	ProblemUnparsable   = "unparsable"    // line is not a JSON entry
+	ProblemEdited       = "edited"        // entry no longer matches the hash recorded after it
+	ProblemGap          = "gap"           // sequence numbers are missing
+	ProblemBadSignature = "bad_signature" // checkpoint or head signed with another key
+	ProblemTruncated    = "truncated"     // entries written after the last one in the log
+)
+
+// Problem is an integrity violation found by Verify
+type Problem struct {
+	File    string
+	Line    int
+	Seq     uint64
+	Kind    string
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s", p.Kind, p.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s: %s", p.File, p.Line, p.Kind, p.Message)
+}
+
+// VerifyReport is the result of Verify
+type VerifyReport struct {
+	Files       []string
+	Entries     int    // chained entries
+	Unchained   int    // entries written before hash chaining was introduced
+	FirstSeq    uint64 // first sequence number found, greater than 1 once old files were rotated away
+	LastSeq     uint64
+	Checkpoints int
+	Signed      bool // checkpoint signatures were checked
+	// UnsignedEntries were written without the checkpoint key after the
+	// last signed entry, so only the hash chain covers them
+	UnsignedEntries uint64
+	Problems        []Problem
+}
+
+// OK reports whether no problem was found
+func (r *VerifyReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// chainedEntry holds the fields of an entry used for verification
+type chainedEntry struct {
+	Seq       *uint64 `json:"seq"`
+	PrevHash  *string `json:"prev_hash"`
+	EventType string  `json:"audit_event_type"`
+	Signature string  `json:"signature"`
+}
+
+// Verify checks the hash chain of the JSON audit log at path and its
+// rotated files. It detects edited, removed and reordered entries, and a
+// truncated log by comparing its end with the head file. With the
+// checkpoint key, checkpoint and head signatures are checked too, which
+// detects a chain rewritten from scratch.
+func Verify(path string, key []byte) (*VerifyReport, error) {
+	report := &VerifyReport{Signed: key != nil}
+
+	var (
+		lastSeq  uint64
+		lastHash string // hash of the previous line, when it is the previous entry
+		chained  bool
+	)
+
+	h, headErr := readHead(path)
+	if headErr != nil && !os.IsNotExist(headErr) {
+		return nil, headErr
+	}
+
+	for _, file := range LogFiles(path) {
+		f, err := os.Open(file)
+		if err != nil {
+			if os.IsNotExist(err) && file == path {
+				continue
+			}
+			return nil, err
+		}
+		report.Files = append(report.Files, file)
+
+		problem := func(line int, seq uint64, kind, format string, args ...interface{}) {
+			report.Problems = append(report.Problems, Problem{File: file, Line: line, Seq: seq, Kind: kind, Message: fmt.Sprintf(format, args...)})
+		}
+
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for lineNo := 1; scanner.Scan(); lineNo++ {
+			line := scanner.Bytes()
+			if len(line) == 0 {
+				continue
+			}
+			hash := hashLine(line)
+
+			var entry chainedEntry
+			if err := json.Unmarshal(line, &entry); err != nil {
+				problem(lineNo, 0, ProblemUnparsable, "not a JSON entry")
+				lastHash = ""
+				continue
+			}
+
+			if entry.Seq == nil || entry.PrevHash == nil {
+				if chained {
+					problem(lineNo, 0, ProblemEdited, "entry without sequence number after seq %d", lastSeq)
+				} else {
+					report.Unchained++
+				}
+				lastHash = hash
+				continue
+			}
+
+			seq := *entry.Seq
+			report.Entries++
+			switch {
+			case !chained:
+				report.FirstSeq = seq
+				chained = true
+				if lastHash != "" && *entry.PrevHash != lastHash {
+					problem(lineNo, seq, ProblemEdited, "previous entry does not match the hash of seq %d", seq)
+				}
+			case seq == lastSeq+1:
+				if lastHash != "" && *entry.PrevHash != lastHash {
+					problem(lineNo, seq, ProblemEdited, "entry %d does not match the hash recorded in entry %d", lastSeq, seq)
+				}
+			case seq > lastSeq+1:
+				problem(lineNo, seq, ProblemGap, "entries %d to %d are missing", lastSeq+1, seq-1)
+			default:
+				problem(lineNo, seq, ProblemEdited, "sequence goes back from %d to %d", lastSeq, seq)
+			}
+
+			// Entries written without key since the signed one must not alter it
+			if h != nil && h.SignedHash != "" && seq == h.SignedSeq && hash != h.SignedHash {
+				problem(lineNo, seq, ProblemEdited, "entry %d does not match the signed head", seq)
+			}
+
+			if entry.EventType == string(AuditCheckpoint) {
+				report.Checkpoints++
+				if key != nil && !validSignature(entry.Signature, checkpointSignature(key, seq, *entry.PrevHash)) {
+					problem(lineNo, seq, ProblemBadSignature, "checkpoint signature does not match")
+				}
+			}
+
+			lastSeq = seq
+			lastHash = hash
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+	}
+	report.LastSeq = lastSeq
+
+	if h == nil {
+		if chained {
+			report.Problems = append(report.Problems, Problem{Kind: ProblemTruncated, Message: "head file is missing, truncation cannot be ruled out"})
+		}
+	} else {
+		signedSeq, signedHash := h.signedEntry()
+		switch {
+		case key == nil:
+		case h.Signature == "" && report.Checkpoints == 0:
+			// No entry was ever written with the key
+			report.UnsignedEntries = uint64(report.Entries)
+		case !validSignature(h.Signature, headSignature(key, signedSeq, signedHash)):
+			report.Problems = append(report.Problems, Problem{Kind: ProblemBadSignature, Message: "head signature does not match"})
+		default:
+			report.UnsignedEntries = h.Seq - signedSeq
+		}
+		switch {
+		case h.Seq > lastSeq:
+			report.Problems = append(report.Problems, Problem{Kind: ProblemTruncated, Message: fmt.Sprintf("log ends at seq %d but %d entries were written", lastSeq, h.Seq)})
+		case h.Seq < lastSeq:
+			report.Problems = append(report.Problems, Problem{Kind: ProblemEdited, Message: fmt.Sprintf("entries after seq %d were not written by the audit logger", h.Seq)})
+		case h.Hash != lastHash:
+			report.Problems = append(report.Problems, Problem{Kind: ProblemEdited, Message: fmt.Sprintf("last entry %d does not match the head file", lastSeq)})
+		}
+	}
+
+	return report, nil
+}
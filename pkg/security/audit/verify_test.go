@@ -0,0 +1,334 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testCheckpointKey = []byte("0123456789abcdef0123456789abcdef")
+
+// writeTestLog logs n events to a new audit log and closes it
+func writeTestLog(t *testing.T, logFile string, n int, config Config) {
+	t.Helper()
+	config.LogLevel = "info"
+	config.OutputFormat = "json"
+	config.LogFile = logFile
+
+	logger, err := NewLogger(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.SetCheckpointKey(testCheckpointKey)
+	for i := 0; i < n; i++ {
+		logger.LogSystemEvent(SystemStart, "test", fmt.Sprintf("step_%d", i), "success")
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readLines(t *testing.T, path string) [][]byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.Split(bytes.TrimRight(data, "\n"), []byte("\n"))
+}
+
+func writeLines(t *testing.T, path string, lines [][]byte) {
+	t.Helper()
+	if err := os.WriteFile(path, append(bytes.Join(lines, []byte("\n")), '\n'), 0640); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expectProblem(t *testing.T, report *VerifyReport, kind string) {
+	t.Helper()
+	for _, problem := range report.Problems {
+		if problem.Kind == kind {
+			return
+		}
+	}
+	t.Errorf("Expected a %s problem, got %v", kind, report.Problems)
+}
+
+func TestVerifyIntactLog(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	writeTestLog(t, logFile, 10, Config{CheckpointInterval: 4})
+	// A second run continues the chain
+	writeTestLog(t, logFile, 3, Config{CheckpointInterval: 4})
+
+	report, err := Verify(logFile, testCheckpointKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() {
+		t.Fatalf("Expected an intact log, got %v", report.Problems)
+	}
+	// 13 events, checkpoints after 4 and 8 entries and on each close
+	if report.Entries != 17 || report.FirstSeq != 1 || report.LastSeq != 17 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+	if report.Checkpoints != 4 {
+		t.Errorf("Expected 4 checkpoints, got %d", report.Checkpoints)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(lines [][]byte) [][]byte
+		kind   string
+	}{
+		{"edited entry", func(lines [][]byte) [][]byte {
+			lines[2] = bytes.Replace(lines[2], []byte("step_2"), []byte("step_x"), 1)
+			return lines
+		}, ProblemEdited},
+		{"removed entry", func(lines [][]byte) [][]byte {
+			return append(lines[:3], lines[4:]...)
+		}, ProblemGap},
+		{"truncated log", func(lines [][]byte) [][]byte {
+			return lines[:len(lines)-2]
+		}, ProblemTruncated},
+		{"edited last entry", func(lines [][]byte) [][]byte {
+			last := len(lines) - 1
+			lines[last] = bytes.Replace(lines[last], []byte("checkpoint"), []byte("checkpoinT"), 1)
+			return lines
+		}, ProblemEdited},
+		{"reordered entries", func(lines [][]byte) [][]byte {
+			lines[3], lines[4] = lines[4], lines[3]
+			return lines
+		}, ProblemEdited},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logFile := filepath.Join(t.TempDir(), "audit.log")
+			writeTestLog(t, logFile, 8, Config{CheckpointInterval: 3})
+			writeLines(t, logFile, tt.tamper(readLines(t, logFile)))
+
+			report, err := Verify(logFile, testCheckpointKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectProblem(t, report, tt.kind)
+		})
+	}
+}
+
+func TestVerifyDetectsRewrittenChain(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	writeTestLog(t, logFile, 5, Config{CheckpointInterval: 2})
+
+	// A consistent chain signed with another key
+	report, err := Verify(logFile, []byte("another key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectProblem(t, report, ProblemBadSignature)
+
+	// Without key, only the hashes are checked
+	report, err = Verify(logFile, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || report.Signed {
+		t.Errorf("Expected an unsigned intact report, got %+v", report)
+	}
+}
+
+func TestVerifyRotatedLogs(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	writeTestLog(t, logFile, 40, Config{MaxFileSize: 2048, MaxFiles: 3})
+
+	files := LogFiles(logFile)
+	if len(files) != 3 {
+		t.Fatalf("Expected 3 log files, got %v", files)
+	}
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > 2048 {
+			t.Errorf("%s exceeds the maximum size: %d bytes", file, info.Size())
+		}
+	}
+
+	report, err := Verify(logFile, testCheckpointKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() {
+		t.Fatalf("Expected intact rotated logs, got %v", report.Problems)
+	}
+	if report.FirstSeq <= 1 || report.LastSeq != 41 {
+		t.Errorf("Expected the oldest entries to be rotated away, got seq %d to %d", report.FirstSeq, report.LastSeq)
+	}
+
+	// Removing a rotated file in the middle leaves a gap
+	if err := os.Remove(files[1]); err != nil {
+		t.Fatal(err)
+	}
+	report, err = Verify(logFile, testCheckpointKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectProblem(t, report, ProblemGap)
+}
+
+func TestVerifyLegacyEntries(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	legacy := `{"audit_event_type":"system_start","level":"info","message":"System initialize: success"}`
+	if err := os.WriteFile(logFile, []byte(legacy+"\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	writeTestLog(t, logFile, 2, Config{})
+
+	report, err := Verify(logFile, testCheckpointKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || report.Unchained != 1 {
+		t.Errorf("Expected one unchained entry and no problem, got %+v", report)
+	}
+
+	lines := readLines(t, logFile)
+	if !strings.Contains(string(lines[1]), `"prev_hash":"`+hashLine([]byte(legacy))+`"`) {
+		t.Errorf("Expected the chain to start from the last legacy entry, got %s", lines[1])
+	}
+}
+
+// writeUnkeyedLog logs n events without checkpoint key, like a process
+// that could not read the key from the keyring
+func writeUnkeyedLog(t *testing.T, logFile string, n int) {
+	t.Helper()
+	logger, err := NewLogger(Config{LogLevel: "info", OutputFormat: "json", LogFile: logFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		logger.LogSystemEvent(SystemStart, "unkeyed", fmt.Sprintf("step_%d", i), "success")
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyUnkeyedWriter(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	writeTestLog(t, logFile, 4, Config{CheckpointInterval: 2})
+	writeUnkeyedLog(t, logFile, 3)
+
+	// Entries written without key are not reported as tampering
+	report, err := Verify(logFile, testCheckpointKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() {
+		t.Fatalf("Expected no problem after an unkeyed writer, got %v", report.Problems)
+	}
+	if report.UnsignedEntries != 3 {
+		t.Errorf("Expected 3 unsigned entries, got %d", report.UnsignedEntries)
+	}
+
+	// The signed entries stay covered
+	lines := readLines(t, logFile)
+	signed := len(lines) - 4
+	lines[signed] = bytes.Replace(lines[signed], []byte("checkpoint"), []byte("checkpoinT"), 1)
+	writeLines(t, logFile, lines)
+	report, err = Verify(logFile, testCheckpointKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectProblem(t, report, ProblemEdited)
+}
+
+func TestVerifyUnkeyedWriterThenKeyed(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	writeTestLog(t, logFile, 2, Config{})
+	writeUnkeyedLog(t, logFile, 2)
+	writeTestLog(t, logFile, 2, Config{})
+
+	report, err := Verify(logFile, testCheckpointKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || report.UnsignedEntries != 0 {
+		t.Errorf("Expected a signed intact log, got %+v", report)
+	}
+}
+
+func TestVerifyNeverKeyedLog(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	writeUnkeyedLog(t, logFile, 3)
+
+	report, err := Verify(logFile, testCheckpointKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || report.UnsignedEntries != 3 {
+		t.Errorf("Expected 3 unsigned entries and no problem, got %+v", report)
+	}
+}
+
+// failingWriter fails writes while fail is set
+type failingWriter struct {
+	io.Writer
+	fail bool
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.fail {
+		return 0, errors.New("disk full")
+	}
+	return w.Writer.Write(p)
+}
+
+func TestVerifyFailedWrite(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	logger, err := NewLogger(Config{LogLevel: "info", OutputFormat: "json", LogFile: logFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.SetCheckpointKey(testCheckpointKey)
+	writer := &failingWriter{Writer: logger.logFile}
+	logger.SetOutput(writer)
+
+	logger.LogSystemEvent(SystemStart, "test", "step_0", "success")
+	writer.fail = true
+	logger.LogSystemEvent(SystemStart, "test", "lost", "success")
+
+	// The head still records the last entry written
+	h, err := readHead(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Seq != 1 {
+		t.Errorf("Expected the head at seq 1 after a failed write, got %d", h.Seq)
+	}
+
+	writer.fail = false
+	logger.LogSystemEvent(SystemStart, "test", "step_1", "success")
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Verify(logFile, testCheckpointKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() {
+		t.Fatalf("Expected an intact log after a failed write, got %v", report.Problems)
+	}
+	if report.LastSeq != 3 {
+		t.Errorf("Expected 3 entries including the closing checkpoint, got %+v", report)
+	}
+}
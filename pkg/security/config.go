@@ -3,19 +3,85 @@ package security
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
 // Config holds all security-related configuration
 type Config struct {
 	EncryptionEnabled  bool         `toml:"encryption_enabled"`
-	KeyringBackend     string       `toml:"keyring_backend"`     // system, env, file
+	KeyringBackend     string       `toml:"keyring_backend"`     // system, env, file, dir, vault, chain
 	AuditLogging       bool         `toml:"audit_logging"`
 	RateLimitEnabled   bool         `toml:"rate_limit_enabled"`
 	RequireHTTPS       bool         `toml:"require_https"`
+	WebPurge           bool         `toml:"web_purge"`           // allow the purge action of the web interface
 	Audit              AuditConfig  `toml:"audit"`
 	RateLimit          RateLimitConfig `toml:"rate_limit"`
+	WebRateLimit       WebRateLimitConfig `toml:"web_rate_limit"`
 	FileSystem         FileSystemConfig `toml:"filesystem"`
 	HTTPS              HTTPSConfig  `toml:"https"`
+	TLS                TLSConfig    `toml:"tls"`
+	FileKeyring        FileKeyringConfig `toml:"file_keyring"`
+	DirKeyring         DirKeyringConfig   `toml:"dir_keyring"`
+	VaultKeyring       VaultKeyringConfig `toml:"vault_keyring"`
+	ChainKeyring       ChainKeyringConfig `toml:"chain_keyring"`
+}
+
+// FileKeyringConfig holds configuration of the encrypted file keyring backend
+type FileKeyringConfig struct {
+	Path          string `toml:"path"`           // Encrypted credentials file
+	KeySource     string `toml:"key_source"`     // auto, passphrase, key_file, system
+	KeyFile       string `toml:"key_file"`       // Key of the key_file source
+	PassphraseEnv string `toml:"passphrase_env"` // Variable holding the passphrase
+}
+
+// DefaultFileKeyringConfig returns the default file keyring configuration
+func DefaultFileKeyringConfig() FileKeyringConfig {
+	return FileKeyringConfig{
+		Path:          "./config/credentials.enc",
+		KeySource:     "auto",
+		KeyFile:       "./config/keyring.key",
+		PassphraseEnv: "EXPORT_TRAKT_KEYRING_PASSPHRASE",
+	}
+}
+
+// DirKeyringConfig holds configuration of the secrets directory keyring backend
+type DirKeyringConfig struct {
+	Path string `toml:"path"` // One file per secret, e.g. /run/secrets
+}
+
+// DefaultDirKeyringConfig returns the default secrets directory configuration
+func DefaultDirKeyringConfig() DirKeyringConfig {
+	return DirKeyringConfig{
+		Path: "/run/secrets",
+	}
+}
+
+// VaultKeyringConfig holds configuration of the HashiCorp Vault keyring backend
+type VaultKeyringConfig struct {
+	Address   string        `toml:"address"`    // Defaults to VAULT_ADDR
+	TokenEnv  string        `toml:"token_env"`  // Variable holding the token
+	TokenFile string        `toml:"token_file"` // File holding the token, e.g. from the Vault agent
+	Namespace string        `toml:"namespace"`  // Vault Enterprise namespace
+	Mount     string        `toml:"mount"`      // KV secrets engine mount
+	Path      string        `toml:"path"`       // Prefix of the credentials within the mount
+	KVVersion int           `toml:"kv_version"` // 1 or 2
+	Timeout   time.Duration `toml:"timeout"`
+}
+
+// DefaultVaultKeyringConfig returns the default Vault configuration
+func DefaultVaultKeyringConfig() VaultKeyringConfig {
+	return VaultKeyringConfig{
+		TokenEnv:  "VAULT_TOKEN",
+		Mount:     "secret",
+		Path:      "export-trakt",
+		KVVersion: 2,
+		Timeout:   10 * time.Second,
+	}
+}
+
+// ChainKeyringConfig holds configuration of the chained keyring backend
+type ChainKeyringConfig struct {
+	Backends []string `toml:"backends"` // Read in order, new credentials go to the first
 }
 
 // AuditConfig holds audit logging configuration
@@ -24,6 +90,9 @@ type AuditConfig struct {
 	RetentionDays   int    `toml:"retention_days"`   // 90 days default
 	IncludeSensitive bool   `toml:"include_sensitive"` // false default for security
 	OutputFormat    string `toml:"output_format"`    // json, text
+	MaxFileSizeMB   int    `toml:"max_file_size_mb"`  // rotate the log above this size, 10 default
+	MaxFiles        int    `toml:"max_files"`         // files kept including the current one, 5 default
+	CheckpointInterval int `toml:"checkpoint_interval"` // entries between signed checkpoints, 100 default
 }
 
 // DefaultSecurityConfig returns a secure default configuration
@@ -39,10 +108,18 @@ func DefaultSecurityConfig() Config {
 			RetentionDays:    90,
 			IncludeSensitive: false,
 			OutputFormat:     "json",
+			MaxFileSizeMB:      10,
+			MaxFiles:           5,
+			CheckpointInterval: 100,
 		},
 		RateLimit:  DefaultRateLimitConfig(),
+		WebRateLimit: DefaultWebRateLimitConfig(),
 		FileSystem: DefaultFileSystemConfig(),
 		HTTPS:      DefaultHTTPSConfig(),
+		TLS:        DefaultTLSConfig(),
+		FileKeyring: DefaultFileKeyringConfig(),
+		DirKeyring:   DefaultDirKeyringConfig(),
+		VaultKeyring: DefaultVaultKeyringConfig(),
 	}
 }
 
@@ -56,22 +133,92 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("audit config: %w", err)
 	}
 
+	if err := c.WebRateLimit.Validate(); err != nil {
+		return fmt.Errorf("web rate limit: %w", err)
+	}
+
+	if err := c.TLS.Validate(); err != nil {
+		return fmt.Errorf("tls: %w", err)
+	}
+
+	if err := c.FileKeyring.Validate(); err != nil {
+		return fmt.Errorf("file keyring: %w", err)
+	}
+
+	if err := c.VaultKeyring.Validate(); err != nil {
+		return fmt.Errorf("vault keyring: %w", err)
+	}
+
+	if strings.ToLower(c.KeyringBackend) == "chain" {
+		if err := c.ChainKeyring.Validate(); err != nil {
+			return fmt.Errorf("chain keyring: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks if the file keyring configuration is valid
+func (fc *FileKeyringConfig) Validate() error {
+	switch fc.KeySource {
+	case "", "auto", "passphrase", "key_file", "system":
+	default:
+		return fmt.Errorf("invalid key source: %s (must be one of: auto, passphrase, key_file, system)", fc.KeySource)
+	}
+
+	if fc.KeySource == "key_file" && fc.KeyFile == "" {
+		return fmt.Errorf("key_file is required by the key_file source")
+	}
+
 	return nil
 }
 
 // validateKeyringBackend validates the keyring backend setting
 func (c *Config) validateKeyringBackend() error {
-	validBackends := []string{"system", "env", "file"}
-	backend := strings.ToLower(c.KeyringBackend)
-	
-	for _, valid := range validBackends {
-		if backend == valid {
+	return checkKeyringBackend(c.KeyringBackend, validKeyringBackends)
+}
+
+var (
+	// validKeyringBackends are the backends keyring_backend can select
+	validKeyringBackends = []string{"system", "env", "file", "dir", "vault", "chain"}
+	// chainableKeyringBackends are the backends a chain can read from
+	chainableKeyringBackends = []string{"system", "env", "file", "dir", "vault"}
+)
+
+func checkKeyringBackend(name string, valid []string) error {
+	backend := strings.ToLower(name)
+	for _, v := range valid {
+		if backend == v {
 			return nil
 		}
 	}
 	
 	return fmt.Errorf("invalid keyring backend: %s (must be one of: %s)", 
-		c.KeyringBackend, strings.Join(validBackends, ", "))
+		name, strings.Join(valid, ", "))
+}
+
+// Validate checks if the Vault configuration is valid
+func (vc *VaultKeyringConfig) Validate() error {
+	if vc.KVVersion != 0 && vc.KVVersion != 1 && vc.KVVersion != 2 {
+		return fmt.Errorf("invalid kv_version: %d (must be 1 or 2)", vc.KVVersion)
+	}
+	if vc.Address != "" && !strings.HasPrefix(vc.Address, "http://") && !strings.HasPrefix(vc.Address, "https://") {
+		return fmt.Errorf("address must be an http(s) URL, got %q", vc.Address)
+	}
+	return nil
+}
+
+// Validate checks if the chained keyring configuration is valid
+func (cc *ChainKeyringConfig) Validate() error {
+	if len(cc.Backends) == 0 {
+		return fmt.Errorf("backends must list at least one backend")
+	}
+	for _, backend := range cc.Backends {
+		if err := checkKeyringBackend(backend, chainableKeyringBackends); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Validate checks if the audit configuration is valid
@@ -88,6 +235,10 @@ func (ac *AuditConfig) Validate() error {
 		return fmt.Errorf("output format: %w", err)
 	}
 
+	if ac.MaxFileSizeMB < 0 || ac.MaxFiles < 0 || ac.CheckpointInterval < 0 {
+		return fmt.Errorf("max_file_size_mb, max_files and checkpoint_interval must not be negative")
+	}
+
 	return nil
 }
 
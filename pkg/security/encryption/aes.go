@@ -4,11 +4,12 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
-	"crypto/sha256"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
+
+	"golang.org/x/crypto/argon2"
 )
 
 const (
@@ -16,6 +17,11 @@ const (
 	AESKeySize = 32
 	// NonceSize is the size of the GCM nonce in bytes
 	NonceSize = 12
+
+	// Argon2id parameters of DeriveKey (RFC 9106 second recommendation)
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
 )
 
 var (
@@ -49,17 +55,26 @@ func NewEncryptor(key []byte) (*Encryptor, error) {
 
 // NewEncryptorFromPassword creates a new encryptor using a password-derived key
 func NewEncryptorFromPassword(password string, salt []byte) (*Encryptor, error) {
+	key, err := DeriveKey(password, salt)
+	if err != nil {
+		return nil, err
+	}
+	defer zero(key)
+
+	return NewEncryptor(key)
+}
+
+// DeriveKey derives an AES-256 key from a password with Argon2id, the salt
+// being stored next to the data it protects
+func DeriveKey(password string, salt []byte) ([]byte, error) {
 	if len(salt) == 0 {
 		return nil, errors.New("salt cannot be empty")
 	}
+	if password == "" {
+		return nil, errors.New("password cannot be empty")
+	}
 
-	// Derive key using SHA-256 (for simplicity, in production consider using PBKDF2 or Argon2)
-	hash := sha256.New()
-	hash.Write([]byte(password))
-	hash.Write(salt)
-	key := hash.Sum(nil)
-
-	return NewEncryptor(key)
+	return argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, AESKeySize), nil
 }
 
 // GenerateKey generates a cryptographically secure random key for AES-256
@@ -161,13 +176,16 @@ func (e *Encryptor) DecryptBytes(ciphertext string) ([]byte, error) {
 
 // Destroy securely clears the encryption key from memory
 func (e *Encryptor) Destroy() {
-	// Zero out the key
-	for i := range e.key {
-		e.key[i] = 0
-	}
+	zero(e.key)
 	e.key = nil
 }
 
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
 // EncryptCredentials is a convenience function to encrypt API credentials
 func EncryptCredentials(clientID, clientSecret, accessToken string, key []byte) (map[string]string, error) {
 	encryptor, err := NewEncryptor(key)
@@ -256,6 +256,44 @@ func TestEncryptorDestroy(t *testing.T) {
 	}
 }
 
+func TestDeriveKey(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+
+	key, err := DeriveKey("correct horse", salt)
+	if err != nil {
+		t.Fatalf("DeriveKey failed: %v", err)
+	}
+	if len(key) != AESKeySize {
+		t.Fatalf("Expected %d byte key, got %d", AESKeySize, len(key))
+	}
+
+	again, _ := DeriveKey("correct horse", salt)
+	if string(again) != string(key) {
+		t.Error("Same password and salt should derive the same key")
+	}
+	other, _ := DeriveKey("correct horse", []byte("fedcba9876543210"))
+	if string(other) == string(key) {
+		t.Error("Different salts should derive different keys")
+	}
+
+	if _, err := DeriveKey("", salt); err == nil {
+		t.Error("Expected error for empty password")
+	}
+	if _, err := DeriveKey("correct horse", nil); err == nil {
+		t.Error("Expected error for empty salt")
+	}
+
+	encryptor, err := NewEncryptorFromPassword("correct horse", salt)
+	if err != nil {
+		t.Fatalf("NewEncryptorFromPassword failed: %v", err)
+	}
+	ciphertext, _ := encryptor.Encrypt("secret")
+	keyed, _ := NewEncryptor(key)
+	if plaintext, err := keyed.Decrypt(ciphertext); err != nil || plaintext != "secret" {
+		t.Errorf("Password encryptor should use the derived key, got %q, %v", plaintext, err)
+	}
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
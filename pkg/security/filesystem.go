@@ -1,7 +1,9 @@
 package security
 
 import (
+	"crypto/rand"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -233,17 +235,31 @@ func (fs *FileSystemSecurity) ValidateFilePermissions(path string) error {
 	return nil
 }
 
-// SecureDeleteFile securely deletes a file
+// SecureDeleteFile securely deletes a file. The content of a regular file is
+// overwritten with random data and flushed before the file is removed; on
+// SSDs and copy-on-write file systems the old blocks may still survive.
 func (fs *FileSystemSecurity) SecureDeleteFile(path string) error {
 	// Validate path first
 	if err := fs.ValidatePath(path); err != nil {
 		return err
 	}
 
-	// Check if file exists
-	if _, err := os.Stat(path); os.IsNotExist(err) {
+	// Check if file exists, without following symlinks
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
 		return nil // File doesn't exist, nothing to do
 	}
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	// Overwrite the content of regular files
+	if info.Mode().IsRegular() {
+		if err := overwriteFile(path, info.Size()); err != nil {
+			fs.logFileOperation("delete_file", path, "failed", err.Error())
+			return fmt.Errorf("failed to overwrite file: %w", err)
+		}
+	}
 
 	// Remove file
 	if err := os.Remove(path); err != nil {
@@ -255,6 +271,20 @@ func (fs *FileSystemSecurity) SecureDeleteFile(path string) error {
 	return nil
 }
 
+// overwriteFile replaces size bytes of a file with random data
+func overwriteFile(path string, size int64) error {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := io.CopyN(file, rand.Reader, size); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
 // CleanupTempFiles removes temporary files older than specified duration
 func (fs *FileSystemSecurity) CleanupTempFiles(tempDir string, maxAge time.Duration) error {
 	// Validate temp directory path
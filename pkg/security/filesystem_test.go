@@ -320,6 +320,25 @@ func TestSecureDelete(t *testing.T) {
 	if _, err := os.Stat(testPath); !os.IsNotExist(err) {
 		t.Error("File should have been deleted")
 	}
+
+	// The content is overwritten, as seen through a hard link
+	linkedPath := filepath.Join(tempDir, "linked.txt")
+	if err := os.WriteFile(testPath, testData, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(testPath, linkedPath); err != nil {
+		t.Skipf("Hard links not supported: %v", err)
+	}
+	if err := fs.SecureDeleteFile(testPath); err != nil {
+		t.Fatalf("SecureDeleteFile failed: %v", err)
+	}
+	remaining, err := os.ReadFile(linkedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != len(testData) || string(remaining) == string(testData) {
+		t.Error("File content should have been overwritten")
+	}
 }
 
 func TestWithAuditLogging(t *testing.T) {
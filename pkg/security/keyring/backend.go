@@ -0,0 +1,80 @@
+package keyring
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Backend stores the credentials of a Manager
+type Backend interface {
+	Store(key, value string) error
+	// Retrieve returns ErrCredentialNotFound for unknown keys
+	Retrieve(key string) (string, error)
+	Delete(key string) error
+	List() ([]string, error)
+}
+
+// BackendConfig holds the options of a Manager available to backend factories
+type BackendConfig struct {
+	FilePath      string
+	EncryptionKey []byte
+	Dir           string
+	Vault         VaultConfig
+	Chain         []BackendName
+}
+
+// BackendFactory creates a backend from the options of a Manager
+type BackendFactory func(cfg BackendConfig) (Backend, error)
+
+var (
+	backendsMutex sync.RWMutex
+	backends      = make(map[BackendName]BackendFactory)
+)
+
+func init() {
+	RegisterBackend(SystemBackend, func(BackendConfig) (Backend, error) { return systemBackend{}, nil })
+	RegisterBackend(EnvBackend, func(BackendConfig) (Backend, error) { return envBackend{}, nil })
+	RegisterBackend(FileBackend, newFileBackend)
+	RegisterBackend(MemoryBackend, func(BackendConfig) (Backend, error) { return newMemoryBackend(), nil })
+	RegisterBackend(DirBackend, newDirBackend)
+	RegisterBackend(VaultBackend, newVaultBackend)
+	RegisterBackend(ChainBackend, newChainBackend)
+}
+
+// RegisterBackend makes a backend available to NewManager under name,
+// replacing any backend registered with the same name
+func RegisterBackend(name BackendName, factory BackendFactory) {
+	backendsMutex.Lock()
+	defer backendsMutex.Unlock()
+	backends[name] = factory
+}
+
+// RegisteredBackends returns the names of the registered backends, sorted
+func RegisteredBackends() []BackendName {
+	backendsMutex.RLock()
+	defer backendsMutex.RUnlock()
+
+	names := make([]BackendName, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// newBackend creates the backend registered under name
+func newBackend(name BackendName, cfg BackendConfig) (Backend, error) {
+	backendsMutex.RLock()
+	factory, ok := backends[name]
+	backendsMutex.RUnlock()
+	if !ok {
+		return nil, ErrUnsupportedBackend
+	}
+
+	backend, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s backend: %w", name, err)
+	}
+	return backend, nil
+}
@@ -0,0 +1,260 @@
+package keyring
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/encryption"
+)
+
+func TestRegisterBackend(t *testing.T) {
+	name := BackendName("test-registered")
+	memory := newMemoryBackend()
+	RegisterBackend(name, func(cfg BackendConfig) (Backend, error) {
+		return memory, nil
+	})
+	defer func() {
+		backendsMutex.Lock()
+		delete(backends, name)
+		backendsMutex.Unlock()
+	}()
+
+	found := false
+	for _, registered := range RegisteredBackends() {
+		found = found || registered == name
+	}
+	if !found {
+		t.Errorf("Expected %s in %v", name, RegisteredBackends())
+	}
+
+	manager, err := NewManager(name)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	if err := manager.Store("key", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := memory.Retrieve("key"); value != "value" {
+		t.Errorf("Expected the registered backend to store the credential, got %q", value)
+	}
+}
+
+func TestDirBackendOperations(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "client_secret"), []byte("s3cret\n"), 0400); err != nil {
+		t.Fatal(err)
+	}
+	// Kubernetes secret volumes hold the files in a hidden directory
+	if err := os.Mkdir(filepath.Join(dir, "..data"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := NewManager(DirBackend, WithDir(dir))
+	if err != nil {
+		t.Fatalf("Failed to create dir manager: %v", err)
+	}
+
+	value, err := manager.Retrieve("client_secret")
+	if err != nil || value != "s3cret" {
+		t.Errorf("Expected trimmed s3cret, got %q (%v)", value, err)
+	}
+	if _, err := manager.Retrieve("access_token"); err != ErrCredentialNotFound {
+		t.Errorf("Expected ErrCredentialNotFound, got %v", err)
+	}
+	for _, key := range []string{"../config.toml", "..data", ""} {
+		if _, err := manager.Retrieve(key); err == nil || err == ErrCredentialNotFound {
+			t.Errorf("Expected secret name %q to be rejected, got %v", key, err)
+		}
+	}
+
+	if err := manager.Store("access_token", "tok"); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "access_token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("Expected stored secret permissions 0600, got %o", perm)
+	}
+
+	keys, err := manager.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if strings.Join(keys, ",") != "access_token,client_secret" {
+		t.Errorf("Unexpected keys %v", keys)
+	}
+
+	if err := manager.Delete("access_token"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := manager.Delete("access_token"); err != ErrCredentialNotFound {
+		t.Errorf("Expected ErrCredentialNotFound, got %v", err)
+	}
+
+	if _, err := NewManager(DirBackend); err == nil {
+		t.Error("Expected an error without directory")
+	}
+}
+
+// failingBackend fails every operation, like an unreachable secret store
+type failingBackend struct{}
+
+var errUnreachable = errors.New("unreachable")
+
+func (failingBackend) Store(key, value string) error       { return errUnreachable }
+func (failingBackend) Retrieve(key string) (string, error) { return "", errUnreachable }
+func (failingBackend) Delete(key string) error             { return errUnreachable }
+func (failingBackend) List() ([]string, error)             { return nil, errUnreachable }
+
+func TestChainBackend(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "client_secret"), []byte("from-dir"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	filePath := filepath.Join(t.TempDir(), "credentials.enc")
+
+	manager, err := NewManager(ChainBackend,
+		WithChain(DirBackend, FileBackend),
+		WithDir(dir),
+		WithEncryptionKey(key),
+		WithFilePath(filePath))
+	if err != nil {
+		t.Fatalf("Failed to create chain manager: %v", err)
+	}
+
+	// The first backend holding a key wins
+	file, err := NewManager(FileBackend, WithEncryptionKey(key), WithFilePath(filePath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Store("client_secret", "from-file"); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Store("access_token", "tok"); err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := manager.Retrieve("client_secret")
+	if err != nil || value != "from-dir" {
+		t.Errorf("Expected from-dir, got %q (%v)", value, err)
+	}
+	value, err = manager.Retrieve("access_token")
+	if err != nil || value != "tok" {
+		t.Errorf("Expected tok from the file backend, got %q (%v)", value, err)
+	}
+	if _, err := manager.Retrieve("client_id"); err != ErrCredentialNotFound {
+		t.Errorf("Expected ErrCredentialNotFound, got %v", err)
+	}
+
+	keys, err := manager.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if strings.Join(keys, ",") != "access_token,client_secret" {
+		t.Errorf("Unexpected keys %v", keys)
+	}
+	if err := manager.Verify(); err != nil {
+		t.Errorf("Verify failed: %v", err)
+	}
+
+	// Deletes apply to every backend
+	if err := manager.Delete("client_secret"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := manager.Retrieve("client_secret"); err != ErrCredentialNotFound {
+		t.Errorf("Expected ErrCredentialNotFound after delete, got %v", err)
+	}
+
+	if _, err := NewManager(ChainBackend); err == nil {
+		t.Error("Expected an error for an empty chain")
+	}
+	if _, err := NewManager(ChainBackend, WithChain(ChainBackend)); err == nil {
+		t.Error("Expected an error for a chain containing itself")
+	}
+}
+
+func TestChainBackendSkipsFailingBackends(t *testing.T) {
+	memory := newMemoryBackend()
+	memory.Store("client_id", "id")
+	chain := &chainBackend{
+		names:    []BackendName{"vault", MemoryBackend},
+		backends: []Backend{failingBackend{}, memory},
+	}
+
+	value, err := chain.Retrieve("client_id")
+	if err != nil || value != "id" {
+		t.Errorf("Expected id from the memory backend, got %q (%v)", value, err)
+	}
+	if _, err := chain.Retrieve("client_secret"); !errors.Is(err, errUnreachable) {
+		t.Errorf("Expected the failing backend's error when no backend holds the key, got %v", err)
+	}
+}
+
+// readOnlyBackend serves credentials but refuses writes, like a read-only
+// /run/secrets mount
+type readOnlyBackend struct {
+	Backend
+}
+
+var errReadOnly = errors.New("read-only file system")
+
+func (readOnlyBackend) Store(key, value string) error { return errReadOnly }
+func (readOnlyBackend) Delete(key string) error       { return errReadOnly }
+
+func TestChainBackendReadOnlyFirst(t *testing.T) {
+	secrets := newMemoryBackend()
+	secrets.Store("client_secret", "mounted")
+	writable := newMemoryBackend()
+	chain := &chainBackend{
+		names:    []BackendName{DirBackend, MemoryBackend},
+		backends: []Backend{readOnlyBackend{secrets}, writable},
+	}
+
+	// New credentials go to the writable backend
+	if err := chain.Store("access_token", "tok"); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if value, _ := writable.Retrieve("access_token"); value != "tok" {
+		t.Errorf("Expected the writable backend to store the token, got %q", value)
+	}
+	if value, err := chain.Retrieve("access_token"); err != nil || value != "tok" {
+		t.Errorf("Expected tok, got %q (%v)", value, err)
+	}
+
+	// Deletes skip the read-only backend when it does not hold the key
+	if err := chain.Delete("access_token"); err != nil {
+		t.Errorf("Delete failed: %v", err)
+	}
+	if err := chain.Delete("access_token"); err != ErrCredentialNotFound {
+		t.Errorf("Expected ErrCredentialNotFound, got %v", err)
+	}
+
+	// A mounted value cannot be replaced or removed
+	if err := chain.Store("client_secret", "new"); !errors.Is(err, errReadOnly) {
+		t.Errorf("Expected storing over a read-only value to fail, got %v", err)
+	}
+	if _, err := writable.Retrieve("client_secret"); err != ErrCredentialNotFound {
+		t.Error("Expected no shadowed copy in the writable backend")
+	}
+	if err := chain.Delete("client_secret"); !errors.Is(err, errReadOnly) {
+		t.Errorf("Expected deleting a read-only value to fail, got %v", err)
+	}
+
+	// Without a writable backend the errors are returned
+	readOnly := &chainBackend{
+		names:    []BackendName{DirBackend},
+		backends: []Backend{readOnlyBackend{newMemoryBackend()}},
+	}
+	if err := readOnly.Store("access_token", "tok"); !errors.Is(err, errReadOnly) {
+		t.Errorf("Expected the read-only error, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// chainBackend reads credentials from several backends in priority order.
+// New credentials are stored in the first writable backend, deletes apply
+// to every backend holding the credential.
+type chainBackend struct {
+	names    []BackendName
+	backends []Backend
+}
+
+func newChainBackend(cfg BackendConfig) (Backend, error) {
+	if len(cfg.Chain) == 0 {
+		return nil, errors.New("chain backend requires at least one backend")
+	}
+
+	chain := &chainBackend{}
+	for _, name := range cfg.Chain {
+		if name == ChainBackend {
+			return nil, errors.New("chain backend cannot contain itself")
+		}
+		backend, err := newBackend(name, cfg)
+		if err != nil {
+			return nil, err
+		}
+		chain.names = append(chain.names, name)
+		chain.backends = append(chain.backends, backend)
+	}
+	return chain, nil
+}
+
+// Store writes to the first backend accepting the credential, so that a
+// read-only backend such as a /run/secrets mount can lead the chain. It
+// fails when a backend that could not be written holds the key, as its
+// value would hide the new one.
+func (b *chainBackend) Store(key, value string) error {
+	var errs []error
+	for i, backend := range b.backends {
+		err := backend.Store(key, value)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s backend: %w", b.names[i], err))
+		if _, err := backend.Retrieve(key); err == nil {
+			return fmt.Errorf("%s backend holds %s but cannot update it: %w", b.names[i], key, errors.Join(errs...))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Retrieve returns the value of the first backend holding key. Failing
+// backends are skipped, so that an unreachable store does not hide the
+// others; their error is returned when no backend holds key.
+func (b *chainBackend) Retrieve(key string) (string, error) {
+	var firstErr error
+	for i, backend := range b.backends {
+		value, err := backend.Retrieve(key)
+		if err == nil {
+			return value, nil
+		}
+		if !errors.Is(err, ErrCredentialNotFound) && firstErr == nil {
+			firstErr = fmt.Errorf("%s backend: %w", b.names[i], err)
+		}
+	}
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return "", ErrCredentialNotFound
+}
+
+// Delete removes key from every backend holding it. Backends without the
+// key are skipped, so a read-only backend only fails a delete of its own
+// credentials.
+func (b *chainBackend) Delete(key string) error {
+	found := false
+	for i, backend := range b.backends {
+		if _, err := backend.Retrieve(key); errors.Is(err, ErrCredentialNotFound) {
+			continue
+		}
+		err := backend.Delete(key)
+		if errors.Is(err, ErrCredentialNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s backend: %w", b.names[i], err)
+		}
+		found = true
+	}
+	if !found {
+		return ErrCredentialNotFound
+	}
+	return nil
+}
+
+// List returns the keys of every backend, without duplicates
+func (b *chainBackend) List() ([]string, error) {
+	seen := make(map[string]bool)
+	keys := []string{}
+	for i, backend := range b.backends {
+		backendKeys, err := backend.List()
+		if err != nil {
+			return nil, fmt.Errorf("%s backend: %w", b.names[i], err)
+		}
+		for _, key := range backendKeys {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Verify checks every backend of the chain that can be verified
+func (b *chainBackend) Verify() error {
+	for i, backend := range b.backends {
+		if v, ok := backend.(verifier); ok {
+			if err := v.Verify(); err != nil {
+				return fmt.Errorf("%s backend: %w", b.names[i], err)
+			}
+		}
+	}
+	return nil
+}
+
+// Destroy clears the backends of the chain
+func (b *chainBackend) Destroy() {
+	for _, backend := range b.backends {
+		if d, ok := backend.(interface{ Destroy() }); ok {
+			d.Destroy()
+		}
+	}
+}
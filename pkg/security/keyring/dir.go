@@ -0,0 +1,90 @@
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// dirBackend reads one file per credential, named after its key, as
+// mounted by Docker secrets (/run/secrets) and Kubernetes secret volumes
+type dirBackend struct {
+	dir string
+}
+
+func newDirBackend(cfg BackendConfig) (Backend, error) {
+	if cfg.Dir == "" {
+		return nil, errors.New("dir backend requires a directory")
+	}
+	return &dirBackend{dir: cfg.Dir}, nil
+}
+
+func (b *dirBackend) Store(key, value string) error {
+	path, err := b.path(key)
+	if err != nil {
+		return err
+	}
+	return writeSecretFile(path, value)
+}
+
+func (b *dirBackend) Retrieve(key string) (string, error) {
+	path, err := b.path(key)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", ErrCredentialNotFound
+		}
+		return "", fmt.Errorf("failed to read secret %s: %w", key, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (b *dirBackend) Delete(key string) error {
+	path, err := b.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return ErrCredentialNotFound
+		}
+		return fmt.Errorf("failed to delete secret %s: %w", key, err)
+	}
+	return nil
+}
+
+func (b *dirBackend) List() ([]string, error) {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("failed to list secrets directory: %w", err)
+	}
+
+	keys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		// Kubernetes keeps the mounted files in hidden ..data directories
+		// behind symlinks named after the keys
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		keys = append(keys, entry.Name())
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// path returns the file of key, refusing keys that would leave the directory
+func (b *dirBackend) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, ".") || strings.ContainsAny(key, `/\`) {
+		return "", fmt.Errorf("invalid secret name %q", key)
+	}
+	return filepath.Join(b.dir, key), nil
+}
@@ -0,0 +1,399 @@
+package keyring
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/encryption"
+	"github.com/zalando/go-keyring"
+)
+
+// KeySource names where the encryption key of the file backend comes from
+type KeySource string
+
+const (
+	// KeySourceAuto tries the passphrase environment variable, the key file,
+	// the system keyring and a passphrase prompt, in that order
+	KeySourceAuto KeySource = "auto"
+	// KeySourcePassphrase derives the key from a passphrase and a stored salt
+	KeySourcePassphrase KeySource = "passphrase"
+	// KeySourceKeyFile reads the key from a file only its owner can read
+	KeySourceKeyFile KeySource = "key_file"
+	// KeySourceSystem keeps the key in the system keyring
+	KeySourceSystem KeySource = "system"
+	// KeySourceLegacy is the fixed key of versions before key management,
+	// only accepted to rekey their credentials
+	KeySourceLegacy KeySource = "legacy"
+)
+
+const (
+	// DefaultPassphraseEnv is the environment variable holding the passphrase
+	DefaultPassphraseEnv = "EXPORT_TRAKT_KEYRING_PASSPHRASE"
+	// systemKeyName is the system keyring entry holding the file backend key
+	systemKeyName = "encryption_key"
+)
+
+// ErrNoFileKey is returned when no key source holds the key of existing credentials
+var ErrNoFileKey = errors.New("no encryption key found for the credentials file")
+
+// ErrKeyFilePermissions is returned when the key file is accessible to others
+var ErrKeyFilePermissions = errors.New("key file permissions must be 0600 or more restrictive")
+
+// FileKeyOptions configures how the encryption key of the file backend is obtained
+type FileKeyOptions struct {
+	Source          KeySource
+	CredentialsPath string // the passphrase salt is stored next to it, with a .salt suffix
+	KeyFile         string
+	PassphraseEnv   string
+	// Prompt asks for a passphrase, twice when confirm is set. It is nil
+	// when there is no terminal to ask on.
+	Prompt func(label string, confirm bool) (string, error)
+}
+
+// FileKey is an encryption key of the file backend and its key material:
+// the passphrase salt, the key file or the system keyring entry
+type FileKey struct {
+	Key    []byte
+	Source KeySource
+	save   func() error
+	remove func() error
+}
+
+// Save stores the key material, so that LoadFileKey finds the key again
+func (k *FileKey) Save() error {
+	if k.save == nil {
+		return nil
+	}
+	return k.save()
+}
+
+// Remove deletes the key material, once credentials are encrypted with another key
+func (k *FileKey) Remove() error {
+	if k.remove == nil {
+		return nil
+	}
+	return k.remove()
+}
+
+// Destroy clears the key from memory
+func (k *FileKey) Destroy() {
+	for i := range k.Key {
+		k.Key[i] = 0
+	}
+	k.Key = nil
+}
+
+// LoadFileKey returns the key of the file backend from the configured
+// source. The key of a source holding none yet is created and its material
+// saved; in auto mode a key file is created when no source holds a key and
+// there are no credentials yet.
+func LoadFileKey(opts FileKeyOptions) (*FileKey, error) {
+	switch opts.Source {
+	case KeySourcePassphrase:
+		return loadPassphraseKey(opts, "")
+	case KeySourceKeyFile:
+		return loadKeyFile(opts, true)
+	case KeySourceSystem:
+		return loadSystemKey(true)
+	case KeySourceLegacy:
+		return legacyKey(), nil
+	case "", KeySourceAuto:
+	default:
+		return nil, fmt.Errorf("unsupported key source: %s", opts.Source)
+	}
+
+	// The passphrase is only used for credentials it encrypted, so that it
+	// can be set for a rekey from another source
+	passphrase := os.Getenv(passphraseEnv(opts))
+	if _, err := os.Stat(saltPath(opts)); err == nil && passphrase != "" {
+		return loadPassphraseKey(opts, passphrase)
+	}
+	if key, err := loadKeyFile(opts, false); key != nil || err != nil {
+		return key, err
+	}
+	if key, _ := loadSystemKey(false); key != nil {
+		return key, nil
+	}
+	if passphrase != "" || opts.Prompt != nil {
+		return loadPassphraseKey(opts, passphrase)
+	}
+	if _, err := os.Stat(opts.CredentialsPath); err == nil {
+		return nil, fmt.Errorf("%w: set %s, provide key_file or run 'keyring rekey --legacy' for files of earlier versions",
+			ErrNoFileKey, passphraseEnv(opts))
+	}
+	return loadKeyFile(opts, true)
+}
+
+// NewFileKey creates a new key for source without saving its material. A
+// passphrase source uses passphrase, or asks for it when empty.
+func NewFileKey(opts FileKeyOptions, source KeySource, passphrase string) (*FileKey, error) {
+	switch source {
+	case KeySourcePassphrase:
+		if passphrase == "" {
+			var err error
+			if passphrase, err = askPassphrase(opts, "New keyring passphrase", true); err != nil {
+				return nil, err
+			}
+		}
+		salt, err := encryption.GenerateSalt()
+		if err != nil {
+			return nil, err
+		}
+		return passphraseKey(passphrase, salt, saltPath(opts))
+
+	case KeySourceKeyFile:
+		key, err := encryption.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		return keyFileKey(key, opts.KeyFile), nil
+
+	case KeySourceSystem:
+		key, err := encryption.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		return systemKey(key), nil
+	}
+	return nil, fmt.Errorf("cannot create a key for source %s", source)
+}
+
+// loadPassphraseKey derives the key from the passphrase, read from the
+// environment or asked for when empty, and the salt stored next to the
+// credentials. A new salt is created along with new credentials.
+func loadPassphraseKey(opts FileKeyOptions, passphrase string) (*FileKey, error) {
+	data, err := os.ReadFile(saltPath(opts))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read passphrase salt: %w", err)
+	}
+
+	if os.IsNotExist(err) {
+		if _, statErr := os.Stat(opts.CredentialsPath); statErr == nil {
+			return nil, fmt.Errorf("%w: %s is missing, the credentials were not encrypted with a passphrase", ErrNoFileKey, saltPath(opts))
+		}
+		if passphrase == "" {
+			passphrase = os.Getenv(passphraseEnv(opts))
+		}
+		key, err := NewFileKey(opts, KeySourcePassphrase, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		if err := key.Save(); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(salt) == 0 {
+		return nil, fmt.Errorf("invalid passphrase salt in %s", saltPath(opts))
+	}
+	if passphrase == "" {
+		passphrase = os.Getenv(passphraseEnv(opts))
+	}
+	if passphrase == "" {
+		if passphrase, err = askPassphrase(opts, "Keyring passphrase", false); err != nil {
+			return nil, err
+		}
+	}
+	return passphraseKey(passphrase, salt, saltPath(opts))
+}
+
+func passphraseKey(passphrase string, salt []byte, path string) (*FileKey, error) {
+	key, err := encryption.DeriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	return &FileKey{
+		Key:    key,
+		Source: KeySourcePassphrase,
+		save: func() error {
+			return writeSecretFile(path, base64.StdEncoding.EncodeToString(salt)+"\n")
+		},
+		remove: func() error { return removeFile(path) },
+	}, nil
+}
+
+func keyFileKey(key []byte, path string) *FileKey {
+	return &FileKey{
+		Key:    key,
+		Source: KeySourceKeyFile,
+		save: func() error {
+			return writeSecretFile(path, base64.StdEncoding.EncodeToString(key)+"\n")
+		},
+		remove: func() error { return removeFile(path) },
+	}
+}
+
+func systemKey(key []byte) *FileKey {
+	return &FileKey{
+		Key:    key,
+		Source: KeySourceSystem,
+		save: func() error {
+			return keyring.Set(ServiceName, systemKeyName, base64.StdEncoding.EncodeToString(key))
+		},
+		remove: func() error {
+			if err := keyring.Delete(ServiceName, systemKeyName); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+				return err
+			}
+			return nil
+		},
+	}
+}
+
+// loadKeyFile reads the key file, holding the key base64-encoded or raw.
+// A missing key file is created when create is set, and ignored otherwise.
+func loadKeyFile(opts FileKeyOptions, create bool) (*FileKey, error) {
+	if opts.KeyFile == "" {
+		if create {
+			return nil, errors.New("the key_file source requires key_file")
+		}
+		return nil, nil
+	}
+
+	if err := checkKeyFilePermissions(opts.KeyFile); err != nil {
+		return nil, fmt.Errorf("key file %s: %w", opts.KeyFile, err)
+	}
+	data, err := os.ReadFile(opts.KeyFile)
+	if os.IsNotExist(err) {
+		if !create {
+			return nil, nil
+		}
+		key, err := NewFileKey(opts, KeySourceKeyFile, "")
+		if err != nil {
+			return nil, err
+		}
+		if err := key.Save(); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		key = data
+	}
+	if len(key) != encryption.AESKeySize {
+		return nil, fmt.Errorf("key file %s must hold a %d byte key", opts.KeyFile, encryption.AESKeySize)
+	}
+	return keyFileKey(key, opts.KeyFile), nil
+}
+
+// checkKeyFilePermissions fails when the key file is accessible to others.
+// Unlike checkPermissions, containers get no leeway: the key file decrypts
+// every credential, and its owner can always read a 0600 file.
+func checkKeyFilePermissions(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("%w, got %04o", ErrKeyFilePermissions, info.Mode().Perm())
+	}
+	return nil
+}
+
+// loadSystemKey reads the key from the system keyring, creating it when
+// create is set. Without create, a missing key or keyring returns nil.
+func loadSystemKey(create bool) (*FileKey, error) {
+	encoded, err := keyring.Get(ServiceName, systemKeyName)
+	if err != nil {
+		if !create {
+			return nil, nil
+		}
+		if !errors.Is(err, keyring.ErrNotFound) {
+			return nil, fmt.Errorf("failed to read the system keyring: %w", err)
+		}
+		key, err := NewFileKey(FileKeyOptions{}, KeySourceSystem, "")
+		if err != nil {
+			return nil, err
+		}
+		if err := key.Save(); err != nil {
+			return nil, fmt.Errorf("failed to store the key in the system keyring: %w", err)
+		}
+		return key, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != encryption.AESKeySize {
+		return nil, fmt.Errorf("invalid key in the system keyring entry %s", systemKeyName)
+	}
+	return systemKey(key), nil
+}
+
+// legacyKey returns the fixed key earlier versions encrypted credentials with
+func legacyKey() *FileKey {
+	key := make([]byte, encryption.AESKeySize)
+	for i := range key {
+		key[i] = byte(i % 256)
+	}
+	return &FileKey{Key: key, Source: KeySourceLegacy}
+}
+
+func askPassphrase(opts FileKeyOptions, label string, confirm bool) (string, error) {
+	if opts.Prompt == nil {
+		return "", fmt.Errorf("%w: set %s or run interactively", ErrNoFileKey, passphraseEnv(opts))
+	}
+	passphrase, err := opts.Prompt(label, confirm)
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", errors.New("the passphrase cannot be empty")
+	}
+	return passphrase, nil
+}
+
+func passphraseEnv(opts FileKeyOptions) string {
+	if opts.PassphraseEnv != "" {
+		return opts.PassphraseEnv
+	}
+	return DefaultPassphraseEnv
+}
+
+func saltPath(opts FileKeyOptions) string {
+	return opts.CredentialsPath + ".salt"
+}
+
+func removeFile(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// writeSecretFile replaces path with content, readable by its owner only
+func writeSecretFile(path, content string) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testKeyOptions(t *testing.T, source KeySource) FileKeyOptions {
+	dir := t.TempDir()
+	t.Setenv(DefaultPassphraseEnv, "")
+	return FileKeyOptions{
+		Source:          source,
+		CredentialsPath: filepath.Join(dir, "credentials.enc"),
+		KeyFile:         filepath.Join(dir, "keyring.key"),
+	}
+}
+
+func TestLoadFileKey_Passphrase(t *testing.T) {
+	opts := testKeyOptions(t, KeySourcePassphrase)
+	t.Setenv(DefaultPassphraseEnv, "correct horse battery staple")
+
+	key, err := LoadFileKey(opts)
+	if err != nil {
+		t.Fatalf("Failed to load key: %v", err)
+	}
+	if key.Source != KeySourcePassphrase {
+		t.Errorf("Expected passphrase source, got %s", key.Source)
+	}
+	if _, err := os.Stat(opts.CredentialsPath + ".salt"); err != nil {
+		t.Fatalf("Salt was not saved: %v", err)
+	}
+
+	again, err := LoadFileKey(opts)
+	if err != nil {
+		t.Fatalf("Failed to load key again: %v", err)
+	}
+	if !bytes.Equal(key.Key, again.Key) {
+		t.Error("The same passphrase and salt should derive the same key")
+	}
+
+	t.Setenv(DefaultPassphraseEnv, "")
+	prompted := ""
+	opts.Prompt = func(label string, confirm bool) (string, error) {
+		prompted = label
+		return "another passphrase", nil
+	}
+	other, err := LoadFileKey(opts)
+	if err != nil {
+		t.Fatalf("Failed to load key from prompt: %v", err)
+	}
+	if prompted == "" {
+		t.Error("Expected the passphrase to be asked for")
+	}
+	if bytes.Equal(key.Key, other.Key) {
+		t.Error("Different passphrases should derive different keys")
+	}
+
+	opts.Prompt = nil
+	if _, err := LoadFileKey(opts); !errors.Is(err, ErrNoFileKey) {
+		t.Errorf("Expected ErrNoFileKey without passphrase, got %v", err)
+	}
+}
+
+func TestLoadFileKey_KeyFile(t *testing.T) {
+	opts := testKeyOptions(t, KeySourceKeyFile)
+
+	key, err := LoadFileKey(opts)
+	if err != nil {
+		t.Fatalf("Failed to create key file: %v", err)
+	}
+	info, err := os.Stat(opts.KeyFile)
+	if err != nil {
+		t.Fatalf("Key file was not created: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("Expected key file permissions 0600, got %o", perm)
+	}
+
+	again, err := LoadFileKey(opts)
+	if err != nil {
+		t.Fatalf("Failed to read key file: %v", err)
+	}
+	if !bytes.Equal(key.Key, again.Key) {
+		t.Error("Expected the key file to hold the same key")
+	}
+
+	// Readable key files are rejected, in containers too
+	if err := os.Chmod(opts.KeyFile, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFileKey(opts); !errors.Is(err, ErrKeyFilePermissions) {
+		t.Errorf("Expected a readable key file to be rejected, got %v", err)
+	}
+	t.Setenv("container", "docker")
+	if !isDockerEnvironment() {
+		t.Fatal("Expected a container environment")
+	}
+	for _, mode := range []os.FileMode{0644, 0640, 0604} {
+		if err := os.Chmod(opts.KeyFile, mode); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFileKey(opts); !errors.Is(err, ErrKeyFilePermissions) {
+			t.Errorf("Expected a %04o key file to be rejected in a container, got %v", mode, err)
+		}
+	}
+	if err := os.Chmod(opts.KeyFile, 0400); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFileKey(opts); err != nil {
+		t.Errorf("Expected a 0400 key file to be accepted in a container, got %v", err)
+	}
+	if err := os.Chmod(opts.KeyFile, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(opts.KeyFile, []byte("too short"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(opts.KeyFile, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFileKey(opts); err == nil {
+		t.Error("Expected an invalid key file to be rejected")
+	}
+}
+
+func TestLoadFileKey_Auto(t *testing.T) {
+	opts := testKeyOptions(t, KeySourceAuto)
+
+	// The passphrase variable comes first
+	t.Setenv(DefaultPassphraseEnv, "from env")
+	key, err := LoadFileKey(opts)
+	if err != nil {
+		t.Fatalf("Failed to load key: %v", err)
+	}
+	if key.Source != KeySourcePassphrase {
+		t.Errorf("Expected passphrase source, got %s", key.Source)
+	}
+	if err := key.Remove(); err != nil {
+		t.Fatalf("Failed to remove salt: %v", err)
+	}
+
+	// Without credentials, a key file is created
+	t.Setenv(DefaultPassphraseEnv, "")
+	key, err = LoadFileKey(opts)
+	if err != nil {
+		t.Fatalf("Failed to load key: %v", err)
+	}
+	if key.Source != KeySourceKeyFile {
+		t.Errorf("Expected key_file source, got %s", key.Source)
+	}
+	if err := key.Remove(); err != nil {
+		t.Fatalf("Failed to remove key file: %v", err)
+	}
+
+	// Existing credentials without any key are not replaced with a new key
+	if err := os.WriteFile(opts.CredentialsPath, []byte("client_id=x\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFileKey(opts); !errors.Is(err, ErrNoFileKey) {
+		t.Errorf("Expected ErrNoFileKey, got %v", err)
+	}
+}
+
+func TestFileKey_Rekey(t *testing.T) {
+	opts := testKeyOptions(t, KeySourceKeyFile)
+
+	// Credentials written by earlier versions with the fixed key
+	legacy, err := LoadFileKey(FileKeyOptions{Source: KeySourceLegacy})
+	if err != nil {
+		t.Fatalf("Failed to load legacy key: %v", err)
+	}
+	manager, err := NewManager(FileBackend, WithEncryptionKey(legacy.Key), WithFilePath(opts.CredentialsPath))
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	if err := manager.Store("client_secret", "s3cret"); err != nil {
+		t.Fatalf("Failed to store credential: %v", err)
+	}
+
+	newKey, err := NewFileKey(opts, KeySourceKeyFile, "")
+	if err != nil {
+		t.Fatalf("Failed to create key: %v", err)
+	}
+	if err := newKey.Save(); err != nil {
+		t.Fatalf("Failed to save key: %v", err)
+	}
+	if err := manager.Rekey(newKey.Key); err != nil {
+		t.Fatalf("Failed to rekey: %v", err)
+	}
+
+	loaded, err := LoadFileKey(opts)
+	if err != nil {
+		t.Fatalf("Failed to load new key: %v", err)
+	}
+	reopened, err := NewManager(FileBackend, WithEncryptionKey(loaded.Key), WithFilePath(opts.CredentialsPath))
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	if err := reopened.Verify(); err != nil {
+		t.Fatalf("New key does not decrypt the credentials: %v", err)
+	}
+	value, err := reopened.Retrieve("client_secret")
+	if err != nil || value != "s3cret" {
+		t.Errorf("Expected s3cret, got %q (%v)", value, err)
+	}
+
+	stale, err := NewManager(FileBackend, WithEncryptionKey(legacy.Key), WithFilePath(opts.CredentialsPath))
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	if err := stale.Verify(); err == nil {
+		t.Error("Expected the legacy key to no longer decrypt the credentials")
+	}
+}
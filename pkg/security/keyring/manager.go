@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/encryption"
 	"github.com/zalando/go-keyring"
@@ -28,18 +29,24 @@ var (
 	ErrPermissionDenied = errors.New("file permissions must be 0600 for security (or 0644 max in Docker)")
 )
 
-// Backend represents different credential storage backends
-type Backend string
+// BackendName names a registered credential storage backend
+type BackendName string
 
 const (
 	// SystemBackend uses the system keyring (keychain on macOS, etc.)
-	SystemBackend Backend = "system"
+	SystemBackend BackendName = "system"
 	// EnvBackend uses environment variables
-	EnvBackend Backend = "env"
+	EnvBackend BackendName = "env"
 	// FileBackend uses encrypted file storage
-	FileBackend Backend = "file"
+	FileBackend BackendName = "file"
 	// MemoryBackend uses in-memory storage (ephemeral, lost on restart)
-	MemoryBackend Backend = "memory"
+	MemoryBackend BackendName = "memory"
+	// DirBackend reads one file per secret (Docker/Kubernetes secret mounts)
+	DirBackend BackendName = "dir"
+	// VaultBackend uses a HashiCorp Vault KV secrets engine
+	VaultBackend BackendName = "vault"
+	// ChainBackend reads from several backends in priority order
+	ChainBackend BackendName = "chain"
 )
 
 // Credential represents a stored credential
@@ -50,22 +57,20 @@ type Credential struct {
 
 // Manager handles credential storage and retrieval across different backends
 type Manager struct {
-	backend       Backend
+	backend       BackendName
 	encryptionKey []byte
 	filePath      string
-	memoryStore   map[string]string // For memory backend
+	dir           string
+	vault         VaultConfig
+	chain         []BackendName
+	store         Backend
 }
 
 // NewManager creates a new credential manager with the specified backend
-func NewManager(backend Backend, options ...Option) (*Manager, error) {
+func NewManager(backend BackendName, options ...Option) (*Manager, error) {
 	m := &Manager{
 		backend: backend,
 	}
-	
-	// Initialize memory store for memory backend
-	if backend == MemoryBackend {
-		m.memoryStore = make(map[string]string)
-	}
 
 	// Apply options
 	for _, opt := range options {
@@ -105,101 +110,91 @@ func WithFilePath(path string) Option {
 	}
 }
 
-// validateBackend ensures the backend is properly configured
-func (m *Manager) validateBackend() error {
-	switch m.backend {
-	case SystemBackend:
-		// System backend should work out of the box
-		return nil
-	case EnvBackend:
-		// Environment backend requires no additional configuration
+// WithDir sets the secrets directory for dir backend
+func WithDir(path string) Option {
+	return func(m *Manager) error {
+		m.dir = path
 		return nil
-	case FileBackend:
-		if m.encryptionKey == nil {
-			return errors.New("file backend requires encryption key")
-		}
-		if m.filePath == "" {
-			return errors.New("file backend requires file path")
-		}
+	}
+}
+
+// WithVault sets the Vault server and secrets engine for vault backend
+func WithVault(cfg VaultConfig) Option {
+	return func(m *Manager) error {
+		m.vault = cfg
 		return nil
-	case MemoryBackend:
-		// Memory backend requires no additional configuration
+	}
+}
+
+// WithChain sets the backends chain backend reads from, highest priority first
+func WithChain(names ...BackendName) Option {
+	return func(m *Manager) error {
+		m.chain = names
 		return nil
-	default:
-		return ErrUnsupportedBackend
 	}
 }
 
+// validateBackend creates the backend, which checks its requirements
+func (m *Manager) validateBackend() error {
+	store, err := newBackend(m.backend, BackendConfig{
+		FilePath:      m.filePath,
+		EncryptionKey: m.encryptionKey,
+		Dir:           m.dir,
+		Vault:         m.vault,
+		Chain:         m.chain,
+	})
+	if err != nil {
+		return err
+	}
+	m.store = store
+	return nil
+}
+
+// Backend returns the name of the configured backend
+func (m *Manager) Backend() BackendName {
+	return m.backend
+}
+
 // Store stores a credential using the configured backend
 func (m *Manager) Store(key, value string) error {
-	switch m.backend {
-	case SystemBackend:
-		return m.storeSystem(key, value)
-	case EnvBackend:
-		return m.storeEnv(key, value)
-	case FileBackend:
-		return m.storeFile(key, value)
-	case MemoryBackend:
-		return m.storeMemory(key, value)
-	default:
+	if m.store == nil {
 		return ErrUnsupportedBackend
 	}
+	return m.store.Store(key, value)
 }
 
 // Retrieve retrieves a credential using the configured backend
 func (m *Manager) Retrieve(key string) (string, error) {
-	switch m.backend {
-	case SystemBackend:
-		return m.retrieveSystem(key)
-	case EnvBackend:
-		return m.retrieveEnv(key)
-	case FileBackend:
-		return m.retrieveFile(key)
-	case MemoryBackend:
-		return m.retrieveMemory(key)
-	default:
+	if m.store == nil {
 		return "", ErrUnsupportedBackend
 	}
+	return m.store.Retrieve(key)
 }
 
 // Delete deletes a credential using the configured backend
 func (m *Manager) Delete(key string) error {
-	switch m.backend {
-	case SystemBackend:
-		return m.deleteSystem(key)
-	case EnvBackend:
-		return m.deleteEnv(key)
-	case FileBackend:
-		return m.deleteFile(key)
-	case MemoryBackend:
-		return m.deleteMemory(key)
-	default:
+	if m.store == nil {
 		return ErrUnsupportedBackend
 	}
+	return m.store.Delete(key)
 }
 
 // List lists all stored credentials (returns keys only for security)
 func (m *Manager) List() ([]string, error) {
-	switch m.backend {
-	case SystemBackend:
-		return m.listSystem()
-	case EnvBackend:
-		return m.listEnv()
-	case FileBackend:
-		return m.listFile()
-	case MemoryBackend:
-		return m.listMemory()
-	default:
+	if m.store == nil {
 		return nil, ErrUnsupportedBackend
 	}
+	return m.store.List()
 }
 
-// System backend implementations
-func (m *Manager) storeSystem(key, value string) error {
+// systemBackend stores credentials in the system keyring
+type systemBackend struct{}
+
+func (systemBackend) Store(key, value string) error {
 	return keyring.Set(ServiceName, key, value)
 }
 
-func (m *Manager) retrieveSystem(key string) (string, error) {
+func (systemBackend) Retrieve(key string) (string, error) {
 	value, err := keyring.Get(ServiceName, key)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "password not found") {
@@ -210,18 +205,18 @@ func (m *Manager) retrieveSystem(key string) (string, error) {
 	return value, nil
 }
 
-func (m *Manager) deleteSystem(key string) error {
+func (systemBackend) Delete(key string) error {
 	return keyring.Delete(ServiceName, key)
 }
 
-func (m *Manager) listSystem() ([]string, error) {
+func (b systemBackend) List() ([]string, error) {
 	// System keyring doesn't provide a way to list all keys
 	// Return known credential keys
 	knownKeys := []string{"client_id", "client_secret", "access_token", "encryption_key"}
 	var existingKeys []string
 	
 	for _, key := range knownKeys {
-		if _, err := m.retrieveSystem(key); err == nil {
+		if _, err := b.Retrieve(key); err == nil {
 			existingKeys = append(existingKeys, key)
 		}
 	}
@@ -229,27 +224,26 @@ func (m *Manager) listSystem() ([]string, error) {
 	return existingKeys, nil
 }
 
-// Environment backend implementations
-func (m *Manager) storeEnv(key, value string) error {
-	envKey := m.getEnvKey(key)
-	return os.Setenv(envKey, value)
+// envBackend stores credentials in TRAKT_ environment variables
+type envBackend struct{}
+
+func (envBackend) Store(key, value string) error {
+	return os.Setenv(envKey(key), value)
 }
 
-func (m *Manager) retrieveEnv(key string) (string, error) {
-	envKey := m.getEnvKey(key)
-	value := os.Getenv(envKey)
+func (envBackend) Retrieve(key string) (string, error) {
+	value := os.Getenv(envKey(key))
 	if value == "" {
 		return "", ErrCredentialNotFound
 	}
 	return value, nil
 }
 
-func (m *Manager) deleteEnv(key string) error {
-	envKey := m.getEnvKey(key)
-	return os.Unsetenv(envKey)
+func (envBackend) Delete(key string) error {
+	return os.Unsetenv(envKey(key))
 }
 
-func (m *Manager) listEnv() ([]string, error) {
+func (envBackend) List() ([]string, error) {
 	prefix := "TRAKT_"
 	var keys []string
 	
@@ -269,13 +263,32 @@ func (m *Manager) listEnv() ([]string, error) {
 }
 
 func (m *Manager) getEnvKey(key string) string {
+	return envKey(key)
+}
+
+func envKey(key string) string {
 	// Convert internal key to environment variable name
 	return "TRAKT_" + strings.ToUpper(key)
 }
 
-// File backend implementations (encrypted)
-func (m *Manager) storeFile(key, value string) error {
-	credentials, err := m.loadCredentialsFile()
+// fileBackend stores credentials encrypted in a single file
+type fileBackend struct {
+	encryptionKey []byte
+	filePath      string
+}
+
+func newFileBackend(cfg BackendConfig) (Backend, error) {
+	if cfg.EncryptionKey == nil {
+		return nil, errors.New("file backend requires encryption key")
+	}
+	if cfg.FilePath == "" {
+		return nil, errors.New("file backend requires file path")
+	}
+	return &fileBackend{encryptionKey: cfg.EncryptionKey, filePath: cfg.FilePath}, nil
+}
+
+func (b *fileBackend) Store(key, value string) error {
+	credentials, err := b.loadCredentialsFile()
 	if err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to load credentials file: %w", err)
 	}
@@ -285,7 +298,7 @@ func (m *Manager) storeFile(key, value string) error {
 	}
 
 	// Encrypt the value
-	encryptor, err := encryption.NewEncryptor(m.encryptionKey)
+	encryptor, err := encryption.NewEncryptor(b.encryptionKey)
 	if err != nil {
 		return fmt.Errorf("failed to create encryptor: %w", err)
 	}
@@ -298,11 +311,11 @@ func (m *Manager) storeFile(key, value string) error {
 
 	credentials[key] = encryptedValue
 
-	return m.saveCredentialsFile(credentials)
+	return b.saveCredentialsFile(credentials)
 }
 
-func (m *Manager) retrieveFile(key string) (string, error) {
-	credentials, err := m.loadCredentialsFile()
+func (b *fileBackend) Retrieve(key string) (string, error) {
+	credentials, err := b.loadCredentialsFile()
 	if err != nil {
 		if os.IsNotExist(err) {
 			return "", ErrCredentialNotFound
@@ -316,7 +329,7 @@ func (m *Manager) retrieveFile(key string) (string, error) {
 	}
 
 	// Decrypt the value
-	encryptor, err := encryption.NewEncryptor(m.encryptionKey)
+	encryptor, err := encryption.NewEncryptor(b.encryptionKey)
 	if err != nil {
 		return "", fmt.Errorf("failed to create encryptor: %w", err)
 	}
@@ -330,8 +343,8 @@ func (m *Manager) retrieveFile(key string) (string, error) {
 	return value, nil
 }
 
-func (m *Manager) deleteFile(key string) error {
-	credentials, err := m.loadCredentialsFile()
+func (b *fileBackend) Delete(key string) error {
+	credentials, err := b.loadCredentialsFile()
 	if err != nil {
 		if os.IsNotExist(err) {
 			return ErrCredentialNotFound
@@ -347,14 +360,14 @@ func (m *Manager) deleteFile(key string) error {
 
 	if len(credentials) == 0 {
 		// Remove file if no credentials left
-		return os.Remove(m.filePath)
+		return os.Remove(b.filePath)
 	}
 
-	return m.saveCredentialsFile(credentials)
+	return b.saveCredentialsFile(credentials)
 }
 
-func (m *Manager) listFile() ([]string, error) {
-	credentials, err := m.loadCredentialsFile()
+func (b *fileBackend) List() ([]string, error) {
+	credentials, err := b.loadCredentialsFile()
 	if err != nil {
 		if os.IsNotExist(err) {
 			return []string{}, nil
@@ -370,13 +383,13 @@ func (m *Manager) listFile() ([]string, error) {
 	return keys, nil
 }
 
-func (m *Manager) loadCredentialsFile() (map[string]string, error) {
+func (b *fileBackend) loadCredentialsFile() (map[string]string, error) {
 	// Check file permissions
-	if err := m.checkFilePermissions(); err != nil {
+	if err := checkPermissions(b.filePath); err != nil {
 		return nil, err
 	}
 
-	data, err := os.ReadFile(m.filePath)
+	data, err := os.ReadFile(b.filePath)
 	if err != nil {
 		return nil, err
 	}
@@ -406,9 +419,9 @@ func (m *Manager) loadCredentialsFile() (map[string]string, error) {
 	return credentials, nil
 }
 
-func (m *Manager) saveCredentialsFile(credentials map[string]string) error {
+func (b *fileBackend) saveCredentialsFile(credentials map[string]string) error {
 	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(m.filePath), 0700); err != nil {
+	if err := os.MkdirAll(filepath.Dir(b.filePath), 0700); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
@@ -422,16 +435,107 @@ func (m *Manager) saveCredentialsFile(credentials map[string]string) error {
 	// Encode to base64
 	encoded := base64.StdEncoding.EncodeToString([]byte(content))
 
-	// Write with secure permissions
-	if err := os.WriteFile(m.filePath, []byte(encoded), 0600); err != nil {
+	// Replace the file atomically with secure permissions
+	if err := writeSecretFile(b.filePath, encoded); err != nil {
 		return fmt.Errorf("failed to write credentials file: %w", err)
 	}
 
 	return nil
 }
 
-func (m *Manager) checkFilePermissions() error {
-	info, err := os.Stat(m.filePath)
+// verifier is implemented by backends that can check their credentials are readable
+type verifier interface {
+	Verify() error
+}
+
+// Verify checks that the backend can read every credential, so that a
+// wrong passphrase or key of the file backend fails early
+func (m *Manager) Verify() error {
+	if v, ok := m.store.(verifier); ok {
+		return v.Verify()
+	}
+	return nil
+}
+
+// Rekey re-encrypts the credentials of the file backend under newKey, which
+// the manager uses from then on
+func (m *Manager) Rekey(newKey []byte) error {
+	fb, ok := m.store.(*fileBackend)
+	if !ok {
+		return fmt.Errorf("rekey requires the file backend, not %s", m.backend)
+	}
+	if err := fb.rekey(newKey); err != nil {
+		return err
+	}
+	m.encryptionKey = fb.encryptionKey
+	return nil
+}
+
+// Verify checks that the encryption key decrypts every credential
+func (b *fileBackend) Verify() error {
+	_, err := b.decryptAll()
+	return err
+}
+
+func (b *fileBackend) rekey(newKey []byte) error {
+	if len(newKey) != encryption.AESKeySize {
+		return encryption.ErrInvalidKeySize
+	}
+
+	credentials, err := b.decryptAll()
+	if err != nil {
+		return err
+	}
+
+	encryptor, err := encryption.NewEncryptor(newKey)
+	if err != nil {
+		return fmt.Errorf("failed to create encryptor: %w", err)
+	}
+	defer encryptor.Destroy()
+
+	if len(credentials) > 0 {
+		for key, value := range credentials {
+			if credentials[key], err = encryptor.Encrypt(value); err != nil {
+				return fmt.Errorf("failed to encrypt %s: %w", key, err)
+			}
+		}
+		if err := b.saveCredentialsFile(credentials); err != nil {
+			return err
+		}
+	}
+
+	b.encryptionKey = make([]byte, len(newKey))
+	copy(b.encryptionKey, newKey)
+	return nil
+}
+
+// decryptAll returns every credential in clear
+func (b *fileBackend) decryptAll() (map[string]string, error) {
+	credentials, err := b.loadCredentialsFile()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("failed to load credentials file: %w", err)
+	}
+
+	encryptor, err := encryption.NewEncryptor(b.encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create encryptor: %w", err)
+	}
+	defer encryptor.Destroy()
+
+	for key, value := range credentials {
+		if credentials[key], err = encryptor.Decrypt(value); err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s, wrong passphrase or key: %w", key, err)
+		}
+	}
+	return credentials, nil
+}
+
+// checkPermissions fails when a secret file is readable by others
+func checkPermissions(path string) error {
+	info, err := os.Stat(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil // File doesn't exist yet, that's ok
@@ -483,54 +587,66 @@ func isDockerEnvironment() bool {
 	return false
 }
 
-// Memory backend implementations
-func (m *Manager) storeMemory(key, value string) error {
-	if m.memoryStore == nil {
-		m.memoryStore = make(map[string]string)
+// memoryBackend keeps credentials in memory, lost on restart
+type memoryBackend struct {
+	mutex sync.RWMutex
+	store map[string]string
+}
+
+func newMemoryBackend() *memoryBackend {
+	return &memoryBackend{store: make(map[string]string)}
+}
+
+func (b *memoryBackend) Store(key, value string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.store == nil {
+		b.store = make(map[string]string)
 	}
-	m.memoryStore[key] = value
+	b.store[key] = value
 	return nil
 }
 
-func (m *Manager) retrieveMemory(key string) (string, error) {
-	if m.memoryStore == nil {
-		return "", ErrCredentialNotFound
-	}
-	
-	value, exists := m.memoryStore[key]
+func (b *memoryBackend) Retrieve(key string) (string, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	value, exists := b.store[key]
 	if !exists {
 		return "", ErrCredentialNotFound
 	}
-	
 	return value, nil
 }
 
-func (m *Manager) deleteMemory(key string) error {
-	if m.memoryStore == nil {
+func (b *memoryBackend) Delete(key string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, exists := b.store[key]; !exists {
 		return ErrCredentialNotFound
 	}
-	
-	if _, exists := m.memoryStore[key]; !exists {
-		return ErrCredentialNotFound
-	}
-	
-	delete(m.memoryStore, key)
+	delete(b.store, key)
 	return nil
 }
 
-func (m *Manager) listMemory() ([]string, error) {
-	if m.memoryStore == nil {
-		return []string{}, nil
-	}
-	
-	keys := make([]string, 0, len(m.memoryStore))
-	for key := range m.memoryStore {
+func (b *memoryBackend) List() ([]string, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	keys := make([]string, 0, len(b.store))
+	for key := range b.store {
 		keys = append(keys, key)
 	}
-	
 	return keys, nil
 }
 
+// Destroy clears the stored credentials
+func (b *memoryBackend) Destroy() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for key := range b.store {
+		delete(b.store, key)
+	}
+	b.store = nil
+}
+
 // Destroy securely clears sensitive data from memory
 func (m *Manager) Destroy() {
 	if m.encryptionKey != nil {
@@ -539,12 +655,9 @@ func (m *Manager) Destroy() {
 		}
 		m.encryptionKey = nil
 	}
-	
-	// Clear memory store
-	if m.memoryStore != nil {
-		for key := range m.memoryStore {
-			delete(m.memoryStore, key)
-		}
-		m.memoryStore = nil
+
+	// Clear the backend, e.g. the memory store
+	if d, ok := m.store.(interface{ Destroy() }); ok {
+		d.Destroy()
 	}
-} 
\ No newline at end of file
+}
@@ -12,7 +12,7 @@ import (
 func TestNewManager(t *testing.T) {
 	tests := []struct {
 		name        string
-		backend     Backend
+		backend     BackendName
 		options     []Option
 		expectError bool
 	}{
@@ -28,7 +28,7 @@ func TestNewManager(t *testing.T) {
 		},
 		{
 			name:        "unsupported backend",
-			backend:     Backend("invalid"),
+			backend:     BackendName("invalid"),
 			expectError: true,
 		},
 		{
@@ -370,7 +370,7 @@ func TestFileBackendFilePermissions(t *testing.T) {
 }
 
 func TestUnsupportedBackendOperations(t *testing.T) {
-	manager := &Manager{backend: Backend("invalid")}
+	manager := &Manager{backend: BackendName("invalid")}
 
 	// Test Store
 	err := manager.Store("key", "value")
@@ -422,7 +422,7 @@ func TestEnvBackendGetEnvKey(t *testing.T) {
 func TestRetrieveNonExistentCredential(t *testing.T) {
 	tests := []struct {
 		name    string
-		backend Backend
+		backend BackendName
 		setup   func() (*Manager, func(), error)
 	}{
 		{
@@ -510,7 +510,7 @@ func TestConstants(t *testing.T) {
 }
 
 func TestBackendConstants(t *testing.T) {
-	backends := []Backend{SystemBackend, EnvBackend, FileBackend}
+	backends := []BackendName{SystemBackend, EnvBackend, FileBackend}
 	
 	for _, backend := range backends {
 		if string(backend) == "" {
@@ -576,4 +576,53 @@ func TestValidateBackendErrors(t *testing.T) {
 // Note: System backend tests are not included here because they would require
 // actual system keyring access which might not be available in CI environments.
 // In a real-world scenario, you might want to mock the keyring library or
-// run system backend tests only in specific environments. 
\ No newline at end of file
+// run system backend tests only in specific environments. 
+
+func TestResolveReferences(t *testing.T) {
+	manager, err := NewManager(MemoryBackend)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	if err := manager.Store("client_secret", "from-keyring"); err != nil {
+		t.Fatalf("Failed to store credential: %v", err)
+	}
+
+	secretFile := filepath.Join(t.TempDir(), "client_secret")
+	if err := os.WriteFile(secretFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatalf("Failed to write secret file: %v", err)
+	}
+
+	tests := []struct {
+		ref         string
+		expected    string
+		expectError bool
+	}{
+		{ref: "secret://keyring/client_secret", expected: "from-keyring"},
+		{ref: "file://" + secretFile, expected: "from-file"},
+		{ref: "secret://keyring/missing", expectError: true},
+		{ref: "secret://keyring/", expectError: true},
+		{ref: "plain-value", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			if IsReference(tt.ref) == (tt.ref == "plain-value") {
+				t.Errorf("IsReference(%q) returned unexpected result", tt.ref)
+			}
+
+			value, err := manager.Resolve(tt.ref)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error for %q", tt.ref)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if value != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, value)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package keyring
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	// KeyringReferencePrefix prefixes references to credentials held by the manager
+	KeyringReferencePrefix = "secret://keyring/"
+	// FileReferencePrefix prefixes references to secrets mounted as files (Docker/K8s secrets)
+	FileReferencePrefix = "file://"
+)
+
+// IsReference reports whether value is a secret reference rather than a literal
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, KeyringReferencePrefix) || strings.HasPrefix(value, FileReferencePrefix)
+}
+
+// Resolve returns the secret a reference points to. secret://keyring/<key>
+// is read from the configured backend, file:///path is read from disk with
+// surrounding whitespace trimmed.
+func (m *Manager) Resolve(ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, KeyringReferencePrefix):
+		key := strings.TrimPrefix(ref, KeyringReferencePrefix)
+		if key == "" {
+			return "", fmt.Errorf("empty keyring reference: %s", ref)
+		}
+		value, err := m.Retrieve(key)
+		if err != nil {
+			return "", fmt.Errorf("failed to retrieve %s: %w", key, err)
+		}
+		return value, nil
+
+	case strings.HasPrefix(ref, FileReferencePrefix):
+		u, err := url.Parse(ref)
+		if err != nil || u.Path == "" {
+			return "", fmt.Errorf("invalid file reference: %s", ref)
+		}
+		data, err := os.ReadFile(u.Path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+
+	default:
+		return "", fmt.Errorf("not a secret reference: %s", ref)
+	}
+}
@@ -0,0 +1,262 @@
+package keyring
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// VaultConfig locates the HashiCorp Vault KV secrets engine of vault backend
+type VaultConfig struct {
+	Address   string // defaults to VAULT_ADDR
+	Token     string // defaults to the content of TokenFile, then VAULT_TOKEN
+	TokenFile string // e.g. written by the Vault agent
+	Namespace string // Vault Enterprise namespace
+	Mount     string // KV engine mount, defaults to "secret"
+	Path      string // prefix of the credentials within the mount
+	KVVersion int    // 1 or 2, defaults to 2
+	Timeout   time.Duration
+	Client    *http.Client
+}
+
+// vaultBackend stores each credential as a secret with a single "value"
+// field under <mount>/<path>/<key>
+type vaultBackend struct {
+	address   string
+	token     string
+	namespace string
+	mount     string
+	path      string
+	kvVersion int
+	client    *http.Client
+}
+
+func newVaultBackend(cfg BackendConfig) (Backend, error) {
+	vc := cfg.Vault
+	if vc.Address == "" {
+		vc.Address = os.Getenv("VAULT_ADDR")
+	}
+	if vc.Address == "" {
+		return nil, errors.New("vault backend requires an address")
+	}
+	if _, err := url.ParseRequestURI(vc.Address); err != nil {
+		return nil, fmt.Errorf("invalid vault address: %w", err)
+	}
+
+	if vc.Token == "" && vc.TokenFile != "" {
+		data, err := os.ReadFile(vc.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read vault token file: %w", err)
+		}
+		vc.Token = strings.TrimSpace(string(data))
+	}
+	if vc.Token == "" {
+		vc.Token = os.Getenv("VAULT_TOKEN")
+	}
+	if vc.Token == "" {
+		return nil, errors.New("vault backend requires a token")
+	}
+
+	if vc.Mount == "" {
+		vc.Mount = "secret"
+	}
+	switch vc.KVVersion {
+	case 0:
+		vc.KVVersion = 2
+	case 1, 2:
+	default:
+		return nil, fmt.Errorf("unsupported KV version %d", vc.KVVersion)
+	}
+	if vc.Client == nil {
+		timeout := vc.Timeout
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+		vc.Client = &http.Client{Timeout: timeout}
+	}
+
+	return &vaultBackend{
+		address:   strings.TrimRight(vc.Address, "/"),
+		token:     vc.Token,
+		namespace: vc.Namespace,
+		mount:     strings.Trim(vc.Mount, "/"),
+		path:      strings.Trim(vc.Path, "/"),
+		kvVersion: vc.KVVersion,
+		client:    vc.Client,
+	}, nil
+}
+
+func (b *vaultBackend) Store(key, value string) error {
+	path, err := b.secretPath("data", key)
+	if err != nil {
+		return err
+	}
+	var body interface{} = map[string]string{"value": value}
+	if b.kvVersion == 2 {
+		body = map[string]interface{}{"data": body}
+	}
+	_, err = b.do(http.MethodPost, path, body)
+	return err
+}
+
+func (b *vaultBackend) Retrieve(key string) (string, error) {
+	path, err := b.secretPath("data", key)
+	if err != nil {
+		return "", err
+	}
+	resp, err := b.do(http.MethodGet, path, nil)
+	if err != nil {
+		return "", err
+	}
+	if resp == nil {
+		return "", ErrCredentialNotFound
+	}
+
+	data := resp.Data
+	if b.kvVersion == 2 {
+		// Deleted versions of KV v2 secrets are returned without data
+		if data.Data == nil {
+			return "", ErrCredentialNotFound
+		}
+		data = *data.Data
+	}
+	if data.Value == nil {
+		return "", fmt.Errorf("vault secret %s has no value field", key)
+	}
+	return *data.Value, nil
+}
+
+func (b *vaultBackend) Delete(key string) error {
+	if _, err := b.Retrieve(key); err != nil {
+		return err
+	}
+	// Deleting the metadata of KV v2 secrets removes all their versions
+	path, err := b.secretPath("metadata", key)
+	if err != nil {
+		return err
+	}
+	_, err = b.do(http.MethodDelete, path, nil)
+	return err
+}
+
+func (b *vaultBackend) List() ([]string, error) {
+	path, err := b.secretPath("metadata", "")
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.do("LIST", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return []string{}, nil
+	}
+
+	keys := make([]string, 0, len(resp.Data.Keys))
+	for _, key := range resp.Data.Keys {
+		// Keys ending with a slash are folders of nested secrets
+		if !strings.HasSuffix(key, "/") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// secretPath returns the API path of key, below the data or metadata
+// endpoint of KV v2 engines
+func (b *vaultBackend) secretPath(endpoint, key string) (string, error) {
+	if strings.HasPrefix(key, ".") || strings.ContainsAny(key, `/\?#`) {
+		return "", fmt.Errorf("invalid secret name %q", key)
+	}
+
+	parts := []string{"v1", b.mount}
+	if b.kvVersion == 2 {
+		parts = append(parts, endpoint)
+	}
+	if b.path != "" {
+		parts = append(parts, b.path)
+	}
+	if key != "" {
+		parts = append(parts, key)
+	}
+	return "/" + strings.Join(parts, "/"), nil
+}
+
+// vaultResponse holds the fields of Vault KV responses used by the backend
+type vaultResponse struct {
+	Data   vaultData `json:"data"`
+	Errors []string  `json:"errors"`
+}
+
+type vaultData struct {
+	Value *string    `json:"value"` // KV v1 secret
+	Data  *vaultData `json:"data"`  // KV v2 secret
+	Keys  []string   `json:"keys"`  // list
+}
+
+// do sends a request to Vault and decodes its response. A 404 returns a
+// nil response, the way Vault reports missing secrets and empty lists.
+func (b *vaultBackend) do(method, path string, body interface{}) (*vaultResponse, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, b.address+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", b.token)
+	req.Header.Set("X-Vault-Request", "true")
+	if b.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", b.namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var decoded vaultResponse
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault response: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &decoded); err != nil && resp.StatusCode < 300 {
+			return nil, fmt.Errorf("invalid vault response: %w", err)
+		}
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound && len(decoded.Errors) == 0:
+		return nil, nil
+	case resp.StatusCode >= 300:
+		if len(decoded.Errors) > 0 {
+			return nil, fmt.Errorf("vault returned status %d: %s", resp.StatusCode, strings.Join(decoded.Errors, "; "))
+		}
+		return nil, fmt.Errorf("vault returned status %d", resp.StatusCode)
+	}
+	return &decoded, nil
+}
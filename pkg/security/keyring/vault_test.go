@@ -0,0 +1,215 @@
+package keyring
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeVault is a local stand-in for the KV secrets engine of a Vault server,
+// mounted at /v1/secret (KV v2) and /v1/kv (KV v1)
+type fakeVault struct {
+	token   string
+	mutex   sync.Mutex
+	secrets map[string]map[string]interface{} // by mount and path
+}
+
+func newFakeVault(t *testing.T, token string) *httptest.Server {
+	fv := &fakeVault{token: token, secrets: make(map[string]map[string]interface{})}
+	server := httptest.NewServer(fv)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (fv *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != fv.token {
+		writeVault(w, http.StatusForbidden, map[string]interface{}{"errors": []string{"permission denied"}})
+		return
+	}
+
+	fv.mutex.Lock()
+	defer fv.mutex.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	kv2 := strings.HasPrefix(path, "secret/")
+	if kv2 {
+		// secret/data/<path> and secret/metadata/<path> address the same secret
+		parts := strings.SplitN(path, "/", 3)
+		if len(parts) < 3 {
+			writeVault(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+			return
+		}
+		path = "secret/" + parts[2]
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		data, ok := fv.secrets[path]
+		if !ok {
+			writeVault(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+			return
+		}
+		if kv2 {
+			writeVault(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"data": data, "metadata": map[string]interface{}{"version": 1}}})
+		} else {
+			writeVault(w, http.StatusOK, map[string]interface{}{"data": data})
+		}
+
+	case http.MethodPost, http.MethodPut:
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeVault(w, http.StatusBadRequest, map[string]interface{}{"errors": []string{err.Error()}})
+			return
+		}
+		if kv2 {
+			body, _ = body["data"].(map[string]interface{})
+		}
+		fv.secrets[path] = body
+		w.WriteHeader(http.StatusNoContent)
+
+	case http.MethodDelete:
+		delete(fv.secrets, path)
+		w.WriteHeader(http.StatusNoContent)
+
+	case "LIST":
+		prefix := strings.TrimSuffix(path, "/") + "/"
+		seen := make(map[string]bool)
+		for secret := range fv.secrets {
+			if rest, ok := strings.CutPrefix(secret, prefix); ok {
+				if i := strings.Index(rest, "/"); i >= 0 {
+					rest = rest[:i+1]
+				}
+				seen[rest] = true
+			}
+		}
+		if len(seen) == 0 {
+			writeVault(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+			return
+		}
+		keys := make([]string, 0, len(seen))
+		for key := range seen {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		writeVault(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"keys": keys}})
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func writeVault(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func TestVaultBackendOperations(t *testing.T) {
+	server := newFakeVault(t, "s.test")
+
+	for _, tt := range []struct {
+		name  string
+		mount string
+		kv    int
+	}{
+		{"kv v2", "secret", 2},
+		{"kv v1", "kv", 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, err := NewManager(VaultBackend, WithVault(VaultConfig{
+				Address:   server.URL,
+				Token:     "s.test",
+				Mount:     tt.mount,
+				Path:      "export-trakt",
+				KVVersion: tt.kv,
+			}))
+			if err != nil {
+				t.Fatalf("Failed to create vault manager: %v", err)
+			}
+
+			if _, err := manager.Retrieve("client_secret"); err != ErrCredentialNotFound {
+				t.Errorf("Expected ErrCredentialNotFound, got %v", err)
+			}
+			keys, err := manager.List()
+			if err != nil || len(keys) != 0 {
+				t.Errorf("Expected no keys, got %v (%v)", keys, err)
+			}
+
+			if err := manager.Store("client_secret", "s3cret"); err != nil {
+				t.Fatalf("Store failed: %v", err)
+			}
+			if err := manager.Store("access_token", "tok"); err != nil {
+				t.Fatalf("Store failed: %v", err)
+			}
+
+			value, err := manager.Retrieve("client_secret")
+			if err != nil || value != "s3cret" {
+				t.Errorf("Expected s3cret, got %q (%v)", value, err)
+			}
+
+			keys, err = manager.List()
+			if err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			if strings.Join(keys, ",") != "access_token,client_secret" {
+				t.Errorf("Unexpected keys %v", keys)
+			}
+
+			if err := manager.Delete("client_secret"); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			if _, err := manager.Retrieve("client_secret"); err != ErrCredentialNotFound {
+				t.Errorf("Expected ErrCredentialNotFound after delete, got %v", err)
+			}
+			if err := manager.Delete("client_secret"); err != ErrCredentialNotFound {
+				t.Errorf("Expected ErrCredentialNotFound deleting twice, got %v", err)
+			}
+		})
+	}
+}
+
+func TestVaultBackendErrors(t *testing.T) {
+	server := newFakeVault(t, "s.test")
+
+	manager, err := NewManager(VaultBackend, WithVault(VaultConfig{Address: server.URL, Token: "s.wrong"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = manager.Retrieve("client_secret")
+	if err == nil || !strings.Contains(err.Error(), "403: permission denied") {
+		t.Errorf("Expected permission denied error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "s.wrong") {
+		t.Error("Errors must not contain the token")
+	}
+
+	if _, err := manager.Retrieve("../sys/seal"); err == nil {
+		t.Error("Expected secret names leaving the path to be rejected")
+	}
+
+	t.Setenv("VAULT_ADDR", "")
+	t.Setenv("VAULT_TOKEN", "")
+	if _, err := NewManager(VaultBackend); err == nil {
+		t.Error("Expected an error without address")
+	}
+	if _, err := NewManager(VaultBackend, WithVault(VaultConfig{Address: server.URL})); err == nil {
+		t.Error("Expected an error without token")
+	}
+	if _, err := NewManager(VaultBackend, WithVault(VaultConfig{Address: server.URL, Token: "t", KVVersion: 3})); err == nil {
+		t.Error("Expected an error for KV version 3")
+	}
+
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "s.test")
+	manager, err = NewManager(VaultBackend)
+	if err != nil {
+		t.Fatalf("Expected VAULT_ADDR and VAULT_TOKEN to be used: %v", err)
+	}
+	if err := manager.Store("client_id", "id"); err != nil {
+		t.Errorf("Store failed: %v", err)
+	}
+}
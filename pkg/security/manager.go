@@ -13,30 +13,51 @@ import (
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/validation"
 )
 
+// AuditLogFile is the audit log written when audit logging is enabled
+const AuditLogFile = "logs/audit.log"
+
 // Manager coordinates all security components
 type Manager struct {
-	config         Config
-	keyringManager *keyring.Manager
-	auditLogger    *audit.Logger
-	encryptionKey  []byte
-	rateLimiter    *RateLimiter
-	fileSecurity   *FileSystemSecurity
+	config             Config
+	keyringManager     *keyring.Manager
+	auditLogger        *audit.Logger
+	encryptionKey      []byte
+	rateLimiter        *RateLimiter
+	fileSecurity       *FileSystemSecurity
+	auditCheckpointKey []byte
+}
+
+// ManagerOption configures optional features of the security manager
+type ManagerOption func(*Manager)
+
+// WithAuditCheckpointKey sets the key signing the checkpoints of the audit
+// log, so that the first entries written are covered too
+func WithAuditCheckpointKey(key []byte) ManagerOption {
+	return func(m *Manager) {
+		m.auditCheckpointKey = key
+	}
 }
 
 // NewManager creates a new security manager with the given configuration
-func NewManager(config Config) (*Manager, error) {
+func NewManager(config Config, opts ...ManagerOption) (*Manager, error) {
 	manager := &Manager{
 		config: config,
 	}
+	for _, opt := range opts {
+		opt(manager)
+	}
 
 	// Initialize audit logger if enabled
 	if config.AuditLogging {
 		auditConfig := audit.Config{
-			LogLevel:         config.Audit.LogLevel,
-			OutputFormat:     config.Audit.OutputFormat,
-			IncludeSensitive: config.Audit.IncludeSensitive,
-			RetentionDays:    config.Audit.RetentionDays,
-			LogFile:          filepath.Join("logs", "audit.log"),
+			LogLevel:           config.Audit.LogLevel,
+			OutputFormat:       config.Audit.OutputFormat,
+			IncludeSensitive:   config.Audit.IncludeSensitive,
+			RetentionDays:      config.Audit.RetentionDays,
+			LogFile:            filepath.FromSlash(AuditLogFile),
+			MaxFileSize:        int64(config.Audit.MaxFileSizeMB) * 1024 * 1024,
+			MaxFiles:           config.Audit.MaxFiles,
+			CheckpointInterval: config.Audit.CheckpointInterval,
 		}
 
 		auditLogger, err := audit.NewLogger(auditConfig)
@@ -44,6 +65,9 @@ func NewManager(config Config) (*Manager, error) {
 			return nil, fmt.Errorf("failed to initialize audit logger: %w", err)
 		}
 		manager.auditLogger = auditLogger
+		if manager.auditCheckpointKey != nil {
+			manager.auditLogger.SetCheckpointKey(manager.auditCheckpointKey)
+		}
 
 		// Log manager initialization
 		manager.auditLogger.LogSystemEvent(audit.SystemStart, "security_manager", "initialize", "success")
@@ -132,7 +156,7 @@ func (m *Manager) initializeEncryptionKey() error {
 
 // initializeKeyring sets up the keyring manager
 func (m *Manager) initializeKeyring() error {
-	var backend keyring.Backend
+	var backend keyring.BackendName
 	switch m.config.KeyringBackend {
 	case "system":
 		backend = keyring.SystemBackend
@@ -386,6 +410,11 @@ func (m *Manager) CleanupOldAuditLogs() error {
 	return nil
 }
 
+// AuditLogger returns the audit logger, nil when audit logging is disabled
+func (m *Manager) AuditLogger() *audit.Logger {
+	return m.auditLogger
+}
+
 // GetSecurityMetrics returns security-related metrics
 func (m *Manager) GetSecurityMetrics() map[string]interface{} {
 	metrics := map[string]interface{}{
@@ -460,6 +489,18 @@ func (m *Manager) ValidateFilePermissions(path string) error {
 	return m.fileSecurity.ValidateFilePermissions(path)
 }
 
+// SecureDeleteFile overwrites and removes a file
+func (m *Manager) SecureDeleteFile(path string) error {
+	if m.fileSecurity == nil {
+		err := os.Remove(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return m.fileSecurity.SecureDeleteFile(path)
+}
+
 // CleanupTempFiles removes old temporary files
 func (m *Manager) CleanupTempFiles(tempDir string, maxAge time.Duration) error {
 	if m.fileSecurity == nil {
@@ -3,6 +3,8 @@ package security
 import (
 	"context"
 	"fmt"
+	"net"
+	"strings"
 	"sync"
 	"time"
 
@@ -308,4 +310,93 @@ func (rl *RateLimiter) Close() error {
 	return nil
 }
 
- 
\ No newline at end of file
+ 
+// WebRateLimitConfig holds the inbound limits of the web server, applied
+// to each client IP and token when rate limiting is enabled
+type WebRateLimitConfig struct {
+	RequestsPerMinute int                  `toml:"requests_per_minute"` // default budget of a client
+	BurstCapacity     int                  `toml:"burst_capacity"`
+	Routes            map[string]RateLimit `toml:"routes"`          // budgets by path prefix
+	ExemptPaths       []string             `toml:"exempt_paths"`    // path prefixes never limited
+	AuthPaths         []string             `toml:"auth_paths"`      // path prefixes where any 4xx response is a failed attempt
+	MaxFailures       int                  `toml:"max_failures"`    // failures within failure_window before a ban
+	FailureWindow     time.Duration        `toml:"failure_window"`
+	BanDuration       time.Duration        `toml:"ban_duration"`
+	TrustedProxies    []string             `toml:"trusted_proxies"` // addresses or CIDRs whose X-Forwarded-For is trusted
+}
+
+// DefaultWebRateLimitConfig returns the default inbound limits
+func DefaultWebRateLimitConfig() WebRateLimitConfig {
+	return WebRateLimitConfig{
+		RequestsPerMinute: 300,
+		BurstCapacity:     60,
+		Routes: map[string]RateLimit{
+			"/callback": {
+				RequestsPerMinute: 10,    // OAuth callbacks carry authorization codes
+				BurstCapacity:     5,
+				Window:            time.Minute,
+			},
+			"/auth-url": {
+				RequestsPerMinute: 20,
+				BurstCapacity:     5,
+				Window:            time.Minute,
+			},
+			"/api/export": {
+				RequestsPerMinute: 30,    // Starting exports calls the Trakt API
+				BurstCapacity:     10,
+				Window:            time.Minute,
+			},
+			"/api/audit/export": {
+				RequestsPerMinute: 10,
+				BurstCapacity:     3,
+				Window:            time.Minute,
+			},
+		},
+		ExemptPaths:   []string{"/static/", "/health"},
+		AuthPaths:     []string{"/callback"},
+		MaxFailures:   10,
+		FailureWindow: 10 * time.Minute,
+		BanDuration:   15 * time.Minute,
+	}
+}
+
+// Validate checks if the inbound limits are valid
+func (wc *WebRateLimitConfig) Validate() error {
+	if wc.RequestsPerMinute < 0 || wc.BurstCapacity < 0 || wc.MaxFailures < 0 {
+		return fmt.Errorf("requests_per_minute, burst_capacity and max_failures must not be negative")
+	}
+	if wc.FailureWindow < 0 || wc.BanDuration < 0 {
+		return fmt.Errorf("failure_window and ban_duration must not be negative")
+	}
+	for route, limit := range wc.Routes {
+		if !strings.HasPrefix(route, "/") {
+			return fmt.Errorf("route %q must start with /", route)
+		}
+		if limit.RequestsPerMinute <= 0 || limit.BurstCapacity <= 0 {
+			return fmt.Errorf("route %q needs a positive requests_per_minute and burst_capacity", route)
+		}
+	}
+	for _, proxy := range wc.TrustedProxies {
+		if _, err := ParseNetwork(proxy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseNetwork parses an IP address or a CIDR network
+func ParseNetwork(value string) (*net.IPNet, error) {
+	if _, network, err := net.ParseCIDR(value); err == nil {
+		return network, nil
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid address or network: %q", value)
+	}
+	bits := 128
+	if ip.To4() != nil {
+		ip = ip.To4()
+		bits = 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
@@ -0,0 +1,168 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ManifestFile is the name of the manifest written in an export directory
+const ManifestFile = "manifest.json"
+
+// Manifest lists the files of an export directory with their hashes. Its
+// signature covers every listed file.
+type Manifest struct {
+	CreatedAt      time.Time       `json:"created_at"`
+	KeyFingerprint string          `json:"key_fingerprint"`
+	Files          []ManifestEntry `json:"files"`
+}
+
+// ManifestEntry is a file listed in a manifest
+type ManifestEntry struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// FileResult is the outcome of verifying one file
+type FileResult struct {
+	Name string
+	Err  error
+}
+
+// SignManifest writes a manifest of files, given by their names in dir, to
+// dir/manifest.json and signs it. It returns the path of the manifest.
+func (s *Signer) SignManifest(dir string, files []string) (string, error) {
+	manifest := Manifest{
+		CreatedAt:      time.Now().UTC(),
+		KeyFingerprint: Fingerprint(s.PublicKey()),
+	}
+
+	names := append([]string(nil), files...)
+	sort.Strings(names)
+	for _, name := range names {
+		size, sum, err := hashFile(filepath.Join(dir, name))
+		if err != nil {
+			return "", err
+		}
+		manifest.Files = append(manifest.Files, ManifestEntry{Name: name, Size: size, SHA256: sum})
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, ManifestFile)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if _, err := s.SignFile(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// VerifyManifest checks the signature of the manifest of dir, then the
+// hash of every file it lists. Files of dir missing from the manifest are
+// reported as unsigned.
+func VerifyManifest(pub ed25519.PublicKey, dir string) (*Manifest, []FileResult, error) {
+	path := filepath.Join(dir, ManifestFile)
+	if err := VerifyFile(pub, path); err != nil {
+		return nil, nil, fmt.Errorf("manifest: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	var results []FileResult
+	listed := make(map[string]bool)
+	for _, entry := range manifest.Files {
+		// The manifest is signed, but only names within dir are accepted
+		if entry.Name != filepath.Base(entry.Name) || entry.Name == ".." {
+			results = append(results, FileResult{Name: entry.Name, Err: fmt.Errorf("invalid file name")})
+			continue
+		}
+		listed[entry.Name] = true
+
+		size, sum, err := hashFile(filepath.Join(dir, entry.Name))
+		if err == nil && (size != entry.Size || sum != entry.SHA256) {
+			err = ErrManifestMismatch
+		}
+		results = append(results, FileResult{Name: entry.Name, Err: err})
+	}
+
+	files, err := exportFiles(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, name := range files {
+		if !listed[name] {
+			results = append(results, FileResult{Name: name, Err: ErrUnsigned})
+		}
+	}
+	return &manifest, results, nil
+}
+
+// VerifyDir checks every export file of dir against its detached signature
+func VerifyDir(pub ed25519.PublicKey, dir string) ([]FileResult, error) {
+	files, err := exportFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]FileResult, 0, len(files))
+	for _, name := range files {
+		results = append(results, FileResult{Name: name, Err: VerifyFile(pub, filepath.Join(dir, name))})
+	}
+	return results, nil
+}
+
+// exportFiles returns the names of the files of dir, leaving out
+// signatures, the manifest and hidden files such as the export state
+func exportFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || strings.HasPrefix(name, ".") ||
+			strings.HasSuffix(name, SignatureExt) || name == ManifestFile {
+			continue
+		}
+		files = append(files, name)
+	}
+	return files, nil
+}
+
+// hashFile returns the size and hex SHA-256 hash of a file
+func hashFile(path string) (int64, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,151 @@
+// Package signing signs export files with Ed25519 so that copies synced to
+// other machines can be checked for alterations. Signatures are detached:
+// the raw 64-byte signature of a file is written next to it with a .sig
+// extension, which OpenSSL can also verify with the exported public key.
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/keyring"
+)
+
+const (
+	// SignatureExt is appended to the name of a file to name its signature
+	SignatureExt = ".sig"
+
+	// publicKeyPEMType is the PEM block type of exported public keys
+	publicKeyPEMType = "PUBLIC KEY"
+)
+
+var (
+	// ErrInvalidSignature is returned when a signature does not match its file
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrUnsigned is returned when a file has no signature
+	ErrUnsigned = errors.New("file is not signed")
+	// ErrManifestMismatch is returned when a file differs from its manifest entry
+	ErrManifestMismatch = errors.New("file does not match the manifest")
+)
+
+// LoadKey returns the private key stored under name in the keyring as a
+// base64 seed, generating and storing a new one when none exists and
+// create is set. The env backend only keeps values for the running
+// process, so it must be given the seed rather than generate one.
+func LoadKey(keyringMgr *keyring.Manager, name string, create bool) (ed25519.PrivateKey, error) {
+	encoded, err := keyringMgr.Retrieve(name)
+	if err == nil {
+		seed, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("invalid signing key in keyring entry %s", name)
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	}
+	if err != keyring.ErrCredentialNotFound || !create {
+		return nil, err
+	}
+	if keyringMgr.Backend() == keyring.EnvBackend {
+		return nil, fmt.Errorf("the env keyring cannot keep a generated key, set TRAKT_%s to a base64 32-byte seed or use another keyring backend", strings.ToUpper(name))
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	if err := keyringMgr.Store(name, base64.StdEncoding.EncodeToString(key.Seed())); err != nil {
+		return nil, fmt.Errorf("failed to store signing key: %w", err)
+	}
+	return key, nil
+}
+
+// MarshalPublicKey encodes a public key as a PEM block
+func MarshalPublicKey(pub ed25519.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: publicKeyPEMType, Bytes: der}), nil
+}
+
+// ParsePublicKey decodes a public key written by MarshalPublicKey
+func ParsePublicKey(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != publicKeyPEMType {
+		return nil, fmt.Errorf("no %s PEM block found", publicKeyPEMType)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is not an Ed25519 key")
+	}
+	return pub, nil
+}
+
+// Fingerprint identifies a public key by the start of its SHA-256 hash
+func Fingerprint(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Signer writes detached signatures and signed manifests of export files
+type Signer struct {
+	key ed25519.PrivateKey
+}
+
+// NewSigner creates a signer using key
+func NewSigner(key ed25519.PrivateKey) *Signer {
+	return &Signer{key: key}
+}
+
+// PublicKey returns the public key verifying the signatures of the signer
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+// SignFile signs the content of path and writes the signature to path.sig,
+// which it returns. Ed25519 signs whole messages, so the file is read into
+// memory.
+func (s *Signer) SignFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	sigPath := path + SignatureExt
+	if err := os.WriteFile(sigPath, ed25519.Sign(s.key, data), 0644); err != nil {
+		return "", fmt.Errorf("failed to write signature: %w", err)
+	}
+	return sigPath, nil
+}
+
+// VerifyFile checks path against the signature in path.sig
+func VerifyFile(pub ed25519.PublicKey, path string) error {
+	sig, err := os.ReadFile(path + SignatureExt)
+	if os.IsNotExist(err) {
+		return ErrUnsigned
+	}
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pub, data, sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
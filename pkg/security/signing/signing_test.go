@@ -0,0 +1,201 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/keyring"
+)
+
+func newTestSigner(t *testing.T) *Signer {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	return NewSigner(key)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	keyringMgr, err := keyring.NewManager(keyring.MemoryBackend)
+	if err != nil {
+		t.Fatalf("Failed to create keyring manager: %v", err)
+	}
+
+	if _, err := LoadKey(keyringMgr, "export_signing_key", false); err != keyring.ErrCredentialNotFound {
+		t.Errorf("Expected ErrCredentialNotFound without create, got %v", err)
+	}
+
+	key, err := LoadKey(keyringMgr, "export_signing_key", true)
+	if err != nil {
+		t.Fatalf("Failed to create key: %v", err)
+	}
+	loaded, err := LoadKey(keyringMgr, "export_signing_key", false)
+	if err != nil {
+		t.Fatalf("Failed to load key: %v", err)
+	}
+	if !key.Equal(loaded) {
+		t.Error("Expected the stored key to be loaded")
+	}
+}
+
+func TestLoadKeyEnvBackend(t *testing.T) {
+	keyringMgr, err := keyring.NewManager(keyring.EnvBackend)
+	if err != nil {
+		t.Fatalf("Failed to create keyring manager: %v", err)
+	}
+
+	t.Setenv("TRAKT_TEST_SIGNING_KEY", "")
+	os.Unsetenv("TRAKT_TEST_SIGNING_KEY")
+	if _, err := LoadKey(keyringMgr, "test_signing_key", true); err == nil {
+		t.Error("Expected the env backend to refuse generating a key")
+	}
+
+	t.Setenv("TRAKT_TEST_SIGNING_KEY", "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=")
+	key, err := LoadKey(keyringMgr, "test_signing_key", true)
+	if err != nil {
+		t.Fatalf("Failed to load key from the environment: %v", err)
+	}
+	if key.Seed()[31] != 31 {
+		t.Error("Expected the key to use the seed from the environment")
+	}
+}
+
+func TestPublicKeyPEM(t *testing.T) {
+	signer := newTestSigner(t)
+
+	data, err := MarshalPublicKey(signer.PublicKey())
+	if err != nil {
+		t.Fatalf("Failed to marshal public key: %v", err)
+	}
+	pub, err := ParsePublicKey(data)
+	if err != nil {
+		t.Fatalf("Failed to parse public key: %v", err)
+	}
+	if !pub.Equal(signer.PublicKey()) {
+		t.Error("Expected the parsed key to match")
+	}
+
+	if _, err := ParsePublicKey([]byte("not a key")); err == nil {
+		t.Error("Expected an error for invalid PEM")
+	}
+}
+
+func TestSignFile(t *testing.T) {
+	signer := newTestSigner(t)
+	path := filepath.Join(t.TempDir(), "watched.csv")
+	writeFile(t, path, "Title,Year\nHeat,1995\n")
+
+	sigPath, err := signer.SignFile(path)
+	if err != nil {
+		t.Fatalf("Failed to sign file: %v", err)
+	}
+	if sigPath != path+SignatureExt {
+		t.Errorf("Expected signature at %s, got %s", path+SignatureExt, sigPath)
+	}
+	if err := VerifyFile(signer.PublicKey(), path); err != nil {
+		t.Errorf("Expected a valid signature, got %v", err)
+	}
+
+	// Another key does not verify the signature
+	if err := VerifyFile(newTestSigner(t).PublicKey(), path); err != ErrInvalidSignature {
+		t.Errorf("Expected ErrInvalidSignature for another key, got %v", err)
+	}
+
+	// Neither does an altered file
+	writeFile(t, path, "Title,Year\nHeat,1996\n")
+	if err := VerifyFile(signer.PublicKey(), path); err != ErrInvalidSignature {
+		t.Errorf("Expected ErrInvalidSignature for an altered file, got %v", err)
+	}
+
+	os.Remove(sigPath)
+	if err := VerifyFile(signer.PublicKey(), path); err != ErrUnsigned {
+		t.Errorf("Expected ErrUnsigned without signature, got %v", err)
+	}
+}
+
+func TestVerifyDir(t *testing.T) {
+	signer := newTestSigner(t)
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "watched.csv"), "Title,Year\nHeat,1995\n")
+	writeFile(t, filepath.Join(dir, "ratings.csv"), "Title,Rating10\nHeat,9\n")
+	writeFile(t, filepath.Join(dir, ".export_state.json"), "{}")
+	if _, err := signer.SignFile(filepath.Join(dir, "watched.csv")); err != nil {
+		t.Fatalf("Failed to sign file: %v", err)
+	}
+
+	results, err := VerifyDir(signer.PublicKey(), dir)
+	if err != nil {
+		t.Fatalf("Failed to verify directory: %v", err)
+	}
+	expected := map[string]error{"ratings.csv": ErrUnsigned, "watched.csv": nil}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %+v", len(expected), results)
+	}
+	for _, result := range results {
+		if result.Err != expected[result.Name] {
+			t.Errorf("Expected %v for %s, got %v", expected[result.Name], result.Name, result.Err)
+		}
+	}
+}
+
+func TestManifest(t *testing.T) {
+	signer := newTestSigner(t)
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "watched.csv"), "Title,Year\nHeat,1995\n")
+	writeFile(t, filepath.Join(dir, "ratings.csv"), "Title,Rating10\nHeat,9\n")
+
+	path, err := signer.SignManifest(dir, []string{"watched.csv", "ratings.csv"})
+	if err != nil {
+		t.Fatalf("Failed to sign manifest: %v", err)
+	}
+	if path != filepath.Join(dir, ManifestFile) {
+		t.Errorf("Unexpected manifest path %s", path)
+	}
+
+	manifest, results, err := VerifyManifest(signer.PublicKey(), dir)
+	if err != nil {
+		t.Fatalf("Failed to verify manifest: %v", err)
+	}
+	if manifest.KeyFingerprint != Fingerprint(signer.PublicKey()) || len(manifest.Files) != 2 {
+		t.Errorf("Unexpected manifest %+v", manifest)
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			t.Errorf("Expected %s to verify, got %v", result.Name, result.Err)
+		}
+	}
+
+	// Altered and added files are reported
+	writeFile(t, filepath.Join(dir, "ratings.csv"), "Title,Rating10\nHeat,10\n")
+	writeFile(t, filepath.Join(dir, "extra.csv"), "Title\n")
+	_, results, err = VerifyManifest(signer.PublicKey(), dir)
+	if err != nil {
+		t.Fatalf("Failed to verify manifest: %v", err)
+	}
+	expected := map[string]error{"ratings.csv": ErrManifestMismatch, "watched.csv": nil, "extra.csv": ErrUnsigned}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %+v", len(expected), results)
+	}
+	for _, result := range results {
+		if result.Err != expected[result.Name] {
+			t.Errorf("Expected %v for %s, got %v", expected[result.Name], result.Name, result.Err)
+		}
+	}
+
+	// An altered manifest fails as a whole
+	writeFile(t, path, `{"files":[]}`)
+	if _, _, err := VerifyManifest(signer.PublicKey(), dir); err == nil {
+		t.Error("Expected an altered manifest to fail")
+	}
+}
@@ -0,0 +1,336 @@
+package security
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// TLS modes of the web server
+const (
+	TLSModeOff        = "off"
+	TLSModeFiles      = "files"
+	TLSModeSelfSigned = "self_signed"
+	TLSModeACME       = "acme"
+)
+
+const (
+	// selfSignedValidity stays below the 398 days browsers accept
+	selfSignedValidity = 397 * 24 * time.Hour
+	// selfSignedRenewBefore regenerates certificates expiring within this period
+	selfSignedRenewBefore = 30 * 24 * time.Hour
+)
+
+// TLSConfig holds configuration of the HTTPS listener of the web server
+type TLSConfig struct {
+	Mode             string     `toml:"mode"`               // off, files, self_signed, acme
+	CertFile         string     `toml:"cert_file"`          // PEM certificate chain of the files mode
+	KeyFile          string     `toml:"key_file"`           // PEM private key of the files mode
+	SelfSignedDir    string     `toml:"self_signed_dir"`    // generated certificate and key
+	Hosts            []string   `toml:"hosts"`              // extra names and IPs of the self-signed certificate
+	HTTPRedirectPort int        `toml:"http_redirect_port"` // plain HTTP listener redirecting to HTTPS, 0 disables
+	ACME             ACMEConfig `toml:"acme"`
+}
+
+// ACMEConfig holds configuration of certificates obtained from an ACME CA
+type ACMEConfig struct {
+	DirectoryURL string   `toml:"directory_url"` // Let's Encrypt when empty
+	Email        string   `toml:"email"`         // contact of the ACME account
+	Domains      []string `toml:"domains"`       // names certificates are requested for
+	CacheDir     string   `toml:"cache_dir"`     // account key and certificates
+	CAFile       string   `toml:"ca_file"`       // trusted CA of the directory, e.g. a local test CA
+}
+
+// DefaultTLSConfig returns the default TLS configuration, plain HTTP
+func DefaultTLSConfig() TLSConfig {
+	return TLSConfig{
+		Mode:          TLSModeOff,
+		SelfSignedDir: "./config/tls",
+		ACME: ACMEConfig{
+			CacheDir: "./config/acme",
+		},
+	}
+}
+
+// Enabled reports whether the web server terminates TLS itself
+func (c TLSConfig) Enabled() bool {
+	mode := strings.ToLower(c.Mode)
+	return mode != "" && mode != TLSModeOff
+}
+
+// Scheme returns the URL scheme the web server is reached with
+func (c TLSConfig) Scheme() string {
+	if c.Enabled() {
+		return "https"
+	}
+	return "http"
+}
+
+// Validate checks if the TLS configuration is valid
+func (c *TLSConfig) Validate() error {
+	switch strings.ToLower(c.Mode) {
+	case "", TLSModeOff, TLSModeSelfSigned:
+	case TLSModeFiles:
+		if c.CertFile == "" || c.KeyFile == "" {
+			return fmt.Errorf("cert_file and key_file are required by the files mode")
+		}
+	case TLSModeACME:
+		if len(c.ACME.Domains) == 0 {
+			return fmt.Errorf("acme.domains must list at least one domain")
+		}
+		if c.ACME.DirectoryURL != "" && !strings.HasPrefix(c.ACME.DirectoryURL, "https://") {
+			return fmt.Errorf("acme.directory_url must be an https URL, got %q", c.ACME.DirectoryURL)
+		}
+	default:
+		return fmt.Errorf("invalid mode: %s (must be one of: off, files, self_signed, acme)", c.Mode)
+	}
+
+	if c.HTTPRedirectPort < 0 || c.HTTPRedirectPort > 65535 {
+		return fmt.Errorf("invalid http_redirect_port: %d", c.HTTPRedirectPort)
+	}
+
+	return nil
+}
+
+// ServerTLS holds the certificates of an HTTPS listener
+type ServerTLS struct {
+	// Config serves the certificates, for http.Server.TLSConfig
+	Config *tls.Config
+	// Fingerprint is the SHA-256 fingerprint of a fixed certificate, empty with ACME
+	Fingerprint string
+
+	manager *autocert.Manager
+}
+
+// NewServerTLS loads or creates the certificates selected by the configuration
+func NewServerTLS(config TLSConfig) (*ServerTLS, error) {
+	st := &ServerTLS{}
+
+	switch strings.ToLower(config.Mode) {
+	case TLSModeFiles:
+		if err := st.loadKeyPair(config.CertFile, config.KeyFile); err != nil {
+			return nil, err
+		}
+	case TLSModeSelfSigned:
+		certFile, keyFile, err := EnsureSelfSignedCertificate(config.SelfSignedDir, SelfSignedHosts(config.Hosts), time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if err := st.loadKeyPair(certFile, keyFile); err != nil {
+			return nil, err
+		}
+	case TLSModeACME:
+		manager, err := newACMEManager(config.ACME)
+		if err != nil {
+			return nil, err
+		}
+		st.manager = manager
+		st.Config = manager.TLSConfig()
+	default:
+		return nil, fmt.Errorf("TLS is disabled")
+	}
+
+	st.Config.MinVersion = tls.VersionTLS12
+	return st, nil
+}
+
+// HTTPHandler answers ACME http-01 challenges on the plain HTTP listener and
+// passes every other request to fallback
+func (st *ServerTLS) HTTPHandler(fallback http.Handler) http.Handler {
+	if st.manager != nil {
+		return st.manager.HTTPHandler(fallback)
+	}
+	return fallback
+}
+
+// loadKeyPair serves a fixed certificate
+func (st *ServerTLS) loadKeyPair(certFile, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	sum := sha256.Sum256(cert.Certificate[0])
+	st.Fingerprint = hex.EncodeToString(sum[:])
+	st.Config = &tls.Config{Certificates: []tls.Certificate{cert}}
+	return nil
+}
+
+// newACMEManager obtains and renews certificates of the configured domains
+func newACMEManager(config ACMEConfig) (*autocert.Manager, error) {
+	if err := os.MkdirAll(config.CacheDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create ACME cache directory: %w", err)
+	}
+
+	client := &acme.Client{DirectoryURL: config.DirectoryURL}
+	if config.CAFile != "" {
+		pemData, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ACME CA file: %w", err)
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pemData) {
+			return nil, fmt.Errorf("no certificate found in ACME CA file %s", config.CAFile)
+		}
+		client.HTTPClient = &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12},
+			},
+		}
+	}
+
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(config.CacheDir),
+		HostPolicy: autocert.HostWhitelist(config.Domains...),
+		Email:      config.Email,
+		Client:     client,
+	}, nil
+}
+
+// SelfSignedHosts returns the names a self-signed certificate covers: localhost,
+// the host name, the addresses of the network interfaces and extra hosts
+func SelfSignedHosts(extra []string) []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		hosts = append(hosts, hostname)
+	}
+
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.IsGlobalUnicast() {
+				hosts = append(hosts, ipNet.IP.String())
+			}
+		}
+	}
+
+	hosts = append(hosts, extra...)
+
+	seen := make(map[string]bool, len(hosts))
+	unique := hosts[:0]
+	for _, host := range hosts {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host != "" && !seen[host] {
+			seen[host] = true
+			unique = append(unique, host)
+		}
+	}
+	return unique
+}
+
+// EnsureSelfSignedCertificate returns the certificate and key files in dir,
+// generating them when they are missing, expire within 30 days or do not
+// cover every host
+func EnsureSelfSignedCertificate(dir string, hosts []string, now time.Time) (string, string, error) {
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	if len(hosts) == 0 {
+		hosts = []string{"localhost"}
+	}
+
+	if selfSignedValid(certFile, keyFile, hosts, now) {
+		return certFile, keyFile, nil
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", "", fmt.Errorf("failed to create certificate directory: %w", err)
+	}
+
+	certPEM, keyPEM, err := generateSelfSigned(hosts, now)
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		return "", "", fmt.Errorf("failed to write private key: %w", err)
+	}
+	if err := os.WriteFile(certFile, certPEM, 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write certificate: %w", err)
+	}
+
+	return certFile, keyFile, nil
+}
+
+// selfSignedValid reports whether an existing certificate can be kept
+func selfSignedValid(certFile, keyFile string, hosts []string, now time.Time) bool {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return false
+	}
+	if now.Add(selfSignedRenewBefore).After(cert.NotAfter) {
+		return false
+	}
+	for _, host := range hosts {
+		if cert.VerifyHostname(host) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// generateSelfSigned creates an ECDSA P-256 certificate for hosts
+func generateSelfSigned(hosts []string, now time.Time) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate private key: %w", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization: []string{"Export Trakt 4 Letterboxd"},
+			CommonName:   hosts[0],
+		},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
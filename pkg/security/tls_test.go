@@ -0,0 +1,381 @@
+package security
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTLSConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  TLSConfig
+		wantErr bool
+	}{
+		{"default", DefaultTLSConfig(), false},
+		{"empty mode", TLSConfig{}, false},
+		{"self signed", TLSConfig{Mode: TLSModeSelfSigned, HTTPRedirectPort: 8088}, false},
+		{"files", TLSConfig{Mode: TLSModeFiles, CertFile: "cert.pem", KeyFile: "key.pem"}, false},
+		{"files without key", TLSConfig{Mode: TLSModeFiles, CertFile: "cert.pem"}, true},
+		{"acme", TLSConfig{Mode: TLSModeACME, ACME: ACMEConfig{Domains: []string{"trakt.example.com"}}}, false},
+		{"acme without domains", TLSConfig{Mode: TLSModeACME}, true},
+		{"acme plain directory", TLSConfig{Mode: TLSModeACME, ACME: ACMEConfig{
+			Domains: []string{"trakt.example.com"}, DirectoryURL: "http://localhost:14000/dir"}}, true},
+		{"unknown mode", TLSConfig{Mode: "stunnel"}, true},
+		{"redirect port", TLSConfig{Mode: TLSModeSelfSigned, HTTPRedirectPort: 70000}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if DefaultTLSConfig().Enabled() || DefaultTLSConfig().Scheme() != "http" {
+		t.Error("Expected TLS to be disabled by default")
+	}
+	if scheme := (TLSConfig{Mode: TLSModeSelfSigned}).Scheme(); scheme != "https" {
+		t.Errorf("Expected https, got %s", scheme)
+	}
+}
+
+func TestEnsureSelfSignedCertificate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tls")
+	now := time.Now()
+	hosts := []string{"localhost", "127.0.0.1", "192.168.1.24"}
+
+	certFile, keyFile, err := EnsureSelfSignedCertificate(dir, hosts, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := readCertificate(t, certFile)
+	for _, host := range hosts {
+		if err := cert.VerifyHostname(host); err != nil {
+			t.Errorf("Expected certificate to cover %s: %v", host, err)
+		}
+	}
+	if info, err := os.Stat(keyFile); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected key file with mode 0600, got %v (%v)", info.Mode().Perm(), err)
+	}
+
+	// A valid certificate is kept
+	original, _ := os.ReadFile(certFile)
+	if _, _, err := EnsureSelfSignedCertificate(dir, hosts, now.Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if current, _ := os.ReadFile(certFile); !bytes.Equal(original, current) {
+		t.Error("Expected the certificate to be reused")
+	}
+
+	// A new LAN address needs a new certificate
+	if _, _, err := EnsureSelfSignedCertificate(dir, append(hosts, "nas.local"), now); err != nil {
+		t.Fatal(err)
+	}
+	if err := readCertificate(t, certFile).VerifyHostname("nas.local"); err != nil {
+		t.Errorf("Expected the certificate to be regenerated for the new host: %v", err)
+	}
+
+	// So does one about to expire
+	original, _ = os.ReadFile(certFile)
+	if _, _, err := EnsureSelfSignedCertificate(dir, hosts, now.Add(selfSignedValidity-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if current, _ := os.ReadFile(certFile); bytes.Equal(original, current) {
+		t.Error("Expected an expiring certificate to be regenerated")
+	}
+}
+
+func TestNewServerTLSSelfSigned(t *testing.T) {
+	dir := t.TempDir()
+	st, err := NewServerTLS(TLSConfig{Mode: TLSModeSelfSigned, SelfSignedDir: dir, Hosts: []string{"trakt.lan"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Fingerprint) != 64 {
+		t.Errorf("Expected a SHA-256 fingerprint, got %q", st.Fingerprint)
+	}
+	if st.Config.MinVersion != tls.VersionTLS12 {
+		t.Errorf("Expected TLS 1.2 minimum, got %x", st.Config.MinVersion)
+	}
+
+	cert := readCertificate(t, filepath.Join(dir, "cert.pem"))
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	server.TLS = st.Config
+	server.StartTLS()
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, ServerName: "trakt.lan"}}}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected the self-signed certificate to verify: %v", err)
+	}
+	resp.Body.Close()
+
+	if _, err := NewServerTLS(TLSConfig{Mode: TLSModeFiles, CertFile: filepath.Join(dir, "missing.pem"), KeyFile: filepath.Join(dir, "key.pem")}); err == nil {
+		t.Error("Expected an error for a missing certificate file")
+	}
+}
+
+func TestNewServerTLSACME(t *testing.T) {
+	ca := newTestACMEServer(t)
+	defer ca.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "directory-ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Certificate().Raw}), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := NewServerTLS(TLSConfig{
+		Mode: TLSModeACME,
+		ACME: ACMEConfig{
+			DirectoryURL: ca.URL + "/directory",
+			Email:        "admin@example.test",
+			Domains:      []string{"trakt.example.test"},
+			CacheDir:     filepath.Join(dir, "acme"),
+			CAFile:       caFile,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", st.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "ok")
+		}),
+		ErrorLog: log.New(io.Discard, "", 0),
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.issuer)
+	dial := func(name string) error {
+		conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{RootCAs: roots, ServerName: name})
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	// The certificate is obtained on the first handshake
+	if err := dial("trakt.example.test"); err != nil {
+		t.Fatalf("Expected a certificate issued by the ACME CA: %v", err)
+	}
+	if ca.issued() != 1 {
+		t.Errorf("Expected one issued certificate, got %d", ca.issued())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "acme", "trakt.example.test")); err != nil {
+		t.Errorf("Expected the certificate to be cached: %v", err)
+	}
+
+	// Later handshakes reuse it
+	if err := dial("trakt.example.test"); err != nil || ca.issued() != 1 {
+		t.Errorf("Expected the certificate to be reused, issued %d (%v)", ca.issued(), err)
+	}
+
+	// Names outside acme.domains are refused
+	if err := dial("other.example.test"); err == nil {
+		t.Error("Expected the handshake to fail for an unknown domain")
+	}
+}
+
+func readCertificate(t *testing.T, certFile string) *x509.Certificate {
+	t.Helper()
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatalf("No PEM block in %s", certFile)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// testACMEServer is a minimal RFC 8555 directory in the spirit of Pebble. It
+// creates orders ready for finalization, so challenges are skipped, and signs
+// every CSR with its own CA.
+type testACMEServer struct {
+	*httptest.Server
+	issuer    *x509.Certificate
+	issuerKey *ecdsa.PrivateKey
+
+	mu     sync.Mutex
+	nonce  int
+	chains [][]byte
+}
+
+func newTestACMEServer(t *testing.T) *testACMEServer {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test ACME CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ca := &testACMEServer{issuer: issuer, issuerKey: key}
+	ca.Server = httptest.NewTLSServer(http.HandlerFunc(ca.handle))
+	return ca
+}
+
+func (ca *testACMEServer) issued() int {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	return len(ca.chains)
+}
+
+func (ca *testACMEServer) handle(w http.ResponseWriter, r *http.Request) {
+	ca.mu.Lock()
+	ca.nonce++
+	w.Header().Set("Replay-Nonce", fmt.Sprintf("nonce-%d", ca.nonce))
+	ca.mu.Unlock()
+
+	var payload struct {
+		CSR string `json:"csr"`
+	}
+	if r.Method == http.MethodPost {
+		var jws struct {
+			Payload string `json:"payload"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &jws); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if data, err := base64.RawURLEncoding.DecodeString(jws.Payload); err == nil && len(data) > 0 {
+			json.Unmarshal(data, &payload)
+		}
+	}
+
+	switch {
+	case r.URL.Path == "/directory":
+		writeACMEJSON(w, http.StatusOK, map[string]string{
+			"newNonce":   ca.URL + "/nonce",
+			"newAccount": ca.URL + "/account",
+			"newOrder":   ca.URL + "/order",
+			"revokeCert": ca.URL + "/revoke",
+			"keyChange":  ca.URL + "/key-change",
+		})
+	case r.URL.Path == "/nonce":
+		w.WriteHeader(http.StatusOK)
+	case r.URL.Path == "/account":
+		w.Header().Set("Location", ca.URL+"/account/1")
+		writeACMEJSON(w, http.StatusCreated, map[string]string{"status": "valid"})
+	case r.URL.Path == "/order":
+		w.Header().Set("Location", ca.URL+"/order/1")
+		writeACMEJSON(w, http.StatusCreated, map[string]interface{}{
+			"status":   "ready",
+			"finalize": ca.URL + "/finalize",
+		})
+	case r.URL.Path == "/finalize":
+		chain, err := ca.sign(payload.CSR)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ca.mu.Lock()
+		ca.chains = append(ca.chains, chain)
+		certURL := fmt.Sprintf("%s/cert/%d", ca.URL, len(ca.chains)-1)
+		ca.mu.Unlock()
+		w.Header().Set("Location", ca.URL+"/order/1")
+		writeACMEJSON(w, http.StatusOK, map[string]string{"status": "valid", "certificate": certURL})
+	case strings.HasPrefix(r.URL.Path, "/cert/"):
+		var index int
+		fmt.Sscanf(strings.TrimPrefix(r.URL.Path, "/cert/"), "%d", &index)
+		ca.mu.Lock()
+		chain := ca.chains[index]
+		ca.mu.Unlock()
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		w.Write(chain)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// sign issues a certificate for the names of a base64url encoded CSR
+func (ca *testACMEServer) sign(encoded string) ([]byte, error) {
+	der, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: csr.Subject.CommonName},
+		DNSNames:     csr.DNSNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	leaf, err := x509.CreateCertificate(rand.Reader, template, ca.issuer, csr.PublicKey, ca.issuerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf})
+	return append(chain, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.issuer.Raw})...), nil
+}
+
+func writeACMEJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
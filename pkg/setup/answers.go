@@ -0,0 +1,187 @@
+// Package setup implements the first-run configuration wizard: the answers
+// it collects, their validation and the config file generated from them.
+package setup
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/validation"
+	"github.com/robfig/cron/v3"
+)
+
+// Supported authentication flows
+const (
+	AuthFlowBrowser = "browser" // local callback server
+	AuthFlowDevice  = "device"  // device code entered on another device
+	AuthFlowCode    = "code"    // authorization code pasted by the user
+	AuthFlowSkip    = "skip"    // authenticate later with the auth command
+)
+
+// Answers holds every choice made in the setup wizard. The JSON form is
+// used by the non-interactive --answers mode.
+type Answers struct {
+	ClientID       string `json:"client_id"`
+	ClientSecret   string `json:"client_secret"`
+	KeyringBackend string `json:"keyring_backend"`
+	AuthFlow       string `json:"auth_flow"`
+	AuthCode       string `json:"auth_code,omitempty"`
+	RedirectURI    string `json:"redirect_uri"`
+	Timezone       string `json:"timezone"`
+	DateFormat     string `json:"date_format"`
+	ExportDir      string `json:"export_dir"`
+	HistoryMode    string `json:"history_mode"`
+	Language       string `json:"language"`
+	Schedule       string `json:"schedule"`
+	ExportType     string `json:"export_type"`
+	ExportMode     string `json:"export_mode"`
+	SkipVerify     bool   `json:"skip_verify"`
+}
+
+// DefaultAnswers returns the answers proposed by the wizard
+func DefaultAnswers() Answers {
+	return Answers{
+		KeyringBackend: "system",
+		AuthFlow:       AuthFlowBrowser,
+		RedirectURI:    "http://localhost:8080/callback",
+		Timezone:       "UTC",
+		DateFormat:     "2006-01-02",
+		ExportDir:      "./exports",
+		HistoryMode:    "aggregated",
+		Language:       "en",
+		ExportType:     "all",
+		ExportMode:     "complete",
+	}
+}
+
+// LoadAnswers reads answers from a JSON file. Missing keys keep their default.
+func LoadAnswers(path string) (*Answers, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read answers file: %w", err)
+	}
+
+	answers := DefaultAnswers()
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&answers); err != nil {
+		return nil, fmt.Errorf("failed to parse answers file: %w", err)
+	}
+
+	return &answers, nil
+}
+
+// Validate checks every answer and returns the first problem found
+func (a *Answers) Validate() error {
+	checks := []struct {
+		field string
+		err   error
+	}{
+		{"credentials", validation.ValidateCredentials(a.ClientID, a.ClientSecret)},
+		{"keyring_backend", ValidateKeyringBackend(a.KeyringBackend)},
+		{"auth_flow", ValidateAuthFlow(a.AuthFlow)},
+		{"redirect_uri", ValidateRedirectURI(a.RedirectURI)},
+		{"timezone", ValidateTimezone(a.Timezone)},
+		{"date_format", ValidateDateFormat(a.DateFormat)},
+		{"export_dir", ValidateExportDir(a.ExportDir)},
+		{"history_mode", oneOf(a.HistoryMode, "aggregated", "individual")},
+		{"schedule", ValidateSchedule(a.Schedule)},
+		{"export_type", oneOf(a.ExportType, "watched", "collection", "shows", "ratings", "watchlist", "all")},
+		{"export_mode", oneOf(a.ExportMode, "normal", "initial", "complete")},
+	}
+
+	for _, check := range checks {
+		if check.err != nil {
+			return fmt.Errorf("%s: %w", check.field, check.err)
+		}
+	}
+	return nil
+}
+
+// CallbackPort returns the port of the redirect URI, 8080 when it has none
+func (a *Answers) CallbackPort() int {
+	u, err := url.Parse(a.RedirectURI)
+	if err != nil || u.Port() == "" {
+		return 8080
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		return 8080
+	}
+	return port
+}
+
+// ValidateKeyringBackend checks that the backend can persist credentials
+func ValidateKeyringBackend(backend string) error {
+	return oneOf(backend, "system", "env", "file")
+}
+
+// ValidateAuthFlow checks the authentication flow name
+func ValidateAuthFlow(flow string) error {
+	return oneOf(flow, AuthFlowBrowser, AuthFlowDevice, AuthFlowCode, AuthFlowSkip)
+}
+
+// ValidateRedirectURI checks the OAuth redirect URI registered with Trakt
+func ValidateRedirectURI(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an absolute http(s) URL, got %q", uri)
+	}
+	return nil
+}
+
+// ValidateTimezone checks that the timezone is known to the system
+func ValidateTimezone(tz string) error {
+	if _, err := time.LoadLocation(tz); err != nil {
+		return fmt.Errorf("unknown timezone %q", tz)
+	}
+	return nil
+}
+
+// ValidateDateFormat checks that the value is a Go time layout
+func ValidateDateFormat(layout string) error {
+	if layout == "" {
+		return fmt.Errorf("date format is required")
+	}
+	// A layout without any reference-time element formats to itself
+	if time.Date(2024, 5, 23, 0, 0, 0, 0, time.UTC).Format(layout) == layout {
+		return fmt.Errorf("%q is not a Go time layout (use e.g. 2006-01-02)", layout)
+	}
+	return nil
+}
+
+// ValidateExportDir checks the export directory path
+func ValidateExportDir(dir string) error {
+	validator := validation.NewValidator()
+	validator.AddRule("export_dir", validation.RequiredRule{})
+	validator.AddRule("export_dir", validation.PathRule{AllowAbsolute: true, AllowParentDir: false})
+	return validator.Validate("export_dir", dir)
+}
+
+// ValidateSchedule checks an optional 5-field cron expression
+func ValidateSchedule(schedule string) error {
+	if schedule == "" {
+		return nil
+	}
+	parser := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+	if _, err := parser.Parse(schedule); err != nil {
+		return fmt.Errorf("invalid cron expression %q: %w", schedule, err)
+	}
+	return nil
+}
+
+// oneOf checks that value is one of the allowed values
+func oneOf(value string, allowed ...string) error {
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid value %q (must be one of: %s)", value, strings.Join(allowed, ", "))
+}
@@ -0,0 +1,96 @@
+package setup
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Prompter asks questions on a terminal and reads the answers
+type Prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+	// terminal is the file descriptor of in when it is a terminal, -1 otherwise
+	terminal int
+}
+
+// NewPrompter creates a prompter reading from in and writing to out
+func NewPrompter(in io.Reader, out io.Writer) *Prompter {
+	p := &Prompter{in: bufio.NewReader(in), out: out, terminal: -1}
+	if file, ok := in.(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+		p.terminal = int(file.Fd())
+	}
+	return p
+}
+
+// AskSecret prompts for a secret, without echo on a terminal. Other input
+// is read as a line, as for Ask.
+func (p *Prompter) AskSecret(question string) (string, error) {
+	if p.terminal < 0 {
+		return p.Ask(question, "", nil)
+	}
+
+	fmt.Fprintf(p.out, "%s: ", question)
+	secret, err := term.ReadPassword(p.terminal)
+	fmt.Fprintln(p.out)
+	if err != nil {
+		return "", fmt.Errorf("failed to read answer: %w", err)
+	}
+	return strings.TrimSpace(string(secret)), nil
+}
+
+// Ask prompts until validate accepts the answer. An empty answer selects def.
+func (p *Prompter) Ask(question, def string, validate func(string) error) (string, error) {
+	for {
+		if def != "" {
+			fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+		} else {
+			fmt.Fprintf(p.out, "%s: ", question)
+		}
+
+		line, err := p.in.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", fmt.Errorf("failed to read answer: %w", err)
+		}
+
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			answer = def
+		}
+
+		if validate == nil {
+			return answer, nil
+		}
+		if err := validate(answer); err != nil {
+			fmt.Fprintf(p.out, "   ❌ %s\n", err)
+			continue
+		}
+		return answer, nil
+	}
+}
+
+// Choose prompts for one of the given options
+func (p *Prompter) Choose(question string, options []string, def string) (string, error) {
+	return p.Ask(fmt.Sprintf("%s (%s)", question, strings.Join(options, "/")), def, func(answer string) error {
+		return oneOf(answer, options...)
+	})
+}
+
+// Confirm asks a yes/no question
+func (p *Prompter) Confirm(question string, def bool) (bool, error) {
+	defAnswer := "n"
+	if def {
+		defAnswer = "y"
+	}
+	answer, err := p.Ask(question+" (y/n)", defAnswer, func(answer string) error {
+		return oneOf(strings.ToLower(answer), "y", "yes", "n", "no")
+	})
+	if err != nil {
+		return false, err
+	}
+	return strings.HasPrefix(strings.ToLower(answer), "y"), nil
+}
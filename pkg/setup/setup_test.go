@@ -0,0 +1,159 @@
+package setup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/keyring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAnswers() Answers {
+	a := DefaultAnswers()
+	a.ClientID = strings.Repeat("a", 64)
+	a.ClientSecret = strings.Repeat("b", 64)
+	a.KeyringBackend = "env"
+	a.Timezone = "Europe/Paris"
+	a.Schedule = "0 */6 * * *"
+	return a
+}
+
+func TestDefaultAnswers(t *testing.T) {
+	a := DefaultAnswers()
+	assert.Error(t, a.Validate(), "credentials have no default")
+
+	a.ClientID = strings.Repeat("a", 64)
+	a.ClientSecret = strings.Repeat("b", 64)
+	assert.NoError(t, a.Validate())
+	assert.Equal(t, 8080, a.CallbackPort())
+}
+
+func TestLoadAnswers(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "answers.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"client_id": "id", "client_secret": "secret", "timezone": "Asia/Tokyo", "auth_flow": "skip"}`), 0600))
+	a, err := LoadAnswers(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Asia/Tokyo", a.Timezone)
+	assert.Equal(t, AuthFlowSkip, a.AuthFlow)
+	assert.Equal(t, "system", a.KeyringBackend, "unset answers keep their defaults")
+
+	unknown := filepath.Join(dir, "unknown.json")
+	require.NoError(t, os.WriteFile(unknown, []byte(`{"client_id": "id", "clientsecret": "typo"}`), 0600))
+	_, err = LoadAnswers(unknown)
+	assert.Error(t, err)
+}
+
+func TestValidators(t *testing.T) {
+	assert.NoError(t, ValidateKeyringBackend("file"))
+	assert.Error(t, ValidateKeyringBackend("memory"))
+	assert.NoError(t, ValidateAuthFlow(AuthFlowCode))
+	assert.NoError(t, ValidateAuthFlow(AuthFlowDevice))
+	assert.Error(t, ValidateAuthFlow("magic"))
+	assert.NoError(t, ValidateRedirectURI("http://192.168.1.24:8089/callback"))
+	assert.Error(t, ValidateRedirectURI("not a url"))
+	assert.NoError(t, ValidateTimezone("America/New_York"))
+	assert.Error(t, ValidateTimezone("Mars/Olympus"))
+	assert.NoError(t, ValidateDateFormat("02/01/2006"))
+	assert.Error(t, ValidateDateFormat("dd/mm/yyyy"))
+	assert.NoError(t, ValidateExportDir("./exports"))
+	assert.Error(t, ValidateExportDir(""))
+	assert.NoError(t, ValidateSchedule(""))
+	assert.NoError(t, ValidateSchedule("30 2 * * *"))
+	assert.Error(t, ValidateSchedule("every day"))
+}
+
+func TestCallbackPort(t *testing.T) {
+	a := DefaultAnswers()
+	a.RedirectURI = "http://192.168.1.24:8089/callback"
+	assert.Equal(t, 8089, a.CallbackPort())
+}
+
+func TestWriteConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", "config.toml")
+	a := testAnswers()
+
+	backup, err := WriteConfig(path, a, false)
+	require.NoError(t, err)
+	assert.Empty(t, backup)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), a.ClientSecret, "the client secret must not be written to the file")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// The secret reference resolves through the env keyring backend
+	t.Setenv("TRAKT_CLIENT_SECRET", a.ClientSecret)
+	resolver, err := keyring.NewManager(keyring.EnvBackend)
+	require.NoError(t, err)
+	loader := config.NewLoader(path)
+	loader.Resolver = resolver
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+	assert.Equal(t, config.CurrentVersion, cfg.Version)
+	assert.Equal(t, a.ClientID, cfg.Trakt.ClientID)
+	assert.Equal(t, a.ClientSecret, cfg.Trakt.ClientSecret)
+	assert.Equal(t, "Europe/Paris", cfg.Export.Timezone)
+	assert.Equal(t, 8080, cfg.Auth.CallbackPort)
+	assert.Equal(t, "0 */6 * * *", cfg.Schedule.Cron)
+	assert.False(t, loader.Migration().Changed())
+
+	t.Run("refuses to overwrite", func(t *testing.T) {
+		_, err := WriteConfig(path, a, false)
+		assert.Error(t, err)
+	})
+
+	t.Run("overwrite keeps a backup", func(t *testing.T) {
+		backup, err := WriteConfig(path, a, true)
+		require.NoError(t, err)
+		saved, err := os.ReadFile(backup)
+		require.NoError(t, err)
+		assert.Equal(t, data, saved)
+	})
+
+	t.Run("invalid answers", func(t *testing.T) {
+		invalid := a
+		invalid.Timezone = "Mars/Olympus"
+		_, err := WriteConfig(filepath.Join(t.TempDir(), "config.toml"), invalid, false)
+		assert.Error(t, err)
+	})
+}
+
+func TestPrompter(t *testing.T) {
+	var out strings.Builder
+	p := NewPrompter(strings.NewReader("\nMars/Olympus\nAsia/Tokyo\nmemory\nfile\nyes\n"), &out)
+
+	answer, err := p.Ask("Export directory", "./exports", ValidateExportDir)
+	require.NoError(t, err)
+	assert.Equal(t, "./exports", answer, "an empty answer selects the default")
+
+	answer, err = p.Ask("Timezone", "UTC", ValidateTimezone)
+	require.NoError(t, err)
+	assert.Equal(t, "Asia/Tokyo", answer, "invalid answers are asked again")
+
+	answer, err = p.Choose("Keyring backend", []string{"system", "file", "env"}, "system")
+	require.NoError(t, err)
+	assert.Equal(t, "file", answer)
+
+	confirmed, err := p.Confirm("Continue?", false)
+	require.NoError(t, err)
+	assert.True(t, confirmed)
+
+	// Without a terminal, secrets are read as a line
+	secret := NewPrompter(strings.NewReader(" s3cret \n"), &out)
+	answer, err = secret.AskSecret("Client Secret")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", answer)
+
+	_, err = p.Ask("Client ID", "", nil)
+	assert.Error(t, err, "end of input is an error")
+	assert.Contains(t, out.String(), "Timezone [UTC]: ")
+}
@@ -0,0 +1,117 @@
+package setup
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/keyring"
+)
+
+// ClientSecretKey is the keyring key holding the Trakt client secret
+const ClientSecretKey = "client_secret"
+
+// Document builds the config file content for the given answers. The client
+// secret is never written; the file references it in the keyring instead.
+func Document(a Answers) map[string]interface{} {
+	sec := security.DefaultSecurityConfig()
+
+	return map[string]interface{}{
+		"version": config.CurrentVersion,
+		"trakt": map[string]interface{}{
+			"client_id":     a.ClientID,
+			"client_secret": keyring.KeyringReferencePrefix + ClientSecretKey,
+			"api_base_url":  "https://api.trakt.tv",
+			"extended_info": "full",
+		},
+		"letterboxd": map[string]interface{}{
+			"export_dir": a.ExportDir,
+		},
+		"export": map[string]interface{}{
+			"format":       "csv",
+			"date_format":  a.DateFormat,
+			"timezone":     a.Timezone,
+			"history_mode": a.HistoryMode,
+		},
+		"logging": map[string]interface{}{
+			"level": "info",
+			"file":  "./logs/export.log",
+		},
+		"i18n": map[string]interface{}{
+			"default_language": "en",
+			"language":         a.Language,
+			"locales_dir":      "./locales",
+		},
+		"security": map[string]interface{}{
+			"encryption_enabled": sec.EncryptionEnabled,
+			"keyring_backend":    a.KeyringBackend,
+			"audit_logging":      sec.AuditLogging,
+			"rate_limit_enabled": sec.RateLimitEnabled,
+			"require_https":      sec.RequireHTTPS,
+			"audit": map[string]interface{}{
+				"log_level":         sec.Audit.LogLevel,
+				"retention_days":    sec.Audit.RetentionDays,
+				"include_sensitive": sec.Audit.IncludeSensitive,
+				"output_format":     sec.Audit.OutputFormat,
+			},
+		},
+		"auth": map[string]interface{}{
+			"use_oauth":     true,
+			"auto_refresh":  true,
+			"redirect_uri":  a.RedirectURI,
+			"callback_port": a.CallbackPort(),
+		},
+		"schedule": map[string]interface{}{
+			"cron":        a.Schedule,
+			"export_type": a.ExportType,
+			"export_mode": a.ExportMode,
+		},
+	}
+}
+
+// WriteConfig writes the config file for the given answers with 0600
+// permissions. An existing file is only replaced when overwrite is set,
+// and is then kept as a backup.
+func WriteConfig(path string, a Answers, overwrite bool) (string, error) {
+	if err := a.Validate(); err != nil {
+		return "", fmt.Errorf("invalid answers: %w", err)
+	}
+
+	var backupPath string
+	if original, err := os.ReadFile(path); err == nil {
+		if !overwrite {
+			return "", fmt.Errorf("config file %s already exists", path)
+		}
+		backupPath = fmt.Sprintf("%s.%s.bak", path, time.Now().Format("20060102-150405"))
+		if err := os.WriteFile(backupPath, original, 0600); err != nil {
+			return "", fmt.Errorf("failed to write backup: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read existing config: %w", err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Generated by the setup wizard on %s\n", time.Now().Format(time.RFC3339))
+	if a.KeyringBackend == "env" {
+		fmt.Fprintf(&buf, "# The client secret is read from the TRAKT_CLIENT_SECRET environment variable.\n\n")
+	} else {
+		fmt.Fprintf(&buf, "# The client secret is stored in the %q keyring backend.\n\n", a.KeyringBackend)
+	}
+	if err := toml.NewEncoder(&buf).Encode(Document(a)); err != nil {
+		return "", fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return "", fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return "", fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	return backupPath, nil
+}
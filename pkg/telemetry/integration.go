@@ -11,26 +11,32 @@ import (
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring/tracing"
 )
 
-// InitializeTelemetryFromConfig initializes telemetry from application config
+// InitializeTelemetryFromConfig initializes telemetry from the [monitoring],
+// [tracing] and [alerts] sections of the application config. The
+// monitoring switches gate each component; the telemetry HTTP server only
+// listens when metrics_port is set, otherwise the metrics are left to be
+// mounted on the web server.
 func InitializeTelemetryFromConfig(appConfig *config.Config, version string) (*TelemetryManager, error) {
+	monitoringConfig := appConfig.Monitoring
+	if monitoringConfig.MetricsPath == "" {
+		monitoringConfig.MetricsPath = "/metrics"
+	}
+	monitoringConfig.MetricsEnabled = monitoringConfig.Enabled && monitoringConfig.MetricsEnabled
+	monitoringConfig.TracingEnabled = monitoringConfig.Enabled && monitoringConfig.TracingEnabled
+	monitoringConfig.HealthChecksEnabled = monitoringConfig.Enabled && monitoringConfig.HealthChecksEnabled
+	monitoringConfig.Enabled = monitoringConfig.Enabled && monitoringConfig.MetricsPort > 0
+
+	tracingConfig := appConfig.Tracing
+	tracingConfig.Enabled = monitoringConfig.TracingEnabled
+	if tracingConfig.ServiceName == "" {
+		tracingConfig.ServiceName = "export-trakt-letterboxd"
+	}
+	tracingConfig.ServiceVersion = version
+
 	// Convert application config to telemetry config
 	telemetryConfig := TelemetryConfig{
-		Monitoring: monitoring.MonitoringConfig{
-			Enabled:             true, // Enable by default
-			MetricsEnabled:      true,
-			TracingEnabled:      true,
-			HealthChecksEnabled: true,
-			MetricsPort:         9090,
-			MetricsPath:         "/metrics",
-		},
-		Tracing: tracing.TracingConfig{
-			Enabled:        true,
-			ServiceName:    "export-trakt-letterboxd",
-			ServiceVersion: version,
-			Environment:    "production", // Could be read from config or env
-			JaegerEndpoint: "",           // Optional
-			SamplingRate:   0.1,          // 10% sampling
-		},
+		Monitoring: monitoringConfig,
+		Tracing:    tracingConfig,
 		Logging: monitoring.LoggingConfig{
 			Level:           appConfig.Logging.Level,
 			Format:          "visual", // Default to visual for compatibility
@@ -40,13 +46,13 @@ func InitializeTelemetryFromConfig(appConfig *config.Config, version string) (*T
 			MaxSizeMB:       100,
 			MaxBackups:      3,
 			CorrelationID:   true,
+			Privacy:         appConfig.Logging.Privacy,
 		},
-		Alerts: monitoring.AlertsConfig{
-			WebhookURL:       "",
-			EmailEnabled:     false,
-			SlackEnabled:     false,
-			RateLimitMinutes: 5,
-		},
+		Alerts: appConfig.Alerts,
+	}
+
+	if telemetryConfig.Alerts.RateLimitMinutes == 0 {
+		telemetryConfig.Alerts.RateLimitMinutes = 5
 	}
 
 	// Override with environment variables if available
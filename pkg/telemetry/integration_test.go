@@ -0,0 +1,64 @@
+package telemetry
+
+import (
+	"testing"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/api"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+)
+
+func TestInitializeTelemetryFromConfig(t *testing.T) {
+	defer api.SetCallRecorder(nil)
+
+	cfg := &config.Config{}
+	cfg.SetDefaults()
+	cfg.Logging.Level = "info"
+	cfg.Monitoring.Enabled = true
+	cfg.Monitoring.MetricsEnabled = true
+	cfg.Monitoring.MetricsPort = 0
+
+	tm, err := InitializeTelemetryFromConfig(cfg, "1.2.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tm.GetMetrics() == nil {
+		t.Error("Metrics should be initialized when enabled")
+	}
+	if tm.tracer != nil {
+		t.Error("Tracer should be nil when tracing_enabled is false")
+	}
+	// Without a metrics port the metrics are mounted on the web server
+	if tm.httpServer != nil {
+		t.Error("HTTP server should be nil when metrics_port is 0")
+	}
+	if tm.config.Tracing.ServiceVersion != "1.2.3" {
+		t.Errorf("Expected service version 1.2.3, got %s", tm.config.Tracing.ServiceVersion)
+	}
+}
+
+func TestInitializeTelemetryFromConfigDisabled(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.SetDefaults()
+	cfg.Logging.Level = "info"
+	cfg.Monitoring.Enabled = false
+	cfg.Monitoring.MetricsEnabled = true
+	cfg.Monitoring.HealthChecksEnabled = true
+	cfg.Monitoring.MetricsPort = 9090
+
+	tm, err := InitializeTelemetryFromConfig(cfg, "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The monitoring switch gates every component
+	if tm.GetMetrics() != nil {
+		t.Error("Metrics should be nil when monitoring is disabled")
+	}
+	if tm.healthChecker != nil {
+		t.Error("Health checker should be nil when monitoring is disabled")
+	}
+	if tm.httpServer != nil {
+		t.Error("HTTP server should be nil when monitoring is disabled")
+	}
+}
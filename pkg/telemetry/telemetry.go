@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/api"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/monitoring/alerts"
@@ -67,6 +68,12 @@ func NewTelemetryManager(config TelemetryConfig, version string) (*TelemetryMana
 		if err := tm.metrics.RegisterMetrics(); err != nil {
 			return nil, fmt.Errorf("failed to register metrics: %w", err)
 		}
+		// Publish the Trakt rate limit budget shared by all API clients
+		api.DefaultRateLimiter().AddObserver(func(b api.RateLimitBudget) {
+			tm.metrics.UpdateRateLimit(b.Name, b.Limit, b.Remaining, b.Until)
+		})
+		// Count the requests of every Trakt client
+		api.SetCallRecorder(tm.metrics)
 		structuredLogger.WithField("metrics_port", config.Monitoring.MetricsPort).Info("Metrics system initialized")
 	}
 
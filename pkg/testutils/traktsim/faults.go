@@ -0,0 +1,109 @@
+package traktsim
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fault is an injected failure answering matching requests instead of the
+// simulated API
+type Fault struct {
+	// Path is the path prefix the fault applies to, empty for every path
+	Path string
+	// Status is the response status, 200 when only Body is set
+	Status int
+	// RetryAfter sets the Retry-After header when positive
+	RetryAfter time.Duration
+	// Body is the raw response body
+	Body string
+	// Times is the number of requests answered by the fault, at least one
+	Times int
+}
+
+func (f *Fault) matches(path string) bool {
+	return f.Path == "" || strings.HasPrefix(path, f.Path)
+}
+
+func (f *Fault) write(w http.ResponseWriter) {
+	if f.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(f.RetryAfter.Seconds()))))
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	status := f.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	w.Write([]byte(f.Body))
+}
+
+// InjectFault queues a fault. Faults are applied in the order they were injected.
+func (s *Simulator) InjectFault(f Fault) {
+	if f.Times < 1 {
+		f.Times = 1
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// RateLimit answers the next requests for path with 429 Too Many Requests
+// and a Retry-After header
+func (s *Simulator) RateLimit(path string, retryAfter time.Duration, times int) {
+	s.InjectFault(Fault{
+		Path:       path,
+		Status:     http.StatusTooManyRequests,
+		RetryAfter: retryAfter,
+		Body:       `{"error":"rate_limit_exceeded"}`,
+		Times:      times,
+	})
+}
+
+// ServerErrors answers the next requests for path with 502 Bad Gateway
+func (s *Simulator) ServerErrors(path string, times int) {
+	s.InjectFault(Fault{
+		Path:   path,
+		Status: http.StatusBadGateway,
+		Body:   `<html><body>502 Bad Gateway</body></html>`,
+		Times:  times,
+	})
+}
+
+// MalformedJSON answers the next requests for path with a truncated JSON body
+func (s *Simulator) MalformedJSON(path string, times int) {
+	s.InjectFault(Fault{
+		Path:  path,
+		Body:  `[{"movie":{"title":"Trunc`,
+		Times: times,
+	})
+}
+
+// ClearFaults removes all pending faults
+func (s *Simulator) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// takeFault returns the first pending fault for path and counts its use
+func (s *Simulator) takeFault(path string) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, fault := range s.faults {
+		if !fault.matches(path) {
+			continue
+		}
+		fault.Times--
+		if fault.Times <= 0 {
+			s.faults = append(s.faults[:i], s.faults[i+1:]...)
+		}
+		return fault
+	}
+	return nil
+}
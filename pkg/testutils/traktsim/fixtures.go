@@ -0,0 +1,172 @@
+package traktsim
+
+import (
+	"fmt"
+	"time"
+)
+
+// HistoryFixtureSize is the number of entries in the default movie history,
+// enough to span several pages at the default page limit
+const HistoryFixtureSize = 25
+
+// fixtureTime is the reference time of the default fixtures
+var fixtureTime = time.Date(2024, time.March, 1, 20, 0, 0, 0, time.UTC)
+
+type fixture = map[string]interface{}
+
+func movie(title string, year, trakt, tmdb int, imdb string) fixture {
+	return fixture{
+		"title": title,
+		"year":  year,
+		"ids": fixture{
+			"trakt": trakt,
+			"tmdb":  tmdb,
+			"imdb":  imdb,
+			"slug":  fmt.Sprintf("movie-%d", trakt),
+		},
+	}
+}
+
+func show(title string, year, trakt, tmdb, tvdb int, imdb string) fixture {
+	return fixture{
+		"title": title,
+		"year":  year,
+		"ids": fixture{
+			"trakt": trakt,
+			"tmdb":  tmdb,
+			"tvdb":  tvdb,
+			"imdb":  imdb,
+			"slug":  fmt.Sprintf("show-%d", trakt),
+		},
+	}
+}
+
+func episode(season, number, trakt int, title string) fixture {
+	return fixture{
+		"season": season,
+		"number": number,
+		"title":  title,
+		"ids": fixture{
+			"trakt": trakt,
+			"tmdb":  trakt + 1000,
+			"tvdb":  trakt + 2000,
+		},
+	}
+}
+
+func at(daysAgo int) string {
+	return fixtureTime.AddDate(0, 0, -daysAgo).Format(time.RFC3339)
+}
+
+// DefaultFixtures returns the datasets served by a new simulator, keyed by
+// endpoint path. The shapes follow the Trakt API documentation.
+func DefaultFixtures() map[string]interface{} {
+	inception := movie("Inception", 2010, 16662, 27205, "tt1375666")
+	matrix := movie("The Matrix", 1999, 481, 603, "tt0133093")
+	parasite := movie("Parasite", 2019, 372, 496243, "tt6751668")
+	dune := movie("Dune", 2021, 287071, 438631, "tt1160419")
+
+	breakingBad := show("Breaking Bad", 2008, 1388, 1396, 81189, "tt0903747")
+	dark := show("Dark", 2017, 120063, 70523, 334824, "tt5753856")
+
+	history := make([]fixture, 0, HistoryFixtureSize)
+	movies := []fixture{inception, matrix, parasite, dune}
+	for i := 0; i < HistoryFixtureSize; i++ {
+		action := "watch"
+		if i%10 == 9 {
+			action = "checkin"
+		}
+		history = append(history, fixture{
+			"id":         9000000 + i,
+			"watched_at": at(i * 3),
+			"action":     action,
+			"type":       "movie",
+			"movie":      movies[i%len(movies)],
+		})
+	}
+
+	return map[string]interface{}{
+		"/sync/watched/movies": []fixture{
+			{"plays": 3, "last_watched_at": at(0), "movie": inception},
+			{"plays": 1, "last_watched_at": at(9), "movie": matrix},
+			{"plays": 2, "last_watched_at": at(21), "movie": parasite},
+		},
+		"/sync/collection/movies": []fixture{
+			{"collected_at": at(40), "movie": inception},
+			{"collected_at": at(120), "movie": dune},
+		},
+		"/sync/watched/shows": []fixture{
+			{
+				"plays":           4,
+				"last_watched_at": at(2),
+				"show":            breakingBad,
+				"seasons": []fixture{
+					{"number": 1, "episodes": []fixture{
+						{"number": 1, "plays": 1, "last_watched_at": at(5)},
+						{"number": 2, "plays": 1, "last_watched_at": at(4)},
+					}},
+					{"number": 2, "episodes": []fixture{
+						{"number": 1, "plays": 2, "last_watched_at": at(2)},
+					}},
+				},
+			},
+			{
+				"plays":           1,
+				"last_watched_at": at(30),
+				"show":            dark,
+				"seasons": []fixture{
+					{"number": 1, "episodes": []fixture{
+						{"number": 1, "plays": 1, "last_watched_at": at(30)},
+					}},
+				},
+			},
+		},
+		"/sync/ratings/movies": []fixture{
+			{"rated_at": at(0), "rating": 9, "movie": inception},
+			{"rated_at": at(9), "rating": 10, "movie": matrix},
+			{"rated_at": at(21), "rating": 8, "movie": parasite},
+		},
+		"/sync/ratings/shows": []fixture{
+			{"rated_at": at(3), "rating": 10, "show": breakingBad},
+		},
+		"/sync/ratings/episodes": []fixture{
+			{"rated_at": at(2), "rating": 9, "show": breakingBad, "episode": episode(2, 1, 62085, "Seven Thirty-Seven")},
+			{"rated_at": at(30), "rating": 8, "show": dark, "episode": episode(1, 1, 2587357, "Secrets")},
+		},
+		"/sync/watchlist/movies": []fixture{
+			{"listed_at": at(1), "movie": dune, "notes": "Before part two"},
+		},
+		"/sync/history/movies": history,
+		"/sync/last_activities": fixture{
+			"all": at(0),
+			"movies": fixture{
+				"watched_at":     at(0),
+				"collected_at":   at(40),
+				"rated_at":       at(0),
+				"watchlisted_at": at(1),
+			},
+			"episodes": fixture{
+				"watched_at":   at(2),
+				"collected_at": at(60),
+				"rated_at":     at(2),
+			},
+			"shows": fixture{
+				"rated_at":       at(3),
+				"watchlisted_at": at(90),
+			},
+		},
+		"/users/settings": fixture{
+			"user": fixture{
+				"username": "traktsim",
+				"private":  false,
+				"name":     "Trakt Simulator",
+				"vip":      false,
+				"ids":      fixture{"slug": "traktsim"},
+			},
+			"account": fixture{
+				"timezone":  "UTC",
+				"time_24hr": true,
+			},
+		},
+	}
+}
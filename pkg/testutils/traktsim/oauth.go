@@ -0,0 +1,224 @@
+package traktsim
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	tokenLifetime    = 90 * 24 * time.Hour
+	simDeviceCode    = "traktsim-device-code"
+	simUserCode      = "SIM12345"
+	deviceCodeExpiry = 600
+)
+
+// tokenStore tracks the issued OAuth tokens
+type tokenStore struct {
+	mu      sync.Mutex
+	issued  int
+	access  map[string]bool
+	refresh map[string]bool
+	current [2]string
+}
+
+func newTokenStore() *tokenStore {
+	store := &tokenStore{
+		access:  make(map[string]bool),
+		refresh: make(map[string]bool),
+	}
+	store.issue()
+	return store
+}
+
+// issue creates a new token pair, which becomes the current one
+func (t *tokenStore) issue() (string, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.issued++
+	accessToken := fmt.Sprintf("traktsim-access-token-%d", t.issued)
+	refreshToken := fmt.Sprintf("traktsim-refresh-token-%d", t.issued)
+	t.access[accessToken] = true
+	t.refresh[refreshToken] = true
+	t.current = [2]string{accessToken, refreshToken}
+	return accessToken, refreshToken
+}
+
+func (t *tokenStore) valid(accessToken string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.access[accessToken]
+}
+
+// rotate exchanges a refresh token for a new pair, invalidating the old one
+func (t *tokenStore) rotate(refreshToken string) (string, string, bool) {
+	t.mu.Lock()
+	if !t.refresh[refreshToken] {
+		t.mu.Unlock()
+		return "", "", false
+	}
+	delete(t.refresh, refreshToken)
+	t.mu.Unlock()
+
+	accessToken, newRefreshToken := t.issue()
+	return accessToken, newRefreshToken, true
+}
+
+func (t *tokenStore) expireAll() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.access = make(map[string]bool)
+}
+
+func (t *tokenStore) revoke(accessToken string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.access, accessToken)
+}
+
+// AccessToken returns the most recently issued access token
+func (s *Simulator) AccessToken() string {
+	s.tokens.mu.Lock()
+	defer s.tokens.mu.Unlock()
+	return s.tokens.current[0]
+}
+
+// RefreshToken returns the most recently issued refresh token
+func (s *Simulator) RefreshToken() string {
+	s.tokens.mu.Lock()
+	defer s.tokens.mu.Unlock()
+	return s.tokens.current[1]
+}
+
+// ExpireTokens invalidates every issued access token, so API requests get
+// 401 until the client refreshes its token
+func (s *Simulator) ExpireTokens() {
+	s.tokens.expireAll()
+}
+
+// serveOAuth handles the token, device and revoke endpoints
+func (s *Simulator) serveOAuth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "OAuth endpoints expect POST")
+		return
+	}
+
+	params, err := readParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	if params["client_id"] != s.ClientID && r.URL.Path != "/oauth/revoke" {
+		writeError(w, http.StatusUnauthorized, "invalid_client", "Unknown client_id")
+		return
+	}
+
+	switch r.URL.Path {
+	case "/oauth/token":
+		s.serveToken(w, params)
+	case "/oauth/device/code":
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"device_code":      simDeviceCode,
+			"user_code":        simUserCode,
+			"verification_url": "https://trakt.tv/activate",
+			"expires_in":       deviceCodeExpiry,
+			"interval":         1,
+		})
+	case "/oauth/device/token":
+		s.serveDeviceToken(w, params)
+	case "/oauth/revoke":
+		s.tokens.revoke(params["token"])
+		w.WriteHeader(http.StatusOK)
+	default:
+		writeError(w, http.StatusNotFound, "not_found", "Unknown OAuth endpoint")
+	}
+}
+
+func (s *Simulator) serveToken(w http.ResponseWriter, params map[string]string) {
+	if params["client_secret"] != s.ClientSecret {
+		writeError(w, http.StatusUnauthorized, "invalid_client", "Invalid client_secret")
+		return
+	}
+
+	switch params["grant_type"] {
+	case "authorization_code":
+		if params["code"] == "" {
+			writeError(w, http.StatusBadRequest, "invalid_grant", "Missing authorization code")
+			return
+		}
+		accessToken, refreshToken := s.tokens.issue()
+		writeToken(w, accessToken, refreshToken)
+	case "refresh_token":
+		accessToken, refreshToken, ok := s.tokens.rotate(params["refresh_token"])
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalid_grant", "The refresh token is invalid or was already used")
+			return
+		}
+		writeToken(w, accessToken, refreshToken)
+	default:
+		writeError(w, http.StatusBadRequest, "unsupported_grant_type", "Unsupported grant_type")
+	}
+}
+
+func (s *Simulator) serveDeviceToken(w http.ResponseWriter, params map[string]string) {
+	if params["code"] != simDeviceCode {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	s.mu.Lock()
+	s.devicePolls++
+	pending := s.devicePolls <= s.DevicePendingPolls
+	s.mu.Unlock()
+
+	if pending {
+		writeError(w, http.StatusBadRequest, "authorization_pending", "The user has not approved the code yet")
+		return
+	}
+
+	accessToken, refreshToken := s.tokens.issue()
+	writeToken(w, accessToken, refreshToken)
+}
+
+func writeToken(w http.ResponseWriter, accessToken, refreshToken string) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token":  accessToken,
+		"token_type":    "bearer",
+		"expires_in":    int(tokenLifetime.Seconds()),
+		"refresh_token": refreshToken,
+		"scope":         "public",
+		"created_at":    time.Now().Unix(),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// readParams reads a form or JSON request body
+func readParams(r *http.Request) (map[string]string, error) {
+	params := make(map[string]string)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			return nil, fmt.Errorf("invalid JSON body: %w", err)
+		}
+		return params, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("invalid form body: %w", err)
+	}
+	for key := range r.PostForm {
+		params[key] = r.PostForm.Get(key)
+	}
+	return params, nil
+}
@@ -0,0 +1,223 @@
+package traktsim
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// recordedHeaders are the response headers kept in a cassette. Credentials
+// and per-request noise are never recorded.
+var recordedHeaders = []string{
+	"Content-Type",
+	"ETag",
+	"Last-Modified",
+	"Retry-After",
+	"X-Pagination-Page",
+	"X-Pagination-Limit",
+	"X-Pagination-Page-Count",
+	"X-Pagination-Item-Count",
+}
+
+// Interaction is one recorded request and its response
+type Interaction struct {
+	Method string            `json:"method"`
+	Path   string            `json:"path"`
+	Query  string            `json:"query,omitempty"`
+	Status int               `json:"status"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   json.RawMessage   `json:"body,omitempty"`
+	// RawBody holds a body that is not valid JSON, such as an HTML error
+	// page or a truncated response, replayed byte for byte
+	RawBody string `json:"raw_body,omitempty"`
+}
+
+func (i Interaction) key() string {
+	return interactionKey(i.Method, i.Path, i.Query)
+}
+
+func (i Interaction) write(w http.ResponseWriter) {
+	for name, value := range i.Header {
+		w.Header().Set(name, value)
+	}
+	w.WriteHeader(i.Status)
+	if i.RawBody != "" {
+		w.Write([]byte(i.RawBody))
+		return
+	}
+	w.Write(i.Body)
+}
+
+func interactionKey(method, path, query string) string {
+	return method + " " + path + "?" + query
+}
+
+// normalizeQuery sorts the query parameters so equivalent requests match
+func normalizeQuery(rawQuery string) string {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+	return values.Encode()
+}
+
+// Cassette is a set of recorded interactions stored as a fixture file
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// LoadCassette reads a cassette file
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	return &cassette, nil
+}
+
+// Save writes the cassette as indented JSON
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Replay serves the recorded interactions. They take precedence over the
+// fixtures for the exact method, path and query they were recorded with;
+// faults and authentication are still applied.
+func (s *Simulator) Replay(cassette *Cassette) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, interaction := range cassette.Interactions {
+		interaction.Query = normalizeQuery(interaction.Query)
+		s.interactions[interaction.key()] = interaction
+	}
+}
+
+// LoadFixtureDir replays every *.json cassette in a directory
+func (s *Simulator) LoadFixtureDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		cassette, err := LoadCassette(path)
+		if err != nil {
+			return err
+		}
+		s.Replay(cassette)
+	}
+	return nil
+}
+
+func (s *Simulator) interaction(r *http.Request) (Interaction, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	interaction, ok := s.interactions[interactionKey(r.Method, r.URL.Path, normalizeQuery(r.URL.RawQuery))]
+	return interaction, ok
+}
+
+// Recorder is a reverse proxy to a real Trakt API that records the traffic
+// into a cassette. Point the client's api_base_url at the recorder's server
+// and save the cassette once the scenario completed. OAuth traffic is
+// proxied but never recorded.
+type Recorder struct {
+	proxy *httputil.ReverseProxy
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder creates a recorder forwarding to target, e.g. "https://api.trakt.tv"
+func NewRecorder(target string) (*Recorder, error) {
+	targetURL, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recorder target: %w", err)
+	}
+
+	rec := &Recorder{}
+	rec.proxy = httputil.NewSingleHostReverseProxy(targetURL)
+	director := rec.proxy.Director
+	rec.proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = targetURL.Host
+		// Uncompressed bodies are needed to record them
+		req.Header.Del("Accept-Encoding")
+	}
+	rec.proxy.ModifyResponse = rec.record
+	return rec, nil
+}
+
+// ServeHTTP implements http.Handler
+func (rec *Recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec.proxy.ServeHTTP(w, r)
+}
+
+func (rec *Recorder) record(resp *http.Response) error {
+	req := resp.Request
+	if strings.HasPrefix(req.URL.Path, "/oauth/") {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	interaction := Interaction{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  normalizeQuery(req.URL.RawQuery),
+		Status: resp.StatusCode,
+		Header: make(map[string]string),
+	}
+	for _, name := range recordedHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			interaction.Header[name] = value
+		}
+	}
+	if len(body) > 0 {
+		if json.Valid(body) {
+			interaction.Body = body
+		} else {
+			interaction.RawBody = string(body)
+		}
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.cassette.Interactions = append(rec.cassette.Interactions, interaction)
+	return nil
+}
+
+// Cassette returns a copy of the recorded interactions
+func (rec *Recorder) Cassette() *Cassette {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return &Cassette{Interactions: append([]Interaction(nil), rec.cassette.Interactions...)}
+}
+
+// Save writes the recorded interactions to a cassette file
+func (rec *Recorder) Save(path string) error {
+	return rec.Cassette().Save(path)
+}
@@ -0,0 +1,347 @@
+// Package traktsim provides an httptest-based stand-in for the Trakt API.
+//
+// The simulator serves fixture datasets for the /sync and /users endpoints
+// with Trakt's pagination, ETag and rate limit headers, issues and refreshes
+// OAuth tokens on the /oauth endpoints, injects faults (429 with Retry-After,
+// 5xx bursts, expired tokens, malformed JSON) and replays traffic recorded
+// from the real API with a Recorder.
+package traktsim
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+)
+
+const (
+	// DefaultClientID is the API key accepted by a new simulator
+	DefaultClientID = "traktsim-client-id"
+	// DefaultClientSecret is the client secret accepted by a new simulator
+	DefaultClientSecret = "traktsim-client-secret"
+
+	defaultPageLimit = 10
+	rateLimitLimit   = 1000
+	rateLimitPeriod  = 5 * time.Minute
+)
+
+// Request is one request received by the simulator
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+	Status int
+	Time   time.Time
+}
+
+// Simulator is an http.Handler imitating the Trakt API
+type Simulator struct {
+	// ClientID is the expected trakt-api-key header and OAuth client_id
+	ClientID string
+	// ClientSecret is the expected OAuth client_secret
+	ClientSecret string
+	// DevicePendingPolls is the number of device token polls answered as
+	// pending before the device code is authorized
+	DevicePendingPolls int
+
+	mu            sync.Mutex
+	fixtures      map[string][]byte
+	interactions  map[string]Interaction
+	faults        []*Fault
+	tokens        *tokenStore
+	requests      []Request
+	rateRemaining int
+	rateReset     time.Time
+	devicePolls   int
+	server        *httptest.Server
+}
+
+// New creates a simulator serving the default fixtures
+func New() *Simulator {
+	s := &Simulator{
+		ClientID:      DefaultClientID,
+		ClientSecret:  DefaultClientSecret,
+		fixtures:      make(map[string][]byte),
+		interactions:  make(map[string]Interaction),
+		tokens:        newTokenStore(),
+		rateRemaining: rateLimitLimit,
+		rateReset:     time.Now().Add(rateLimitPeriod),
+	}
+	for path, data := range DefaultFixtures() {
+		if err := s.SetFixture(path, data); err != nil {
+			panic(fmt.Sprintf("traktsim: invalid default fixture %s: %v", path, err))
+		}
+	}
+	return s
+}
+
+// Start serves the simulator on a local httptest server
+func (s *Simulator) Start() *httptest.Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.server == nil {
+		s.server = httptest.NewServer(s)
+	}
+	return s.server
+}
+
+// URL returns the base URL of the started server
+func (s *Simulator) URL() string {
+	return s.Start().URL
+}
+
+// Close stops the server started with Start
+func (s *Simulator) Close() {
+	s.mu.Lock()
+	server := s.server
+	s.server = nil
+	s.mu.Unlock()
+
+	if server != nil {
+		server.Close()
+	}
+}
+
+// Config returns a configuration pointing at the simulator, authenticated
+// with its current access token
+func (s *Simulator) Config() *config.Config {
+	cfg := &config.Config{}
+	cfg.SetDefaults()
+	cfg.Trakt.APIBaseURL = s.URL()
+	cfg.Trakt.ClientID = s.ClientID
+	cfg.Trakt.ClientSecret = s.ClientSecret
+	cfg.Trakt.AccessToken = s.AccessToken()
+	cfg.Auth.UseOAuth = false
+	return cfg
+}
+
+// SetFixture sets the data served for an endpoint path such as
+// "/sync/watched/movies". Data is marshalled to JSON unless it is already
+// []byte or json.RawMessage.
+func (s *Simulator) SetFixture(path string, data interface{}) error {
+	var body []byte
+	switch v := data.(type) {
+	case []byte:
+		body = v
+	case json.RawMessage:
+		body = v
+	default:
+		var err error
+		if body, err = json.Marshal(v); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fixtures[path] = body
+	return nil
+}
+
+// Requests returns the requests received so far
+func (s *Simulator) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// RequestCount returns the number of requests received for a path
+func (s *Simulator) RequestCount(path string) int {
+	count := 0
+	for _, req := range s.Requests() {
+		if req.Path == path {
+			count++
+		}
+	}
+	return count
+}
+
+// statusRecorder captures the status written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// ServeHTTP implements http.Handler
+func (s *Simulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	s.serve(rec, r)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.RawQuery,
+		Status: rec.status,
+		Time:   time.Now(),
+	})
+	s.mu.Unlock()
+}
+
+func (s *Simulator) serve(w http.ResponseWriter, r *http.Request) {
+	if fault := s.takeFault(r.URL.Path); fault != nil {
+		fault.write(w)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, "/oauth/") {
+		s.serveOAuth(w, r)
+		return
+	}
+
+	if r.Header.Get("trakt-api-key") != s.ClientID {
+		writeError(w, http.StatusForbidden, "invalid_api_key", "Invalid API key or unapproved app")
+		return
+	}
+
+	if !s.tokens.valid(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")) {
+		writeError(w, http.StatusUnauthorized, "invalid_token", "The access token is invalid or expired")
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET requests are simulated")
+		return
+	}
+
+	if interaction, ok := s.interaction(r); ok {
+		interaction.write(w)
+		return
+	}
+
+	body, ok := s.fixture(r.URL.Path)
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "No fixture for "+r.URL.Path)
+		return
+	}
+
+	body, ok = paginate(w.Header(), body, r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_pagination", "Invalid page or limit")
+		return
+	}
+
+	s.writeRateLimit(w.Header())
+
+	sum := sha1.Sum(body)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// fixture finds the data of a path. /users/{id}/... endpoints fall back to
+// the matching /sync/... fixture, as both return the same user data.
+func (s *Simulator) fixture(path string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if body, ok := s.fixtures[path]; ok {
+		return body, true
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(path, "/users/"), "/", 2)
+	if strings.HasPrefix(path, "/users/") && len(parts) == 2 {
+		body, ok := s.fixtures["/sync/"+parts[1]]
+		return body, ok
+	}
+	return nil, false
+}
+
+// paginate slices array fixtures when the request asks for a page or a
+// limit and sets the X-Pagination headers like Trakt does
+func paginate(header http.Header, body []byte, r *http.Request) ([]byte, bool) {
+	query := r.URL.Query()
+	if query.Get("page") == "" && query.Get("limit") == "" {
+		return body, true
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
+		// Objects are not paginated
+		return body, true
+	}
+
+	page, limit := 1, defaultPageLimit
+	var err error
+	if value := query.Get("page"); value != "" {
+		if page, err = strconv.Atoi(value); err != nil || page < 1 {
+			return nil, false
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 {
+			return nil, false
+		}
+	}
+
+	pageCount := (len(items) + limit - 1) / limit
+	header.Set("X-Pagination-Page", strconv.Itoa(page))
+	header.Set("X-Pagination-Limit", strconv.Itoa(limit))
+	header.Set("X-Pagination-Page-Count", strconv.Itoa(pageCount))
+	header.Set("X-Pagination-Item-Count", strconv.Itoa(len(items)))
+
+	start := (page - 1) * limit
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + limit
+	if end > len(items) {
+		end = len(items)
+	}
+
+	paged, _ := json.Marshal(items[start:end])
+	return paged, true
+}
+
+// writeRateLimit counts the request against the GET budget and reports it
+// in the X-Ratelimit header
+func (s *Simulator) writeRateLimit(header http.Header) {
+	s.mu.Lock()
+	now := time.Now()
+	if now.After(s.rateReset) {
+		s.rateRemaining = rateLimitLimit
+		s.rateReset = now.Add(rateLimitPeriod)
+	}
+	if s.rateRemaining > 0 {
+		s.rateRemaining--
+	}
+	remaining, reset := s.rateRemaining, s.rateReset
+	s.mu.Unlock()
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"name":      "AUTHED_API_GET_LIMIT",
+		"period":    int(rateLimitPeriod.Seconds()),
+		"limit":     rateLimitLimit,
+		"remaining": remaining,
+		"until":     reset.UTC().Format(time.RFC3339),
+	})
+	header.Set("X-Ratelimit", string(data))
+}
+
+func writeError(w http.ResponseWriter, status int, code, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
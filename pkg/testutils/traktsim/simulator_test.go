@@ -0,0 +1,276 @@
+package traktsim_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/api"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/auth"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/testutils"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/testutils/traktsim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSimulator(t *testing.T) *traktsim.Simulator {
+	sim := traktsim.New()
+	sim.Start()
+	t.Cleanup(sim.Close)
+	return sim
+}
+
+func get(t *testing.T, sim *traktsim.Simulator, path string) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, sim.URL()+path, nil)
+	require.NoError(t, err)
+	req.Header.Set("trakt-api-key", sim.ClientID)
+	req.Header.Set("Authorization", "Bearer "+sim.AccessToken())
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestSimulatorServesFixturesToClient(t *testing.T) {
+	sim := newSimulator(t)
+	client := api.NewClient(sim.Config(), testutils.NewNoOpLogger())
+
+	movies, err := client.GetWatchedMovies()
+	require.NoError(t, err)
+	assert.Len(t, movies, 3)
+	assert.Equal(t, "Inception", movies[0].Movie.Title)
+	assert.Equal(t, "tt1375666", movies[0].Movie.IDs.IMDB)
+
+	shows, err := client.GetWatchedShows()
+	require.NoError(t, err)
+	require.Len(t, shows, 2)
+	assert.Len(t, shows[0].Seasons, 2)
+
+	history, err := client.GetMovieHistory()
+	require.NoError(t, err)
+	// Checkins are filtered out by the client
+	assert.Len(t, history, traktsim.HistoryFixtureSize-2)
+
+	activities, err := client.GetLastActivities()
+	require.NoError(t, err)
+	assert.False(t, activities.All.IsZero())
+}
+
+func TestSimulatorPagination(t *testing.T) {
+	sim := newSimulator(t)
+
+	resp := get(t, sim, "/users/me/history/movies?page=3&limit=10")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "3", resp.Header.Get("X-Pagination-Page"))
+	assert.Equal(t, "10", resp.Header.Get("X-Pagination-Limit"))
+	assert.Equal(t, "3", resp.Header.Get("X-Pagination-Page-Count"))
+	assert.Equal(t, "25", resp.Header.Get("X-Pagination-Item-Count"))
+	assert.NotEmpty(t, resp.Header.Get("X-Ratelimit"))
+
+	var items []json.RawMessage
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&items))
+	assert.Len(t, items, 5)
+}
+
+func TestSimulatorETagRevalidation(t *testing.T) {
+	sim := newSimulator(t)
+
+	first := get(t, sim, "/sync/watchlist/movies")
+	etag := first.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	req, err := http.NewRequest(http.MethodGet, sim.URL()+"/sync/watchlist/movies", nil)
+	require.NoError(t, err)
+	req.Header.Set("trakt-api-key", sim.ClientID)
+	req.Header.Set("Authorization", "Bearer "+sim.AccessToken())
+	req.Header.Set("If-None-Match", etag)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+}
+
+func TestSimulatorRejectsBadCredentials(t *testing.T) {
+	sim := newSimulator(t)
+
+	req, err := http.NewRequest(http.MethodGet, sim.URL()+"/sync/watched/movies", nil)
+	require.NoError(t, err)
+	req.Header.Set("trakt-api-key", "wrong")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	req.Header.Set("trakt-api-key", sim.ClientID)
+	req.Header.Set("Authorization", "Bearer wrong")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestSimulatorRateLimitFault(t *testing.T) {
+	sim := newSimulator(t)
+	sim.RateLimit("/sync/watched/movies", time.Second, 1)
+
+	client := api.NewClient(sim.Config(), testutils.NewNoOpLogger())
+	start := time.Now()
+	movies, err := client.GetWatchedMovies()
+	require.NoError(t, err)
+	assert.Len(t, movies, 3)
+
+	// The transport waits for Retry-After before the second attempt
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	assert.Equal(t, 2, sim.RequestCount("/sync/watched/movies"))
+}
+
+func TestSimulatorServerErrorBurst(t *testing.T) {
+	sim := newSimulator(t)
+	sim.ServerErrors("/sync/ratings/movies", 1)
+
+	client := api.NewClient(sim.Config(), testutils.NewNoOpLogger())
+	ratings, err := client.GetRatings()
+	require.NoError(t, err)
+	assert.Len(t, ratings, 3)
+
+	requests := sim.Requests()
+	require.Len(t, requests, 2)
+	assert.Equal(t, http.StatusBadGateway, requests[0].Status)
+	assert.Equal(t, http.StatusOK, requests[1].Status)
+}
+
+func TestSimulatorMalformedJSON(t *testing.T) {
+	sim := newSimulator(t)
+	sim.MalformedJSON("/sync/collection/movies", 1)
+
+	client := api.NewClient(sim.Config(), testutils.NewNoOpLogger())
+	_, err := client.GetCollectionMovies()
+	assert.Error(t, err)
+
+	// The fault is used up
+	movies, err := client.GetCollectionMovies()
+	require.NoError(t, err)
+	assert.Len(t, movies, 2)
+}
+
+func TestSimulatorExpiredTokensAndRefresh(t *testing.T) {
+	sim := newSimulator(t)
+	cfg := sim.Config()
+	refreshToken := sim.RefreshToken()
+	sim.ExpireTokens()
+
+	_, err := api.NewClient(cfg, testutils.NewNoOpLogger()).GetWatchlist()
+	require.Error(t, err)
+
+	oauth := auth.NewOAuthManager(cfg, testutils.NewNoOpLogger())
+	assert.Error(t, oauth.ValidateToken(cfg.Trakt.AccessToken))
+
+	token, err := oauth.RefreshToken(refreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, sim.AccessToken(), token.AccessToken)
+
+	// Refresh tokens are single use
+	_, err = oauth.RefreshToken(refreshToken)
+	assert.Error(t, err)
+
+	cfg.Trakt.AccessToken = token.AccessToken
+	watchlist, err := api.NewClient(cfg, testutils.NewNoOpLogger()).GetWatchlist()
+	require.NoError(t, err)
+	assert.Len(t, watchlist, 1)
+}
+
+func TestSimulatorDeviceFlow(t *testing.T) {
+	sim := newSimulator(t)
+	sim.DevicePendingPolls = 1
+
+	oauth := auth.NewOAuthManager(sim.Config(), testutils.NewNoOpLogger())
+	code, err := oauth.RequestDeviceCode()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	token, err := oauth.PollDeviceToken(ctx, code)
+	require.NoError(t, err)
+	assert.Equal(t, sim.AccessToken(), token.AccessToken)
+	assert.Equal(t, 2, sim.RequestCount("/oauth/device/token"))
+}
+
+func TestRecorderAndReplay(t *testing.T) {
+	upstream := newSimulator(t)
+
+	recorder, err := traktsim.NewRecorder(upstream.URL())
+	require.NoError(t, err)
+	proxy := httptest.NewServer(recorder)
+	defer proxy.Close()
+
+	cfg := upstream.Config()
+	cfg.Trakt.APIBaseURL = proxy.URL
+	ratings, err := api.NewClient(cfg, testutils.NewNoOpLogger()).GetShowRatings()
+	require.NoError(t, err)
+	require.Len(t, ratings, 1)
+
+	dir := t.TempDir()
+	require.NoError(t, recorder.Save(filepath.Join(dir, "ratings.json")))
+
+	cassette, err := traktsim.LoadCassette(filepath.Join(dir, "ratings.json"))
+	require.NoError(t, err)
+	require.Len(t, cassette.Interactions, 1)
+	assert.Equal(t, "/sync/ratings/shows", cassette.Interactions[0].Path)
+
+	// The replaying simulator has no fixture left for the endpoint
+	replay := newSimulator(t)
+	require.NoError(t, replay.SetFixture("/sync/ratings/shows", []interface{}{}))
+	require.NoError(t, replay.LoadFixtureDir(dir))
+
+	replayed, err := api.NewClient(replay.Config(), testutils.NewNoOpLogger()).GetShowRatings()
+	require.NoError(t, err)
+	assert.Equal(t, ratings, replayed)
+}
+
+func TestRecorderReplaysRawBodies(t *testing.T) {
+	upstream := newSimulator(t)
+	page := "<html><body>502 Bad Gateway</body></html>"
+	truncated := `[{"rank":1,`
+	upstream.InjectFault(traktsim.Fault{Path: "/sync/ratings/shows", Status: http.StatusBadGateway, Body: page})
+	upstream.InjectFault(traktsim.Fault{Path: "/sync/watchlist/movies", Body: truncated})
+
+	recorder, err := traktsim.NewRecorder(upstream.URL())
+	require.NoError(t, err)
+	proxy := httptest.NewServer(recorder)
+	defer proxy.Close()
+
+	fetch := func(baseURL, path string) (int, string) {
+		req, err := http.NewRequest(http.MethodGet, baseURL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("trakt-api-key", upstream.ClientID)
+		req.Header.Set("Authorization", "Bearer "+upstream.AccessToken())
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+	fetch(proxy.URL, "/sync/ratings/shows")
+	fetch(proxy.URL, "/sync/watchlist/movies")
+
+	dir := t.TempDir()
+	require.NoError(t, recorder.Save(filepath.Join(dir, "malformed.json")))
+
+	// Malformed bodies come back exactly as recorded
+	replay := newSimulator(t)
+	require.NoError(t, replay.LoadFixtureDir(dir))
+	status, body := fetch(replay.URL(), "/sync/ratings/shows")
+	assert.Equal(t, http.StatusBadGateway, status)
+	assert.Equal(t, page, body)
+	status, body = fetch(replay.URL(), "/sync/watchlist/movies")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, truncated, body)
+}
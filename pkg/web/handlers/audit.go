@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/audit"
+)
+
+// auditPageSize is the number of events shown per page
+const auditPageSize = 50
+
+// maxAuditPageSize bounds the limit requested through the API
+const maxAuditPageSize = 500
+
+type AuditData struct {
+	Title        string
+	CurrentPage  string
+	ServerStatus string
+	LastUpdated  string
+	Filters      AuditFilters
+	EventTypes   []string
+	Severities   []string
+	Events       []audit.AuditEvent
+	Total        int
+	Stats        audit.Stats
+	Page         int
+	TotalPages   int
+	PrevURL      string
+	NextURL      string
+	CSVURL       string
+	JSONURL      string
+	Alert        *AlertData
+}
+
+// AuditFilters holds the filters of the audit page as submitted
+type AuditFilters struct {
+	Type     string
+	Severity string
+	Since    string
+	Until    string
+	Remote   string
+	Result   string
+}
+
+type AuditHandler struct {
+	config    *config.Config
+	logger    logger.Logger
+	templates *template.Template
+	logFile   string
+}
+
+func NewAuditHandler(cfg *config.Config, log logger.Logger, templates *template.Template, logFile string) *AuditHandler {
+	return &AuditHandler{
+		config:    cfg,
+		logger:    log,
+		templates: templates,
+		logFile:   logFile,
+	}
+}
+
+func (h *AuditHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/api/audit":
+		h.handleAPISearch(w, r)
+	case "/api/audit/export":
+		h.handleExport(w, r)
+	default:
+		h.handleAuditPage(w, r)
+	}
+}
+
+func (h *AuditHandler) handleAuditPage(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	data := AuditData{
+		Title:        "Audit Log",
+		CurrentPage:  "audit",
+		ServerStatus: "healthy",
+		LastUpdated:  time.Now().Format("2006-01-02 15:04:05"),
+		Filters: AuditFilters{
+			Type:     values.Get("type"),
+			Severity: values.Get("severity"),
+			Since:    values.Get("since"),
+			Until:    values.Get("until"),
+			Remote:   values.Get("remote"),
+			Result:   values.Get("result"),
+		},
+		EventTypes: knownEventTypes,
+		Severities: []string{"low", "medium", "high", "critical"},
+		Stats:      audit.NewStats(),
+		Page:       1,
+	}
+
+	query, page, err := parseAuditQuery(values, auditPageSize)
+	if err != nil {
+		data.Alert = &AlertData{Type: "error", Icon: "❌", Message: err.Error()}
+	} else if !h.config.Security.AuditLogging {
+		data.Alert = &AlertData{Type: "warning", Icon: "⚠️", Message: "Audit logging is disabled, enable audit_logging in [security]"}
+	} else {
+		result, err := audit.Search(h.logFile, query)
+		switch {
+		case err != nil:
+			h.logger.Error("web.audit_search_failed", map[string]interface{}{"error": err.Error()})
+			data.Alert = &AlertData{Type: "error", Icon: "❌", Message: "Failed to search the audit log"}
+		case len(result.Files) == 0:
+			data.Alert = &AlertData{Type: "info", Icon: "ℹ️", Message: "No audit log has been written yet"}
+		default:
+			data.Events = result.Events
+			data.Total = result.Total
+			data.Stats = result.Stats
+			data.Page = page
+			data.TotalPages = (result.Total + query.Limit - 1) / query.Limit
+			if page > 1 {
+				data.PrevURL = auditURL("/audit", values, "page", strconv.Itoa(page-1))
+			}
+			if page < data.TotalPages {
+				data.NextURL = auditURL("/audit", values, "page", strconv.Itoa(page+1))
+			}
+		}
+	}
+	data.CSVURL = auditURL("/api/audit/export", values, "format", "csv")
+	data.JSONURL = auditURL("/api/audit/export", values, "format", "json")
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := h.templates.ExecuteTemplate(w, "audit.html", data); err != nil {
+		h.logger.Error("web.template_error", map[string]interface{}{
+			"error":    err.Error(),
+			"template": "audit.html",
+		})
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *AuditHandler) handleAPISearch(w http.ResponseWriter, r *http.Request) {
+	query, page, err := parseAuditQuery(r.URL.Query(), auditPageSize)
+	if err != nil {
+		h.writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	result, ok := h.search(w, query)
+	if !ok {
+		return
+	}
+
+	response := ExportAPIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"events":     result.Events,
+			"total":      result.Total,
+			"stats":      result.Stats,
+			"page":       page,
+			"limit":      query.Limit,
+			"totalPages": (result.Total + query.Limit - 1) / query.Limit,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleExport downloads every matching event, unless page or limit are set
+func (h *AuditHandler) handleExport(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	format := values.Get("format")
+	if format != "csv" && format != "json" {
+		h.writeAPIError(w, http.StatusBadRequest, "format must be csv or json")
+		return
+	}
+
+	query, _, err := parseAuditQuery(values, 0)
+	if err != nil {
+		h.writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	result, ok := h.search(w, query)
+	if !ok {
+		return
+	}
+
+	h.logger.Info("web.audit_exported", map[string]interface{}{
+		"format":    format,
+		"events":    len(result.Events),
+		"client_ip": r.RemoteAddr,
+	})
+
+	filename := fmt.Sprintf("audit-%s.%s", time.Now().Format("2006-01-02"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		err = audit.WriteCSV(w, result.Events)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		err = audit.WriteJSON(w, result.Events)
+	}
+	if err != nil {
+		h.logger.Error("web.audit_export_failed", map[string]interface{}{"error": err.Error()})
+	}
+}
+
+// search runs the query, writing an error response when it fails
+func (h *AuditHandler) search(w http.ResponseWriter, query audit.Query) (*audit.SearchResult, bool) {
+	if !h.config.Security.AuditLogging {
+		h.writeAPIError(w, http.StatusNotFound, "audit logging is disabled")
+		return nil, false
+	}
+	result, err := audit.Search(h.logFile, query)
+	if err != nil {
+		h.logger.Error("web.audit_search_failed", map[string]interface{}{"error": err.Error()})
+		h.writeAPIError(w, http.StatusInternalServerError, "failed to search the audit log")
+		return nil, false
+	}
+	return result, true
+}
+
+func (h *AuditHandler) writeAPIError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ExportAPIResponse{Success: false, Error: message})
+}
+
+// parseAuditQuery builds a search query from the type, severity, since,
+// until, remote, result, page and limit parameters. A defaultLimit of 0
+// returns every matching event when no limit is requested.
+func parseAuditQuery(values url.Values, defaultLimit int) (audit.Query, int, error) {
+	query := audit.Query{
+		EventTypes: audit.ParseEventTypes(values.Get("type")),
+		RemoteAddr: values.Get("remote"),
+		Result:     values.Get("result"),
+		Limit:      defaultLimit,
+	}
+
+	var err error
+	if query.Severities, err = audit.ParseSeverities(values.Get("severity")); err != nil {
+		return query, 0, err
+	}
+	now := time.Now()
+	if since := values.Get("since"); since != "" {
+		if query.Since, err = audit.ParseTime(since, now); err != nil {
+			return query, 0, err
+		}
+	}
+	if until := values.Get("until"); until != "" {
+		if query.Until, err = audit.ParseTime(until, now); err != nil {
+			return query, 0, err
+		}
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxAuditPageSize {
+			return query, 0, fmt.Errorf("limit must be between 1 and %d", maxAuditPageSize)
+		}
+		query.Limit = n
+	}
+
+	page := 1
+	if p := values.Get("page"); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 {
+			return query, 0, fmt.Errorf("page must be a positive number")
+		}
+		page = n
+		if query.Limit == 0 {
+			query.Limit = auditPageSize
+		}
+	}
+	query.Offset = (page - 1) * query.Limit
+
+	return query, page, nil
+}
+
+// auditURL returns path with the current filters and one parameter replaced
+func auditURL(path string, values url.Values, key, value string) string {
+	params := url.Values{}
+	for k, v := range values {
+		params[k] = v
+	}
+	params.Set(key, value)
+	if key == "format" {
+		params.Del("page")
+		params.Del("limit")
+	}
+	return path + "?" + params.Encode()
+}
+
+// knownEventTypes are offered by the event type filter of the audit page
+var knownEventTypes = []string{
+	string(audit.AuthSuccess), string(audit.AuthFailure), string(audit.AuthLogout),
+	string(audit.CredentialAccess), string(audit.CredentialStore), string(audit.CredentialDelete), string(audit.CredentialRotation),
+	string(audit.DataExport), string(audit.DataEncrypt), string(audit.DataDecrypt), string(audit.DataAccess), string(audit.DataPurge),
+	string(audit.SystemStart), string(audit.SystemStop), string(audit.SystemError), string(audit.ConfigChange),
+	string(audit.SecurityViolation), string(audit.RateLimitHit), string(audit.PermissionDenied), string(audit.IntrusionAttempt),
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"html/template"
@@ -8,6 +10,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -17,10 +20,22 @@ import (
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/web/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	ExportCacheTTL = 30 * time.Minute
+
+	// exportTracerName is the instrumentation scope of the export spans
+	exportTracerName = "github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/web/handlers"
+
+	// exportCancelGracePeriod is how long a cancelled export may take to
+	// stop after being interrupted before it is killed
+	exportCancelGracePeriod = 30 * time.Second
 )
 
 type ExportsData struct {
@@ -31,6 +46,7 @@ type ExportsData struct {
 	CSRFToken    string
 	TokenStatus  *TokenStatusData
 	Exports      []ExportItem
+	Running      []RunningExport
 	Alert        *AlertData
 	Pagination   *PaginationData
 }
@@ -59,6 +75,17 @@ type ExportItem struct {
 	Error       string    `json:"error"`
 }
 
+// RunningExport is an export started from the web interface that has not
+// finished yet. TraceID identifies its trace when tracing is enabled.
+type RunningExport struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	StartedAt time.Time `json:"startedAt"`
+	TraceID   string    `json:"traceId,omitempty"`
+	cancel    context.CancelFunc
+	span      trace.Span
+}
+
 type ExportAPIResponse struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
@@ -93,6 +120,13 @@ type ExportsHandler struct {
 	exportsDir     string
 	cache          *ExportCache
 	csrfMiddleware *middleware.CSRFMiddleware
+
+	// Exports started from the web interface, cancelled on Shutdown
+	ctx            context.Context
+	stop           context.CancelFunc
+	runsMu         sync.Mutex
+	runs           map[string]*RunningExport
+	runsWg         sync.WaitGroup
 }
 
 func NewExportsHandler(cfg *config.Config, log logger.Logger, tokenManager *auth.TokenManager, templates *template.Template, csrfMiddleware *middleware.CSRFMiddleware) *ExportsHandler {
@@ -101,7 +135,12 @@ func NewExportsHandler(cfg *config.Config, log logger.Logger, tokenManager *auth
 		exportsDir = cfg.Letterboxd.ExportDir
 	}
 
+	ctx, stop := context.WithCancel(context.Background())
+
 	return &ExportsHandler{
+		ctx:            ctx,
+		stop:           stop,
+		runs:           make(map[string]*RunningExport),
 		config:         cfg,
 		logger:         log,
 		tokenManager:   tokenManager,
@@ -120,6 +159,10 @@ func (h *ExportsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	case "GET":
 		h.handleGetExports(w, r)
 	case "POST":
+		if strings.HasSuffix(r.URL.Path, "/cancel") {
+			h.handleCancelExport(w, r)
+			return
+		}
 		h.handleStartExport(w, r)
 	case "DELETE":
 		h.handleDeleteExport(w, r)
@@ -164,9 +207,11 @@ func (h *ExportsHandler) handleGetExportsPaginated(w http.ResponseWriter, r *htt
 
 	response := struct {
 		Exports    []ExportItem    `json:"exports"`
+		Running    []RunningExport `json:"running"`
 		Pagination *PaginationData `json:"pagination"`
 	}{
 		Exports:    data.Exports,
+		Running:    data.Running,
 		Pagination: data.Pagination,
 	}
 
@@ -207,15 +252,45 @@ func (h *ExportsHandler) handleStartExport(w http.ResponseWriter, r *http.Reques
 	})
 
 	// Start export in background
-	exportID := fmt.Sprintf("export_%d", time.Now().Unix())
-	go h.runExportAsync(exportID, exportType, historyMode)
+	exportID := fmt.Sprintf("export_%d", time.Now().UnixNano())
+	ctx := h.trackExport(exportID, exportType)
+	go h.runExportAsync(ctx, exportID, exportType, historyMode)
+
+	data := map[string]interface{}{
+		"export_id": exportID,
+		"type":      exportType,
+		"status":    "started",
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		data["trace_id"] = spanContext.TraceID().String()
+	}
+	h.writeJSONResponse(w, ExportAPIResponse{
+		Success: true,
+		Data:    data,
+	})
+}
+
+func (h *ExportsHandler) handleCancelExport(w http.ResponseWriter, r *http.Request) {
+	exportID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/export/"), "/cancel")
+
+	if !h.cancelExport(exportID) {
+		h.writeJSONResponse(w, ExportAPIResponse{
+			Success: false,
+			Error:   "Export is not running",
+		})
+		return
+	}
+
+	h.logger.Info("web.export_cancel_requested", map[string]interface{}{
+		"export_id": exportID,
+		"client_ip": r.RemoteAddr,
+	})
 
 	h.writeJSONResponse(w, ExportAPIResponse{
 		Success: true,
 		Data: map[string]interface{}{
 			"export_id": exportID,
-			"type":      exportType,
-			"status":    "started",
+			"status":    "cancelling",
 		},
 	})
 }
@@ -249,6 +324,7 @@ func (h *ExportsHandler) prepareExportsData(r *http.Request) *ExportsData {
 		ServerStatus: "healthy",
 		LastUpdated:  h.formatTimeInConfigTimezone(time.Now(), "2006-01-02 15:04:05"),
 		CSRFToken:    h.csrfMiddleware.GetToken(r),
+		Running:      h.runningExports(),
 	}
 
 	// Get token status
@@ -428,8 +504,11 @@ func (h *ExportsHandler) writeJSONResponse(w http.ResponseWriter, response Expor
 	}
 }
 
-// runExportAsync executes an export command asynchronously
-func (h *ExportsHandler) runExportAsync(exportID, exportType, historyMode string) {
+// runExportAsync executes an export command asynchronously. Cancelling ctx
+// interrupts the command so it can stop its in-flight API requests.
+func (h *ExportsHandler) runExportAsync(ctx context.Context, exportID, exportType, historyMode string) {
+	defer h.untrackExport(exportID)
+
 	h.logger.Info("web.export_async_started", map[string]interface{}{
 		"export_id":    exportID,
 		"export_type":  exportType,
@@ -451,6 +530,8 @@ func (h *ExportsHandler) runExportAsync(exportID, exportType, historyMode string
 		"--run",
 		"--export", exportType,
 		"--mode", "complete",
+		// The server process already listens on the metrics port
+		"--set", "monitoring.metrics_port=0",
 	}
 
 	// Add history mode for watched exports
@@ -464,24 +545,52 @@ func (h *ExportsHandler) runExportAsync(exportID, exportType, historyMode string
 		"args":      strings.Join(args, " "),
 	})
 
-	// Execute the command
-	cmd := exec.Command(execPath, args...)
+	// Execute the command, interrupting it rather than killing it on cancel
+	cmd := exec.CommandContext(ctx, execPath, args...)
 	cmd.Env = os.Environ() // Inherit environment variables
+	// The export joins the trace of this export
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	if traceparent := carrier.Get("traceparent"); traceparent != "" {
+		cmd.Env = append(cmd.Env, "TRACEPARENT="+traceparent)
+	}
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = exportCancelGracePeriod
 
 	// Capture both stdout and stderr for better debugging
 	output, err := cmd.CombinedOutput()
 
-	if err != nil {
+	span := trace.SpanFromContext(ctx)
+	traceID := ""
+	if span.SpanContext().IsValid() {
+		traceID = span.SpanContext().TraceID().String()
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		span.SetStatus(codes.Error, "cancelled")
+		h.logger.Warn("web.export_async_cancelled", map[string]interface{}{
+			"export_id": exportID,
+			"trace_id":  traceID,
+			"output":    h.commandOutput(output),
+		})
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		h.logger.Error("web.export_async_failed", map[string]interface{}{
 			"export_id": exportID,
+			"trace_id":  traceID,
 			"error":     err.Error(),
-			"output":    string(output),
+			"output":    h.commandOutput(output),
 			"command":   execPath + " " + strings.Join(args, " "),
 		})
-	} else {
+	default:
 		h.logger.Info("web.export_async_completed", map[string]interface{}{
 			"export_id": exportID,
-			"output":    string(output),
+			"trace_id":  traceID,
+			"output":    h.commandOutput(output),
 		})
 	}
 
@@ -492,6 +601,102 @@ func (h *ExportsHandler) runExportAsync(exportID, exportType, historyMode string
 	})
 }
 
+// commandOutput returns the output of an export command for the log. Titles
+// in free text cannot be told apart, so privacy mode keeps only its size.
+func (h *ExportsHandler) commandOutput(output []byte) string {
+	if h.config.Logging.Privacy {
+		return fmt.Sprintf("%d lines omitted in privacy mode", bytes.Count(output, []byte("\n")))
+	}
+	return string(output)
+}
+
+// trackExport registers a running export and returns the context it runs
+// in, carrying the root span of the export
+func (h *ExportsHandler) trackExport(exportID, exportType string) context.Context {
+	ctx, cancel := context.WithCancel(h.ctx)
+	ctx, span := otel.Tracer(exportTracerName).Start(ctx, "web.export",
+		trace.WithAttributes(
+			attribute.String("export.id", exportID),
+			attribute.String("export.type", exportType),
+		),
+	)
+
+	run := &RunningExport{
+		ID:        exportID,
+		Type:      exportType,
+		StartedAt: time.Now(),
+		cancel:    cancel,
+		span:      span,
+	}
+	if span.SpanContext().IsValid() {
+		run.TraceID = span.SpanContext().TraceID().String()
+	}
+
+	h.runsMu.Lock()
+	defer h.runsMu.Unlock()
+	h.runs[exportID] = run
+	h.runsWg.Add(1)
+	return ctx
+}
+
+// untrackExport removes a finished export
+func (h *ExportsHandler) untrackExport(exportID string) {
+	h.runsMu.Lock()
+	defer h.runsMu.Unlock()
+	if run, ok := h.runs[exportID]; ok {
+		run.cancel()
+		run.span.End()
+		delete(h.runs, exportID)
+		h.runsWg.Done()
+	}
+}
+
+// cancelExport cancels a running export and reports whether it was running
+func (h *ExportsHandler) cancelExport(exportID string) bool {
+	h.runsMu.Lock()
+	defer h.runsMu.Unlock()
+	run, ok := h.runs[exportID]
+	if ok {
+		run.cancel()
+	}
+	return ok
+}
+
+// runningExports returns the running exports, oldest first
+func (h *ExportsHandler) runningExports() []RunningExport {
+	h.runsMu.Lock()
+	defer h.runsMu.Unlock()
+
+	running := make([]RunningExport, 0, len(h.runs))
+	for _, run := range h.runs {
+		running = append(running, *run)
+	}
+	sort.Slice(running, func(i, j int) bool { return running[i].StartedAt.Before(running[j].StartedAt) })
+	return running
+}
+
+// Shutdown cancels every running export and waits for them to stop or for
+// ctx to be done
+func (h *ExportsHandler) Shutdown(ctx context.Context) error {
+	h.stop()
+	if len(h.runningExports()) == 0 {
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.runsWg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // DownloadHandler handles file downloads
 type DownloadHandler struct {
 	exportsDir string
@@ -1,19 +1,29 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
 	"html/template"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/auth"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/purge"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/audit"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/keyring"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/web/middleware"
+	"go.opentelemetry.io/otel"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestDashboardHandler(t *testing.T) {
@@ -867,4 +877,297 @@ func TestExportUtilityFunctions(t *testing.T) {
 	if len(allExports) != 3 {
 		t.Errorf("applyFilters no filter: expected 3, got %d", len(allExports))
 	}
-}
\ No newline at end of file
+}
+
+func TestExportsHandlerCancelExport(t *testing.T) {
+	cfg := &config.Config{
+		Letterboxd: config.LetterboxdConfig{
+			ExportDir: "./test_exports",
+		},
+	}
+	log := logger.NewLogger()
+
+	keyringMgr, err := keyring.NewManager(keyring.MemoryBackend)
+	if err != nil {
+		t.Fatalf("Failed to create keyring manager: %v", err)
+	}
+	tokenManager := auth.NewTokenManager(cfg, log, keyringMgr)
+	handler := NewExportsHandler(cfg, log, tokenManager, template.New(""), &middleware.CSRFMiddleware{})
+
+	// Cancelling an unknown export fails
+	req := httptest.NewRequest("POST", "/api/export/unknown/cancel", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), `"success":false`) {
+		t.Errorf("Expected failure for unknown export, got %s", rec.Body.String())
+	}
+
+	// Cancelling a running export cancels its context
+	ctx := handler.trackExport("export_1", "watched")
+	if running := handler.runningExports(); len(running) != 1 || running[0].ID != "export_1" {
+		t.Fatalf("Expected export_1 to be running, got %v", running)
+	}
+
+	req = httptest.NewRequest("POST", "/api/export/export_1/cancel", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), `"success":true`) {
+		t.Errorf("Expected cancel to succeed, got %s", rec.Body.String())
+	}
+	select {
+	case <-ctx.Done():
+	default:
+		t.Error("Expected export context to be cancelled")
+	}
+
+	// Shutdown cancels the remaining exports and waits for them
+	ctx2 := handler.trackExport("export_2", "all")
+	go func() {
+		<-ctx2.Done()
+		handler.untrackExport("export_2")
+	}()
+	handler.untrackExport("export_1")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := handler.Shutdown(shutdownCtx); err != nil {
+		t.Errorf("Expected shutdown to complete, got %v", err)
+	}
+	if running := handler.runningExports(); len(running) != 0 {
+		t.Errorf("Expected no running exports after shutdown, got %v", running)
+	}
+}
+
+func TestExportsHandlerTracesExports(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	cfg := &config.Config{
+		Letterboxd: config.LetterboxdConfig{
+			ExportDir: "./test_exports",
+		},
+	}
+	log := logger.NewLogger()
+	keyringMgr, err := keyring.NewManager(keyring.MemoryBackend)
+	if err != nil {
+		t.Fatalf("Failed to create keyring manager: %v", err)
+	}
+	tokenManager := auth.NewTokenManager(cfg, log, keyringMgr)
+	handler := NewExportsHandler(cfg, log, tokenManager, template.New(""), &middleware.CSRFMiddleware{})
+
+	ctx := handler.trackExport("export_1", "watched")
+	running := handler.runningExports()
+	if len(running) != 1 || running[0].TraceID == "" {
+		t.Fatalf("Expected the running export to have a trace ID, got %v", running)
+	}
+	if got := trace.SpanContextFromContext(ctx).TraceID().String(); got != running[0].TraceID {
+		t.Errorf("Expected context trace ID %s, got %s", running[0].TraceID, got)
+	}
+
+	handler.untrackExport("export_1")
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "web.export" {
+		t.Fatalf("Expected the web.export span to end with the export, got %v", spans)
+	}
+}
+
+func TestAuditHandler(t *testing.T) {
+	// Write an audit log with a few events
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	auditLogger, err := audit.NewLogger(audit.Config{LogLevel: "info", OutputFormat: "json", LogFile: logFile})
+	if err != nil {
+		t.Fatalf("Failed to create audit logger: %v", err)
+	}
+	auditLogger.LogAuthEvent(audit.AuthSuccess, "user", "success", "192.168.1.10")
+	auditLogger.LogAuthEvent(audit.AuthFailure, "user", "failed", "10.0.0.5")
+	auditLogger.LogAuthEvent(audit.AuthFailure, "user", "failed", "10.0.0.6")
+	if err := auditLogger.Close(); err != nil {
+		t.Fatalf("Failed to close audit logger: %v", err)
+	}
+
+	cfg := &config.Config{Security: security.Config{AuditLogging: true}}
+	templates := template.Must(template.New("audit.html").Parse(`{{.Total}} events, page {{.Page}} of {{.TotalPages}}`))
+	handler := NewAuditHandler(cfg, logger.NewLogger(), templates, logFile)
+
+	// Audit page
+	req := httptest.NewRequest("GET", "/audit?type=auth_failure", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "2 events, page 1 of 1" {
+		t.Errorf("Unexpected audit page %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Paginated API search
+	req = httptest.NewRequest("GET", "/api/audit?result=failed&limit=1&page=2", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	var response struct {
+		Success bool
+		Data    struct {
+			Events     []audit.AuditEvent
+			Total      int
+			TotalPages int
+		}
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !response.Success || response.Data.Total != 2 || response.Data.TotalPages != 2 || len(response.Data.Events) != 1 {
+		t.Fatalf("Unexpected search response %+v", response)
+	}
+	if response.Data.Events[0].RemoteAddr != "10.0.0.5" {
+		t.Errorf("Expected the oldest failure on page 2, got %s", response.Data.Events[0].RemoteAddr)
+	}
+
+	// CSV export of every matching event
+	req = httptest.NewRequest("GET", "/api/audit/export?format=csv&remote=10.0.0.", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("Unexpected export response %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n"); len(lines) != 3 {
+		t.Errorf("Expected a header and 2 rows, got %d lines", len(lines))
+	}
+
+	// Invalid filters are rejected
+	req = httptest.NewRequest("GET", "/api/audit?severity=urgent", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestStatusHandlerDownloadLogs(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "export.log")
+	content := "exported title=\"Heat\" for /users/johnd from 192.168.1.20\n"
+	if err := os.WriteFile(logFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+
+	cfg := &config.Config{Logging: config.LoggingConfig{File: logFile}}
+	log := logger.NewLogger()
+	keyringMgr, err := keyring.NewManager(keyring.MemoryBackend)
+	if err != nil {
+		t.Fatalf("Failed to create keyring manager: %v", err)
+	}
+	handler := NewStatusHandler(cfg, log, auth.NewTokenManager(cfg, log, keyringMgr), template.New(""))
+
+	// Redacted by default, on request and in privacy mode even with raw downloads allowed
+	for _, tc := range []struct {
+		target         string
+		rawLogDownload bool
+		privacy        bool
+	}{
+		{"/api/logs/download", false, false},
+		{"/api/logs/download?redact=false", false, false},
+		{"/api/logs/download?redact=true", true, false},
+		{"/api/logs/download", true, true},
+	} {
+		cfg.Logging.RawLogDownload = tc.rawLogDownload
+		cfg.Logging.Privacy = tc.privacy
+		req := httptest.NewRequest("GET", tc.target, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		body := rec.Body.String()
+		if rec.Code != http.StatusOK || !strings.Contains(body, "# Redacted: true") {
+			t.Errorf("Expected a redacted download for %+v, got %d", tc, rec.Code)
+		}
+		for _, secret := range []string{"Heat", "johnd", "192.168.1.20"} {
+			if strings.Contains(body, secret) {
+				t.Errorf("Expected %q to be redacted for %+v: %s", secret, tc, body)
+			}
+		}
+	}
+
+	// Unfiltered download once opted in
+	cfg.Logging.RawLogDownload = true
+	cfg.Logging.Privacy = false
+	req := httptest.NewRequest("GET", "/api/logs/download", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Heat") {
+		t.Errorf("Expected the log file, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Missing log file
+	cfg.Logging.File = filepath.Join(t.TempDir(), "missing.log")
+	req = httptest.NewRequest("GET", "/api/logs/download", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a missing log, got %d", rec.Code)
+	}
+}
+
+func TestPurgeHandler(t *testing.T) {
+	exportDir := t.TempDir()
+	exportFile := filepath.Join(exportDir, "watched.csv")
+	if err := os.WriteFile(exportFile, []byte("Title,Year\nHeat,1995\n"), 0600); err != nil {
+		t.Fatalf("Failed to write export: %v", err)
+	}
+
+	cfg := &config.Config{
+		Letterboxd: config.LetterboxdConfig{ExportDir: exportDir},
+	}
+	log := logger.NewLogger()
+	keyringMgr, err := keyring.NewManager(keyring.MemoryBackend)
+	if err != nil {
+		t.Fatalf("Failed to create keyring manager: %v", err)
+	}
+	securityManager, err := security.NewManager(security.Config{KeyringBackend: "env"})
+	if err != nil {
+		t.Fatalf("Failed to create security manager: %v", err)
+	}
+	defer securityManager.Close()
+	tokenManager := auth.NewTokenManager(cfg, log, keyringMgr)
+	exports := NewExportsHandler(cfg, log, tokenManager, template.New(""), middleware.NewCSRFMiddleware(log, false))
+	handler := NewPurgeHandler(cfg, log, purge.NewPurger(cfg, log, securityManager, keyringMgr, tokenManager), exports)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/purge", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Disabled unless web_purge is set
+	if rec := post(`{"dry_run":true}`); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 when disabled, got %d", rec.Code)
+	}
+	cfg.Security.WebPurge = true
+
+	// A purge must be confirmed
+	if rec := post(`{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without confirmation, got %d", rec.Code)
+	}
+
+	// A dry run lists the exports and keeps them
+	rec := post(`{"dry_run":true}`)
+	var response struct {
+		Success bool
+		Data    PurgeResult
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !response.Success || !response.Data.DryRun || response.Data.Categories[0].Count != 1 {
+		t.Errorf("Unexpected dry run response: %+v", response)
+	}
+	if _, err := os.Stat(exportFile); err != nil {
+		t.Error("Expected a dry run to keep the export")
+	}
+
+	// A confirmed purge removes them
+	if rec := post(`{"confirm":"PURGE"}`); rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, err := os.Stat(exportFile); !os.IsNotExist(err) {
+		t.Error("Expected the export to be purged")
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/purge"
+)
+
+// purgeConfirmation must be sent to run a purge other than a dry run
+const purgeConfirmation = "PURGE"
+
+// PurgeRequest is the body of a purge request
+type PurgeRequest struct {
+	Confirm    string `json:"confirm"`
+	DryRun     bool   `json:"dry_run"`
+	SkipRevoke bool   `json:"skip_revoke"`
+}
+
+// PurgeCategory summarizes one category of a purge
+type PurgeCategory struct {
+	Name   string   `json:"name"`
+	Count  int      `json:"count"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// PurgeResult summarizes a purge for the web interface
+type PurgeResult struct {
+	DryRun     bool            `json:"dry_run"`
+	Revoked    bool            `json:"revoked"`
+	Removed    int             `json:"removed"`
+	Failed     int             `json:"failed"`
+	Categories []PurgeCategory `json:"categories"`
+}
+
+// PurgeHandler serves the purge action, removing the local data of the
+// account. It is only enabled with web_purge in [security].
+type PurgeHandler struct {
+	config  *config.Config
+	logger  logger.Logger
+	purger  *purge.Purger
+	exports *ExportsHandler
+}
+
+func NewPurgeHandler(cfg *config.Config, log logger.Logger, purger *purge.Purger, exports *ExportsHandler) *PurgeHandler {
+	return &PurgeHandler{
+		config:  cfg,
+		logger:  log,
+		purger:  purger,
+		exports: exports,
+	}
+}
+
+func (h *PurgeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !h.config.Security.WebPurge {
+		h.writeError(w, http.StatusForbidden, "Purge from the web interface is disabled, set web_purge = true in [security]")
+		return
+	}
+
+	var req PurgeRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "Invalid purge request")
+		return
+	}
+
+	if !req.DryRun {
+		if req.Confirm != purgeConfirmation {
+			h.writeError(w, http.StatusBadRequest, "Type "+purgeConfirmation+" to confirm the purge")
+			return
+		}
+		if len(h.exports.runningExports()) > 0 {
+			h.writeError(w, http.StatusConflict, "Exports are running, wait for them to finish or cancel them")
+			return
+		}
+	}
+
+	report := h.purger.Purge(purge.Options{
+		DryRun:     req.DryRun,
+		SkipRevoke: req.SkipRevoke,
+		Origin:     "web",
+		RemoteAddr: r.RemoteAddr,
+	})
+
+	if !req.DryRun {
+		// Purged exports must disappear from the exports page
+		h.exports.invalidateCache()
+		h.logger.Warn("web.purge_completed", map[string]interface{}{
+			"removed":   report.Removed(),
+			"failures":  report.Failed(),
+			"client_ip": r.RemoteAddr,
+		})
+	}
+
+	result := PurgeResult{
+		DryRun:  report.DryRun,
+		Revoked: report.Revoked,
+		Removed: report.Removed(),
+		Failed:  report.Failed(),
+	}
+	for _, category := range report.Categories {
+		result.Categories = append(result.Categories, PurgeCategory{
+			Name:   category.Name,
+			Count:  len(category.Removed),
+			Errors: category.Errors,
+		})
+	}
+
+	h.writeJSON(w, http.StatusOK, ExportAPIResponse{
+		Success: report.Failed() == 0,
+		Data:    result,
+	})
+}
+
+func (h *PurgeHandler) writeError(w http.ResponseWriter, status int, message string) {
+	h.writeJSON(w, status, ExportAPIResponse{Success: false, Error: message})
+}
+
+func (h *PurgeHandler) writeJSON(w http.ResponseWriter, status int, response ExportAPIResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Error("web.json_encode_error", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+}
@@ -1,13 +1,17 @@
 package handlers
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"io"
 	"net/http"
+	"os"
 	"runtime"
 	"time"
 
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/api"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/auth"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
@@ -35,6 +39,7 @@ type StatusData struct {
 	Config          *ConfigData
 	Resources       *ResourcesData
 	RecentLogs      []LogEntry
+	WebPurge        bool
 	Alert           *AlertData
 }
 
@@ -136,6 +141,7 @@ func (h *StatusHandler) handleAPIStatus(w http.ResponseWriter, r *http.Request)
 			"tokenStatus":  data.TokenStatus,
 			"apiStatus":    data.APIStatus,
 			"uptime":       data.Uptime,
+			"rateLimits":   api.DefaultRateLimiter().Budgets(),
 		},
 	}
 	
@@ -203,26 +209,52 @@ func (h *StatusHandler) handleRecentLogs(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(response)
 }
 
+// maxLogDownloadSize limits a log download to the end of large log files
+const maxLogDownloadSize = 5 << 20
+
+// handleDownloadLogs serves the end of the log file with titles, user IDs,
+// tokens and IP addresses redacted. The raw log is only served when
+// logging.raw_log_download is set, outside privacy mode and without
+// ?redact=true.
 func (h *StatusHandler) handleDownloadLogs(w http.ResponseWriter, r *http.Request) {
-	// In a real implementation, this would generate and serve actual log files
-	logContent := fmt.Sprintf(`# Export Trakt 4 Letterboxd - Log Export
-# Generated: %s
-# 
-[%s] INFO: Server started on port %d
-[%s] INFO: OAuth token is valid
-[%s] INFO: Export completed successfully
-`, 
-		time.Now().Format("2006-01-02 15:04:05"),
-		time.Now().Add(-1*time.Hour).Format("15:04:05"),
-		h.config.Auth.CallbackPort,
-		time.Now().Add(-30*time.Minute).Format("15:04:05"),
-		time.Now().Add(-5*time.Minute).Format("15:04:05"),
-	)
+	file, err := os.Open(h.config.Logging.File)
+	if err != nil {
+		h.logger.Warn("web.log_download_unavailable", map[string]interface{}{
+			"error": err.Error(),
+		})
+		http.Error(w, "Log file not available", http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+	
+	// Start at the first full line of the last maxLogDownloadSize bytes
+	reader := bufio.NewReader(file)
+	if info, err := file.Stat(); err == nil && info.Size() > maxLogDownloadSize {
+		if _, err := file.Seek(info.Size()-maxLogDownloadSize, io.SeekStart); err == nil {
+			reader.Reset(file)
+			reader.ReadString('\n')
+		}
+	}
+	
+	redact := !h.config.Logging.RawLogDownload || h.config.Logging.Privacy || r.URL.Query().Get("redact") == "true"
 	
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=export-trakt-logs-%s.txt", time.Now().Format("2006-01-02")))
-	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
 	
-	w.Write([]byte(logContent))
+	fmt.Fprintf(w, "# Export Trakt 4 Letterboxd - Log Export\n# Generated: %s\n# Redacted: %t\n",
+		time.Now().Format("2006-01-02 15:04:05"), redact)
+	
+	if redact {
+		err = logger.CopyRedacted(w, reader)
+	} else {
+		_, err = io.Copy(w, reader)
+	}
+	if err != nil {
+		h.logger.Warn("web.log_download_failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
 }
 
 func (h *StatusHandler) prepareStatusData() *StatusData {
@@ -237,6 +269,7 @@ func (h *StatusHandler) prepareStatusData() *StatusData {
 		BuildDate:    "2025-07-11",
 		GoVersion:    runtime.Version(),
 		ConfigStatus: "healthy",
+		WebPurge:     h.config.Security.WebPurge,
 	}
 	
 	// Get token status
@@ -261,11 +294,15 @@ func (h *StatusHandler) prepareStatusData() *StatusData {
 	data.LastAPICheck = time.Now().Add(-2 * time.Minute)
 	data.APIResponseTime = "158ms"
 	
-	// Mock rate limit data
-	data.RateLimit = &RateLimitData{
-		Limit:     1000,
-		Remaining: 856,
-		ResetTime: &[]time.Time{time.Now().Add(45 * time.Minute)}[0],
+	// Rate limit budget last reported by Trakt, unknown until the first API call
+	if budget, ok := api.DefaultRateLimiter().Budget(http.MethodGet); ok {
+		data.RateLimit = &RateLimitData{
+			Limit:     budget.Limit,
+			Remaining: budget.Remaining,
+		}
+		if !budget.Until.IsZero() {
+			data.RateLimit.ResetTime = &budget.Until
+		}
 	}
 	
 	// Configuration data
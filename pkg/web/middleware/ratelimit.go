@@ -0,0 +1,492 @@
+package middleware
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/audit"
+)
+
+// rateLimitCleanupInterval is how often idle clients are forgotten
+const rateLimitCleanupInterval = 5 * time.Minute
+
+// RateLimiter limits the requests each client sends to the web server. Every
+// request is charged to the client IP and, when it carries one, to the token
+// of its Authorization header, using the budget of the longest matching route.
+// Clients that keep failing, by exceeding their budget or with 401, 403 or
+// failed authentication responses, are banned for a while.
+type RateLimiter struct {
+	logger   logger.Logger
+	auditLog *audit.Logger
+	config   security.WebRateLimitConfig
+	proxies  []*net.IPNet
+	clients  map[string]*rateLimitClient
+	mu       sync.Mutex
+	now      func() time.Time
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// rateLimitClient tracks the budgets and failures of a client IP or token
+type rateLimitClient struct {
+	buckets     map[string]*tokenBucket // by route
+	failures    []time.Time
+	bannedUntil time.Time
+	limited     bool // a rate limit hit was audited since the last allowed request
+	lastSeen    time.Time
+}
+
+// tokenBucket holds up to capacity tokens, refilled at rate tokens per second
+type tokenBucket struct {
+	tokens   float64
+	capacity float64
+	rate     float64
+	last     time.Time
+}
+
+// NewRateLimiter creates a new inbound rate limiting middleware. The audit
+// logger may be nil.
+func NewRateLimiter(logger logger.Logger, config security.WebRateLimitConfig, auditLog *audit.Logger) (*RateLimiter, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	rl := &RateLimiter{
+		logger:   logger,
+		auditLog: auditLog,
+		config:   config,
+		clients:  make(map[string]*rateLimitClient),
+		now:      time.Now,
+		done:     make(chan struct{}),
+	}
+	for _, proxy := range config.TrustedProxies {
+		network, _ := security.ParseNetwork(proxy)
+		rl.proxies = append(rl.proxies, network)
+	}
+
+	go rl.cleanupRoutine()
+
+	return rl, nil
+}
+
+// Middleware returns the rate limiting middleware function
+func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hasPrefix(r.URL.Path, rl.config.ExemptPaths) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ip := rl.clientIP(r)
+		keys := []string{"ip:" + ip}
+		if token := requestToken(r); token != "" {
+			keys = append(keys, "token:"+token)
+		}
+
+		if retryAfter, banned := rl.allow(keys, r, ip); retryAfter > 0 {
+			rl.reject(w, r, retryAfter, banned)
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		if rl.isFailure(r.URL.Path, recorder.status) {
+			rl.mu.Lock()
+			for _, key := range keys {
+				rl.recordFailure(key, r, ip, fmt.Sprintf("%d response", recorder.status))
+			}
+			rl.mu.Unlock()
+		}
+	})
+}
+
+// allow charges the request to every key. It returns how long the client
+// has to wait when the request is refused, and whether it is banned.
+func (rl *RateLimiter) allow(keys []string, r *http.Request, ip string) (time.Duration, bool) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	for _, key := range keys {
+		if c := rl.clients[key]; c != nil && now.Before(c.bannedUntil) {
+			c.lastSeen = now
+			return c.bannedUntil.Sub(now), true
+		}
+	}
+
+	route, limit := rl.route(r.URL.Path)
+	var retryAfter time.Duration
+	for _, key := range keys {
+		c := rl.client(key, now)
+		bucket := c.buckets[route]
+		if bucket == nil {
+			bucket = newTokenBucket(limit, now)
+			c.buckets[route] = bucket
+		}
+		if wait := bucket.take(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	if retryAfter == 0 {
+		for _, key := range keys {
+			rl.clients[key].limited = false
+		}
+		return 0, false
+	}
+
+	for _, key := range keys {
+		c := rl.clients[key]
+		if !c.limited {
+			c.limited = true
+			rl.logAuditEvent(audit.AuditEvent{
+				EventType:  audit.RateLimitHit,
+				Severity:   audit.SeverityMedium,
+				Source:     "web_server",
+				Target:     route,
+				Action:     "limit_exceeded",
+				Result:     "denied",
+				Message:    fmt.Sprintf("Rate limit exceeded for %s on %s", key, route),
+				RemoteAddr: ip,
+				UserAgent:  r.UserAgent(),
+				Details: map[string]interface{}{
+					"client": key,
+					"path":   r.URL.Path,
+				},
+			})
+		}
+		if ban := rl.recordFailure(key, r, ip, "rate limit exceeded"); ban > 0 {
+			return ban, true
+		}
+	}
+	return retryAfter, false
+}
+
+// recordFailure counts a failed request of a client and bans it once it
+// reaches max_failures within failure_window. It returns the ban duration
+// when the client was banned. rl.mu must be held.
+func (rl *RateLimiter) recordFailure(key string, r *http.Request, ip, reason string) time.Duration {
+	if rl.config.MaxFailures == 0 {
+		return 0
+	}
+	now := rl.now()
+	c := rl.client(key, now)
+
+	cutoff := now.Add(-rl.config.FailureWindow)
+	failures := c.failures[:0]
+	for _, at := range c.failures {
+		if at.After(cutoff) {
+			failures = append(failures, at)
+		}
+	}
+	c.failures = append(failures, now)
+	if len(c.failures) < rl.config.MaxFailures {
+		return 0
+	}
+
+	c.failures = nil
+	c.bannedUntil = now.Add(rl.config.BanDuration)
+
+	rl.logger.Warn("web.client_banned", map[string]interface{}{
+		"client":   key,
+		"path":     r.URL.Path,
+		"reason":   reason,
+		"duration": rl.config.BanDuration.String(),
+	})
+	rl.logAuditEvent(audit.AuditEvent{
+		EventType:  audit.IntrusionAttempt,
+		Severity:   audit.SeverityHigh,
+		Source:     "web_server",
+		Target:     r.URL.Path,
+		Action:     "ban_client",
+		Result:     "banned",
+		Message:    fmt.Sprintf("Client %s banned for %s after %d failed requests", key, rl.config.BanDuration, rl.config.MaxFailures),
+		RemoteAddr: ip,
+		UserAgent:  r.UserAgent(),
+		Details: map[string]interface{}{
+			"client":       key,
+			"last_failure": reason,
+			"failures":     rl.config.MaxFailures,
+			"window":       rl.config.FailureWindow.String(),
+			"banned_until": c.bannedUntil.UTC().Format(time.RFC3339),
+		},
+	})
+	return rl.config.BanDuration
+}
+
+// reject answers a refused request with 429 and a Retry-After header
+func (rl *RateLimiter) reject(w http.ResponseWriter, r *http.Request, retryAfter time.Duration, banned bool) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	message := "Too many requests, retry later"
+	if banned {
+		message = "Too many failed requests, temporarily blocked"
+	}
+
+	rl.logger.Debug("web.request_rate_limited", map[string]interface{}{
+		"path":        r.URL.Path,
+		"remote_addr": r.RemoteAddr,
+		"retry_after": seconds,
+		"banned":      banned,
+	})
+
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   message,
+		})
+		return
+	}
+	http.Error(w, message, http.StatusTooManyRequests)
+}
+
+// isFailure reports whether a response counts as a failed attempt
+func (rl *RateLimiter) isFailure(path string, status int) bool {
+	if status == http.StatusUnauthorized || status == http.StatusForbidden {
+		return true
+	}
+	return status >= 400 && status < 500 && hasPrefix(path, rl.config.AuthPaths)
+}
+
+// route returns the longest configured route matching path and its budget,
+// or the default budget
+func (rl *RateLimiter) route(path string) (string, security.RateLimit) {
+	best := ""
+	for route := range rl.config.Routes {
+		if strings.HasPrefix(path, route) && len(route) > len(best) {
+			best = route
+		}
+	}
+	if best != "" {
+		return best, rl.config.Routes[best]
+	}
+	return "default", security.RateLimit{
+		RequestsPerMinute: rl.config.RequestsPerMinute,
+		BurstCapacity:     rl.config.BurstCapacity,
+		Window:            time.Minute,
+	}
+}
+
+// client returns the state of a key, creating it when needed. rl.mu must
+// be held.
+func (rl *RateLimiter) client(key string, now time.Time) *rateLimitClient {
+	c := rl.clients[key]
+	if c == nil {
+		c = &rateLimitClient{buckets: make(map[string]*tokenBucket)}
+		rl.clients[key] = c
+	}
+	c.lastSeen = now
+	return c
+}
+
+// clientIP returns the address of the client. X-Forwarded-For is only
+// trusted when the request comes from a trusted proxy, and its rightmost
+// untrusted address is used.
+func (rl *RateLimiter) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !rl.trusted(host) {
+		return host
+	}
+
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(addr) == nil {
+			break
+		}
+		host = addr
+		if !rl.trusted(addr) {
+			break
+		}
+	}
+	return host
+}
+
+func (rl *RateLimiter) trusted(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range rl.proxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestToken returns a digest of the token sent in the Authorization
+// header, so that tokens are never kept in memory or logged
+func requestToken(r *http.Request) string {
+	auth := strings.TrimSpace(r.Header.Get("Authorization"))
+	if auth == "" {
+		return ""
+	}
+	if i := strings.IndexByte(auth, ' '); i > 0 {
+		auth = strings.TrimSpace(auth[i+1:])
+	}
+	sum := sha256.Sum256([]byte(auth))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (rl *RateLimiter) logAuditEvent(event audit.AuditEvent) {
+	if rl.auditLog != nil {
+		rl.auditLog.LogEvent(event)
+	}
+}
+
+// GetStats returns the number of tracked and banned clients
+func (rl *RateLimiter) GetStats() map[string]interface{} {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	banned := 0
+	for _, c := range rl.clients {
+		if now.Before(c.bannedUntil) {
+			banned++
+		}
+	}
+	return map[string]interface{}{
+		"clients":        len(rl.clients),
+		"banned_clients": banned,
+	}
+}
+
+// cleanupRoutine periodically forgets idle clients
+func (rl *RateLimiter) cleanupRoutine() {
+	ticker := time.NewTicker(rateLimitCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			rl.cleanup()
+		case <-rl.done:
+			return
+		}
+	}
+}
+
+// cleanup removes clients that are not banned and were idle long enough
+// for their buckets to refill and their failures to expire
+func (rl *RateLimiter) cleanup() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	idle := rl.config.FailureWindow
+	if idle < rateLimitCleanupInterval {
+		idle = rateLimitCleanupInterval
+	}
+	for key, c := range rl.clients {
+		if now.After(c.bannedUntil) && now.Sub(c.lastSeen) > idle {
+			delete(rl.clients, key)
+		}
+	}
+}
+
+// Close stops the cleanup goroutine
+func (rl *RateLimiter) Close() error {
+	rl.closeOnce.Do(func() {
+		close(rl.done)
+	})
+	return nil
+}
+
+func newTokenBucket(limit security.RateLimit, now time.Time) *tokenBucket {
+	window := limit.Window
+	if window == 0 {
+		window = time.Minute
+	}
+	return &tokenBucket{
+		tokens:   float64(limit.BurstCapacity),
+		capacity: float64(limit.BurstCapacity),
+		rate:     float64(limit.RequestsPerMinute) / window.Seconds(),
+		last:     now,
+	}
+}
+
+// take removes a token, or returns how long until one is available
+func (b *tokenBucket) take(now time.Time) time.Duration {
+	b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	if b.rate <= 0 {
+		return time.Minute
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+func hasPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// statusRecorder records the status code of a response. It keeps the
+// streaming and connection hijacking used by SSE and WebSocket handlers.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	if !sr.wroteHeader {
+		sr.status = status
+		sr.wroteHeader = true
+	}
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+func (sr *statusRecorder) Write(p []byte) (int, error) {
+	sr.wroteHeader = true
+	return sr.ResponseWriter.Write(p)
+}
+
+func (sr *statusRecorder) Flush() {
+	if flusher, ok := sr.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (sr *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := sr.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	return hijacker.Hijack()
+}
+
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
@@ -0,0 +1,276 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/audit"
+)
+
+// fakeClock is a settable time source for the rate limiter
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestRateLimiter(t *testing.T, config security.WebRateLimitConfig, auditLog *audit.Logger) (*RateLimiter, *fakeClock) {
+	t.Helper()
+	rl, err := NewRateLimiter(&mockLogger{}, config, auditLog)
+	if err != nil {
+		t.Fatalf("Failed to create rate limiter: %v", err)
+	}
+	t.Cleanup(func() { rl.Close() })
+
+	clock := &fakeClock{now: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)}
+	rl.now = clock.Now
+	return rl, clock
+}
+
+func serve(handler http.Handler, path, remoteAddr string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	req.RemoteAddr = remoteAddr
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func TestRateLimiterRouteBudgets(t *testing.T) {
+	config := security.DefaultWebRateLimitConfig()
+	config.RequestsPerMinute = 60
+	config.BurstCapacity = 5
+	config.Routes = map[string]security.RateLimit{
+		"/callback": {RequestsPerMinute: 6, BurstCapacity: 2},
+	}
+	config.MaxFailures = 0
+	rl, clock := newTestRateLimiter(t, config, nil)
+	handler := rl.Middleware(okHandler)
+
+	for i := 0; i < 2; i++ {
+		if rec := serve(handler, "/callback?code=x", "10.0.0.1:5000", nil); rec.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected status %d, got %d", i, http.StatusOK, rec.Code)
+		}
+	}
+	rec := serve(handler, "/callback?code=x", "10.0.0.1:5001", nil)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status %d, got %d", http.StatusTooManyRequests, rec.Code)
+	}
+	// One token every 10 seconds
+	if retryAfter := rec.Header().Get("Retry-After"); retryAfter != "10" {
+		t.Errorf("Expected Retry-After 10, got %q", retryAfter)
+	}
+
+	// Other routes and clients have their own budget
+	if rec := serve(handler, "/status", "10.0.0.1:5000", nil); rec.Code != http.StatusOK {
+		t.Errorf("Expected the default budget to be separate, got %d", rec.Code)
+	}
+	if rec := serve(handler, "/callback", "10.0.0.2:5000", nil); rec.Code != http.StatusOK {
+		t.Errorf("Expected another client to be allowed, got %d", rec.Code)
+	}
+
+	// Exempt paths are never limited
+	for i := 0; i < 10; i++ {
+		if rec := serve(handler, "/static/css/style.css", "10.0.0.1:5000", nil); rec.Code != http.StatusOK {
+			t.Fatalf("Expected static files to be exempt, got %d", rec.Code)
+		}
+	}
+
+	clock.Advance(10 * time.Second)
+	if rec := serve(handler, "/callback", "10.0.0.1:5000", nil); rec.Code != http.StatusOK {
+		t.Errorf("Expected the budget to refill, got %d", rec.Code)
+	}
+}
+
+func TestRateLimiterTokenBudget(t *testing.T) {
+	config := security.DefaultWebRateLimitConfig()
+	config.BurstCapacity = 3
+	config.MaxFailures = 0
+	rl, _ := newTestRateLimiter(t, config, nil)
+	handler := rl.Middleware(okHandler)
+
+	// A token shares its budget across addresses
+	header := http.Header{"Authorization": {"Bearer secret-token"}}
+	for i := 0; i < 3; i++ {
+		if rec := serve(handler, "/api/status", "10.0.0."+string(rune('1'+i))+":5000", header); rec.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected status %d, got %d", i, http.StatusOK, rec.Code)
+		}
+	}
+	rec := serve(handler, "/api/status", "10.0.0.9:5000", header)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status %d, got %d", http.StatusTooManyRequests, rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `"success":false`) {
+		t.Errorf("Expected a JSON error for API paths, got %s", rec.Body.String())
+	}
+	if rec := serve(handler, "/api/status", "10.0.0.9:5000", nil); rec.Code != http.StatusOK {
+		t.Errorf("Expected the address alone to be allowed, got %d", rec.Code)
+	}
+}
+
+func TestRateLimiterBansAfterFailures(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	auditLog, err := audit.NewLogger(audit.Config{LogLevel: "info", OutputFormat: "json", LogFile: logFile})
+	if err != nil {
+		t.Fatalf("Failed to create audit logger: %v", err)
+	}
+
+	config := security.DefaultWebRateLimitConfig()
+	config.MaxFailures = 3
+	config.FailureWindow = time.Minute
+	config.BanDuration = 10 * time.Minute
+	rl, clock := newTestRateLimiter(t, config, auditLog)
+
+	handler := rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("code") != "valid" {
+			http.Error(w, "invalid code", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Failures outside the window are forgotten
+	serve(handler, "/callback?code=bad", "203.0.113.7:4000", nil)
+	clock.Advance(2 * time.Minute)
+	serve(handler, "/callback?code=bad", "203.0.113.7:4000", nil)
+	serve(handler, "/callback?code=bad", "203.0.113.7:4000", nil)
+	if rec := serve(handler, "/callback?code=valid", "203.0.113.7:4000", nil); rec.Code != http.StatusOK {
+		t.Fatalf("Expected the client not to be banned yet, got %d", rec.Code)
+	}
+
+	// A 4xx outside the auth paths is not a failure
+	serve(handler, "/status?code=bad", "203.0.113.7:4000", nil)
+	if rec := serve(handler, "/callback?code=valid", "203.0.113.7:4000", nil); rec.Code != http.StatusOK {
+		t.Fatalf("Expected the client not to be banned yet, got %d", rec.Code)
+	}
+
+	serve(handler, "/callback?code=bad", "203.0.113.7:4000", nil)
+	rec := serve(handler, "/status", "203.0.113.7:4000", nil)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "600" {
+		t.Fatalf("Expected a 10 minute ban, got %d with Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if stats := rl.GetStats(); stats["banned_clients"] != 1 {
+		t.Errorf("Expected one banned client, got %v", stats)
+	}
+
+	clock.Advance(10 * time.Minute)
+	if rec := serve(handler, "/callback?code=valid", "203.0.113.7:4000", nil); rec.Code != http.StatusOK {
+		t.Errorf("Expected the ban to expire, got %d", rec.Code)
+	}
+
+	if err := auditLog.Close(); err != nil {
+		t.Fatalf("Failed to close audit logger: %v", err)
+	}
+	result, err := audit.Search(logFile, audit.Query{EventTypes: []audit.EventType{audit.IntrusionAttempt}})
+	if err != nil {
+		t.Fatalf("Failed to search audit log: %v", err)
+	}
+	if result.Total != 1 || result.Events[0].RemoteAddr != "203.0.113.7" || result.Events[0].Result != "banned" {
+		t.Errorf("Expected one intrusion attempt event, got %+v", result.Events)
+	}
+}
+
+func TestRateLimiterAuditsRateLimitHitsOnce(t *testing.T) {
+	var buf bytes.Buffer
+	auditLog, err := audit.NewLogger(audit.Config{LogLevel: "info", OutputFormat: "json"})
+	if err != nil {
+		t.Fatalf("Failed to create audit logger: %v", err)
+	}
+	auditLog.SetOutput(&buf)
+
+	config := security.DefaultWebRateLimitConfig()
+	config.BurstCapacity = 1
+	config.MaxFailures = 5
+	rl, _ := newTestRateLimiter(t, config, auditLog)
+	handler := rl.Middleware(okHandler)
+
+	serve(handler, "/", "198.51.100.4:1234", nil)
+	for i := 0; i < 4; i++ {
+		if rec := serve(handler, "/", "198.51.100.4:1234", nil); rec.Code != http.StatusTooManyRequests {
+			t.Fatalf("Expected status %d, got %d", http.StatusTooManyRequests, rec.Code)
+		}
+	}
+	if hits := strings.Count(buf.String(), `"audit_event_type":"rate_limit_hit"`); hits != 1 {
+		t.Errorf("Expected one rate_limit_hit event, got %d", hits)
+	}
+
+	// Exceeding the budget max_failures times bans the client
+	serve(handler, "/", "198.51.100.4:1234", nil)
+	if !strings.Contains(buf.String(), `"audit_event_type":"intrusion_attempt"`) {
+		t.Errorf("Expected an intrusion_attempt event, got %s", buf.String())
+	}
+}
+
+func TestRateLimiterClientIP(t *testing.T) {
+	config := security.DefaultWebRateLimitConfig()
+	config.TrustedProxies = []string{"10.0.0.0/8", "192.168.1.1"}
+	rl, _ := newTestRateLimiter(t, config, nil)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{"direct client", "203.0.113.7:4000", "", "203.0.113.7"},
+		{"untrusted proxy", "203.0.113.7:4000", "198.51.100.1", "203.0.113.7"},
+		{"trusted proxy", "10.1.2.3:4000", "198.51.100.1", "198.51.100.1"},
+		{"spoofed chain", "10.1.2.3:4000", "1.2.3.4, 198.51.100.1, 10.0.0.5", "198.51.100.1"},
+		{"single trusted address", "192.168.1.1:4000", "198.51.100.2", "198.51.100.2"},
+		{"invalid header", "10.1.2.3:4000", "not-an-ip", "10.1.2.3"},
+		{"ipv6 client", "[2001:db8::1]:4000", "", "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if got := rl.clientIP(req); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRateLimiterKeepsStreaming(t *testing.T) {
+	rl, _ := newTestRateLimiter(t, security.DefaultWebRateLimitConfig(), nil)
+	handler := rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Flusher); !ok {
+			t.Error("Expected the response writer to support flushing")
+		}
+		if _, ok := w.(http.Hijacker); !ok {
+			t.Error("Expected the response writer to support hijacking")
+		}
+	}))
+	serve(handler, "/sse/status", "10.0.0.1:5000", nil)
+}
+
+func TestNewRateLimiterRejectsInvalidConfig(t *testing.T) {
+	config := security.DefaultWebRateLimitConfig()
+	config.TrustedProxies = []string{"not-a-network"}
+	if _, err := NewRateLimiter(&mockLogger{}, config, nil); err == nil {
+		t.Error("Expected an error for an invalid trusted proxy")
+	}
+
+	config = security.DefaultWebRateLimitConfig()
+	config.Routes = map[string]security.RateLimit{"/callback": {RequestsPerMinute: 0, BurstCapacity: 1}}
+	if _, err := NewRateLimiter(&mockLogger{}, config, nil); err == nil {
+		t.Error("Expected an error for a route without budget")
+	}
+}
@@ -13,6 +13,9 @@ import (
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/auth"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/purge"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/audit"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/web/handlers"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/web/middleware"
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/web/realtime"
@@ -24,9 +27,16 @@ type Server struct {
 	tokenManager       *auth.TokenManager
 	templates          *template.Template
 	server             *http.Server
+	redirectServer     *http.Server
+	serverTLS          *security.ServerTLS
+	mux                *http.ServeMux
 	startTime          time.Time
 	csrfMiddleware     *middleware.CSRFMiddleware
 	securityMiddleware *middleware.SecurityHeaders
+	rateLimiter        *middleware.RateLimiter
+	auditLog           *audit.Logger
+	exportsHandler     *handlers.ExportsHandler
+	purger             *purge.Purger
 	
 	// Real-time components
 	realtimeHub        *realtime.Hub
@@ -43,9 +53,28 @@ type TemplateData struct {
 	CSRFToken    string
 }
 
-func NewServer(cfg *config.Config, log logger.Logger, tokenManager *auth.TokenManager) (*Server, error) {
+// ServerOption configures optional features of a Server
+type ServerOption func(*Server)
+
+// WithAuditLogger records the security events of the web server, such as
+// rate limit hits and banned clients, in the audit log
+func WithAuditLogger(auditLog *audit.Logger) ServerOption {
+	return func(s *Server) {
+		s.auditLog = auditLog
+	}
+}
+
+// WithPurger enables the purge action of the web interface when web_purge
+// is set in [security]
+func WithPurger(purger *purge.Purger) ServerOption {
+	return func(s *Server) {
+		s.purger = purger
+	}
+}
+
+func NewServer(cfg *config.Config, log logger.Logger, tokenManager *auth.TokenManager, opts ...ServerOption) (*Server, error) {
 	// Determine if we should use secure cookies (HTTPS)
-	secureCookies := cfg.Security.RequireHTTPS
+	secureCookies := cfg.Security.RequireHTTPS || cfg.Security.TLS.Enabled()
 	
 	// Initialize real-time components
 	realtimeHub := realtime.NewHub(log)
@@ -66,6 +95,28 @@ func NewServer(cfg *config.Config, log logger.Logger, tokenManager *auth.TokenMa
 		sseHandler:         sseHandler,
 	}
 	
+	for _, opt := range opts {
+		opt(s)
+	}
+	
+	// Limit inbound requests per client
+	if cfg.Security.RateLimitEnabled {
+		rateLimiter, err := middleware.NewRateLimiter(log, cfg.Security.WebRateLimit, s.auditLog)
+		if err != nil {
+			return nil, fmt.Errorf("invalid web rate limit: %w", err)
+		}
+		s.rateLimiter = rateLimiter
+	}
+	
+	// Serve HTTPS directly when TLS is configured
+	if cfg.Security.TLS.Enabled() {
+		serverTLS, err := security.NewServerTLS(cfg.Security.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up TLS: %w", err)
+		}
+		s.serverTLS = serverTLS
+	}
+	
 	// Load templates
 	if err := s.loadTemplates(); err != nil {
 		return nil, fmt.Errorf("failed to load templates: %w", err)
@@ -199,8 +250,10 @@ func (s *Server) setupRoutes() {
 	// Create handlers
 	dashboardHandler := handlers.NewDashboardHandler(s.config, s.logger, s.tokenManager, s.templates)
 	exportsHandler := handlers.NewExportsHandler(s.config, s.logger, s.tokenManager, s.templates, s.csrfMiddleware)
+	s.exportsHandler = exportsHandler
 	statusHandler := handlers.NewStatusHandler(s.config, s.logger, s.tokenManager, s.templates)
 	authHandler := handlers.NewAuthHandler(s.config, s.logger, s.tokenManager, s.templates)
+	auditHandler := handlers.NewAuditHandler(s.config, s.logger, s.templates, filepath.FromSlash(security.AuditLogFile))
 	
 	// Download handler for export files
 	exportsDir := "./exports"
@@ -219,6 +272,12 @@ func (s *Server) setupRoutes() {
 	mux.Handle("/api/test-connection", statusHandler)
 	mux.Handle("/api/logs/recent", statusHandler)
 	mux.Handle("/api/logs/download", statusHandler)
+	mux.Handle("/audit", auditHandler)
+	mux.Handle("/api/audit", auditHandler)
+	mux.Handle("/api/audit/export", auditHandler)
+	if s.purger != nil {
+		mux.Handle("/api/purge", handlers.NewPurgeHandler(s.config, s.logger, s.purger, exportsHandler))
+	}
 	mux.Handle("/auth-url", authHandler)
 	mux.Handle("/callback", authHandler)
 	mux.Handle("/download/", downloadHandler)
@@ -239,8 +298,14 @@ func (s *Server) setupRoutes() {
 	mux.HandleFunc("/sse/export", s.sseHandler.HandleSSEExports)
 	mux.HandleFunc("/sse/all", s.sseHandler.HandleSSE)
 	
-	// Add middleware (order matters: security headers first, then CSRF, then CORS, then logging)
-	handler := s.withLogging(s.withCORS(s.csrfMiddleware.Middleware(s.securityMiddleware.Middleware(mux))))
+	s.mux = mux
+	
+	// Add middleware (order matters: security headers first, then CSRF, then CORS, then rate limiting, then logging)
+	handler := s.withCORS(s.csrfMiddleware.Middleware(s.securityMiddleware.Middleware(mux)))
+	if s.rateLimiter != nil {
+		handler = s.rateLimiter.Middleware(handler)
+	}
+	handler = s.withLogging(handler)
 	
 	port := s.config.Auth.CallbackPort
 	if port == 0 {
@@ -254,6 +319,11 @@ func (s *Server) setupRoutes() {
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  120 * time.Second,
 	}
+	
+	if s.serverTLS != nil {
+		s.server.TLSConfig = s.serverTLS.Config
+		s.setupRedirectServer(port)
+	}
 }
 
 func (s *Server) handleLegacyExport(w http.ResponseWriter, r *http.Request) {
@@ -352,6 +422,7 @@ func (s *Server) withCORS(next http.Handler) http.Handler {
 func (s *Server) Start() error {
 	s.logger.Info("web.server_starting", map[string]interface{}{
 		"addr":       s.server.Addr,
+		"scheme":     s.config.Security.TLS.Scheme(),
 		"start_time": s.startTime.Format(time.RFC3339),
 	})
 	
@@ -359,7 +430,12 @@ func (s *Server) Start() error {
 	go s.realtimeHub.Start()
 	s.statusBroadcaster.Start()
 	
-	return s.server.ListenAndServe()
+	if s.serverTLS == nil {
+		return s.server.ListenAndServe()
+	}
+	
+	s.startRedirectServer()
+	return s.server.ListenAndServeTLS("", "")
 }
 
 func (s *Server) Stop(ctx context.Context) error {
@@ -368,9 +444,37 @@ func (s *Server) Stop(ctx context.Context) error {
 	// Stop real-time components
 	s.statusBroadcaster.Stop()
 	
+	if s.rateLimiter != nil {
+		s.rateLimiter.Close()
+	}
+	
+	// Interrupt exports started from the web interface
+	if err := s.exportsHandler.Shutdown(ctx); err != nil {
+		s.logger.Warn("web.exports_shutdown_timeout", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+	
+	if s.redirectServer != nil {
+		if err := s.redirectServer.Shutdown(ctx); err != nil {
+			s.logger.Warn("web.redirect_server_shutdown_failed", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+	
 	return s.server.Shutdown(ctx)
 }
 
+// HandleMetrics exposes a metrics handler, such as the Prometheus handler of
+// the telemetry manager, on the web server. It must be called before Start.
+func (s *Server) HandleMetrics(path string, handler http.Handler) {
+	s.mux.Handle(path, handler)
+	s.logger.Info("web.metrics_endpoint_registered", map[string]interface{}{
+		"path": path,
+	})
+}
+
 func (s *Server) GetAddr() string {
 	return s.server.Addr
 }
@@ -3,6 +3,7 @@ package web
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/auth"
@@ -218,4 +219,42 @@ func TestServerMiddleware(t *testing.T) {
 	if optionsRec.Code != http.StatusOK {
 		t.Errorf("Expected status %d for OPTIONS, got %d", http.StatusOK, optionsRec.Code)
 	}
-}
\ No newline at end of file
+}
+
+func TestServerHandleMetrics(t *testing.T) {
+	cfg := &config.Config{
+		Auth: config.AuthConfig{
+			CallbackPort: 8080,
+		},
+		Letterboxd: config.LetterboxdConfig{
+			ExportDir: "./test_exports",
+		},
+	}
+
+	log := logger.NewLogger()
+
+	keyringMgr, err := keyring.NewManager(keyring.MemoryBackend)
+	if err != nil {
+		t.Fatalf("Failed to create keyring manager: %v", err)
+	}
+	tokenManager := auth.NewTokenManager(cfg, log, keyringMgr)
+
+	server, err := NewServer(cfg, log, tokenManager)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	server.HandleMetrics("/metrics", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("trakt_api_calls_total 1\n"))
+	}))
+
+	rec := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "trakt_api_calls_total") {
+		t.Errorf("Expected metrics in the response, got %q", rec.Body.String())
+	}
+}
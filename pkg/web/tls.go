@@ -0,0 +1,89 @@
+package web
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setupRedirectServer creates the plain HTTP listener sending clients to the
+// HTTPS port. With ACME it also answers http-01 challenges.
+func (s *Server) setupRedirectServer(httpsPort int) {
+	port := s.config.Security.TLS.HTTPRedirectPort
+	if port == 0 {
+		return
+	}
+
+	s.redirectServer = &http.Server{
+		Addr:         fmt.Sprintf(":%d", port),
+		Handler:      s.serverTLS.HTTPHandler(redirectToHTTPS(httpsPort)),
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+}
+
+// startRedirectServer runs the redirect listener in the background, a failure
+// is logged without stopping the HTTPS server
+func (s *Server) startRedirectServer() {
+	if s.redirectServer == nil {
+		return
+	}
+
+	s.logger.Info("web.redirect_server_starting", map[string]interface{}{
+		"addr": s.redirectServer.Addr,
+	})
+	go func() {
+		if err := s.redirectServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			s.logger.Error("web.redirect_server_failed", map[string]interface{}{
+				"addr":  s.redirectServer.Addr,
+				"error": err.Error(),
+			})
+		}
+	}()
+}
+
+// TLSFingerprint returns the SHA-256 fingerprint of the served certificate,
+// empty without TLS or when certificates come from an ACME CA
+func (s *Server) TLSFingerprint() string {
+	if s.serverTLS == nil {
+		return ""
+	}
+	return s.serverTLS.Fingerprint
+}
+
+// redirectToHTTPS sends requests to the same host, path and query on the
+// HTTPS port. 308 keeps the method and body of form and API submissions.
+func redirectToHTTPS(httpsPort int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.Trim(host, "[]")
+		if host == "" {
+			http.Error(w, "Missing Host header", http.StatusBadRequest)
+			return
+		}
+
+		if httpsPort == 443 {
+			if strings.Contains(host, ":") {
+				host = "[" + host + "]"
+			}
+		} else {
+			host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+		}
+
+		target := url.URL{
+			Scheme:   "https",
+			Host:     host,
+			Path:     r.URL.Path,
+			RawQuery: r.URL.RawQuery,
+		}
+		http.Redirect(w, r, target.String(), http.StatusPermanentRedirect)
+	})
+}
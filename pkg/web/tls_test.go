@@ -0,0 +1,112 @@
+package web
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/auth"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/config"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/logger"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security"
+	"github.com/JohanDevl/Export_Trakt_4_Letterboxd/pkg/security/keyring"
+)
+
+func TestRedirectToHTTPS(t *testing.T) {
+	tests := []struct {
+		name   string
+		port   int
+		target string
+		host   string
+		want   string
+	}{
+		{"custom port", 8089, "/exports?page=2", "192.168.1.24:8088", "https://192.168.1.24:8089/exports?page=2"},
+		{"default port", 443, "/callback?code=abc&state=xyz", "trakt.example.com", "https://trakt.example.com/callback?code=abc&state=xyz"},
+		{"ipv6", 8089, "/", "[fd00::24]:8088", "https://[fd00::24]:8089/"},
+		{"ipv6 default port", 443, "/", "[fd00::24]:80", "https://[fd00::24]/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.target, nil)
+			req.Host = tt.host
+			w := httptest.NewRecorder()
+
+			redirectToHTTPS(tt.port).ServeHTTP(w, req)
+
+			if w.Code != http.StatusPermanentRedirect {
+				t.Errorf("Expected status 308, got %d", w.Code)
+			}
+			if location := w.Header().Get("Location"); location != tt.want {
+				t.Errorf("Expected redirect to %s, got %s", tt.want, location)
+			}
+		})
+	}
+}
+
+func TestServerTLS(t *testing.T) {
+	cfg := &config.Config{
+		Auth: config.AuthConfig{
+			CallbackPort: 8089,
+		},
+		Security: security.Config{
+			TLS: security.TLSConfig{
+				Mode:             security.TLSModeSelfSigned,
+				SelfSignedDir:    t.TempDir(),
+				HTTPRedirectPort: 8088,
+			},
+		},
+		Letterboxd: config.LetterboxdConfig{
+			ExportDir: "./test_exports",
+		},
+	}
+
+	log := logger.NewLogger()
+	keyringMgr, err := keyring.NewManager(keyring.MemoryBackend)
+	if err != nil {
+		t.Fatalf("Failed to create keyring manager: %v", err)
+	}
+	tokenManager := auth.NewTokenManager(cfg, log, keyringMgr)
+
+	server, err := NewServer(cfg, log, tokenManager)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if server.server.TLSConfig == nil {
+		t.Fatal("Expected the server to be configured for TLS")
+	}
+	if server.TLSFingerprint() == "" {
+		t.Error("Expected the fingerprint of the self-signed certificate")
+	}
+
+	ts := httptest.NewUnstartedServer(server.server.Handler)
+	ts.TLS = server.server.TLSConfig
+	ts.StartTLS()
+	defer ts.Close()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	resp, err := client.Get(ts.URL + "/health")
+	if err != nil {
+		t.Fatalf("Expected the server to answer over HTTPS: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("Strict-Transport-Security") == "" {
+		t.Error("Expected HSTS over HTTPS")
+	}
+
+	// The plain listener only redirects
+	if server.redirectServer == nil || server.redirectServer.Addr != ":8088" {
+		t.Fatal("Expected a redirect listener on port 8088")
+	}
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	req.Host = "localhost:8088"
+	w := httptest.NewRecorder()
+	server.redirectServer.Handler.ServeHTTP(w, req)
+	if location := w.Header().Get("Location"); location != "https://localhost:8089/status" {
+		t.Errorf("Expected redirect to the HTTPS port, got %q", location)
+	}
+}